@@ -0,0 +1,194 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// checkAnnotation checks that `annot` has subtype `subtype`, a non-empty `key` array (e.g. QuadPoints),
+// a valid Rect and a non-empty normal appearance stream.  Returns the annotation dictionary.
+func checkAnnotation(t *testing.T, annot *pdf.PdfAnnotation, subtype, key string) *pdfcore.PdfObjectDictionary {
+	// The context (subtype) writes both the generic and the subtype entries, as for page annotations.
+	dict, ok := pdfcore.GetDict(annot.GetContext().ToPdfObject())
+	if !ok {
+		t.Fatalf("%s: annotation not a dictionary", subtype)
+	}
+	if name, ok := pdfcore.GetNameVal(dict.Get("Subtype")); !ok || name != subtype {
+		t.Errorf("%s: invalid Subtype %v", subtype, dict.Get("Subtype"))
+	}
+	if len(key) > 0 {
+		if arr, ok := pdfcore.GetArray(dict.Get(pdfcore.PdfObjectName(key))); !ok || arr.Len() == 0 {
+			t.Errorf("%s: missing %s", subtype, key)
+		}
+	}
+
+	rectArr, ok := pdfcore.GetArray(dict.Get("Rect"))
+	if !ok {
+		t.Fatalf("%s: missing Rect", subtype)
+	}
+	rect, err := pdf.NewPdfRectangle(*rectArr)
+	if err != nil {
+		t.Fatalf("%s: invalid Rect: %v", subtype, err)
+	}
+	if rect.Urx <= rect.Llx || rect.Ury <= rect.Lly {
+		t.Errorf("%s: empty Rect %+v", subtype, rect)
+	}
+
+	apDict, ok := pdfcore.GetDict(dict.Get("AP"))
+	if !ok {
+		t.Fatalf("%s: missing AP", subtype)
+	}
+	stream, ok := pdfcore.GetStream(apDict.Get("N"))
+	if !ok {
+		t.Fatalf("%s: AP N not a stream", subtype)
+	}
+	content, err := pdfcore.DecodeStream(stream)
+	if err != nil {
+		t.Fatalf("%s: %v", subtype, err)
+	}
+	if len(content) == 0 {
+		t.Errorf("%s: empty appearance stream", subtype)
+	}
+	if stream.Get("BBox") == nil {
+		t.Errorf("%s: appearance stream without BBox", subtype)
+	}
+	return dict
+}
+
+// makeTestPath returns a path through the points with coordinates `xy`.
+func makeTestPath(xy ...float64) draw.Path {
+	path := draw.NewPath()
+	for i := 0; i+1 < len(xy); i += 2 {
+		path = path.AppendPoint(draw.NewPoint(xy[i], xy[i+1]))
+	}
+	return path
+}
+
+func TestTextMarkupAnnotations(t *testing.T) {
+	quads := []float64{100, 720, 200, 720, 100, 700, 200, 700}
+	creators := map[string]func(TextMarkupAnnotationDef) (*pdf.PdfAnnotation, error){
+		"Highlight": CreateHighlightAnnotation,
+		"Underline": CreateUnderlineAnnotation,
+		"StrikeOut": CreateStrikeOutAnnotation,
+		"Squiggly":  CreateSquigglyAnnotation,
+	}
+	for subtype, create := range creators {
+		annot, err := create(TextMarkupAnnotationDef{QuadPoints: quads, Opacity: 1})
+		if err != nil {
+			t.Fatalf("%s: %v", subtype, err)
+		}
+		dict := checkAnnotation(t, annot, subtype, "QuadPoints")
+		// Opaque.
+		if ca := dict.Get("CA"); ca != nil {
+			t.Errorf("%s: unexpected CA %v", subtype, ca)
+		}
+	}
+
+	// Opacity is the alpha value, 0 is fully transparent as for the other annotation definitions.
+	for _, opacity := range []float64{0.5, 0} {
+		annot, err := CreateHighlightAnnotation(TextMarkupAnnotationDef{QuadPoints: quads, Opacity: opacity})
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		dict := checkAnnotation(t, annot, "Highlight", "QuadPoints")
+		if ca, err := pdfcore.GetNumberAsFloat(dict.Get("CA")); err != nil || ca != opacity {
+			t.Errorf("Invalid CA %v, expected %v", dict.Get("CA"), opacity)
+		}
+	}
+
+	if _, err := CreateUnderlineAnnotation(TextMarkupAnnotationDef{QuadPoints: quads[:6]}); err == nil {
+		t.Errorf("Incomplete QuadPoints should fail")
+	}
+}
+
+func TestInkAnnotation(t *testing.T) {
+	annot, err := CreateInkAnnotation(InkAnnotationDef{
+		Paths:     []draw.Path{makeTestPath(100, 100, 150, 120, 200, 100), makeTestPath(100, 90, 200, 90)},
+		LineWidth: 2,
+		Opacity:   1,
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	dict := checkAnnotation(t, annot, "Ink", "InkList")
+	if inkList, ok := pdfcore.GetArray(dict.Get("InkList")); !ok || inkList.Len() != 2 {
+		t.Errorf("Invalid InkList %v", dict.Get("InkList"))
+	}
+	if ca := dict.Get("CA"); ca != nil {
+		t.Errorf("Unexpected CA %v", ca)
+	}
+
+	if _, err := CreateInkAnnotation(InkAnnotationDef{}); err == nil {
+		t.Errorf("Ink annotation without paths should fail")
+	}
+}
+
+func TestPolygonAnnotations(t *testing.T) {
+	vertices := makeTestPath(100, 100, 200, 100, 150, 180)
+	annot, err := CreatePolygonAnnotation(PolygonAnnotationDef{
+		Vertices:    vertices,
+		LineWidth:   1,
+		FillEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	dict := checkAnnotation(t, annot, "Polygon", "Vertices")
+	if ic, ok := pdfcore.GetArray(dict.Get("IC")); !ok || ic.Len() != 3 {
+		t.Errorf("Invalid IC %v", dict.Get("IC"))
+	}
+
+	annot, err = CreatePolyLineAnnotation(PolyLineAnnotationDef{Vertices: vertices, LineWidth: 1})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	checkAnnotation(t, annot, "PolyLine", "Vertices")
+
+	if _, err := CreatePolygonAnnotation(PolygonAnnotationDef{Vertices: makeTestPath(1, 1)}); err == nil {
+		t.Errorf("Polygon with a single vertex should fail")
+	}
+}
+
+func TestFreeTextAnnotation(t *testing.T) {
+	annot, err := CreateFreeTextAnnotation(FreeTextAnnotationDef{
+		X:             100,
+		Y:             600,
+		Width:         150,
+		Height:        50,
+		Text:          "Free text annotation wrapped in the box",
+		BorderEnabled: true,
+		BorderWidth:   1,
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	dict := checkAnnotation(t, annot, "FreeText", "")
+	if da, ok := pdfcore.GetStringVal(dict.Get("DA")); !ok || da != "/Helv 12 Tf 0 0 0 rg" {
+		t.Errorf("Invalid DA %v", dict.Get("DA"))
+	}
+}
+
+func TestStampAnnotation(t *testing.T) {
+	annot, err := CreateStampAnnotation(StampAnnotationDef{
+		X:      100,
+		Y:      500,
+		Width:  120,
+		Height: 40,
+		Name:   "NotApproved",
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	dict := checkAnnotation(t, annot, "Stamp", "")
+	if name, ok := pdfcore.GetNameVal(dict.Get("Name")); !ok || name != "NotApproved" {
+		t.Errorf("Invalid Name %v", dict.Get("Name"))
+	}
+}
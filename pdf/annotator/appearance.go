@@ -0,0 +1,111 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"math"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// makeAppearanceDict creates an appearance dictionary with a normal appearance XObject form with the
+// specified `content`, local bounding box `bbox` and `resources`.
+func makeAppearanceDict(content []byte, bbox *pdf.PdfRectangle, resources *pdf.PdfPageResources) (*pdfcore.PdfObjectDictionary, error) {
+	form := pdf.NewXObjectForm()
+	form.Resources = resources
+	if form.Resources == nil {
+		form.Resources = pdf.NewPdfPageResources()
+	}
+
+	err := form.SetContentStream(content, nil)
+	if err != nil {
+		return nil, err
+	}
+	form.BBox = bbox.ToPdfObject()
+
+	apDict := pdfcore.MakeDict()
+	apDict.Set("N", form.ToPdfObject())
+	return apDict, nil
+}
+
+// addOpacityExtGState adds a graphics state named gs1 to `resources` for drawing with `opacity` and the
+// blend mode `blendMode` (optional, may be empty).  Returns the graphics state name, or an empty string
+// if not needed.
+func addOpacityExtGState(resources *pdf.PdfPageResources, opacity float64, blendMode string) (string, error) {
+	if opacity >= 1.0 && len(blendMode) == 0 {
+		return "", nil
+	}
+
+	gsState := pdfcore.MakeDict()
+	if opacity < 1.0 {
+		gsState.Set("ca", pdfcore.MakeFloat(opacity))
+		gsState.Set("CA", pdfcore.MakeFloat(opacity))
+	}
+	if len(blendMode) > 0 {
+		gsState.Set("BM", pdfcore.MakeName(blendMode))
+	}
+	err := resources.AddExtGState("gs1", gsState)
+	if err != nil {
+		common.Log.Debug("Unable to add extgstate gs1")
+		return "", err
+	}
+	return "gs1", nil
+}
+
+// rgbOrDefault returns the components of `color`, or the default components if `color` is nil.
+func rgbOrDefault(color *pdf.PdfColorDeviceRGB, r, g, b float64) (float64, float64, float64) {
+	if color == nil {
+		return r, g, b
+	}
+	return color.R(), color.G(), color.B()
+}
+
+// addRoundLineCapJoin sets round line caps and joins, as used for free-hand drawing.
+func addRoundLineCapJoin(cc *contentstream.ContentCreator) {
+	ops := cc.Operations()
+	*ops = append(*ops,
+		&contentstream.ContentStreamOperation{Operand: "J", Params: []pdfcore.PdfObject{pdfcore.MakeInteger(1)}},
+		&contentstream.ContentStreamOperation{Operand: "j", Params: []pdfcore.PdfObject{pdfcore.MakeInteger(1)}})
+}
+
+// pointsBoundingBox returns the bounding box of `points` expanded by `margin` on all sides.
+func pointsBoundingBox(points []draw.Point, margin float64) *pdf.PdfRectangle {
+	bbox := &pdf.PdfRectangle{}
+	if len(points) == 0 {
+		return bbox
+	}
+	bbox.Llx, bbox.Lly = points[0].X, points[0].Y
+	bbox.Urx, bbox.Ury = points[0].X, points[0].Y
+	for _, p := range points[1:] {
+		bbox.Llx = math.Min(bbox.Llx, p.X)
+		bbox.Lly = math.Min(bbox.Lly, p.Y)
+		bbox.Urx = math.Max(bbox.Urx, p.X)
+		bbox.Ury = math.Max(bbox.Ury, p.Y)
+	}
+	bbox.Llx -= margin
+	bbox.Lly -= margin
+	bbox.Urx += margin
+	bbox.Ury += margin
+	return bbox
+}
+
+// pathToArray returns the points of `path` as a flat array of coordinates [x1 y1 x2 y2 ...].
+func pathToArray(path draw.Path) *pdfcore.PdfObjectArray {
+	coords := []float64{}
+	for _, p := range path.Points {
+		coords = append(coords, p.X, p.Y)
+	}
+	return pdfcore.MakeArrayFromFloats(coords)
+}
+
+// localRectangle returns a rectangle with the dimensions of `bbox` and the lower left corner at the origin.
+func localRectangle(bbox *pdf.PdfRectangle) *pdf.PdfRectangle {
+	return &pdf.PdfRectangle{Llx: 0, Lly: 0, Urx: bbox.Urx - bbox.Llx, Ury: bbox.Ury - bbox.Lly}
+}
@@ -0,0 +1,356 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// FieldAppearance generates appearance streams for form field widget annotations.
+// Implements pdf.FieldAppearanceGenerator, so it can be passed to PdfAcroForm.FillWithAppearance.
+// Appearances are generated for text and choice fields.  Button appearances are defined by the
+// document and are selected through the appearance state (AS) of the widget, so they are not generated.
+type FieldAppearance struct {
+	// OnlyIfMissing specifies that appearances are only generated for widgets missing one.
+	OnlyIfMissing bool
+}
+
+// Padding between the widget border and the field text.
+const fieldTextPadding = 2.0
+
+// Default font size for auto-sized text (font size 0 in the default appearance).
+const fieldAutoFontSize = 12.0
+
+// GenerateAppearanceDict generates an appearance dictionary for widget `wa` of `field` in `form`.
+// Returns nil if no appearance is generated for the field type.
+func (fa FieldAppearance) GenerateAppearanceDict(form *pdf.PdfAcroForm, field *pdf.PdfField,
+	wa *pdf.PdfAnnotationWidget) (*pdfcore.PdfObjectDictionary, error) {
+	if fa.OnlyIfMissing && wa.AP != nil {
+		return nil, nil
+	}
+
+	switch field.GetFieldType() {
+	case "Tx", "Ch":
+	default:
+		common.Log.Trace("Not generating appearance for field type %s", field.GetFieldType())
+		return nil, nil
+	}
+
+	rectArr, ok := pdfcore.GetArray(wa.Rect)
+	if !ok {
+		return nil, errors.New("Widget Rect not an array")
+	}
+	rect, err := pdf.NewPdfRectangle(*rectArr)
+	if err != nil {
+		return nil, err
+	}
+	width := rect.Urx - rect.Llx
+	height := rect.Ury - rect.Lly
+	if width < 0 {
+		width = -width
+	}
+	if height < 0 {
+		height = -height
+	}
+
+	da := field.GetDA()
+	if len(da) == 0 {
+		if str, ok := pdfcore.GetString(form.DA); ok {
+			da = str.Str()
+		}
+	}
+	fontName, fontSize, daOps, err := parseDefaultAppearance(da)
+	if err != nil {
+		return nil, err
+	}
+
+	font, fontObj, fontName, err := getFieldFont(form, fontName)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := fieldValueLines(field)
+	flags := field.GetFieldFlags()
+	multiline := flags.Has(pdf.FieldFlagMultiline) || (field.GetFieldType() == "Ch" && !flags.Has(pdf.FieldFlagCombo))
+
+	availWidth := width - 2*fieldTextPadding
+	availHeight := height - 2*fieldTextPadding
+	if fontSize <= 0 {
+		fontSize = fieldAutoFontSize
+		if !multiline {
+			if h := availHeight / 1.2; h < fontSize {
+				fontSize = h
+			}
+			for _, line := range lines {
+				w := textWidth(font, line, fontSize)
+				if w > availWidth && w > 0 {
+					fontSize = fontSize * availWidth / w
+				}
+			}
+		}
+		if fontSize < 1 {
+			fontSize = 1
+		}
+	}
+	if multiline && flags.Has(pdf.FieldFlagMultiline) {
+		lines = wrapLines(font, lines, fontSize, availWidth)
+	}
+
+	quadding := 0
+	if q, ok := pdfcore.GetIntVal(field.Q); ok {
+		quadding = q
+	} else if form.Q != nil {
+		quadding = int(*form.Q)
+	}
+
+	cc := contentstream.NewContentCreator()
+	drawWidgetBackground(cc, wa, width, height)
+
+	cc.Add_BMC("Tx")
+	cc.Add_q()
+	cc.Add_re(1, 1, width-2, height-2).Add_W().Add_n()
+	cc.Add_BT()
+	// Replay the color operations of the default appearance.
+	ops := cc.Operations()
+	*ops = append(*ops, daOps...)
+	cc.Add_Tf(pdfcore.PdfObjectName(fontName), fontSize)
+
+	leading := fontSize * 1.2
+	y := (height-fontSize)/2 + 0.2*fontSize
+	if multiline {
+		y = height - fieldTextPadding - fontSize
+	}
+	prevX, prevY := 0.0, 0.0
+	for i, line := range lines {
+		x := fieldTextPadding
+		switch quadding {
+		case 1:
+			x = (width - textWidth(font, line, fontSize)) / 2
+		case 2:
+			x = width - fieldTextPadding - textWidth(font, line, fontSize)
+		}
+		ly := y - float64(i)*leading
+		cc.Add_Td(x-prevX, ly-prevY)
+		prevX, prevY = x, ly
+
+		encoded := line
+		if encoder := font.Encoder(); encoder != nil {
+			encoded = encoder.Encode(line)
+		}
+		cc.Add_Tj(*pdfcore.MakeString(encoded))
+	}
+	cc.Add_ET()
+	cc.Add_Q()
+	cc.Add_EMC()
+
+	xform := pdf.NewXObjectForm()
+	xform.Resources = pdf.NewPdfPageResources()
+	xform.Resources.SetFontByName(pdfcore.PdfObjectName(fontName), fontObj)
+	xform.BBox = pdfcore.MakeArrayFromFloats([]float64{0, 0, width, height})
+	err = xform.SetContentStream(cc.Bytes(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	apDict := pdfcore.MakeDict()
+	apDict.Set("N", xform.ToPdfObject())
+	return apDict, nil
+}
+
+// parseDefaultAppearance parses the default appearance string `da` and returns the font name,
+// font size and the remaining (color) operations.
+func parseDefaultAppearance(da string) (string, float64, []*contentstream.ContentStreamOperation, error) {
+	fontName := ""
+	fontSize := 0.0
+	ops := []*contentstream.ContentStreamOperation{}
+	if len(da) == 0 {
+		return fontName, fontSize, ops, nil
+	}
+
+	parser := contentstream.NewContentStreamParser(da)
+	operations, err := parser.Parse()
+	if err != nil {
+		return "", 0, nil, err
+	}
+	for _, op := range *operations {
+		if op.Operand == "Tf" {
+			if len(op.Params) != 2 {
+				common.Log.Debug("ERROR: Invalid Tf in DA: %s", da)
+				continue
+			}
+			if name, ok := pdfcore.GetNameVal(op.Params[0]); ok {
+				fontName = name
+			}
+			if size, err := pdfcore.GetNumberAsFloat(op.Params[1]); err == nil {
+				fontSize = size
+			}
+			continue
+		}
+		ops = append(ops, op)
+	}
+	return fontName, fontSize, ops, nil
+}
+
+// getFieldFont returns the font named `name` in the default resources of `form`, along with
+// the font object and the resource name.  Falls back to Helvetica if not found.
+func getFieldFont(form *pdf.PdfAcroForm, name string) (*pdf.PdfFont, pdfcore.PdfObject, string, error) {
+	if form.DR != nil && len(name) > 0 {
+		if fontObj, has := form.DR.GetFontByName(pdfcore.PdfObjectName(name)); has {
+			font, err := pdf.NewPdfFontFromPdfObject(fontObj)
+			if err == nil {
+				return font, fontObj, name, nil
+			}
+			common.Log.Debug("Unable to load field font %s: %v - falling back to Helvetica", name, err)
+		}
+	}
+
+	font, err := pdf.NewStandard14Font("Helvetica")
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return font, font.ToPdfObject(), "Helv", nil
+}
+
+// fieldValueLines returns the display value of `field` split into lines.
+func fieldValueLines(field *pdf.PdfField) []string {
+	value := ""
+	switch v := pdfcore.TraceToDirectObject(field.GetValue()).(type) {
+	case *pdfcore.PdfObjectString:
+		value = v.Decoded()
+	case *pdfcore.PdfObjectName:
+		value = string(*v)
+	case *pdfcore.PdfObjectArray:
+		parts := []string{}
+		for _, elem := range v.Elements() {
+			if str, ok := pdfcore.GetString(elem); ok {
+				parts = append(parts, str.Decoded())
+			} else if name, ok := pdfcore.GetNameVal(elem); ok {
+				parts = append(parts, name)
+			}
+		}
+		value = strings.Join(parts, "\n")
+	}
+
+	if field.GetFieldFlags().Has(pdf.FieldFlagPassword) {
+		value = strings.Repeat("*", len([]rune(value)))
+	}
+
+	value = strings.Replace(value, "\r\n", "\n", -1)
+	value = strings.Replace(value, "\r", "\n", -1)
+	return strings.Split(value, "\n")
+}
+
+// textWidth returns the width of `text` in `font` at size `fontSize`.
+func textWidth(font *pdf.PdfFont, text string, fontSize float64) float64 {
+	encoder := font.Encoder()
+	if encoder == nil {
+		return 0
+	}
+	width := 0.0
+	for _, r := range text {
+		glyph, found := encoder.RuneToGlyph(r)
+		if !found {
+			continue
+		}
+		metrics, found := font.GetGlyphCharMetrics(glyph)
+		if !found {
+			continue
+		}
+		width += metrics.Wx * fontSize / 1000.0
+	}
+	return width
+}
+
+// wrapLines wraps `lines` at word boundaries so that each line fits within `maxWidth`.
+func wrapLines(font *pdf.PdfFont, lines []string, fontSize, maxWidth float64) []string {
+	wrapped := []string{}
+	for _, line := range lines {
+		words := strings.Fields(line)
+		if len(words) == 0 {
+			wrapped = append(wrapped, "")
+			continue
+		}
+		current := words[0]
+		for _, word := range words[1:] {
+			candidate := current + " " + word
+			if textWidth(font, candidate, fontSize) > maxWidth {
+				wrapped = append(wrapped, current)
+				current = word
+			} else {
+				current = candidate
+			}
+		}
+		wrapped = append(wrapped, current)
+	}
+	return wrapped
+}
+
+// drawWidgetBackground draws the background and border of the widget as specified in its
+// appearance characteristics (MK) dictionary.
+func drawWidgetBackground(cc *contentstream.ContentCreator, wa *pdf.PdfAnnotationWidget, width, height float64) {
+	mk, ok := pdfcore.GetDict(wa.MK)
+	if !ok {
+		return
+	}
+
+	setColor := func(obj pdfcore.PdfObject, stroke bool) bool {
+		arr, ok := pdfcore.GetArray(obj)
+		if !ok {
+			return false
+		}
+		c, err := arr.ToFloat64Array()
+		if err != nil {
+			return false
+		}
+		switch len(c) {
+		case 1:
+			if stroke {
+				cc.Add_G(c[0])
+			} else {
+				cc.Add_g(c[0])
+			}
+		case 3:
+			if stroke {
+				cc.Add_RG(c[0], c[1], c[2])
+			} else {
+				cc.Add_rg(c[0], c[1], c[2])
+			}
+		case 4:
+			if stroke {
+				cc.Add_K(c[0], c[1], c[2], c[3])
+			} else {
+				cc.Add_k(c[0], c[1], c[2], c[3])
+			}
+		default:
+			return false
+		}
+		return true
+	}
+
+	cc.Add_q()
+	if setColor(mk.Get("BG"), false) {
+		cc.Add_re(0, 0, width, height).Add_f()
+	}
+	if setColor(mk.Get("BC"), true) {
+		borderWidth := 1.0
+		if bs, ok := pdfcore.GetDict(wa.BS); ok {
+			if w, err := pdfcore.GetNumberAsFloat(bs.Get("W")); err == nil {
+				borderWidth = w
+			}
+		}
+		if borderWidth > 0 {
+			cc.Add_w(borderWidth)
+			cc.Add_re(borderWidth/2, borderWidth/2, width-borderWidth, height-borderWidth).Add_S()
+		}
+	}
+	cc.Add_Q()
+}
@@ -0,0 +1,126 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"strings"
+	"testing"
+
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// testFieldValues is a FieldValueProvider with fixed values.
+type testFieldValues map[string]pdfcore.PdfObject
+
+func (v testFieldValues) FieldValues() (map[string]pdfcore.PdfObject, error) {
+	return v, nil
+}
+
+// makeTestField returns a field named `name` of type `ft` with a single widget at `rect`.
+func makeTestField(name, ft string, rect []float64) (*pdf.PdfField, *pdf.PdfAnnotationWidget) {
+	field := pdf.NewPdfField()
+	field.T = pdfcore.MakeString(name)
+	field.FT = pdfcore.MakeName(ft)
+	widget := pdf.NewPdfAnnotationWidget()
+	widget.Rect = pdfcore.MakeArrayFromFloats(rect)
+	field.KidsA = append(field.KidsA, widget.PdfAnnotation)
+	return field, widget
+}
+
+// getNormalAppearance returns the normal appearance (N) entry of the AP dictionary of `widget`.
+func getNormalAppearance(t *testing.T, widget *pdf.PdfAnnotationWidget) pdfcore.PdfObject {
+	apDict, ok := pdfcore.GetDict(widget.AP)
+	if !ok {
+		t.Fatalf("Missing AP dictionary: %v", widget.AP)
+	}
+	return pdfcore.TraceToDirectObject(apDict.Get("N"))
+}
+
+// Test filling a text field and a checkbox with appearance generation.
+func TestFieldAppearance(t *testing.T) {
+	helvetica, err := pdf.NewStandard14Font("Helvetica")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	form := pdf.NewPdfAcroForm()
+	form.DR = pdf.NewPdfPageResources()
+	form.DR.SetFontByName("Helv", helvetica.ToPdfObject())
+	form.DA = pdfcore.MakeString("/Helv 0 Tf 0 g")
+
+	text, textWidget := makeTestField("name", "Tx", []float64{100, 700, 300, 720})
+	text.DA = pdfcore.MakeString("/Helv 10 Tf 0 0 1 rg")
+	mk := pdfcore.MakeDict()
+	mk.Set("BG", pdfcore.MakeArrayFromFloats([]float64{1, 1, 0}))
+	textWidget.MK = mk
+
+	check, checkWidget := makeTestField("agree", "Btn", []float64{100, 650, 112, 662})
+	states := pdfcore.MakeDict()
+	for _, state := range []string{"Yes", "Off"} {
+		stream, err := pdfcore.MakeStream([]byte("q Q"), nil)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		states.Set(pdfcore.PdfObjectName(state), stream)
+	}
+	checkAP := pdfcore.MakeDict()
+	checkAP.Set("N", states)
+	checkWidget.AP = checkAP
+	checkWidget.AS = pdfcore.MakeName("Off")
+
+	form.Fields = &[]*pdf.PdfField{text, check}
+	err = form.FillWithAppearance(testFieldValues{
+		"name":  pdfcore.MakeString("Hello"),
+		"agree": pdfcore.MakeString("Yes"),
+	}, FieldAppearance{})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	stream, ok := getNormalAppearance(t, textWidget).(*pdfcore.PdfObjectStream)
+	if !ok {
+		t.Fatalf("Text field appearance not a stream")
+	}
+	bbox, ok := pdfcore.GetArray(stream.Get("BBox"))
+	if !ok {
+		t.Fatalf("Missing appearance BBox")
+	}
+	if vals, err := bbox.ToFloat64Array(); err != nil || len(vals) != 4 || vals[2] != 200 || vals[3] != 20 {
+		t.Errorf("Invalid BBox %v", bbox)
+	}
+	resources, ok := pdfcore.GetDict(stream.Get("Resources"))
+	if !ok {
+		t.Fatalf("Missing appearance resources")
+	}
+	if fonts, ok := pdfcore.GetDict(resources.Get("Font")); !ok || fonts.Get("Helv") == nil {
+		t.Errorf("Missing DA font in appearance resources: %v", resources)
+	}
+	decoded, err := pdfcore.DecodeStream(stream)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	content := string(decoded)
+	// The DA of the field overrides the DA of the form, the background is from MK.
+	for _, s := range []string{"1.000000 1.000000 0.000000 rg", "/Tx BMC", "0 0 1 rg", "/Helv 10.000000 Tf", "(Hello) Tj", "EMC"} {
+		if !strings.Contains(content, s) {
+			t.Errorf("%q missing from text field appearance:\n%s", s, content)
+		}
+	}
+	if strings.Contains(content, " g\n") {
+		t.Errorf("Form DA used instead of field DA:\n%s", content)
+	}
+
+	// The checkbox keeps the appearances of the document, the value selects the state.
+	if getNormalAppearance(t, checkWidget) != states {
+		t.Errorf("Checkbox appearance replaced")
+	}
+	if state, ok := pdfcore.GetNameVal(checkWidget.AS); !ok || state != "Yes" {
+		t.Errorf("Invalid checkbox AS %v", checkWidget.AS)
+	}
+	if val, ok := pdfcore.GetNameVal(check.V); !ok || val != "Yes" {
+		t.Errorf("Invalid checkbox value %v", check.V)
+	}
+}
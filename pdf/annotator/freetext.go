@@ -0,0 +1,187 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// FreeTextAlignment defines the horizontal alignment (quadding) of free text annotations.
+type FreeTextAlignment int
+
+// Free text alignments.  The values match the quadding (Q) values of the annotation.
+const (
+	FreeTextAlignmentLeft   FreeTextAlignment = 0
+	FreeTextAlignmentCenter FreeTextAlignment = 1
+	FreeTextAlignmentRight  FreeTextAlignment = 2
+)
+
+// FreeTextAnnotationDef defines a free text annotation: text displayed directly on the page in a
+// box with a lower left corner at (X,Y) and specified Width and Height.  The text is wrapped to fit the
+// box width.  The box can optionally have a border and a filling color.
+type FreeTextAnnotationDef struct {
+	X             float64
+	Y             float64
+	Width         float64
+	Height        float64
+	Text          string
+	Font          *pdf.PdfFont           // Defaults to Helvetica.
+	FontSize      float64                // Defaults to 12.
+	TextColor     *pdf.PdfColorDeviceRGB // Defaults to black.
+	Alignment     FreeTextAlignment
+	FillEnabled   bool // Show fill?
+	FillColor     *pdf.PdfColorDeviceRGB
+	BorderEnabled bool // Show border?
+	BorderWidth   float64
+	BorderColor   *pdf.PdfColorDeviceRGB
+	Opacity       float64 // Alpha value (0-1).
+}
+
+// CreateFreeTextAnnotation creates a free text annotation object that can be added to page PDF annotations.
+func CreateFreeTextAnnotation(textDef FreeTextAnnotationDef) (*pdf.PdfAnnotation, error) {
+	textAnnotation := pdf.NewPdfAnnotationFreeText()
+
+	font := textDef.Font
+	fontName := "F1"
+	if font == nil {
+		var err error
+		font, err = pdf.NewStandard14Font("Helvetica")
+		if err != nil {
+			return nil, err
+		}
+		fontName = "Helv"
+	}
+	if textDef.FontSize <= 0 {
+		textDef.FontSize = fieldAutoFontSize
+	}
+
+	textAnnotation.Contents = pdfcore.MakeEncodedString(textDef.Text)
+
+	// Default appearance, used by viewers when regenerating the appearance.
+	r, g, b := rgbOrDefault(textDef.TextColor, 0, 0, 0)
+	da := fmt.Sprintf("/%s %.4g Tf %.4g %.4g %.4g rg", fontName, textDef.FontSize, r, g, b)
+	textAnnotation.DA = pdfcore.MakeString(da)
+	textAnnotation.Q = pdfcore.MakeInteger(int64(textDef.Alignment))
+
+	if textDef.FillEnabled {
+		fr, fg, fb := rgbOrDefault(textDef.FillColor, 1, 1, 1)
+		textAnnotation.C = pdfcore.MakeArrayFromFloats([]float64{fr, fg, fb})
+	}
+	bs := pdf.NewBorderStyle()
+	if textDef.BorderEnabled {
+		bs.SetBorderWidth(textDef.BorderWidth)
+	} else {
+		bs.SetBorderWidth(0)
+	}
+	textAnnotation.BS = bs.ToPdfObject()
+
+	if textDef.Opacity < 1.0 {
+		textAnnotation.CA = pdfcore.MakeFloat(textDef.Opacity)
+	}
+
+	apDict, err := makeFreeTextAppearanceStream(textDef, font, fontName)
+	if err != nil {
+		return nil, err
+	}
+	textAnnotation.AP = apDict
+	textAnnotation.Rect = pdfcore.MakeArrayFromFloats([]float64{textDef.X, textDef.Y,
+		textDef.X + textDef.Width, textDef.Y + textDef.Height})
+
+	return textAnnotation.PdfAnnotation, nil
+}
+
+func makeFreeTextAppearanceStream(textDef FreeTextAnnotationDef, font *pdf.PdfFont, fontName string) (
+	*pdfcore.PdfObjectDictionary, error) {
+	resources := pdf.NewPdfPageResources()
+	err := resources.SetFontByName(pdfcore.PdfObjectName(fontName), font.ToPdfObject())
+	if err != nil {
+		return nil, err
+	}
+	gsName, err := addOpacityExtGState(resources, textDef.Opacity, "")
+	if err != nil {
+		return nil, err
+	}
+
+	cc := contentstream.NewContentCreator()
+	if len(gsName) > 0 {
+		cc.Add_q()
+		cc.Add_gs(pdfcore.PdfObjectName(gsName))
+	}
+
+	// Box with background and border.  The border is drawn inside the box.
+	if textDef.FillEnabled || textDef.BorderEnabled {
+		cc.Add_q()
+		bw := 0.0
+		if textDef.BorderEnabled {
+			bw = textDef.BorderWidth
+			br, bg, bb := rgbOrDefault(textDef.BorderColor, 0, 0, 0)
+			cc.Add_RG(br, bg, bb)
+			cc.Add_w(bw)
+		}
+		if textDef.FillEnabled {
+			fr, fg, fb := rgbOrDefault(textDef.FillColor, 1, 1, 1)
+			cc.Add_rg(fr, fg, fb)
+		}
+		cc.Add_re(bw/2, bw/2, textDef.Width-bw, textDef.Height-bw)
+		if textDef.FillEnabled && textDef.BorderEnabled {
+			cc.Add_B() // Fill and stroke.
+		} else if textDef.FillEnabled {
+			cc.Add_f() // Fill.
+		} else {
+			cc.Add_S() // Stroke.
+		}
+		cc.Add_Q()
+	}
+
+	padding := fieldTextPadding
+	if textDef.BorderEnabled {
+		padding += textDef.BorderWidth
+	}
+	fontSize := textDef.FontSize
+	text := strings.Replace(textDef.Text, "\r\n", "\n", -1)
+	lines := wrapLines(font, strings.Split(text, "\n"), fontSize, textDef.Width-2*padding)
+
+	r, g, b := rgbOrDefault(textDef.TextColor, 0, 0, 0)
+	cc.Add_q()
+	cc.Add_re(padding, padding, textDef.Width-2*padding, textDef.Height-2*padding).Add_W().Add_n()
+	cc.Add_BT()
+	cc.Add_rg(r, g, b)
+	cc.Add_Tf(pdfcore.PdfObjectName(fontName), fontSize)
+
+	leading := 1.2 * fontSize
+	prevX, prevY := 0.0, 0.0
+	for i, line := range lines {
+		x := padding
+		switch textDef.Alignment {
+		case FreeTextAlignmentCenter:
+			x = (textDef.Width - textWidth(font, line, fontSize)) / 2
+		case FreeTextAlignmentRight:
+			x = textDef.Width - padding - textWidth(font, line, fontSize)
+		}
+		y := textDef.Height - padding - fontSize - float64(i)*leading
+		cc.Add_Td(x-prevX, y-prevY)
+		prevX, prevY = x, y
+
+		encoded := line
+		if encoder := font.Encoder(); encoder != nil {
+			encoded = encoder.Encode(line)
+		}
+		cc.Add_Tj(*pdfcore.MakeString(encoded))
+	}
+	cc.Add_ET()
+	cc.Add_Q()
+	if len(gsName) > 0 {
+		cc.Add_Q()
+	}
+
+	bbox := &pdf.PdfRectangle{Llx: 0, Lly: 0, Urx: textDef.Width, Ury: textDef.Height}
+	return makeAppearanceDict(cc.Bytes(), bbox, resources)
+}
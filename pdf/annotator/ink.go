@@ -0,0 +1,86 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"errors"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// InkAnnotationDef defines a freehand "scribble" composed of one or more disjoint paths in page
+// coordinates, drawn with a specified color, line width and opacity.
+type InkAnnotationDef struct {
+	Paths     []draw.Path
+	Color     *pdf.PdfColorDeviceRGB // Defaults to black.
+	LineWidth float64
+	Opacity   float64 // Alpha value (0-1).
+}
+
+// CreateInkAnnotation creates an ink annotation object that can be added to page PDF annotations.
+func CreateInkAnnotation(inkDef InkAnnotationDef) (*pdf.PdfAnnotation, error) {
+	if len(inkDef.Paths) == 0 {
+		return nil, errors.New("Ink annotation requires at least one path")
+	}
+
+	inkAnnotation := pdf.NewPdfAnnotationInk()
+
+	inkList := pdfcore.MakeArray()
+	for _, path := range inkDef.Paths {
+		inkList.Append(pathToArray(path))
+	}
+	inkAnnotation.InkList = inkList
+
+	r, g, b := rgbOrDefault(inkDef.Color, 0, 0, 0)
+	inkAnnotation.C = pdfcore.MakeArrayFromFloats([]float64{r, g, b})
+	bs := pdf.NewBorderStyle()
+	bs.SetBorderWidth(inkDef.LineWidth)
+	inkAnnotation.BS = bs.ToPdfObject()
+
+	if inkDef.Opacity < 1.0 {
+		inkAnnotation.CA = pdfcore.MakeFloat(inkDef.Opacity)
+	}
+
+	// Make the appearance stream (for uniform appearance).
+	resources := pdf.NewPdfPageResources()
+	gsName, err := addOpacityExtGState(resources, inkDef.Opacity, "")
+	if err != nil {
+		return nil, err
+	}
+
+	points := []draw.Point{}
+	for _, path := range inkDef.Paths {
+		points = append(points, path.Points...)
+	}
+	bbox := pointsBoundingBox(points, inkDef.LineWidth/2)
+
+	cc := contentstream.NewContentCreator()
+	cc.Add_q()
+	if len(gsName) > 0 {
+		cc.Add_gs(pdfcore.PdfObjectName(gsName))
+	}
+	cc.Add_RG(r, g, b)
+	cc.Add_w(inkDef.LineWidth)
+	addRoundLineCapJoin(cc)
+	for _, path := range inkDef.Paths {
+		// Drawn relative to the lower left corner of the bounding box.
+		draw.DrawPathWithCreator(path.Copy().Offset(-bbox.Llx, -bbox.Lly), cc)
+	}
+	cc.Add_S()
+	cc.Add_Q()
+
+	apDict, err := makeAppearanceDict(cc.Bytes(), localRectangle(bbox), resources)
+	if err != nil {
+		return nil, err
+	}
+	inkAnnotation.AP = apDict
+	inkAnnotation.Rect = pdfcore.MakeArrayFromFloats([]float64{bbox.Llx, bbox.Lly, bbox.Urx, bbox.Ury})
+
+	return inkAnnotation.PdfAnnotation, nil
+}
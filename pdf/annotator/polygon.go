@@ -0,0 +1,146 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"errors"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// PolygonAnnotationDef defines a closed polygon with the specified vertices in page coordinates.
+// The polygon can optionally be filled.
+type PolygonAnnotationDef struct {
+	Vertices    draw.Path
+	LineColor   *pdf.PdfColorDeviceRGB // Defaults to black.
+	LineWidth   float64
+	FillEnabled bool // Show fill?
+	FillColor   *pdf.PdfColorDeviceRGB
+	Opacity     float64 // Alpha value (0-1).
+}
+
+// PolyLineAnnotationDef defines an open polyline with the specified vertices in page coordinates.
+type PolyLineAnnotationDef struct {
+	Vertices  draw.Path
+	LineColor *pdf.PdfColorDeviceRGB // Defaults to black.
+	LineWidth float64
+	Opacity   float64 // Alpha value (0-1).
+}
+
+// CreatePolygonAnnotation creates a polygon annotation object that can be added to page PDF annotations.
+func CreatePolygonAnnotation(polyDef PolygonAnnotationDef) (*pdf.PdfAnnotation, error) {
+	if polyDef.Vertices.Length() < 2 {
+		return nil, errors.New("Polygon requires at least two vertices")
+	}
+
+	polyAnnotation := pdf.NewPdfAnnotationPolygon()
+	polyAnnotation.Vertices = pathToArray(polyDef.Vertices)
+
+	r, g, b := rgbOrDefault(polyDef.LineColor, 0, 0, 0)
+	polyAnnotation.C = pdfcore.MakeArrayFromFloats([]float64{r, g, b})
+	bs := pdf.NewBorderStyle()
+	bs.SetBorderWidth(polyDef.LineWidth)
+	polyAnnotation.BS = bs.ToPdfObject()
+
+	var fill []float64
+	if polyDef.FillEnabled {
+		fr, fg, fb := rgbOrDefault(polyDef.FillColor, 1, 1, 1)
+		fill = []float64{fr, fg, fb}
+		polyAnnotation.IC = pdfcore.MakeArrayFromFloats(fill)
+	}
+
+	if polyDef.Opacity < 1.0 {
+		polyAnnotation.CA = pdfcore.MakeFloat(polyDef.Opacity)
+	}
+
+	apDict, bbox, err := makePolyAppearanceStream(polyDef.Vertices, true, []float64{r, g, b}, fill,
+		polyDef.LineWidth, polyDef.Opacity)
+	if err != nil {
+		return nil, err
+	}
+	polyAnnotation.AP = apDict
+	polyAnnotation.Rect = pdfcore.MakeArrayFromFloats([]float64{bbox.Llx, bbox.Lly, bbox.Urx, bbox.Ury})
+
+	return polyAnnotation.PdfAnnotation, nil
+}
+
+// CreatePolyLineAnnotation creates a polyline annotation object that can be added to page PDF annotations.
+func CreatePolyLineAnnotation(polyDef PolyLineAnnotationDef) (*pdf.PdfAnnotation, error) {
+	if polyDef.Vertices.Length() < 2 {
+		return nil, errors.New("PolyLine requires at least two vertices")
+	}
+
+	polyAnnotation := pdf.NewPdfAnnotationPolyLine()
+	polyAnnotation.Vertices = pathToArray(polyDef.Vertices)
+
+	r, g, b := rgbOrDefault(polyDef.LineColor, 0, 0, 0)
+	polyAnnotation.C = pdfcore.MakeArrayFromFloats([]float64{r, g, b})
+	bs := pdf.NewBorderStyle()
+	bs.SetBorderWidth(polyDef.LineWidth)
+	polyAnnotation.BS = bs.ToPdfObject()
+	polyAnnotation.LE = pdfcore.MakeArray(pdfcore.MakeName("None"), pdfcore.MakeName("None"))
+
+	if polyDef.Opacity < 1.0 {
+		polyAnnotation.CA = pdfcore.MakeFloat(polyDef.Opacity)
+	}
+
+	apDict, bbox, err := makePolyAppearanceStream(polyDef.Vertices, false, []float64{r, g, b}, nil,
+		polyDef.LineWidth, polyDef.Opacity)
+	if err != nil {
+		return nil, err
+	}
+	polyAnnotation.AP = apDict
+	polyAnnotation.Rect = pdfcore.MakeArrayFromFloats([]float64{bbox.Llx, bbox.Lly, bbox.Urx, bbox.Ury})
+
+	return polyAnnotation.PdfAnnotation, nil
+}
+
+// makePolyAppearanceStream makes the appearance stream for a polygon (`closed`) or polyline with the
+// specified stroke color, fill color (nil for no fill), line width and opacity.
+func makePolyAppearanceStream(vertices draw.Path, closed bool, stroke, fill []float64, lineWidth, opacity float64) (
+	*pdfcore.PdfObjectDictionary, *pdf.PdfRectangle, error) {
+	resources := pdf.NewPdfPageResources()
+	gsName, err := addOpacityExtGState(resources, opacity, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bbox := pointsBoundingBox(vertices.Points, lineWidth/2)
+
+	cc := contentstream.NewContentCreator()
+	cc.Add_q()
+	if len(gsName) > 0 {
+		cc.Add_gs(pdfcore.PdfObjectName(gsName))
+	}
+	cc.Add_RG(stroke[0], stroke[1], stroke[2])
+	cc.Add_w(lineWidth)
+	if fill != nil {
+		cc.Add_rg(fill[0], fill[1], fill[2])
+	}
+	// Drawn relative to the lower left corner of the bounding box.
+	draw.DrawPathWithCreator(vertices.Copy().Offset(-bbox.Llx, -bbox.Lly), cc)
+	if closed {
+		cc.Add_h()
+	}
+
+	if fill != nil && lineWidth > 0 {
+		cc.Add_B() // Fill and stroke.
+	} else if fill != nil {
+		cc.Add_f() // Fill.
+	} else {
+		cc.Add_S() // Stroke.
+	}
+	cc.Add_Q()
+
+	apDict, err := makeAppearanceDict(cc.Bytes(), localRectangle(bbox), resources)
+	if err != nil {
+		return nil, nil, err
+	}
+	return apDict, bbox, nil
+}
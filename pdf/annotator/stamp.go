@@ -0,0 +1,166 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// StampAnnotationDef defines a rubber stamp annotation with a lower left corner at (X,Y) and specified
+// Width and Height.  The stamp is drawn as a rounded box with the stamp text centered inside.
+// Name is the icon name of the stamp, e.g. Approved, Experimental, NotApproved, AsIs, Expired,
+// NotForPublicRelease, Confidential, Final, Sold, Departmental, ForComment, TopSecret, Draft or
+// ForPublicRelease.
+type StampAnnotationDef struct {
+	X           float64
+	Y           float64
+	Width       float64
+	Height      float64
+	Name        string                 // Defaults to Draft.
+	Text        string                 // Text shown on the stamp, defaults to the name in upper case (e.g. NOT APPROVED).
+	Color       *pdf.PdfColorDeviceRGB // Color of the text and border, defaults to red.
+	BorderWidth float64
+	Opacity     float64 // Alpha value (0-1).
+}
+
+// CreateStampAnnotation creates a rubber stamp annotation object that can be added to page PDF annotations.
+func CreateStampAnnotation(stampDef StampAnnotationDef) (*pdf.PdfAnnotation, error) {
+	stampAnnotation := pdf.NewPdfAnnotationStamp()
+
+	if len(stampDef.Name) == 0 {
+		stampDef.Name = "Draft"
+	}
+	if len(stampDef.Text) == 0 {
+		stampDef.Text = stampText(stampDef.Name)
+	}
+	stampAnnotation.Name = pdfcore.MakeName(stampDef.Name)
+
+	r, g, b := rgbOrDefault(stampDef.Color, 0.8, 0.1, 0.1)
+	stampAnnotation.C = pdfcore.MakeArrayFromFloats([]float64{r, g, b})
+
+	if stampDef.Opacity < 1.0 {
+		stampAnnotation.CA = pdfcore.MakeFloat(stampDef.Opacity)
+	}
+
+	apDict, err := makeStampAppearanceStream(stampDef, r, g, b)
+	if err != nil {
+		return nil, err
+	}
+	stampAnnotation.AP = apDict
+	stampAnnotation.Rect = pdfcore.MakeArrayFromFloats([]float64{stampDef.X, stampDef.Y,
+		stampDef.X + stampDef.Width, stampDef.Y + stampDef.Height})
+
+	return stampAnnotation.PdfAnnotation, nil
+}
+
+// stampText returns the text shown for the stamp icon `name`, i.e. the words of the name in upper case.
+func stampText(name string) string {
+	var words []string
+	word := []rune{}
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) && len(word) > 0 {
+			words = append(words, string(word))
+			word = []rune{}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return strings.ToUpper(strings.Join(words, " "))
+}
+
+func makeStampAppearanceStream(stampDef StampAnnotationDef, r, g, b float64) (*pdfcore.PdfObjectDictionary, error) {
+	font, err := pdf.NewStandard14Font("Helvetica-Bold")
+	if err != nil {
+		return nil, err
+	}
+
+	resources := pdf.NewPdfPageResources()
+	err = resources.SetFontByName("HeBo", font.ToPdfObject())
+	if err != nil {
+		return nil, err
+	}
+	gsName, err := addOpacityExtGState(resources, stampDef.Opacity, "")
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := stampDef.Width, stampDef.Height
+	bw := stampDef.BorderWidth
+
+	cc := contentstream.NewContentCreator()
+	cc.Add_q()
+	if len(gsName) > 0 {
+		cc.Add_gs(pdfcore.PdfObjectName(gsName))
+	}
+
+	// Rounded box inside the annotation rectangle.
+	if bw > 0 {
+		radius := math.Min(width, height) / 8
+		drawRoundedRectangle(cc, bw/2, bw/2, width-bw, height-bw, radius)
+		cc.Add_RG(r, g, b)
+		cc.Add_w(bw)
+		cc.Add_S()
+	}
+
+	// Text centered and scaled to fit the box.
+	padding := bw + 2*fieldTextPadding
+	fontSize := (height - 2*padding) / 1.2
+	if w := textWidth(font, stampDef.Text, fontSize); w > width-2*padding && w > 0 {
+		fontSize = fontSize * (width - 2*padding) / w
+	}
+	if fontSize > 0 {
+		x := (width - textWidth(font, stampDef.Text, fontSize)) / 2
+		y := (height-fontSize)/2 + 0.15*fontSize
+		encoded := stampDef.Text
+		if encoder := font.Encoder(); encoder != nil {
+			encoded = encoder.Encode(stampDef.Text)
+		}
+		cc.Add_BT()
+		cc.Add_rg(r, g, b)
+		cc.Add_Tf("HeBo", fontSize)
+		cc.Add_Td(x, y)
+		cc.Add_Tj(*pdfcore.MakeString(encoded))
+		cc.Add_ET()
+	}
+	cc.Add_Q()
+
+	bbox := &pdf.PdfRectangle{Llx: 0, Lly: 0, Urx: width, Ury: height}
+	return makeAppearanceDict(cc.Bytes(), bbox, resources)
+}
+
+// drawRoundedRectangle adds a closed rectangle path with lower left corner (x,y) and corners rounded
+// with `radius`.
+func drawRoundedRectangle(cc *contentstream.ContentCreator, x, y, width, height, radius float64) {
+	// Bezier control point distance for approximating a quarter circle.
+	magic := 0.551784 * radius
+
+	bpath := draw.NewCubicBezierPath()
+	bpath = bpath.AppendCurve(draw.NewCubicBezierCurve(x+width-radius, y, x+width-radius+magic, y,
+		x+width, y+radius-magic, x+width, y+radius))
+	bpath = bpath.AppendCurve(draw.NewCubicBezierCurve(x+width, y+height-radius, x+width, y+height-radius+magic,
+		x+width-radius+magic, y+height, x+width-radius, y+height))
+	bpath = bpath.AppendCurve(draw.NewCubicBezierCurve(x+radius, y+height, x+radius-magic, y+height,
+		x, y+height-radius+magic, x, y+height-radius))
+	bpath = bpath.AppendCurve(draw.NewCubicBezierCurve(x, y+radius, x, y+radius-magic,
+		x+radius-magic, y, x+radius, y))
+
+	// Connect the corner arcs with straight edges.
+	cc.Add_m(x+radius, y)
+	for _, c := range bpath.Curves {
+		cc.Add_l(c.P0.X, c.P0.Y)
+		cc.Add_c(c.P1.X, c.P1.Y, c.P2.X, c.P2.Y, c.P3.X, c.P3.Y)
+	}
+	cc.Add_h()
+}
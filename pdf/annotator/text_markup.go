@@ -0,0 +1,215 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"errors"
+	"math"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// TextMarkupAnnotationDef defines a text markup annotation (highlight, underline, strikeout or squiggly)
+// covering the text inside the quadrilaterals specified by QuadPoints.
+// Each quadrilateral is defined by 8 values x1 y1 x2 y2 x3 y3 x4 y4 in page coordinates, where
+// (x1,y1) and (x2,y2) are the upper left and right corners and (x3,y3) and (x4,y4) are the lower left
+// and right corners of the text, i.e. (x3,y3)-(x4,y4) runs along the bottom of the text.
+type TextMarkupAnnotationDef struct {
+	QuadPoints []float64
+	Color      *pdf.PdfColorDeviceRGB // Defaults to yellow for highlights and black otherwise.
+	Opacity    float64                // Alpha value (0-1).
+}
+
+// textMarkupType is the type of a text markup annotation.
+type textMarkupType int
+
+const (
+	textMarkupHighlight textMarkupType = iota
+	textMarkupUnderline
+	textMarkupStrikeOut
+	textMarkupSquiggly
+)
+
+// CreateHighlightAnnotation creates a highlight annotation object that can be added to page PDF annotations.
+func CreateHighlightAnnotation(def TextMarkupAnnotationDef) (*pdf.PdfAnnotation, error) {
+	annotation := pdf.NewPdfAnnotationHighlight()
+	quads, err := setTextMarkupAnnotation(annotation.PdfAnnotation, annotation.PdfAnnotationMarkup, def, textMarkupHighlight)
+	if err != nil {
+		return nil, err
+	}
+	annotation.QuadPoints = quads
+	return annotation.PdfAnnotation, nil
+}
+
+// CreateUnderlineAnnotation creates an underline annotation object that can be added to page PDF annotations.
+func CreateUnderlineAnnotation(def TextMarkupAnnotationDef) (*pdf.PdfAnnotation, error) {
+	annotation := pdf.NewPdfAnnotationUnderline()
+	quads, err := setTextMarkupAnnotation(annotation.PdfAnnotation, annotation.PdfAnnotationMarkup, def, textMarkupUnderline)
+	if err != nil {
+		return nil, err
+	}
+	annotation.QuadPoints = quads
+	return annotation.PdfAnnotation, nil
+}
+
+// CreateStrikeOutAnnotation creates a strikeout annotation object that can be added to page PDF annotations.
+func CreateStrikeOutAnnotation(def TextMarkupAnnotationDef) (*pdf.PdfAnnotation, error) {
+	annotation := pdf.NewPdfAnnotationStrikeOut()
+	quads, err := setTextMarkupAnnotation(annotation.PdfAnnotation, annotation.PdfAnnotationMarkup, def, textMarkupStrikeOut)
+	if err != nil {
+		return nil, err
+	}
+	annotation.QuadPoints = quads
+	return annotation.PdfAnnotation, nil
+}
+
+// CreateSquigglyAnnotation creates a squiggly underline annotation object that can be added to page PDF annotations.
+func CreateSquigglyAnnotation(def TextMarkupAnnotationDef) (*pdf.PdfAnnotation, error) {
+	annotation := pdf.NewPdfAnnotationSquiggly()
+	quads, err := setTextMarkupAnnotation(annotation.PdfAnnotation, annotation.PdfAnnotationMarkup, def, textMarkupSquiggly)
+	if err != nil {
+		return nil, err
+	}
+	annotation.QuadPoints = quads
+	return annotation.PdfAnnotation, nil
+}
+
+// setTextMarkupAnnotation sets the common entries and appearance of a text markup annotation.
+// Returns the QuadPoints array.
+func setTextMarkupAnnotation(annotation *pdf.PdfAnnotation, markup *pdf.PdfAnnotationMarkup,
+	def TextMarkupAnnotationDef, markupType textMarkupType) (*pdfcore.PdfObjectArray, error) {
+	if len(def.QuadPoints) == 0 || len(def.QuadPoints)%8 != 0 {
+		return nil, errors.New("QuadPoints must contain 8 values per quadrilateral")
+	}
+
+	r, g, b := rgbOrDefault(def.Color, 0, 0, 0)
+	if markupType == textMarkupHighlight {
+		r, g, b = rgbOrDefault(def.Color, 1, 1, 0)
+	}
+	annotation.C = pdfcore.MakeArrayFromFloats([]float64{r, g, b})
+	if def.Opacity < 1.0 {
+		markup.CA = pdfcore.MakeFloat(def.Opacity)
+	}
+
+	apDict, bbox, err := makeTextMarkupAppearanceStream(def, markupType, r, g, b)
+	if err != nil {
+		return nil, err
+	}
+	annotation.AP = apDict
+	annotation.Rect = pdfcore.MakeArrayFromFloats([]float64{bbox.Llx, bbox.Lly, bbox.Urx, bbox.Ury})
+
+	return pdfcore.MakeArrayFromFloats(def.QuadPoints), nil
+}
+
+func makeTextMarkupAppearanceStream(def TextMarkupAnnotationDef, markupType textMarkupType,
+	r, g, b float64) (*pdfcore.PdfObjectDictionary, *pdf.PdfRectangle, error) {
+	resources := pdf.NewPdfPageResources()
+
+	// Highlights are multiplied with the page content, so that the marked text remains legible.
+	blendMode := ""
+	if markupType == textMarkupHighlight {
+		blendMode = "Multiply"
+	}
+	gsName, err := addOpacityExtGState(resources, def.Opacity, blendMode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	points := []draw.Point{}
+	for i := 0; i < len(def.QuadPoints); i += 2 {
+		points = append(points, draw.NewPoint(def.QuadPoints[i], def.QuadPoints[i+1]))
+	}
+	globalBbox := pointsBoundingBox(points, 1)
+
+	cc := contentstream.NewContentCreator()
+	cc.Add_q()
+	if len(gsName) > 0 {
+		cc.Add_gs(pdfcore.PdfObjectName(gsName))
+	}
+	if markupType == textMarkupHighlight {
+		cc.Add_rg(r, g, b)
+	} else {
+		cc.Add_RG(r, g, b)
+	}
+
+	// The annotation is drawn locally in a relative coordinate system with the lower left corner of the
+	// bounding box as the origin.
+	for i := 0; i+3 < len(points); i += 4 {
+		ul := points[i].Add(-globalBbox.Llx, -globalBbox.Lly)
+		ur := points[i+1].Add(-globalBbox.Llx, -globalBbox.Lly)
+		ll := points[i+2].Add(-globalBbox.Llx, -globalBbox.Lly)
+		lr := points[i+3].Add(-globalBbox.Llx, -globalBbox.Lly)
+		drawTextMarkupQuad(cc, markupType, ul, ur, ll, lr)
+	}
+	cc.Add_Q()
+
+	apDict, err := makeAppearanceDict(cc.Bytes(), localRectangle(globalBbox), resources)
+	if err != nil {
+		return nil, nil, err
+	}
+	return apDict, globalBbox, nil
+}
+
+// drawTextMarkupQuad draws the markup for the quadrilateral with upper left corner `ul`, upper right
+// `ur`, lower left `ll` and lower right `lr`.
+func drawTextMarkupQuad(cc *contentstream.ContentCreator, markupType textMarkupType, ul, ur, ll, lr draw.Point) {
+	// Unit vectors along the text direction and towards the top of the text.
+	along := draw.NewVectorBetween(ll, lr)
+	length := along.Magnitude()
+	up := draw.NewVectorBetween(ll, ul)
+	height := up.Magnitude()
+	if length == 0 || height == 0 {
+		return
+	}
+	along = along.Scale(1 / length)
+	up = up.Scale(1 / height)
+
+	lineWidth := math.Max(height/14, 0.5)
+
+	switch markupType {
+	case textMarkupHighlight:
+		cc.Add_m(ll.X, ll.Y)
+		cc.Add_l(lr.X, lr.Y)
+		cc.Add_l(ur.X, ur.Y)
+		cc.Add_l(ul.X, ul.Y)
+		cc.Add_h()
+		cc.Add_f()
+	case textMarkupUnderline:
+		offset := up.Scale(0.08*height + lineWidth/2)
+		start := ll.AddVector(offset)
+		end := lr.AddVector(offset)
+		cc.Add_w(lineWidth)
+		cc.Add_m(start.X, start.Y)
+		cc.Add_l(end.X, end.Y)
+		cc.Add_S()
+	case textMarkupStrikeOut:
+		offset := up.Scale(0.45 * height)
+		start := ll.AddVector(offset)
+		end := lr.AddVector(offset)
+		cc.Add_w(lineWidth)
+		cc.Add_m(start.X, start.Y)
+		cc.Add_l(end.X, end.Y)
+		cc.Add_S()
+	case textMarkupSquiggly:
+		// Zig-zag along the bottom of the text.
+		period := math.Max(height/4, 1)
+		amplitude := period / 2
+		base := ll.AddVector(up.Scale(lineWidth / 2))
+		cc.Add_w(lineWidth)
+		cc.Add_m(base.X, base.Y)
+		for i := 1; float64(i)*period/2 <= length; i++ {
+			p := base.AddVector(along.Scale(float64(i) * period / 2))
+			if i%2 == 1 {
+				p = p.AddVector(up.Scale(amplitude))
+			}
+			cc.Add_l(p.X, p.Y)
+		}
+		cc.Add_S()
+	}
+}
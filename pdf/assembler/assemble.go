@@ -0,0 +1,420 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package assembler
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// source is a source document used by an assembly.
+type source struct {
+	reader *model.PdfReader
+	num    int // Number of the source in order of first use (starting from 1).
+
+	// pageMap maps the source page objects to the first output page using them.  Destinations to
+	// source pages are remapped to those pages.
+	pageMap map[core.PdfObject]*core.PdfIndirectObject
+
+	// destNames maps the source named destinations to their names in the output document.
+	destNames map[string]string
+
+	// widgetCopies maps the source widget annotations to their copies on the output pages.
+	widgetCopies map[core.PdfObject][]*core.PdfIndirectObject
+
+	// fieldMap maps the source form fields to their copies.
+	fieldMap map[core.PdfObject]*core.PdfIndirectObject
+
+	// formDict is the interactive form dictionary of the source, or nil if it has no form.
+	formDict *core.PdfObjectDictionary
+
+	// drRenames holds the default resources of the form renamed in the output form.
+	drRenames resourceRenames
+
+	// renamedStreams maps the source appearance streams to their copies using the renamed
+	// default resources.
+	renamedStreams map[*core.PdfObjectStream]*core.PdfObjectStream
+}
+
+// assembly holds the state of assembling an output document.
+type assembly struct {
+	refs    []pageRef
+	sources []*source
+	writer  *model.PdfWriter
+
+	// claimed holds the widget annotation copies that belong to an output form field.
+	claimed map[*core.PdfIndirectObject]bool
+}
+
+func newAssembly(refs []pageRef) *assembly {
+	asm := &assembly{refs: refs, claimed: map[*core.PdfIndirectObject]bool{}}
+	writer := model.NewPdfWriter()
+	asm.writer = &writer
+	return asm
+}
+
+// assemble adds the pages to the writer, together with the document level structures of the
+// sources remapped to the output pages.
+func (asm *assembly) assemble() error {
+	bySource := map[*model.PdfReader]*source{}
+	srcPages := make([]*model.PdfPage, len(asm.refs))
+	outPages := make([]*model.PdfPage, len(asm.refs))
+
+	for i, ref := range asm.refs {
+		src, has := bySource[ref.reader]
+		if !has {
+			src = &source{
+				reader:       ref.reader,
+				num:          len(asm.sources) + 1,
+				pageMap:      map[core.PdfObject]*core.PdfIndirectObject{},
+				destNames:    map[string]string{},
+				widgetCopies: map[core.PdfObject][]*core.PdfIndirectObject{},
+				fieldMap:     map[core.PdfObject]*core.PdfIndirectObject{},
+
+				renamedStreams: map[*core.PdfObjectStream]*core.PdfObjectStream{},
+			}
+			bySource[ref.reader] = src
+			asm.sources = append(asm.sources, src)
+			if ref.reader.AcroForm != nil {
+				// Update the field and widget dictionaries from the form model.
+				src.formDict, _ = core.GetDict(ref.reader.AcroForm.ToPdfObject())
+			}
+		}
+
+		page, err := ref.reader.GetPage(ref.index + 1)
+		if err != nil {
+			return err
+		}
+		page.GetPageDict()
+		srcPages[i] = page
+
+		// The page copy shares the resources and contents with the source page.  Article beads
+		// are not copied as they refer to other pages through the article threads.
+		outPage := page.Duplicate()
+		outPage.Annotations = nil
+		outPage.B = nil
+		outPages[i] = outPage
+
+		srcObj := page.GetPageAsIndirectObject()
+		if _, has := src.pageMap[srcObj]; !has {
+			src.pageMap[srcObj] = outPage.GetPageAsIndirectObject()
+		}
+	}
+
+	err := asm.addNames()
+	if err != nil {
+		return err
+	}
+
+	for i, ref := range asm.refs {
+		src := bySource[ref.reader]
+		annots := asm.copyAnnotations(src, srcPages[i].GetPageDict(), outPages[i].GetPageAsIndirectObject())
+		if annots.Len() > 0 {
+			outPages[i].GetPageAsIndirectObject().PdfObject.(*core.PdfObjectDictionary).Set("Annots", annots)
+		}
+	}
+
+	err = asm.addOutlines()
+	if err != nil {
+		return err
+	}
+	err = asm.addForms()
+	if err != nil {
+		return err
+	}
+	err = asm.addOptionalContent()
+	if err != nil {
+		return err
+	}
+
+	for _, page := range outPages {
+		err := asm.writer.AddPage(page)
+		if err != nil {
+			return err
+		}
+	}
+
+	asm.setVersion()
+	return nil
+}
+
+// addNames adds the named destinations of the sources to pages in the output document, and the
+// entries of the other name trees (such as JavaScript and EmbeddedFiles) of the name dictionaries.
+// Names used by more than one source are made unique by adding the source number.
+func (asm *assembly) addNames() error {
+	names := model.NewPdfNames()
+	usedDests := map[string]bool{}
+
+	for _, src := range asm.sources {
+		dests, err := src.reader.GetNamedDestinations()
+		if err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(dests))
+		for key := range dests {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			dest := *dests[key]
+			page, has := src.pageMap[dest.Page]
+			if !has {
+				continue
+			}
+			dest.Page = page
+			name := uniqueName(key, src.num, usedDests)
+			src.destNames[key] = name
+			names.SetDestination(name, &dest)
+		}
+
+		srcNames, err := src.reader.GetNames()
+		if err != nil {
+			return err
+		}
+		if srcNames == nil {
+			continue
+		}
+		for _, category := range srcNames.Categories() {
+			switch category {
+			case "Dests":
+				continue
+			case "Pages", "Templates":
+				// Named pages are not part of the page tree and are not carried over.
+				common.Log.Debug("Name tree %s not supported - dropping", category)
+				continue
+			}
+			tree := names.GetTree(category)
+			if tree == nil {
+				tree = model.NewPdfNameTree()
+				names.SetTree(category, tree)
+			}
+			used := map[string]bool{}
+			for _, key := range tree.Keys() {
+				used[key] = true
+			}
+			srcTree := srcNames.GetTree(category)
+			for _, key := range srcTree.Keys() {
+				val, _ := srcTree.Get(key)
+				tree.Set(uniqueName(key, src.num, used), val)
+			}
+		}
+	}
+
+	asm.writer.SetNames(names)
+	return nil
+}
+
+// uniqueName returns `name`, or `name` with the source number `num` appended if it is already in
+// `used`, and adds the returned name to `used`.
+func uniqueName(name string, num int, used map[string]bool) string {
+	unique := name
+	if used[unique] {
+		unique = fmt.Sprintf("%s_%d", name, num)
+	}
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d_%d", name, num, i)
+	}
+	used[unique] = true
+	return unique
+}
+
+// copyAnnotations returns copies of the annotations of the source page dictionary `pageDict` for the
+// output page `page`.  Destinations of links and actions are remapped, and links to pages that are
+// not in the output document are dropped.  References to popups and replied-to annotations are
+// remapped to the copies on the same page, or removed.
+func (asm *assembly) copyAnnotations(src *source, pageDict *core.PdfObjectDictionary, page *core.PdfIndirectObject) *core.PdfObjectArray {
+	kept := core.MakeArray()
+	arr, ok := core.GetArray(pageDict.Get("Annots"))
+	if !ok {
+		return kept
+	}
+
+	copies := map[core.PdfObject]*core.PdfIndirectObject{}
+	for _, obj := range arr.Elements() {
+		if dict, ok := core.GetDict(obj); ok {
+			copies[obj] = core.MakeIndirectObject(copyDict(dict))
+		}
+	}
+
+	for _, obj := range arr.Elements() {
+		annot, ok := copies[obj]
+		if !ok {
+			continue
+		}
+		dict := annot.PdfObject.(*core.PdfObjectDictionary)
+		dict.Set("P", page)
+		subtype, _ := core.GetNameVal(dict.Get("Subtype"))
+
+		for _, key := range []core.PdfObjectName{"Popup", "IRT", "Parent"} {
+			ref := dict.Get(key)
+			if ref == nil || (key == "Parent" && subtype == "Widget") {
+				// Widget parents are set when copying the form fields.
+				continue
+			}
+			if c, has := copies[ref]; has {
+				dict.Set(key, c)
+			} else {
+				dict.Remove(key)
+			}
+		}
+
+		if dest := dict.Get("Dest"); dest != nil {
+			remapped, ok := asm.remapDest(src, dest)
+			if !ok {
+				common.Log.Debug("Link to a page not in the output - dropping")
+				continue
+			}
+			dict.Set("Dest", remapped)
+		}
+		if action := dict.Get("A"); action != nil {
+			remapped, ok := asm.remapAction(src, action)
+			if !ok {
+				if subtype == "Link" {
+					common.Log.Debug("Link to a page not in the output - dropping")
+					continue
+				}
+				dict.Remove("A")
+			} else {
+				dict.Set("A", remapped)
+			}
+		}
+
+		if subtype == "Widget" {
+			src.widgetCopies[obj] = append(src.widgetCopies[obj], annot)
+		}
+		kept.Append(annot)
+	}
+	return kept
+}
+
+// remapDest returns the destination `obj` of a source document remapped to the output document:
+// explicit destinations to the output page and named destinations to the output name.  Returns
+// false if the destination page is not in the output document.
+func (asm *assembly) remapDest(src *source, obj core.PdfObject) (core.PdfObject, bool) {
+	switch t := core.TraceToDirectObject(obj).(type) {
+	case *core.PdfObjectArray:
+		if t.Len() == 0 {
+			return nil, false
+		}
+		page, has := src.pageMap[t.Get(0)]
+		if !has {
+			return nil, false
+		}
+		elements := append([]core.PdfObject{page}, t.Elements()[1:]...)
+		return core.MakeArray(elements...), true
+	case *core.PdfObjectName:
+		// Names refer to the Dests dictionary of PDF 1.1, which are written to the name tree.
+		name, has := src.destNames[string(*t)]
+		if !has {
+			return nil, false
+		}
+		return core.MakeString(name), true
+	case *core.PdfObjectString:
+		name, has := src.destNames[t.Str()]
+		if !has {
+			return nil, false
+		}
+		return core.MakeString(name), true
+	case *core.PdfObjectDictionary:
+		dest, ok := asm.remapDest(src, t.Get("D"))
+		if !ok {
+			return nil, false
+		}
+		dict := copyDict(t)
+		dict.Set("D", dest)
+		return dict, true
+	}
+	return nil, false
+}
+
+// remapAction returns the action `obj` of a source document with the destinations of the GoTo
+// actions of its Next chain remapped to the output document.  GoTo actions to pages not in the
+// output document are removed from the chain.  Returns false if the destination page of `obj` is
+// not in the output document.
+func (asm *assembly) remapAction(src *source, obj core.PdfObject) (core.PdfObject, bool) {
+	return asm.remapActionChain(src, obj, map[core.PdfObject]core.PdfObject{})
+}
+
+// remapActionChain remaps the action `obj` and its Next chain.  `copies` maps the remapped source
+// action dictionaries to their copies (nil if removed), so that actions shared in the chain are
+// copied once and loops end.
+func (asm *assembly) remapActionChain(src *source, obj core.PdfObject, copies map[core.PdfObject]core.PdfObject) (core.PdfObject, bool) {
+	dict, ok := core.GetDict(obj)
+	if !ok {
+		return obj, true
+	}
+	if c, has := copies[dict]; has {
+		return c, c != nil
+	}
+
+	action := copyDict(dict)
+	if s, _ := core.GetNameVal(dict.Get("S")); s == "GoTo" {
+		dest, ok := asm.remapDest(src, dict.Get("D"))
+		if !ok {
+			copies[dict] = nil
+			return nil, false
+		}
+		action.Set("D", dest)
+	}
+	copies[dict] = action
+
+	switch next := core.TraceToDirectObject(dict.Get("Next")).(type) {
+	case *core.PdfObjectDictionary:
+		if remapped, ok := asm.remapActionChain(src, next, copies); ok {
+			action.Set("Next", remapped)
+		} else {
+			action.Remove("Next")
+		}
+	case *core.PdfObjectArray:
+		arr := core.MakeArray()
+		for _, elem := range next.Elements() {
+			if remapped, ok := asm.remapActionChain(src, elem, copies); ok {
+				arr.Append(remapped)
+			}
+		}
+		if arr.Len() > 0 {
+			action.Set("Next", arr)
+		} else {
+			action.Remove("Next")
+		}
+	}
+	return action, true
+}
+
+// setVersion sets the version of the output document to the highest version of the sources.
+func (asm *assembly) setVersion() {
+	major, minor := 1, 3
+	for _, src := range asm.sources {
+		parts := strings.SplitN(src.reader.PdfVersion(), ".", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		ma, err1 := strconv.Atoi(parts[0])
+		mi, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if ma > major || (ma == major && mi > minor) {
+			major, minor = ma, mi
+		}
+	}
+	asm.writer.SetVersion(major, minor)
+}
+
+// copyDict returns a shallow copy of `dict`.
+func copyDict(dict *core.PdfObjectDictionary) *core.PdfObjectDictionary {
+	c := core.MakeDict()
+	for _, key := range dict.Keys() {
+		c.Set(key, dict.Get(key))
+	}
+	return c
+}
@@ -0,0 +1,279 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+// Package assembler assembles PDF documents from the pages of other documents: merging, splitting,
+// extracting page ranges and inserting, deleting, reordering and duplicating pages.  Outlines,
+// named destinations, links, form fields and optional content are carried over and remapped to the
+// assembled pages.
+package assembler
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// PageRange is a range of page numbers (starting from 1) from First to Last inclusive.  The pages
+// are in reverse order if Last is smaller than First.
+type PageRange struct {
+	First int
+	Last  int
+}
+
+// pages returns the page numbers of the range in order.
+func (r PageRange) pages() []int {
+	pages := []int{}
+	if r.First <= r.Last {
+		for i := r.First; i <= r.Last; i++ {
+			pages = append(pages, i)
+		}
+	} else {
+		for i := r.First; i >= r.Last; i-- {
+			pages = append(pages, i)
+		}
+	}
+	return pages
+}
+
+// ParsePageRanges parses page ranges such as "1-3,5,8-" for a document with `numPages` pages.
+// A range may be a single page number, "first-last", "first-" (to the last page) or "-last" (from
+// the first page).
+func ParsePageRanges(spec string, numPages int) ([]PageRange, error) {
+	ranges := []PageRange{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+
+		r := PageRange{First: 1, Last: numPages}
+		var err error
+		if idx := strings.Index(part, "-"); idx >= 0 {
+			first, last := strings.TrimSpace(part[:idx]), strings.TrimSpace(part[idx+1:])
+			if len(first) > 0 {
+				if r.First, err = strconv.Atoi(first); err != nil {
+					return nil, err
+				}
+			}
+			if len(last) > 0 {
+				if r.Last, err = strconv.Atoi(last); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			if r.First, err = strconv.Atoi(part); err != nil {
+				return nil, err
+			}
+			r.Last = r.First
+		}
+
+		if r.First < 1 || r.First > numPages || r.Last < 1 || r.Last > numPages {
+			common.Log.Debug("Page range %q out of range (%d pages)", part, numPages)
+			return nil, errors.New("Page range out of range")
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// pageRef refers to a page of a source document.
+type pageRef struct {
+	reader *model.PdfReader
+	index  int // Page index starting from 0.
+}
+
+// Assembler holds the list of pages of an output document, each referring to a page of a source
+// document.  The same source page may be used several times, in which case each use gets its own
+// copy of the page annotations and form field widgets.
+type Assembler struct {
+	pages []pageRef
+}
+
+// New returns an assembler with no pages.
+func New() *Assembler {
+	return &Assembler{}
+}
+
+// Merge returns an assembler with all the pages of `readers` in order.
+func Merge(readers ...*model.PdfReader) (*Assembler, error) {
+	a := New()
+	for _, reader := range readers {
+		err := a.Append(reader)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// Extract returns an assembler with the pages of `reader` within `ranges`.
+func Extract(reader *model.PdfReader, ranges ...PageRange) (*Assembler, error) {
+	a := New()
+	err := a.Append(reader, ranges...)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Split returns an assembler per range of `ranges`, each with the pages of `reader` within the
+// range.
+func Split(reader *model.PdfReader, ranges ...PageRange) ([]*Assembler, error) {
+	parts := []*Assembler{}
+	for _, r := range ranges {
+		part, err := Extract(reader, r)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// NumPages returns the number of pages of the output document.
+func (a *Assembler) NumPages() int {
+	return len(a.pages)
+}
+
+// sourcePages returns the pages of `reader` within `ranges`, or all its pages if no ranges are
+// specified.
+func sourcePages(reader *model.PdfReader, ranges []PageRange) ([]pageRef, error) {
+	numPages, err := reader.GetNumPages()
+	if err != nil {
+		return nil, err
+	}
+	if len(ranges) == 0 {
+		ranges = []PageRange{{First: 1, Last: numPages}}
+	}
+
+	refs := []pageRef{}
+	for _, r := range ranges {
+		if r.First < 1 || r.First > numPages || r.Last < 1 || r.Last > numPages {
+			common.Log.Debug("Page range %d-%d out of range (%d pages)", r.First, r.Last, numPages)
+			return nil, errors.New("Page range out of range")
+		}
+		for _, pageNum := range r.pages() {
+			refs = append(refs, pageRef{reader: reader, index: pageNum - 1})
+		}
+	}
+	return refs, nil
+}
+
+// Append appends the pages of `reader` within `ranges` to the output document, or all its pages if
+// no ranges are specified.
+func (a *Assembler) Append(reader *model.PdfReader, ranges ...PageRange) error {
+	return a.Insert(len(a.pages)+1, reader, ranges...)
+}
+
+// Insert inserts the pages of `reader` within `ranges`, or all its pages if no ranges are
+// specified, before page `at` of the output document.  Pages are appended if `at` is one past the
+// last page.
+func (a *Assembler) Insert(at int, reader *model.PdfReader, ranges ...PageRange) error {
+	if at < 1 || at > len(a.pages)+1 {
+		return errors.New("Page number out of range")
+	}
+	refs, err := sourcePages(reader, ranges)
+	if err != nil {
+		return err
+	}
+
+	pages := make([]pageRef, 0, len(a.pages)+len(refs))
+	pages = append(pages, a.pages[:at-1]...)
+	pages = append(pages, refs...)
+	pages = append(pages, a.pages[at-1:]...)
+	a.pages = pages
+	return nil
+}
+
+// Delete deletes `pageNums` (starting from 1) from the output document.
+func (a *Assembler) Delete(pageNums ...int) error {
+	deleted := map[int]bool{}
+	for _, pageNum := range pageNums {
+		if pageNum < 1 || pageNum > len(a.pages) {
+			return errors.New("Page number out of range")
+		}
+		deleted[pageNum-1] = true
+	}
+
+	pages := []pageRef{}
+	for i, ref := range a.pages {
+		if !deleted[i] {
+			pages = append(pages, ref)
+		}
+	}
+	a.pages = pages
+	return nil
+}
+
+// Reorder reorders the pages of the output document, such that page i of the result is page
+// `order[i-1]` of the current output document.  `order` must be a permutation of the page numbers.
+func (a *Assembler) Reorder(order []int) error {
+	if len(order) != len(a.pages) {
+		return errors.New("Order not a permutation of the pages")
+	}
+	used := map[int]bool{}
+	pages := make([]pageRef, len(order))
+	for i, pageNum := range order {
+		if pageNum < 1 || pageNum > len(a.pages) || used[pageNum] {
+			return errors.New("Order not a permutation of the pages")
+		}
+		used[pageNum] = true
+		pages[i] = a.pages[pageNum-1]
+	}
+	a.pages = pages
+	return nil
+}
+
+// Duplicate inserts a copy of page `pageNum` of the output document before page `at`.  The copy is
+// appended if `at` is one past the last page.
+func (a *Assembler) Duplicate(pageNum, at int) error {
+	if pageNum < 1 || pageNum > len(a.pages) || at < 1 || at > len(a.pages)+1 {
+		return errors.New("Page number out of range")
+	}
+	ref := a.pages[pageNum-1]
+
+	pages := make([]pageRef, 0, len(a.pages)+1)
+	pages = append(pages, a.pages[:at-1]...)
+	pages = append(pages, ref)
+	pages = append(pages, a.pages[at-1:]...)
+	a.pages = pages
+	return nil
+}
+
+// Assemble returns a PdfWriter with the pages of the output document and the outlines, named
+// destinations, forms and optional content of the source documents remapped to those pages.  The
+// writer can be further modified, e.g. encrypted, before writing.
+func (a *Assembler) Assemble() (*model.PdfWriter, error) {
+	asm := newAssembly(a.pages)
+	err := asm.assemble()
+	if err != nil {
+		return nil, err
+	}
+	return asm.writer, nil
+}
+
+// Write writes the output document to `w`.
+func (a *Assembler) Write(w io.Writer) error {
+	writer, err := a.Assemble()
+	if err != nil {
+		return err
+	}
+	return writer.Write(w)
+}
+
+// WriteToFile writes the output document to the file at `outputPath`.
+func (a *Assembler) WriteToFile(outputPath string) error {
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return a.Write(f)
+}
@@ -0,0 +1,441 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package assembler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// makeSourceDoc returns a reader for a 3 page document with a link from page 1 to page 3, named
+// destinations "intro" (page 1) and "end" (page 3), outline items to pages 1 and 3 and a text field
+// "name" with a widget on page 2.
+func makeSourceDoc(t *testing.T) *model.PdfReader {
+	pages := []*model.PdfPage{}
+	for i := 0; i < 3; i++ {
+		page := model.NewPdfPage()
+		page.MediaBox = &model.PdfRectangle{Llx: 0, Lly: 0, Urx: 612, Ury: 792}
+		page.Resources = model.NewPdfPageResources()
+		pages = append(pages, page)
+	}
+	pageObj := func(i int) core.PdfObject {
+		return pages[i].GetPageAsIndirectObject()
+	}
+
+	link := model.NewPdfAnnotationLink()
+	link.Rect = core.MakeArrayFromFloats([]float64{10, 10, 100, 30})
+	link.Dest = core.MakeArray(pageObj(2), core.MakeName("Fit"))
+	pages[0].Annotations = append(pages[0].Annotations, link.PdfAnnotation)
+
+	field := model.NewPdfField()
+	field.T = core.MakeString("name")
+	field.FT = core.MakeName("Tx")
+	widget := model.NewPdfAnnotationWidget()
+	widget.Rect = core.MakeArrayFromFloats([]float64{100, 700, 300, 720})
+	widget.Parent = field.GetContainingPdfObject()
+	field.KidsA = append(field.KidsA, widget.PdfAnnotation)
+	pages[1].Annotations = append(pages[1].Annotations, widget.PdfAnnotation)
+	form := model.NewPdfAcroForm()
+	form.Fields = &[]*model.PdfField{field}
+
+	writer := model.NewPdfWriter()
+	for _, page := range pages {
+		if err := writer.AddPage(page); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+	writer.SetForms(form)
+	writer.AddNamedDestination("intro", model.NewPdfDestinationFit(pageObj(0)))
+	writer.AddNamedDestination("end", model.NewPdfDestinationFit(pageObj(2)))
+
+	start := model.NewPdfOutlineItem()
+	start.Title = core.MakeString("Start")
+	start.Dest = core.MakeArray(pageObj(0), core.MakeName("Fit"))
+	end := model.NewPdfOutlineItem()
+	end.Title = core.MakeString("End")
+	end.Dest = core.MakeString("end")
+	outlines := model.NewPdfOutlineTree()
+	linkOutlineItems(&outlines.PdfOutlineTreeNode, []*model.PdfOutlineItem{start, end})
+	writer.AddOutlineTree(&outlines.PdfOutlineTreeNode)
+
+	var buf bytes.Buffer
+	if err := writer.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	reader, err := model.NewPdfReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return reader
+}
+
+// writeAndRead writes the output document of `a` and reads it back.
+func writeAndRead(t *testing.T, a *Assembler) *model.PdfReader {
+	var buf bytes.Buffer
+	if err := a.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	reader, err := model.NewPdfReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return reader
+}
+
+// pageAnnots returns the annotation dictionaries of page `pageNum` of `reader`.
+func pageAnnots(t *testing.T, reader *model.PdfReader, pageNum int) []*core.PdfObjectDictionary {
+	page, err := reader.GetPage(pageNum)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	annots := []*core.PdfObjectDictionary{}
+	if arr, ok := core.GetArray(page.GetPageDict().Get("Annots")); ok {
+		for _, obj := range arr.Elements() {
+			if dict, ok := core.GetDict(obj); ok {
+				annots = append(annots, dict)
+			}
+		}
+	}
+	return annots
+}
+
+func TestParsePageRanges(t *testing.T) {
+	ranges, err := ParsePageRanges("1-3, 5,8-, -2, 4-2", 10)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	expected := []PageRange{{1, 3}, {5, 5}, {8, 10}, {1, 2}, {4, 2}}
+	if len(ranges) != len(expected) {
+		t.Fatalf("Got %v, expected %v", ranges, expected)
+	}
+	for i := range expected {
+		if ranges[i] != expected[i] {
+			t.Errorf("Range %d: got %v, expected %v", i, ranges[i], expected[i])
+		}
+	}
+	if pages := ranges[4].pages(); len(pages) != 3 || pages[0] != 4 || pages[2] != 2 {
+		t.Errorf("Incorrect reversed pages: %v", pages)
+	}
+
+	for _, spec := range []string{"0-2", "3-11", "a"} {
+		if _, err := ParsePageRanges(spec, 10); err == nil {
+			t.Errorf("No error for %q", spec)
+		}
+	}
+}
+
+// Test merging two documents: the fields, named destinations and links of each document should
+// refer to the pages of that document in the output.
+func TestMerge(t *testing.T) {
+	a, err := Merge(makeSourceDoc(t), makeSourceDoc(t))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	reader := writeAndRead(t, a)
+	if numPages, _ := reader.GetNumPages(); numPages != 6 {
+		t.Fatalf("Got %d pages, expected 6", numPages)
+	}
+	page3, _ := reader.GetPage(3)
+	page6, _ := reader.GetPage(6)
+
+	// Links.
+	for _, check := range []struct {
+		pageNum int
+		target  *model.PdfPage
+	}{{1, page3}, {4, page6}} {
+		annots := pageAnnots(t, reader, check.pageNum)
+		if len(annots) != 1 {
+			t.Fatalf("Page %d: got %d annotations", check.pageNum, len(annots))
+		}
+		dest, ok := core.GetArray(annots[0].Get("Dest"))
+		if !ok || dest.Get(0) != check.target.GetPageAsIndirectObject() {
+			t.Errorf("Page %d: incorrect link destination %v", check.pageNum, annots[0].Get("Dest"))
+		}
+	}
+
+	// Named destinations.
+	dests, err := reader.GetNamedDestinations()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(dests) != 4 || dests["end"] == nil || dests["end_2"] == nil {
+		t.Fatalf("Incorrect named destinations: %v", dests)
+	}
+	if dests["end"].Page != page3.GetPageAsIndirectObject() || dests["end_2"].Page != page6.GetPageAsIndirectObject() {
+		t.Errorf("Incorrect named destination pages")
+	}
+
+	// Outlines: the second "End" item should use the renamed destination.
+	var titles []string
+	var lastDest core.PdfObject
+	for node := reader.GetOutlineTree().First; node != nil; node = node.Item().Next {
+		titles = append(titles, node.Item().Title.Str())
+		lastDest = node.Item().Dest
+	}
+	if len(titles) != 4 || titles[0] != "Start" || titles[3] != "End" {
+		t.Fatalf("Incorrect outlines: %v", titles)
+	}
+	if name, _ := core.GetStringVal(lastDest); name != "end_2" {
+		t.Errorf("Incorrect outline destination: %v", lastDest)
+	}
+
+	// Fields.
+	if reader.AcroForm == nil {
+		t.Fatalf("No form")
+	}
+	names := []string{}
+	for _, field := range reader.AcroForm.AllFields() {
+		name, _ := field.FullName()
+		names = append(names, name)
+	}
+	if len(names) != 2 || names[0] != "name" || names[1] != "name_2" {
+		t.Errorf("Incorrect field names: %v", names)
+	}
+}
+
+// Test that links and destinations to pages not in the output are dropped.
+func TestExtract(t *testing.T) {
+	a, err := Extract(makeSourceDoc(t), PageRange{1, 2})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	reader := writeAndRead(t, a)
+	if numPages, _ := reader.GetNumPages(); numPages != 2 {
+		t.Fatalf("Got %d pages, expected 2", numPages)
+	}
+	if annots := pageAnnots(t, reader, 1); len(annots) != 0 {
+		t.Errorf("Link to removed page not dropped")
+	}
+	dests, _ := reader.GetNamedDestinations()
+	if len(dests) != 1 || dests["intro"] == nil {
+		t.Errorf("Incorrect named destinations: %v", dests)
+	}
+	tree := reader.GetOutlineTree()
+	if tree == nil || tree.First == nil || tree.First.Item().Next != nil {
+		t.Errorf("Outline item to removed page not dropped")
+	}
+	if reader.AcroForm == nil || len(reader.AcroForm.AllFields()) != 1 {
+		t.Errorf("Field not kept")
+	}
+
+	// Without page 2 the field has no widgets and is dropped.
+	err = a.Delete(2)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	reader = writeAndRead(t, a)
+	if reader.AcroForm != nil && len(reader.AcroForm.AllFields()) != 0 {
+		t.Errorf("Field without widgets not dropped")
+	}
+}
+
+// Test that duplicated pages get their own widgets of the same field, and that links point to the
+// first use of the target page.
+func TestDuplicateReorder(t *testing.T) {
+	a, err := Extract(makeSourceDoc(t))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := a.Duplicate(2, 4); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	// Pages are now 1 2 3 2, reordered to 3 2 1 2.
+	if err := a.Reorder([]int{3, 2, 1, 4}); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := a.Reorder([]int{1, 1, 2, 3}); err == nil {
+		t.Errorf("No error for invalid order")
+	}
+
+	reader := writeAndRead(t, a)
+	if numPages, _ := reader.GetNumPages(); numPages != 4 {
+		t.Fatalf("Got %d pages, expected 4", numPages)
+	}
+	page1, _ := reader.GetPage(1)
+	annots := pageAnnots(t, reader, 3)
+	if len(annots) != 1 {
+		t.Fatalf("Got %d annotations on page 3", len(annots))
+	}
+	if dest, ok := core.GetArray(annots[0].Get("Dest")); !ok || dest.Get(0) != page1.GetPageAsIndirectObject() {
+		t.Errorf("Incorrect link destination %v", annots[0].Get("Dest"))
+	}
+
+	widget2, widget4 := pageAnnots(t, reader, 2), pageAnnots(t, reader, 4)
+	if len(widget2) != 1 || len(widget4) != 1 {
+		t.Fatalf("Widgets not copied")
+	}
+	if widget2[0] == widget4[0] {
+		t.Errorf("Widget shared between pages")
+	}
+	fields := reader.AcroForm.AllFields()
+	if len(fields) != 1 || len(fields[0].Widgets()) != 2 {
+		t.Errorf("Widgets not kids of the same field")
+	}
+	// The widgets are direct kids of the field, without nameless field levels in between.  The
+	// form model loads each widget kid as a nameless field holding the widget.
+	for _, kid := range fields[0].KidsF {
+		if child, ok := kid.(*model.PdfField); !ok || child.T != nil || len(child.KidsF) != 0 || len(child.KidsA) != 1 {
+			t.Errorf("Field kid not a widget: %v", kid)
+		}
+	}
+}
+
+// makeFormDoc returns a reader for a 1 page document with a text field "name" using the font
+// /Helv of the default resources, set to the standard font `basefont`, in its default appearance
+// and in the appearance stream of its widget.
+func makeFormDoc(t *testing.T, basefont string) *model.PdfReader {
+	page := model.NewPdfPage()
+	page.MediaBox = &model.PdfRectangle{Llx: 0, Lly: 0, Urx: 612, Ury: 792}
+	page.Resources = model.NewPdfPageResources()
+
+	font, err := model.NewStandard14Font(basefont)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	form := model.NewPdfAcroForm()
+	form.DR = model.NewPdfPageResources()
+	form.DR.SetFontByName("Helv", font.ToPdfObject())
+	form.DA = core.MakeString("/Helv 0 Tf 0 g")
+
+	field := model.NewPdfField()
+	field.T = core.MakeString("name")
+	field.FT = core.MakeName("Tx")
+	field.DA = core.MakeString("/Helv 10 Tf 0 g")
+	widget := model.NewPdfAnnotationWidget()
+	widget.Rect = core.MakeArrayFromFloats([]float64{100, 700, 300, 720})
+	widget.Parent = field.GetContainingPdfObject()
+	stream, err := core.MakeStream([]byte("/Tx BMC BT /Helv 10 Tf 2 5 Td (Hello) Tj ET EMC"), nil)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	stream.Set("BBox", core.MakeArrayFromFloats([]float64{0, 0, 200, 20}))
+	ap := core.MakeDict()
+	ap.Set("N", stream)
+	widget.AP = ap
+	field.KidsA = append(field.KidsA, widget.PdfAnnotation)
+	page.Annotations = append(page.Annotations, widget.PdfAnnotation)
+	form.Fields = &[]*model.PdfField{field}
+
+	writer := model.NewPdfWriter()
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	writer.SetForms(form)
+	var buf bytes.Buffer
+	if err := writer.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	reader, err := model.NewPdfReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return reader
+}
+
+// Test that default resources with the same name in different sources are renamed, together with
+// their uses in default appearances and appearance streams.
+func TestMergeFormResources(t *testing.T) {
+	a, err := Merge(makeFormDoc(t, "Helvetica"), makeFormDoc(t, "Courier"))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	reader := writeAndRead(t, a)
+	form := reader.AcroForm
+	if form == nil || form.DR == nil {
+		t.Fatalf("No form default resources")
+	}
+	for name, basefont := range map[core.PdfObjectName]string{"Helv": "Helvetica", "Helv_2": "Courier"} {
+		obj, ok := form.DR.GetFontByName(name)
+		if !ok {
+			t.Fatalf("Missing font %s", name)
+		}
+		font, _ := core.GetDict(obj)
+		if font == nil || font.Get("BaseFont").String() != basefont {
+			t.Errorf("Font %s: got %v, expected %s", name, obj, basefont)
+		}
+	}
+
+	fields := form.AllFields()
+	if len(fields) != 2 {
+		t.Fatalf("Got %d fields, expected 2", len(fields))
+	}
+	for i, font := range []string{"/Helv", "/Helv_2"} {
+		da := fields[i].GetDA()
+		if !strings.HasPrefix(da, font+" 10") {
+			t.Errorf("Field %d: DA %q does not use %s", i, da, font)
+		}
+		widgets := fields[i].Widgets()
+		if len(widgets) != 1 {
+			t.Fatalf("Field %d: got %d widgets", i, len(widgets))
+		}
+		ap, _ := core.GetDict(widgets[0].AP)
+		stream, ok := core.GetStream(ap.Get("N"))
+		if !ok {
+			t.Fatalf("Field %d: missing appearance stream", i)
+		}
+		data, err := core.DecodeStream(stream)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if !strings.Contains(string(data), font+" 10") {
+			t.Errorf("Field %d: appearance stream does not use %s: %s", i, font, data)
+		}
+	}
+}
+
+// Test that the GoTo actions of Next chains are remapped, and removed if their page is not in the
+// output.
+func TestRemapActionChain(t *testing.T) {
+	kept, dropped := core.MakeIndirectObject(core.MakeDict()), core.MakeIndirectObject(core.MakeDict())
+	out := core.MakeIndirectObject(core.MakeDict())
+	src := &source{pageMap: map[core.PdfObject]*core.PdfIndirectObject{kept: out}}
+	asm := &assembly{}
+
+	goTo := func(page core.PdfObject) *core.PdfObjectDictionary {
+		action := core.MakeDict()
+		action.Set("S", core.MakeName("GoTo"))
+		action.Set("D", core.MakeArray(page, core.MakeName("Fit")))
+		return action
+	}
+	js := core.MakeDict()
+	js.Set("S", core.MakeName("JavaScript"))
+	js.Set("JS", core.MakeString("app.alert('Hello');"))
+	last := goTo(kept)
+	js.Set("Next", core.MakeArray(goTo(dropped), last))
+	// Loop back to the first action.
+	last.Set("Next", js)
+
+	obj, ok := asm.remapAction(src, js)
+	if !ok {
+		t.Fatalf("Action chain dropped")
+	}
+	action, _ := core.GetDict(obj)
+	if action == js {
+		t.Fatalf("Source action modified in place")
+	}
+	next, ok := core.GetArray(action.Get("Next"))
+	if !ok || next.Len() != 1 {
+		t.Fatalf("Incorrect Next %v", action.Get("Next"))
+	}
+	remapped, _ := core.GetDict(next.Get(0))
+	if dest, ok := core.GetArray(remapped.Get("D")); !ok || dest.Get(0) != out {
+		t.Errorf("Incorrect destination %v", remapped.Get("D"))
+	}
+	if remapped.Get("Next") != action {
+		t.Errorf("Loop not kept in the copied chain")
+	}
+	if dest, _ := core.GetArray(last.Get("D")); dest.Get(0) != kept {
+		t.Errorf("Source destination modified")
+	}
+
+	if _, ok := asm.remapAction(src, goTo(dropped)); ok {
+		t.Errorf("GoTo action to a page not in the output kept")
+	}
+}
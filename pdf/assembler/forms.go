@@ -0,0 +1,478 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package assembler
+
+import (
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Keys of field entries that are moved from widget annotations to their fields, for widgets that
+// were merged with their field in the source document.
+var fieldKeys = []core.PdfObjectName{"FT", "T", "TU", "TM", "Ff", "V", "DV", "Opt", "TI", "I", "MaxLen", "Lock",
+	"SV", "RV", "DS"}
+
+// addForms merges the interactive forms of the sources into the output form.  Only fields with
+// widgets on the output pages are kept, with the copies of the widgets.  Top level fields with
+// names used by an earlier source are renamed by adding the source number, so that fields of
+// different sources do not share values.
+func (asm *assembly) addForms() error {
+	formDict := core.MakeDict()
+	fields := core.MakeArray()
+	usedNames := map[string]bool{}
+	calcOrder := []string{}
+	needAppearances := false
+	sigFlags := 0
+
+	for _, src := range asm.sources {
+		srcDict := src.formDict
+		if srcDict == nil {
+			continue
+		}
+
+		if dr, ok := core.GetDict(srcDict.Get("DR")); ok {
+			src.drRenames = mergeResources(formDict, dr, src.num)
+		}
+		// The form defaults of the first source are used for the output form.  The top level
+		// fields of the other sources get their own defaults if they differ.
+		defaults := map[core.PdfObjectName]core.PdfObject{}
+		for _, key := range []core.PdfObjectName{"DA", "Q"} {
+			val := srcDict.Get(key)
+			if val == nil {
+				continue
+			}
+			if key == "DA" {
+				val = src.drRenames.renameDA(val)
+			}
+			if formDict.Get(key) == nil {
+				formDict.Set(key, val)
+			} else if !sameValue(formDict.Get(key), val) {
+				defaults[key] = val
+			}
+		}
+
+		if arr, ok := core.GetArray(srcDict.Get("Fields")); ok {
+			for _, obj := range arr.Elements() {
+				field := asm.copyField(src, obj, nil)
+				if field == nil {
+					continue
+				}
+				dict := field.PdfObject.(*core.PdfObjectDictionary)
+				for key, val := range defaults {
+					if dict.Get(key) == nil {
+						dict.Set(key, val)
+					}
+				}
+				name := ""
+				if str, ok := core.GetString(dict.Get("T")); ok {
+					name = str.Decoded()
+				}
+				if unique := uniqueName(name, src.num, usedNames); unique != name {
+					dict.Set("T", core.MakeEncodedString(unique))
+				}
+				fields.Append(field)
+			}
+		}
+
+		if arr, ok := core.GetArray(srcDict.Get("CO")); ok {
+			for _, obj := range arr.Elements() {
+				if field, has := src.fieldMap[obj]; has {
+					calcOrder = append(calcOrder, fullName(field))
+				}
+			}
+		}
+		if val, ok := core.GetBoolVal(srcDict.Get("NeedAppearances")); ok && val {
+			needAppearances = true
+		}
+		if val, ok := core.GetIntVal(srcDict.Get("SigFlags")); ok {
+			sigFlags |= val
+		}
+		if srcDict.Get("XFA") != nil {
+			common.Log.Debug("XFA forms cannot be merged - dropping XFA")
+		}
+	}
+
+	// Widgets without a field in the output form are kept as plain annotations.
+	for _, src := range asm.sources {
+		for _, widgets := range src.widgetCopies {
+			for _, widget := range widgets {
+				if !asm.claimed[widget] {
+					widget.PdfObject.(*core.PdfObjectDictionary).Remove("Parent")
+				}
+			}
+		}
+	}
+
+	if fields.Len() == 0 {
+		return nil
+	}
+	formDict.Set("Fields", fields)
+	if needAppearances {
+		formDict.Set("NeedAppearances", core.MakeBool(true))
+	}
+	if sigFlags != 0 {
+		formDict.Set("SigFlags", core.MakeInteger(int64(sigFlags)))
+	}
+
+	form, err := model.NewPdfAcroFormFromObject(formDict)
+	if err != nil {
+		return err
+	}
+	for _, field := range form.AllFields() {
+		collapseWidgetFields(field)
+	}
+	if len(calcOrder) > 0 {
+		// The form model has its own field objects, which are looked up by name.
+		co := core.MakeArray()
+		for _, name := range calcOrder {
+			if field := form.GetFieldByFullName(name); field != nil {
+				co.Append(field.GetContainingPdfObject())
+			}
+		}
+		form.CO = co
+	}
+	return asm.writer.SetForms(form)
+}
+
+// copyField returns a copy of the source field `obj` with parent `parent`, with the copies of its
+// widgets on the output pages as kids.  Returns nil if none of its widgets is on the output pages.
+func (asm *assembly) copyField(src *source, obj core.PdfObject, parent *core.PdfIndirectObject) *core.PdfIndirectObject {
+	dict, ok := core.GetDict(obj)
+	if !ok {
+		return nil
+	}
+
+	fieldDict := core.MakeDict()
+	kids := []core.PdfObject{}
+	if isWidget(dict) {
+		// Field merged with its widget.
+		kids = append(kids, obj)
+	} else {
+		for _, key := range dict.Keys() {
+			if key != "Kids" && key != "Parent" {
+				fieldDict.Set(key, dict.Get(key))
+			}
+		}
+		if arr, ok := core.GetArray(dict.Get("Kids")); ok {
+			kids = arr.Elements()
+		}
+		if da := fieldDict.Get("DA"); da != nil {
+			fieldDict.Set("DA", src.drRenames.renameDA(da))
+		}
+	}
+	field := core.MakeIndirectObject(fieldDict)
+	if parent != nil {
+		fieldDict.Set("Parent", parent)
+	}
+
+	kidsArr := core.MakeArray()
+	visited := map[core.PdfObject]bool{}
+	var addKids func(kids []core.PdfObject)
+	addKids = func(kids []core.PdfObject) {
+		for _, kid := range kids {
+			kidDict, ok := core.GetDict(kid)
+			if !ok || visited[kid] {
+				continue
+			}
+			visited[kid] = true
+			if !isWidget(kidDict) && kidDict.Get("T") == nil {
+				// Nameless level holding widgets of the field, as written by the form model for
+				// widgets in separate dictionaries.  Its widgets are moved to the field.
+				for _, key := range fieldKeys {
+					if val := kidDict.Get(key); val != nil && fieldDict.Get(key) == nil {
+						fieldDict.Set(key, val)
+					}
+				}
+				if arr, ok := core.GetArray(kidDict.Get("Kids")); ok {
+					addKids(arr.Elements())
+				}
+				continue
+			}
+			if !isWidget(kidDict) {
+				if kidField := asm.copyField(src, kid, field); kidField != nil {
+					kidsArr.Append(kidField)
+				}
+				continue
+			}
+			for _, widget := range src.widgetCopies[kid] {
+				widgetDict := widget.PdfObject.(*core.PdfObjectDictionary)
+				for _, key := range fieldKeys {
+					if val := widgetDict.Get(key); val != nil {
+						if fieldDict.Get(key) == nil {
+							fieldDict.Set(key, val)
+						}
+						widgetDict.Remove(key)
+					}
+				}
+				if da := widgetDict.Get("DA"); da != nil {
+					widgetDict.Set("DA", src.drRenames.renameDA(da))
+				}
+				src.renameAppearances(widgetDict)
+				widgetDict.Set("Parent", field)
+				asm.claimed[widget] = true
+				kidsArr.Append(widget)
+			}
+		}
+	}
+	addKids(kids)
+	if kidsArr.Len() == 0 {
+		return nil
+	}
+	fieldDict.Set("Kids", kidsArr)
+	src.fieldMap[obj] = field
+	return field
+}
+
+// collapseWidgetFields moves the widgets of the nameless kids of `field` to the field.  The form
+// model loads the widgets of a field as nameless kid fields, which would add a field level between
+// the field and its widgets when the form is written.
+func collapseWidgetFields(field *model.PdfField) {
+	kidsF := []model.PdfModel{}
+	for _, kid := range field.KidsF {
+		child, ok := kid.(*model.PdfField)
+		if !ok || child.T != nil {
+			kidsF = append(kidsF, kid)
+			continue
+		}
+		for _, widget := range child.Widgets() {
+			widget.Parent = field.GetContainingPdfObject()
+			field.KidsA = append(field.KidsA, widget.PdfAnnotation)
+		}
+	}
+	field.KidsF = kidsF
+}
+
+// isWidget returns true if `dict` is a widget annotation dictionary.
+func isWidget(dict *core.PdfObjectDictionary) bool {
+	subtype, _ := core.GetNameVal(dict.Get("Subtype"))
+	return subtype == "Widget"
+}
+
+// fullName returns the fully qualified name of the field `field`.
+func fullName(field *core.PdfIndirectObject) string {
+	parts := []string{}
+	visited := map[core.PdfObject]bool{}
+	for obj := core.PdfObject(field); obj != nil && !visited[obj]; {
+		visited[obj] = true
+		dict, ok := core.GetDict(obj)
+		if !ok {
+			break
+		}
+		if str, ok := core.GetString(dict.Get("T")); ok {
+			parts = append([]string{str.Decoded()}, parts...)
+		}
+		obj = dict.Get("Parent")
+	}
+	return strings.Join(parts, ".")
+}
+
+// mergeResources adds the resources of the default resources `dr` of source number `num` to the
+// default resources of `formDict`.  Resources with names already in use are added under new names.
+// Returns the renamed resources.
+func mergeResources(formDict *core.PdfObjectDictionary, dr *core.PdfObjectDictionary, num int) resourceRenames {
+	out, ok := core.GetDict(formDict.Get("DR"))
+	if !ok {
+		out = core.MakeDict()
+		formDict.Set("DR", out)
+	}
+	renames := resourceRenames{}
+	for _, category := range dr.Keys() {
+		srcRes, ok := core.GetDict(dr.Get(category))
+		if !ok {
+			if out.Get(category) == nil {
+				out.Set(category, dr.Get(category))
+			}
+			continue
+		}
+		res, ok := core.GetDict(out.Get(category))
+		if !ok {
+			res = core.MakeDict()
+			out.Set(category, res)
+		}
+		used := map[string]bool{}
+		for _, name := range res.Keys() {
+			used[string(name)] = true
+		}
+		for _, name := range srcRes.Keys() {
+			obj := srcRes.Get(name)
+			if existing := res.Get(name); existing == obj {
+				continue
+			}
+			unique := core.PdfObjectName(uniqueName(string(name), num, used))
+			res.Set(unique, obj)
+			if unique != name {
+				if renames[category] == nil {
+					renames[category] = map[core.PdfObjectName]core.PdfObjectName{}
+				}
+				renames[category][name] = unique
+			}
+		}
+	}
+	return renames
+}
+
+// resourceRenames maps resource categories (e.g. Font) to the renamed default resources of a source,
+// from the name in the source to the name in the output.
+type resourceRenames map[core.PdfObjectName]map[core.PdfObjectName]core.PdfObjectName
+
+// resourceOperators maps the content stream operators with a resource name operand to the category
+// of the resource.
+var resourceOperators = map[string]core.PdfObjectName{
+	"Tf":  "Font",
+	"Do":  "XObject",
+	"gs":  "ExtGState",
+	"cs":  "ColorSpace",
+	"CS":  "ColorSpace",
+	"sh":  "Shading",
+	"scn": "Pattern",
+	"SCN": "Pattern",
+}
+
+// rename replaces the names of the renamed resources in the operands of `ops`, except the names
+// defined in `resources`.  Returns true if any name was replaced.
+func (renames resourceRenames) rename(ops *contentstream.ContentStreamOperations, resources *core.PdfObjectDictionary) bool {
+	changed := false
+	for _, op := range *ops {
+		category, has := resourceOperators[op.Operand]
+		if !has || len(op.Params) == 0 {
+			continue
+		}
+		// The pattern name is the last operand of scn and SCN, the name is the first otherwise.
+		i := 0
+		if category == "Pattern" {
+			i = len(op.Params) - 1
+		}
+		name, ok := op.Params[i].(*core.PdfObjectName)
+		if !ok {
+			continue
+		}
+		unique, has := renames[category][*name]
+		if !has {
+			continue
+		}
+		if local, ok := core.GetDict(resources.Get(category)); ok && local.Get(*name) != nil {
+			continue
+		}
+		op.Params[i] = core.MakeName(string(unique))
+		changed = true
+	}
+	return changed
+}
+
+// renameDA returns the default appearance string `obj` with the names of the renamed fonts
+// replaced, or `obj` if it does not use any of them.
+func (renames resourceRenames) renameDA(obj core.PdfObject) core.PdfObject {
+	da, ok := core.GetStringVal(obj)
+	if !ok || len(renames["Font"]) == 0 {
+		return obj
+	}
+	ops, err := contentstream.NewContentStreamParser(da).Parse()
+	if err != nil {
+		common.Log.Debug("Invalid DA %q: %v", da, err)
+		return obj
+	}
+	if !renames.rename(ops, core.MakeDict()) {
+		return obj
+	}
+	return core.MakeString(strings.TrimSpace(strings.Replace(string(ops.Bytes()), "\n", " ", -1)))
+}
+
+// renameAppearances replaces the appearance streams of the widget annotation `dict` that use
+// renamed default resources with copies using the new names.
+func (src *source) renameAppearances(dict *core.PdfObjectDictionary) {
+	ap, ok := core.GetDict(dict.Get("AP"))
+	if !ok || len(src.drRenames) == 0 {
+		return
+	}
+	renamedAP := copyDict(ap)
+	changed := false
+	for _, key := range ap.Keys() {
+		obj := ap.Get(key)
+		if stream, ok := core.GetStream(obj); ok {
+			if renamed := src.renameStream(stream); renamed != stream {
+				renamedAP.Set(key, renamed)
+				changed = true
+			}
+			continue
+		}
+		// Appearance subdictionary of the states of the widget.
+		states, ok := core.GetDict(obj)
+		if !ok {
+			continue
+		}
+		renamedStates := copyDict(states)
+		statesChanged := false
+		for _, state := range states.Keys() {
+			stream, ok := core.GetStream(states.Get(state))
+			if !ok {
+				continue
+			}
+			if renamed := src.renameStream(stream); renamed != stream {
+				renamedStates.Set(state, renamed)
+				statesChanged = true
+			}
+		}
+		if statesChanged {
+			renamedAP.Set(key, renamedStates)
+			changed = true
+		}
+	}
+	if changed {
+		dict.Set("AP", renamedAP)
+	}
+}
+
+// renameStream returns a copy of the appearance stream `stream` with the names of the renamed
+// default resources replaced, or `stream` if it does not use any of them.  The source streams are
+// not modified as they are shared with the source document.
+func (src *source) renameStream(stream *core.PdfObjectStream) *core.PdfObjectStream {
+	if renamed, has := src.renamedStreams[stream]; has {
+		return renamed
+	}
+	src.renamedStreams[stream] = stream
+
+	data, err := core.DecodeStream(stream)
+	if err != nil {
+		common.Log.Debug("Unable to decode appearance stream: %v", err)
+		return stream
+	}
+	ops, err := contentstream.NewContentStreamParser(string(data)).Parse()
+	if err != nil {
+		common.Log.Debug("Invalid appearance stream: %v", err)
+		return stream
+	}
+	resources, ok := core.GetDict(stream.Get("Resources"))
+	if !ok {
+		resources = core.MakeDict()
+	}
+	if !src.drRenames.rename(ops, resources) {
+		return stream
+	}
+
+	renamed, err := core.MakeStream(ops.Bytes(), core.NewFlateEncoder())
+	if err != nil {
+		common.Log.Debug("Unable to encode appearance stream: %v", err)
+		return stream
+	}
+	for _, key := range stream.Keys() {
+		switch key {
+		case "Length", "Filter", "DecodeParms", "F", "FFilter", "FDecodeParms", "DL":
+			continue
+		}
+		renamed.Set(key, stream.Get(key))
+	}
+	src.renamedStreams[stream] = renamed
+	return renamed
+}
+
+// sameValue returns true if the objects `a` and `b` have the same value.
+func sameValue(a, b core.PdfObject) bool {
+	return core.TraceToDirectObject(a).DefaultWriteString() == core.TraceToDirectObject(b).DefaultWriteString()
+}
@@ -0,0 +1,103 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package assembler
+
+import (
+	"github.com/unidoc/unidoc/pdf/core"
+)
+
+// addOptionalContent merges the optional content properties of the sources.  The optional content
+// groups are shared with the source pages, so only the properties dictionary is rebuilt: the
+// default configuration of the first source is extended with the groups, the order and the initial
+// states of the other sources.  Alternate configurations are dropped.
+func (asm *assembly) addOptionalContent() error {
+	ocgs := core.MakeArray()
+	seen := map[core.PdfObject]bool{}
+	var config *core.PdfObjectDictionary
+	order := core.MakeArray()
+	off := core.MakeArray()
+	listKeys := []core.PdfObjectName{"Locked", "RBGroups", "AS"}
+	lists := map[core.PdfObjectName]*core.PdfObjectArray{}
+
+	for _, src := range asm.sources {
+		obj, err := src.reader.GetOCProperties()
+		if err != nil {
+			return err
+		}
+		props, ok := core.GetDict(obj)
+		if !ok {
+			continue
+		}
+		srcOCGs, ok := core.GetArray(props.Get("OCGs"))
+		if !ok {
+			continue
+		}
+		for _, ocg := range srcOCGs.Elements() {
+			if !seen[ocg] {
+				seen[ocg] = true
+				ocgs.Append(ocg)
+			}
+		}
+
+		d, ok := core.GetDict(props.Get("D"))
+		if !ok {
+			d = core.MakeDict()
+		}
+		if config == nil {
+			config = copyDict(d)
+		}
+
+		if arr, ok := core.GetArray(d.Get("Order")); ok {
+			order.Append(arr.Elements()...)
+		} else {
+			order.Append(srcOCGs.Elements()...)
+		}
+
+		// Groups that are initially off.  With base state OFF, all groups not listed as ON are off.
+		on := map[core.PdfObject]bool{}
+		if arr, ok := core.GetArray(d.Get("ON")); ok {
+			for _, ocg := range arr.Elements() {
+				on[ocg] = true
+			}
+		}
+		if base, _ := core.GetNameVal(d.Get("BaseState")); base == "OFF" {
+			for _, ocg := range srcOCGs.Elements() {
+				if !on[ocg] {
+					off.Append(ocg)
+				}
+			}
+		} else if arr, ok := core.GetArray(d.Get("OFF")); ok {
+			off.Append(arr.Elements()...)
+		}
+
+		for _, key := range listKeys {
+			if arr, ok := core.GetArray(d.Get(key)); ok {
+				if lists[key] == nil {
+					lists[key] = core.MakeArray()
+				}
+				lists[key].Append(arr.Elements()...)
+			}
+		}
+	}
+	if ocgs.Len() == 0 {
+		return nil
+	}
+
+	config.Remove("BaseState")
+	config.Remove("ON")
+	config.Set("Order", order)
+	config.Set("OFF", off)
+	for _, key := range listKeys {
+		if lists[key] != nil {
+			config.Set(key, lists[key])
+		}
+	}
+
+	props := core.MakeDict()
+	props.Set("OCGs", ocgs)
+	props.Set("D", config)
+	return asm.writer.SetOCProperties(props)
+}
@@ -0,0 +1,104 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package assembler
+
+import (
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// addOutlines adds the outlines of the sources, in order, to the output document.  Items pointing
+// to pages that are not in the output document are dropped unless they have descendants that are
+// kept.
+func (asm *assembly) addOutlines() error {
+	items := []*model.PdfOutlineItem{}
+	for _, src := range asm.sources {
+		tree := src.reader.GetOutlineTree()
+		if tree == nil {
+			continue
+		}
+		items = append(items, asm.copyOutlineItems(src, tree)...)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	outlines := model.NewPdfOutlineTree()
+	linkOutlineItems(&outlines.PdfOutlineTreeNode, items)
+	count := outlineCount(items)
+	outlines.Count = &count
+	asm.writer.AddOutlineTree(&outlines.PdfOutlineTreeNode)
+	return nil
+}
+
+// copyOutlineItems returns copies of the child items of the source outline node `node` with their
+// destinations remapped to the output document.
+func (asm *assembly) copyOutlineItems(src *source, node *model.PdfOutlineTreeNode) []*model.PdfOutlineItem {
+	items := []*model.PdfOutlineItem{}
+	for child := node.First; child != nil; {
+		item := child.Item()
+		if item == nil {
+			break
+		}
+		child = item.Next
+
+		kids := asm.copyOutlineItems(src, &item.PdfOutlineTreeNode)
+
+		copied := model.NewPdfOutlineItem()
+		copied.Title = item.Title
+		copied.C = item.C
+		copied.F = item.F
+		hasTarget, targetKept := false, false
+		if item.Dest != nil {
+			hasTarget = true
+			copied.Dest, targetKept = asm.remapDest(src, item.Dest)
+		} else if item.A != nil {
+			hasTarget = true
+			copied.A, targetKept = asm.remapAction(src, item.A)
+		}
+		if hasTarget && !targetKept && len(kids) == 0 {
+			continue
+		}
+
+		if len(kids) > 0 {
+			linkOutlineItems(&copied.PdfOutlineTreeNode, kids)
+			count := outlineCount(kids)
+			if item.Count != nil && *item.Count < 0 {
+				// Closed item.
+				count = -count
+			}
+			copied.Count = &count
+		}
+		items = append(items, copied)
+	}
+	return items
+}
+
+// linkOutlineItems sets `items` as the children of `parent`.
+func linkOutlineItems(parent *model.PdfOutlineTreeNode, items []*model.PdfOutlineItem) {
+	for i, item := range items {
+		item.Parent = parent
+		item.Prev, item.Next = nil, nil
+		if i > 0 {
+			item.Prev = &items[i-1].PdfOutlineTreeNode
+			items[i-1].Next = &item.PdfOutlineTreeNode
+		}
+	}
+	parent.First = &items[0].PdfOutlineTreeNode
+	parent.Last = &items[len(items)-1].PdfOutlineTreeNode
+}
+
+// outlineCount returns the number of visible descendants of an open outline node with children
+// `items`.
+func outlineCount(items []*model.PdfOutlineItem) int64 {
+	count := int64(0)
+	for _, item := range items {
+		count++
+		if item.Count != nil && *item.Count > 0 {
+			count += *item.Count
+		}
+	}
+	return count
+}
@@ -622,3 +622,35 @@ func (this *ContentCreator) Add_TJ(vals ...PdfObject) *ContentCreator {
 	this.operands = append(this.operands, &op)
 	return this
 }
+
+/* Marked content operators */
+
+// Add_BMC adds 'BMC' operation to the content stream, which begins a marked-content sequence
+// identified by `tag`, terminated by a matching EMC.
+func (this *ContentCreator) Add_BMC(tag PdfObjectName) *ContentCreator {
+	op := ContentStreamOperation{}
+	op.Operand = "BMC"
+	op.Params = makeParamsFromNames([]PdfObjectName{tag})
+	this.operands = append(this.operands, &op)
+	return this
+}
+
+// Add_BDC adds 'BDC' operation to the content stream, which begins a marked-content sequence
+// identified by `tag` with an associated property list. The `propertyList` is either an inline
+// dictionary or a name referring to an entry in the Properties resource dictionary.
+func (this *ContentCreator) Add_BDC(tag PdfObjectName, propertyList PdfObject) *ContentCreator {
+	op := ContentStreamOperation{}
+	op.Operand = "BDC"
+	op.Params = makeParamsFromNames([]PdfObjectName{tag})
+	op.Params = append(op.Params, propertyList)
+	this.operands = append(this.operands, &op)
+	return this
+}
+
+// Add_EMC adds 'EMC' operation to the content stream, which ends a marked-content sequence.
+func (this *ContentCreator) Add_EMC() *ContentCreator {
+	op := ContentStreamOperation{}
+	op.Operand = "EMC"
+	this.operands = append(this.operands, &op)
+	return this
+}
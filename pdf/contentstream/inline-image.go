@@ -194,6 +194,12 @@ func (this *ContentStreamInlineImage) GetColorSpace(resources *model.PdfPageReso
 
 }
 
+// GetEncodedData returns the image data of the inline image as found in the content stream, i.e.
+// encoded with its filters.
+func (this *ContentStreamInlineImage) GetEncodedData() []byte {
+	return this.stream
+}
+
 func (this *ContentStreamInlineImage) GetEncoder() (core.StreamEncoder, error) {
 	return newEncoderFromInlineImage(this)
 }
@@ -0,0 +1,83 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package contentstream
+
+import (
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// MarkedContent is a marked-content sequence of a content stream (14.6 Marked Content p. 558).
+type MarkedContent struct {
+	Tag        core.PdfObjectName
+	MCID       int                       // Marked-content identifier, -1 if none.
+	Properties *core.PdfObjectDictionary // Property list, nil for BMC sequences.
+
+	// Operations of the sequence, excluding the enclosing BMC/BDC and EMC operations.
+	Operations ContentStreamOperations
+}
+
+// GetMarkedContent returns the marked-content sequences of the operations with marked-content
+// identifiers, keyed by identifier.  These are the content items of structure elements.  Property
+// lists referred to by name are looked up in the Properties resources of `resources`, which may be
+// nil.  Nested sequences are also included in the operations of the enclosing sequences.
+func (ops *ContentStreamOperations) GetMarkedContent(resources *model.PdfPageResources) map[int]*MarkedContent {
+	sequences := map[int]*MarkedContent{}
+	stack := []*MarkedContent{}
+
+	for _, op := range *ops {
+		switch op.Operand {
+		case "BMC", "BDC":
+			mc := &MarkedContent{MCID: -1}
+			if len(op.Params) > 0 {
+				if tag, ok := op.Params[0].(*core.PdfObjectName); ok {
+					mc.Tag = *tag
+				}
+			}
+			if op.Operand == "BDC" && len(op.Params) == 2 {
+				mc.Properties = lookupPropertyList(op.Params[1], resources)
+				if mc.Properties != nil {
+					if mcid, ok := core.GetIntVal(mc.Properties.Get("MCID")); ok {
+						mc.MCID = mcid
+					}
+				}
+			}
+			for _, outer := range stack {
+				outer.Operations = append(outer.Operations, op)
+			}
+			stack = append(stack, mc)
+			continue
+		case "EMC":
+			if len(stack) == 0 {
+				common.Log.Debug("Unbalanced EMC - ignoring")
+				continue
+			}
+			mc := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if mc.MCID >= 0 {
+				sequences[mc.MCID] = mc
+			}
+		}
+		for _, outer := range stack {
+			outer.Operations = append(outer.Operations, op)
+		}
+	}
+	return sequences
+}
+
+// lookupPropertyList returns the property list `obj`, which is either a dictionary or the name of
+// a property list in the Properties resources.
+func lookupPropertyList(obj core.PdfObject, resources *model.PdfPageResources) *core.PdfObjectDictionary {
+	if name, ok := obj.(*core.PdfObjectName); ok {
+		if resources == nil {
+			return nil
+		}
+		obj, _ = resources.GetPropertiesByName(*name)
+	}
+	dict, _ := core.GetDict(obj)
+	return dict
+}
@@ -0,0 +1,94 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package contentstream
+
+import (
+	"fmt"
+	"math"
+)
+
+// Matrix is a transformation matrix as used in PDF (8.3.4 Transformation Matrices p. 118), i.e.
+// the matrix
+//   | a  b  0 |
+//   | c  d  0 |
+//   | tx ty 1 |
+// represented by the six values [a b c d tx ty].  Points are transformed as row vectors:
+// [x' y' 1] = [x y 1] × M.
+type Matrix [6]float64
+
+// IdentityMatrix returns the identity transformation matrix.
+func IdentityMatrix() Matrix {
+	return Matrix{1, 0, 0, 1, 0, 0}
+}
+
+// NewMatrix returns the matrix [a b c d tx ty].
+func NewMatrix(a, b, c, d, tx, ty float64) Matrix {
+	return Matrix{a, b, c, d, tx, ty}
+}
+
+// TranslationMatrix returns a matrix that translates by `tx`, `ty`.
+func TranslationMatrix(tx, ty float64) Matrix {
+	return Matrix{1, 0, 0, 1, tx, ty}
+}
+
+// ScaleMatrix returns a matrix that scales by `sx`, `sy`.
+func ScaleMatrix(sx, sy float64) Matrix {
+	return Matrix{sx, 0, 0, sy, 0, 0}
+}
+
+// String returns a string describing `m`.
+func (m Matrix) String() string {
+	return fmt.Sprintf("[%.4f %.4f %.4f %.4f %.4f %.4f]", m[0], m[1], m[2], m[3], m[4], m[5])
+}
+
+// Mult returns the matrix product m × b, i.e. the transformation `m` followed by `b`.
+// For example, the text rendering matrix is Trm = params × Tm × CTM.
+func (m Matrix) Mult(b Matrix) Matrix {
+	return Matrix{
+		m[0]*b[0] + m[1]*b[2],
+		m[0]*b[1] + m[1]*b[3],
+		m[2]*b[0] + m[3]*b[2],
+		m[2]*b[1] + m[3]*b[3],
+		m[4]*b[0] + m[5]*b[2] + b[4],
+		m[4]*b[1] + m[5]*b[3] + b[5],
+	}
+}
+
+// Transform returns the point (`x`,`y`) transformed by `m`.
+func (m Matrix) Transform(x, y float64) (float64, float64) {
+	return x*m[0] + y*m[2] + m[4], x*m[1] + y*m[3] + m[5]
+}
+
+// Translation returns the translation part of `m`.
+func (m Matrix) Translation() (float64, float64) {
+	return m[4], m[5]
+}
+
+// Inverse returns the inverse of `m`.  The bool flag is false if `m` is not invertible.
+func (m Matrix) Inverse() (Matrix, bool) {
+	det := m[0]*m[3] - m[1]*m[2]
+	if math.Abs(det) < 1e-12 {
+		return Matrix{}, false
+	}
+	return Matrix{
+		m[3] / det,
+		-m[1] / det,
+		-m[2] / det,
+		m[0] / det,
+		(m[2]*m[5] - m[3]*m[4]) / det,
+		(m[1]*m[4] - m[0]*m[5]) / det,
+	}, true
+}
+
+// ScalingFactorX returns the length of the transformed unit vector along the x axis.
+func (m Matrix) ScalingFactorX() float64 {
+	return math.Hypot(m[0], m[1])
+}
+
+// ScalingFactorY returns the length of the transformed unit vector along the y axis.
+func (m Matrix) ScalingFactorY() float64 {
+	return math.Hypot(m[2], m[3])
+}
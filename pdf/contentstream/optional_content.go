@@ -0,0 +1,230 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package contentstream
+
+import (
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Operators painting text, paths, shadings, XObjects and inline images, with the operations
+// replacing them in hidden optional content.  Path painting is replaced by n to keep clipping
+// paths, and the next line operators by T* to keep the text position.
+var hiddenContentReplacements = map[string]string{
+	"Tj": "", "TJ": "", "'": "T*", "\"": "T*",
+	"S": "n", "s": "n", "f": "n", "F": "n", "f*": "n", "B": "n", "B*": "n", "b": "n", "b*": "n",
+	"sh": "", "Do": "", "BI": "",
+}
+
+// RemoveHiddenContent returns the operations without the optional content hidden in `state`: the
+// painting operations of the marked-content sequences /OC ... BDC ... EMC of hidden groups or
+// membership dictionaries, and the XObjects painted with Do whose OC entry is hidden.  Property
+// lists and XObjects are looked up in `resources`, which may be nil.  Graphics state operations of
+// hidden sequences are kept, as they still apply to the following content.
+func (ops *ContentStreamOperations) RemoveHiddenContent(resources *model.PdfPageResources, state *model.PdfOCState) ContentStreamOperations {
+	filtered := ContentStreamOperations{}
+	hiddenDepth := 0
+	for _, op := range *ops {
+		switch op.Operand {
+		case "BMC", "BDC":
+			if hiddenDepth > 0 {
+				hiddenDepth++
+				continue
+			}
+			if op.Operand == "BDC" && len(op.Params) == 2 && isOptionalContentTag(op.Params[0]) {
+				oc := op.Params[1]
+				if name, ok := oc.(*core.PdfObjectName); ok && resources != nil {
+					oc, _ = resources.GetPropertiesByName(*name)
+				}
+				if oc != nil && !state.IsVisible(oc) {
+					hiddenDepth = 1
+					continue
+				}
+			}
+		case "EMC":
+			if hiddenDepth > 0 {
+				hiddenDepth--
+				continue
+			}
+		case "Do":
+			if hiddenDepth == 0 && len(op.Params) == 1 && resources != nil {
+				if name, ok := op.Params[0].(*core.PdfObjectName); ok {
+					stream, _ := resources.GetXObjectByName(*name)
+					if stream != nil {
+						if oc := stream.PdfObjectDictionary.Get("OC"); oc != nil && !state.IsVisible(oc) {
+							continue
+						}
+					}
+				}
+			}
+		}
+
+		if hiddenDepth > 0 {
+			if replacement, painting := hiddenContentReplacements[op.Operand]; painting {
+				if len(replacement) > 0 {
+					filtered = append(filtered, &ContentStreamOperation{Operand: replacement})
+				}
+				continue
+			}
+		}
+		filtered = append(filtered, op)
+	}
+	return filtered
+}
+
+// isOptionalContentTag returns whether `obj` is the OC tag of optional content marked-content
+// sequences.
+func isOptionalContentTag(obj core.PdfObject) bool {
+	tag, ok := obj.(*core.PdfObjectName)
+	return ok && *tag == "OC"
+}
+
+// RemoveHiddenOptionalContent removes the optional content hidden in `state` from `page`: from its
+// contents, from the contents of the Form XObjects it paints, and the annotations whose OC entry
+// is hidden.  The Form XObjects are replaced by filtered copies in a copy of the page resources,
+// leaving objects shared with other pages unchanged.  The page can then be rendered or its text
+// extracted with the layers of `state` turned off.
+func RemoveHiddenOptionalContent(page *model.PdfPage, state *model.PdfOCState) error {
+	content, err := page.GetAllContentStreams()
+	if err != nil {
+		return err
+	}
+	f := &optionalContentFilter{state: state, forms: map[*core.PdfObjectStream]*core.PdfObjectStream{}}
+	filtered, err := f.filterContent(content, page.Resources)
+	if err != nil {
+		return err
+	}
+	resources := f.filterResources(page.Resources)
+	if err := page.SetContentStreams([]string{filtered}, core.NewFlateEncoder()); err != nil {
+		return err
+	}
+	page.Resources = resources
+
+	annotations := []*model.PdfAnnotation{}
+	for _, annot := range page.Annotations {
+		if annot.OC != nil && !state.IsVisible(annot.OC) {
+			continue
+		}
+		annotations = append(annotations, annot)
+	}
+	page.Annotations = annotations
+	return nil
+}
+
+// optionalContentFilter removes hidden optional content from pages and Form XObjects.
+type optionalContentFilter struct {
+	state *model.PdfOCState
+
+	// Filtered copies of the Form XObjects.
+	forms map[*core.PdfObjectStream]*core.PdfObjectStream
+}
+
+// filterContent returns the content stream `content` without its hidden optional content.
+func (f *optionalContentFilter) filterContent(content string, resources *model.PdfPageResources) (string, error) {
+	ops, err := NewContentStreamParser(content).Parse()
+	if err != nil {
+		return "", err
+	}
+	filtered := ops.RemoveHiddenContent(resources, f.state)
+	return string(filtered.Bytes()), nil
+}
+
+// filterResources returns a copy of `resources` where the Form XObjects are replaced by filtered
+// copies.  Returns `resources` if it has no Form XObjects.
+func (f *optionalContentFilter) filterResources(resources *model.PdfPageResources) *model.PdfPageResources {
+	if resources == nil {
+		return nil
+	}
+	xobjects, ok := core.GetDict(resources.XObject)
+	if !ok {
+		return resources
+	}
+
+	copied := core.MakeDict()
+	replaced := false
+	for _, name := range xobjects.Keys() {
+		obj := xobjects.Get(name)
+		stream, xtype := resources.GetXObjectByName(name)
+		if xtype == model.XObjectTypeForm {
+			if form := f.filterForm(stream, resources); form != nil {
+				obj = form
+				replaced = true
+			}
+		}
+		copied.Set(name, obj)
+	}
+	if !replaced {
+		return resources
+	}
+
+	res := model.NewPdfPageResources()
+	res.ExtGState = resources.ExtGState
+	res.ColorSpace = resources.ColorSpace
+	res.Pattern = resources.Pattern
+	res.Shading = resources.Shading
+	res.XObject = copied
+	res.Font = resources.Font
+	res.ProcSet = resources.ProcSet
+	res.Properties = resources.Properties
+	return res
+}
+
+// filterForm returns a filtered copy of the Form XObject `stream`, whose resources default to
+// `parentResources`.  Returns nil if the form cannot be loaded.
+func (f *optionalContentFilter) filterForm(stream *core.PdfObjectStream, parentResources *model.PdfPageResources) *core.PdfObjectStream {
+	if copied, has := f.forms[stream]; has {
+		return copied
+	}
+	form, err := model.NewXObjectFormFromStream(stream)
+	if err != nil {
+		common.Log.Debug("Unable to load form XObject (%v) - skipping", err)
+		return nil
+	}
+	content, err := form.GetContentStream()
+	if err != nil {
+		common.Log.Debug("Unable to decode form XObject (%v) - skipping", err)
+		return nil
+	}
+
+	// Register the copy before filtering the nested forms, for forms painting themselves.
+	copied := &core.PdfObjectStream{PdfObjectDictionary: core.MakeDict()}
+	f.forms[stream] = copied
+
+	resources := form.Resources
+	if resources == nil {
+		resources = parentResources
+	}
+	filtered, err := f.filterContent(string(content), resources)
+	if err != nil {
+		common.Log.Debug("Unable to parse form XObject (%v) - skipping", err)
+		delete(f.forms, stream)
+		return nil
+	}
+	encoder := core.NewFlateEncoder()
+	encoded, err := encoder.EncodeBytes([]byte(filtered))
+	if err != nil {
+		common.Log.Debug("Unable to encode form XObject (%v) - skipping", err)
+		delete(f.forms, stream)
+		return nil
+	}
+
+	dict := encoder.MakeStreamDict()
+	for _, key := range stream.PdfObjectDictionary.Keys() {
+		switch key {
+		case "Filter", "DecodeParms", "Length":
+			continue
+		case "Resources":
+			dict.Set(key, f.filterResources(resources).ToPdfObject())
+		default:
+			dict.Set(key, stream.PdfObjectDictionary.Get(key))
+		}
+	}
+	dict.Set("Length", core.MakeInteger(int64(len(encoded))))
+	copied.PdfObjectDictionary = dict
+	copied.Stream = encoded
+	return copied
+}
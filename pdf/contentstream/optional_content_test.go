@@ -0,0 +1,86 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package contentstream
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Test removing the content of hidden layers from a page and a form XObject.
+func TestRemoveHiddenOptionalContent(t *testing.T) {
+	props := model.NewPdfOCProperties()
+	visible := model.NewPdfOptionalContentGroup("Visible")
+	hidden := model.NewPdfOptionalContentGroup("Hidden")
+	props.AddGroup(visible)
+	props.AddGroup(hidden)
+	props.D.OFF = []*model.PdfOptionalContentGroup{hidden}
+	state := props.NewState()
+
+	form := model.NewXObjectForm()
+	form.BBox = core.MakeArrayFromFloats([]float64{0, 0, 100, 100})
+	form.Resources = model.NewPdfPageResources()
+	form.Resources.SetPropertiesByName("OC0", hidden.ToPdfObject())
+	if err := form.SetContentStream([]byte("/OC /OC0 BDC (FormHidden) Tj EMC (FormVisible) Tj"), nil); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	hiddenForm := model.NewXObjectForm()
+	hiddenForm.OC = hidden.ToPdfObject()
+
+	page := model.NewPdfPage()
+	page.Resources = model.NewPdfPageResources()
+	page.Resources.SetPropertiesByName("OC0", visible.ToPdfObject())
+	page.Resources.SetPropertiesByName("OC1", hidden.ToPdfObject())
+	page.Resources.SetXObjectFormByName("Fm0", form)
+	page.Resources.SetXObjectFormByName("Fm1", hiddenForm)
+	content := "/OC /OC0 BDC (Shown) Tj EMC " +
+		"/OC /OC1 BDC 1 0 0 1 10 10 cm (Hidden) Tj 0 0 10 10 re f /P BMC (Nested) Tj EMC EMC " +
+		"/Fm0 Do /Fm1 Do"
+	if err := page.SetContentStreams([]string{content}, nil); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	hiddenAnnot := model.NewPdfAnnotationSquare()
+	hiddenAnnot.OC = hidden.ToPdfObject()
+	page.Annotations = []*model.PdfAnnotation{hiddenAnnot.PdfAnnotation, model.NewPdfAnnotationSquare().PdfAnnotation}
+	original, _ := form.GetContentStream()
+
+	if err := RemoveHiddenOptionalContent(page, state); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	filtered, err := page.GetAllContentStreams()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	for _, s := range []string{"Hidden", "Nested", " f", "Fm1"} {
+		if strings.Contains(filtered, s) {
+			t.Errorf("Hidden content %q in %q", s, filtered)
+		}
+	}
+	for _, s := range []string{"Shown", "cm", "re", "Fm0"} {
+		if !strings.Contains(filtered, s) {
+			t.Errorf("Missing content %q in %q", s, filtered)
+		}
+	}
+	if len(page.Annotations) != 1 {
+		t.Errorf("Expected 1 annotation, got %d", len(page.Annotations))
+	}
+
+	// The form is replaced by a filtered copy.
+	stream, _ := page.Resources.GetXObjectByName("Fm0")
+	decoded, err := core.DecodeStream(stream)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if strings.Contains(string(decoded), "FormHidden") || !strings.Contains(string(decoded), "FormVisible") {
+		t.Errorf("Invalid form content %q", decoded)
+	}
+	if content, _ := form.GetContentStream(); string(content) != string(original) {
+		t.Errorf("Original form changed")
+	}
+}
@@ -20,6 +20,14 @@ type GraphicsState struct {
 	ColorspaceNonStroking PdfColorspace
 	ColorStroking         PdfColor
 	ColorNonStroking      PdfColor
+	CTM                   Matrix  // Current transformation matrix.
+	LineWidth             float64 // Line width in user space units.
+	LineCap               int     // 0: butt, 1: round, 2: projecting square cap.
+	LineJoin              int     // 0: miter, 1: round, 2: bevel join.
+	MiterLimit            float64
+	DashArray             []float64 // Dash lengths in user space units, empty for solid lines.
+	DashPhase             float64
+	Text                  TextState
 }
 
 type GraphicStateStack []GraphicsState
@@ -44,6 +52,9 @@ type ContentStreamProcessor struct {
 
 	handlers     []HandlerEntry
 	currentIndex int
+
+	// Fonts loaded for the Tf operator, keyed by font object.
+	fontCache map[PdfObject]*PdfFont
 }
 
 type HandlerFunc func(op *ContentStreamOperation, gs GraphicsState, resources *PdfPageResources) error
@@ -81,6 +92,7 @@ func NewContentStreamProcessor(ops []*ContentStreamOperation) *ContentStreamProc
 	csp.handlers = []HandlerEntry{}
 	csp.currentIndex = 0
 	csp.operations = ops
+	csp.fontCache = map[PdfObject]*PdfFont{}
 
 	return &csp
 }
@@ -200,6 +212,10 @@ func (this *ContentStreamProcessor) Process(resources *PdfPageResources) error {
 	this.graphicsState.ColorspaceNonStroking = NewPdfColorspaceDeviceGray()
 	this.graphicsState.ColorStroking = NewPdfColorDeviceGray(0)
 	this.graphicsState.ColorNonStroking = NewPdfColorDeviceGray(0)
+	this.graphicsState.CTM = IdentityMatrix()
+	this.graphicsState.LineWidth = 1
+	this.graphicsState.MiterLimit = 10
+	this.graphicsState.Text = newTextState()
 
 	for _, op := range this.operations {
 		var err error
@@ -209,8 +225,31 @@ func (this *ContentStreamProcessor) Process(resources *PdfPageResources) error {
 		case "q":
 			this.graphicsStack.Push(this.graphicsState)
 		case "Q":
+			if len(this.graphicsStack) == 0 {
+				common.Log.Debug("Q without matching q - ignoring")
+				break
+			}
 			this.graphicsState = this.graphicsStack.Pop()
 
+		// Transformation and line style, text state and text positioning operators.
+		// Failures are not fatal as the state is only tracked for use by the handlers.
+		case "cm", "w", "J", "j", "M", "d":
+			if err := this.handleGraphicsStateCommand(op); err != nil {
+				common.Log.Debug("Invalid %s operation (%v) - ignoring", op.Operand, err)
+			}
+		case "gs":
+			if err := this.handleCommand_gs(op, resources); err != nil {
+				common.Log.Debug("Invalid gs operation (%v) - ignoring", err)
+			}
+		case "BT", "ET", "Tc", "Tw", "Tz", "TL", "Tr", "Ts", "Td", "TD", "Tm", "T*", "'", `"`:
+			if err := this.handleTextStateCommand(op); err != nil {
+				common.Log.Debug("Invalid %s operation (%v) - ignoring", op.Operand, err)
+			}
+		case "Tf":
+			if err := this.handleCommand_Tf(op, resources); err != nil {
+				common.Log.Debug("Invalid Tf operation (%v) - ignoring", err)
+			}
+
 		// Color operations (Table 74 p. 179)
 		case "CS":
 			err = this.handleCommand_CS(op, resources)
@@ -256,6 +295,18 @@ func (this *ContentStreamProcessor) Process(resources *PdfPageResources) error {
 				return err
 			}
 		}
+
+		// The text position is advanced after the text showing operations have been handled, so
+		// that the handlers get the position at the start of the shown text.
+		switch op.Operand {
+		case "Tj", "TJ", "'", `"`:
+			_, tm, err := this.graphicsState.TextGlyphs(op)
+			if err != nil {
+				common.Log.Debug("Invalid %s operation (%v) - ignoring", op.Operand, err)
+				break
+			}
+			this.graphicsState.Text.Tm = tm
+		}
 	}
 
 	return nil
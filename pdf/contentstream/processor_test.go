@@ -0,0 +1,151 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package contentstream
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+func TestMatrix(t *testing.T) {
+	m := TranslationMatrix(10, 20).Mult(ScaleMatrix(2, 3))
+	x, y := m.Transform(1, 1)
+	if x != 22 || y != 63 {
+		t.Fatalf("Transform: got (%v, %v), expected (22, 63)", x, y)
+	}
+
+	inv, ok := m.Inverse()
+	if !ok {
+		t.Fatalf("Matrix not invertible: %s", m)
+	}
+	x, y = inv.Transform(22, 63)
+	if math.Abs(x-1) > 1e-9 || math.Abs(y-1) > 1e-9 {
+		t.Fatalf("Inverse transform: got (%v, %v), expected (1, 1)", x, y)
+	}
+
+	if _, ok := ScaleMatrix(0, 1).Inverse(); ok {
+		t.Fatalf("Singular matrix reported as invertible")
+	}
+}
+
+// Test the tracking of the transformation and text matrices by the processor.
+func TestProcessorTextGlyphs(t *testing.T) {
+	font, err := model.NewStandard14Font("Helvetica")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	resources := model.NewPdfPageResources()
+	resources.SetFontByName("F1", font.ToPdfObject())
+
+	content := `q 2 0 0 2 0 0 cm
+BT /F1 10 Tf 100 200 Td (AB) Tj [(C) -1000 (D)] TJ 12 TL (E) ' ET
+Q`
+	parser := NewContentStreamParser(content)
+	operations, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var origins [][2]float64
+	processor := NewContentStreamProcessor(*operations)
+	processor.AddHandler(HandlerConditionEnumAllOperands, "",
+		func(op *ContentStreamOperation, gs GraphicsState, resources *model.PdfPageResources) error {
+			switch op.Operand {
+			case "Tj", "TJ", "'":
+				glyphs, _, err := gs.TextGlyphs(op)
+				if err != nil {
+					return err
+				}
+				for _, glyph := range glyphs {
+					x, y := glyph.Trm.Translation()
+					origins = append(origins, [2]float64{x, y})
+				}
+			}
+			return nil
+		})
+	err = processor.Process(resources)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// Helvetica widths: A 667, B 667, C 722.  The TJ adjustment of -1000 moves by the font size.
+	expected := [][2]float64{
+		{200, 400},
+		{200 + 2*6.67, 400},
+		{200 + 4*6.67, 400},
+		{200 + 4*6.67 + 2*7.22 + 2*10, 400},
+		{200, 400 - 2*12},
+	}
+	if len(origins) != len(expected) {
+		t.Fatalf("Got %d glyphs, expected %d: %v", len(origins), len(expected), origins)
+	}
+	for i := range expected {
+		if math.Abs(origins[i][0]-expected[i][0]) > 1e-6 || math.Abs(origins[i][1]-expected[i][1]) > 1e-6 {
+			t.Errorf("Glyph %d origin: got %v, expected %v", i, origins[i], expected[i])
+		}
+	}
+}
+
+func TestTextGlyphQuad(t *testing.T) {
+	glyph := TextGlyph{Trm: NewMatrix(10, 0, 0, 10, 100, 200), Width: 0.5}
+	quad := glyph.Quad()
+	expected := []float64{100, 208, 105, 208, 100, 198, 105, 198}
+	for i := range expected {
+		if math.Abs(quad[i]-expected[i]) > 1e-9 {
+			t.Fatalf("Quad: got %v, expected %v", quad, expected)
+		}
+	}
+
+	bbox := glyph.BBox()
+	if bbox != (model.PdfRectangle{Llx: 100, Lly: 198, Urx: 105, Ury: 208}) {
+		t.Fatalf("BBox: got %+v", bbox)
+	}
+}
+
+// Test the tracking of the line style parameters, set by operators and graphics state parameter
+// dictionaries.
+func TestProcessorLineStyle(t *testing.T) {
+	gsDict := core.MakeDict()
+	gsDict.Set("LW", core.MakeFloat(3))
+	gsDict.Set("LJ", core.MakeInteger(2))
+	gsDict.Set("D", core.MakeArray(core.MakeArrayFromIntegers([]int{4, 2}), core.MakeInteger(1)))
+	resources := model.NewPdfPageResources()
+	if err := resources.AddExtGState("GS1", gsDict); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	parser := NewContentStreamParser("q 2 w 1 J 5 M [3] 0 d S Q S /GS1 gs S")
+	operations, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	states := []GraphicsState{}
+	processor := NewContentStreamProcessor(*operations)
+	processor.AddHandler(HandlerConditionEnumOperand, "S",
+		func(op *ContentStreamOperation, gs GraphicsState, resources *model.PdfPageResources) error {
+			states = append(states, gs)
+			return nil
+		})
+	if err := processor.Process(resources); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(states) != 3 {
+		t.Fatalf("Invalid number of states %d", len(states))
+	}
+	if gs := states[0]; gs.LineWidth != 2 || gs.LineCap != 1 || gs.MiterLimit != 5 ||
+		len(gs.DashArray) != 1 || gs.DashArray[0] != 3 {
+		t.Errorf("Invalid line style %+v", gs)
+	}
+	if gs := states[1]; gs.LineWidth != 1 || gs.LineCap != 0 || gs.MiterLimit != 10 || len(gs.DashArray) != 0 {
+		t.Errorf("Line style not restored %+v", gs)
+	}
+	if gs := states[2]; gs.LineWidth != 3 || gs.LineJoin != 2 || len(gs.DashArray) != 2 || gs.DashPhase != 1 {
+		t.Errorf("Invalid ExtGState line style %+v", gs)
+	}
+}
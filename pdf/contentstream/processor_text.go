@@ -0,0 +1,410 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package contentstream
+
+import (
+	"errors"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Default glyph ascent and descent in text space units (fraction of the font size), used for
+// glyph bounding boxes.
+const (
+	defaultGlyphAscent  = 0.8
+	defaultGlyphDescent = -0.2
+)
+
+// Width (in glyph space units) assumed for glyphs without metrics.
+const defaultGlyphWidth = 500.0
+
+// TextState represents the text state parameters (9.3 Text State Parameters and Operators p. 243)
+// along with the text matrix and text line matrix of the current text object (9.4.2 p. 248).
+type TextState struct {
+	CharSpacing       float64 // Tc
+	WordSpacing       float64 // Tw
+	HorizontalScaling float64 // Tz (percent)
+	Leading           float64 // TL
+	Font              *model.PdfFont
+	FontName          string
+	FontSize          float64 // Tfs
+	RenderMode        int     // Tr
+	Rise              float64 // Ts
+
+	Tm  Matrix // Text matrix.
+	Tlm Matrix // Text line matrix.
+}
+
+// newTextState returns a TextState with the initial values.
+func newTextState() TextState {
+	return TextState{
+		HorizontalScaling: 100,
+		Tm:                IdentityMatrix(),
+		Tlm:               IdentityMatrix(),
+	}
+}
+
+// TextGlyph represents a glyph shown by a text showing operator.
+type TextGlyph struct {
+	Code        uint16 // Character code.
+	Data        []byte // Bytes of the character code in the shown string.
+	StringIndex int    // Index of the shown string within the operands of a TJ operator (0 otherwise).
+	ByteOffset  int    // Offset of Data within the shown string.
+
+	// Trm is the text rendering matrix at the glyph origin, mapping text space (in units of the font
+	// size) to device space.
+	Trm Matrix
+	// Width is the glyph width in text space (in units of the font size).
+	Width float64
+	// Advance is the horizontal displacement in text space after the glyph, including character
+	// and word spacing, scaled by the horizontal scaling (Th).
+	Advance float64
+}
+
+// Quad returns the device space quadrilateral of the glyph as 8 values x1 y1 x2 y2 x3 y3 x4 y4
+// with the upper left, upper right, lower left and lower right corners.  The quadrilateral extends
+// from the default descent to the default ascent of the font.
+func (g TextGlyph) Quad() []float64 {
+	x1, y1 := g.Trm.Transform(0, defaultGlyphAscent)
+	x2, y2 := g.Trm.Transform(g.Width, defaultGlyphAscent)
+	x3, y3 := g.Trm.Transform(0, defaultGlyphDescent)
+	x4, y4 := g.Trm.Transform(g.Width, defaultGlyphDescent)
+	return []float64{x1, y1, x2, y2, x3, y3, x4, y4}
+}
+
+// BBox returns the device space bounding box of the glyph quadrilateral.
+func (g TextGlyph) BBox() model.PdfRectangle {
+	q := g.Quad()
+	bbox := model.PdfRectangle{Llx: q[0], Lly: q[1], Urx: q[0], Ury: q[1]}
+	for i := 2; i < len(q); i += 2 {
+		if q[i] < bbox.Llx {
+			bbox.Llx = q[i]
+		}
+		if q[i] > bbox.Urx {
+			bbox.Urx = q[i]
+		}
+		if q[i+1] < bbox.Lly {
+			bbox.Lly = q[i+1]
+		}
+		if q[i+1] > bbox.Ury {
+			bbox.Ury = q[i+1]
+		}
+	}
+	return bbox
+}
+
+// TextGlyphs returns the glyphs shown by the text showing operation `op` (Tj, TJ, ' or ") in the
+// graphics state `gs`, along with the text matrix following the operation.
+// For the ' and " operators, `gs` is expected to be the state following the move to the next line,
+// as passed to the processor handlers.
+func (gs GraphicsState) TextGlyphs(op *ContentStreamOperation) ([]TextGlyph, Matrix, error) {
+	ts := gs.Text
+	tm := ts.Tm
+	if len(op.Params) == 0 {
+		return nil, tm, errors.New("Too few parameters")
+	}
+
+	var elements []core.PdfObject
+	switch op.Operand {
+	case "Tj", "'", `"`:
+		elements = op.Params[len(op.Params)-1:]
+	case "TJ":
+		arr, ok := core.GetArray(op.Params[0])
+		if !ok {
+			return nil, tm, core.ErrTypeError
+		}
+		elements = arr.Elements()
+	default:
+		return nil, tm, errors.New("Not a text showing operator")
+	}
+
+	th := ts.HorizontalScaling / 100
+	glyphs := []TextGlyph{}
+	for i, elem := range elements {
+		if str, ok := core.GetString(elem); ok {
+			data := str.Bytes()
+			offset := 0
+			for _, code := range textCharcodes(ts.Font, data) {
+				size := 1
+				if ts.Font != nil && ts.Font.IsCID() {
+					size = 2
+				}
+				if offset+size > len(data) {
+					size = len(data) - offset
+				}
+
+				w0 := defaultGlyphWidth
+				if ts.Font != nil {
+					if metrics, found := ts.Font.GetCharMetrics(code); found {
+						w0 = metrics.Wx
+					} else {
+						common.Log.Trace("No metrics for code %d - assuming default width", code)
+					}
+				}
+				tx := w0/1000*ts.FontSize + ts.CharSpacing
+				if size == 1 && code == 32 {
+					tx += ts.WordSpacing
+				}
+				tx *= th
+
+				params := NewMatrix(ts.FontSize*th, 0, 0, ts.FontSize, 0, ts.Rise)
+				glyphs = append(glyphs, TextGlyph{
+					Code:        code,
+					Data:        data[offset : offset+size],
+					StringIndex: i,
+					ByteOffset:  offset,
+					Trm:         params.Mult(tm).Mult(gs.CTM),
+					Width:       w0 / 1000,
+					Advance:     tx,
+				})
+				tm = TranslationMatrix(tx, 0).Mult(tm)
+				offset += size
+			}
+			continue
+		}
+
+		// Position adjustment in thousandths of text space units.
+		adj, err := core.GetNumberAsFloat(core.TraceToDirectObject(elem))
+		if err != nil {
+			return nil, tm, core.ErrTypeError
+		}
+		tx := -adj / 1000 * ts.FontSize * th
+		tm = TranslationMatrix(tx, 0).Mult(tm)
+	}
+
+	return glyphs, tm, nil
+}
+
+// textCharcodes returns the character codes of `data` shown with `font`.
+func textCharcodes(font *model.PdfFont, data []byte) []uint16 {
+	if font == nil {
+		codes := make([]uint16, len(data))
+		for i, b := range data {
+			codes[i] = uint16(b)
+		}
+		return codes
+	}
+	return font.BytesToCharcodes(data)
+}
+
+// handleGraphicsStateCommand handles the cm operator and the line style operators w, J, j, M and d.
+func (csp *ContentStreamProcessor) handleGraphicsStateCommand(op *ContentStreamOperation) error {
+	gs := &csp.graphicsState
+	if op.Operand == "d" {
+		if len(op.Params) != 2 {
+			return errors.New("Invalid parameters")
+		}
+		return gs.setDash(op.Params[0], op.Params[1])
+	}
+
+	vals, err := core.GetNumbersAsFloat(op.Params)
+	if err != nil {
+		return err
+	}
+	switch op.Operand {
+	case "cm":
+		if len(vals) != 6 {
+			return errors.New("Invalid parameters")
+		}
+		m := NewMatrix(vals[0], vals[1], vals[2], vals[3], vals[4], vals[5])
+		gs.CTM = m.Mult(gs.CTM)
+	case "w", "J", "j", "M":
+		if len(vals) != 1 {
+			return errors.New("Invalid parameters")
+		}
+		switch op.Operand {
+		case "w":
+			gs.LineWidth = vals[0]
+		case "J":
+			gs.LineCap = int(vals[0])
+		case "j":
+			gs.LineJoin = int(vals[0])
+		case "M":
+			gs.MiterLimit = vals[0]
+		}
+	}
+	return nil
+}
+
+// setDash sets the dash pattern of `gs` to the dash array `array` and phase `phase`.
+func (gs *GraphicsState) setDash(array, phase core.PdfObject) error {
+	arr, ok := core.GetArray(array)
+	if !ok {
+		return errors.New("Invalid dash array")
+	}
+	dashes, err := core.GetNumbersAsFloat(arr.Elements())
+	if err != nil {
+		return err
+	}
+	p, err := core.GetNumberAsFloat(core.TraceToDirectObject(phase))
+	if err != nil {
+		return err
+	}
+	gs.DashArray = dashes
+	gs.DashPhase = p
+	return nil
+}
+
+// handleCommand_gs handles the gs operator, setting the line style parameters of the graphics state
+// parameter dictionary named by the operand in `resources` (8.4.5 Graphics State Parameter
+// Dictionaries p. 128).
+func (csp *ContentStreamProcessor) handleCommand_gs(op *ContentStreamOperation, resources *model.PdfPageResources) error {
+	if len(op.Params) != 1 {
+		return errors.New("Invalid parameters")
+	}
+	name, ok := core.GetNameVal(op.Params[0])
+	if !ok || resources == nil {
+		return errors.New("Invalid parameters")
+	}
+	obj, ok := resources.GetExtGState(core.PdfObjectName(name))
+	if !ok {
+		return errors.New("ExtGState not found")
+	}
+	dict, ok := core.GetDict(obj)
+	if !ok {
+		return errors.New("Invalid ExtGState")
+	}
+
+	gs := &csp.graphicsState
+	if lw, err := core.GetNumberAsFloat(core.TraceToDirectObject(dict.Get("LW"))); err == nil {
+		gs.LineWidth = lw
+	}
+	if lc, ok := core.GetIntVal(dict.Get("LC")); ok {
+		gs.LineCap = lc
+	}
+	if lj, ok := core.GetIntVal(dict.Get("LJ")); ok {
+		gs.LineJoin = lj
+	}
+	if ml, err := core.GetNumberAsFloat(core.TraceToDirectObject(dict.Get("ML"))); err == nil {
+		gs.MiterLimit = ml
+	}
+	if d, ok := core.GetArray(dict.Get("D")); ok && d.Len() == 2 {
+		if err := gs.setDash(d.Get(0), d.Get(1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleTextStateCommand handles the text object, text state and text positioning operators, and
+// the line moves of the ' and " text showing operators.
+func (csp *ContentStreamProcessor) handleTextStateCommand(op *ContentStreamOperation) error {
+	ts := &csp.graphicsState.Text
+
+	switch op.Operand {
+	case "BT", "ET":
+		ts.Tm = IdentityMatrix()
+		ts.Tlm = IdentityMatrix()
+		return nil
+	case "T*":
+		ts.moveLine(0, -ts.Leading)
+		return nil
+	case "'":
+		ts.moveLine(0, -ts.Leading)
+		return nil
+	}
+
+	vals, err := core.GetNumbersAsFloat(op.Params)
+	if op.Operand == `"` {
+		// aw ac string "
+		if len(op.Params) != 3 {
+			return errors.New("Invalid parameters")
+		}
+		vals, err = core.GetNumbersAsFloat(op.Params[:2])
+	}
+	if err != nil {
+		return err
+	}
+
+	switch op.Operand {
+	case "Tc", "Tw", "Tz", "TL", "Tr", "Ts":
+		if len(vals) != 1 {
+			return errors.New("Invalid parameters")
+		}
+		switch op.Operand {
+		case "Tc":
+			ts.CharSpacing = vals[0]
+		case "Tw":
+			ts.WordSpacing = vals[0]
+		case "Tz":
+			ts.HorizontalScaling = vals[0]
+		case "TL":
+			ts.Leading = vals[0]
+		case "Tr":
+			ts.RenderMode = int(vals[0])
+		case "Ts":
+			ts.Rise = vals[0]
+		}
+	case "Td", "TD":
+		if len(vals) != 2 {
+			return errors.New("Invalid parameters")
+		}
+		if op.Operand == "TD" {
+			ts.Leading = -vals[1]
+		}
+		ts.moveLine(vals[0], vals[1])
+	case "Tm":
+		if len(vals) != 6 {
+			return errors.New("Invalid parameters")
+		}
+		ts.Tm = NewMatrix(vals[0], vals[1], vals[2], vals[3], vals[4], vals[5])
+		ts.Tlm = ts.Tm
+	case `"`:
+		ts.WordSpacing = vals[0]
+		ts.CharSpacing = vals[1]
+		ts.moveLine(0, -ts.Leading)
+	}
+	return nil
+}
+
+// moveLine moves to the start of the next line, offset from the start of the current line by
+// (`tx`,`ty`).
+func (ts *TextState) moveLine(tx, ty float64) {
+	ts.Tlm = TranslationMatrix(tx, ty).Mult(ts.Tlm)
+	ts.Tm = ts.Tlm
+}
+
+// handleCommand_Tf handles the Tf operator, setting the font and font size.
+func (csp *ContentStreamProcessor) handleCommand_Tf(op *ContentStreamOperation, resources *model.PdfPageResources) error {
+	if len(op.Params) != 2 {
+		return errors.New("Invalid parameters")
+	}
+	name, ok := core.GetNameVal(op.Params[0])
+	if !ok {
+		return core.ErrTypeError
+	}
+	size, err := core.GetNumberAsFloat(core.TraceToDirectObject(op.Params[1]))
+	if err != nil {
+		return err
+	}
+
+	ts := &csp.graphicsState.Text
+	ts.FontName = name
+	ts.FontSize = size
+	ts.Font = nil
+
+	if resources == nil {
+		return errors.New("No resources")
+	}
+	fontObj, has := resources.GetFontByName(core.PdfObjectName(name))
+	if !has {
+		return errors.New("Font not found")
+	}
+	if font, cached := csp.fontCache[fontObj]; cached {
+		ts.Font = font
+		return nil
+	}
+	font, err := model.NewPdfFontFromPdfObject(fontObj)
+	if err != nil {
+		csp.fontCache[fontObj] = nil
+		return err
+	}
+	csp.fontCache[fontObj] = font
+	ts.Font = font
+	return nil
+}
@@ -94,6 +94,12 @@ func MakeInteger(val int64) *PdfObjectInteger {
 	return &num
 }
 
+// MakeBool creates a PdfObjectBool from a bool.
+func MakeBool(val bool) *PdfObjectBool {
+	b := PdfObjectBool(val)
+	return &b
+}
+
 // MakeArray creates an PdfObjectArray from a list of PdfObjects.
 func MakeArray(objects ...PdfObject) *PdfObjectArray {
 	array := &PdfObjectArray{}
@@ -0,0 +1,92 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package core
+
+import (
+	"bytes"
+	"unicode/utf16"
+)
+
+// Text strings (7.9.2.2 Text String Type p. 86) are encoded either in PDFDocEncoding or as
+// UTF-16BE with a leading byte order marker (FE FF).
+
+// pdfDocEncodingSpecial maps the PDFDocEncoding character codes which differ from ISO Latin-1.
+var pdfDocEncodingSpecial = map[byte]rune{
+	0x18: 0x02D8, 0x19: 0x02C7, 0x1A: 0x02C6, 0x1B: 0x02D9, 0x1C: 0x02DD, 0x1D: 0x02DB, 0x1E: 0x02DA,
+	0x1F: 0x02DC, 0x80: 0x2022, 0x81: 0x2020, 0x82: 0x2021, 0x83: 0x2026, 0x84: 0x2014, 0x85: 0x2013,
+	0x86: 0x0192, 0x87: 0x2044, 0x88: 0x2039, 0x89: 0x203A, 0x8A: 0x2212, 0x8B: 0x2030, 0x8C: 0x201E,
+	0x8D: 0x201C, 0x8E: 0x201D, 0x8F: 0x2018, 0x90: 0x2019, 0x91: 0x201A, 0x92: 0x2122, 0x93: 0xFB01,
+	0x94: 0xFB02, 0x95: 0x0141, 0x96: 0x0152, 0x97: 0x0160, 0x98: 0x0178, 0x99: 0x017D, 0x9A: 0x0131,
+	0x9B: 0x0142, 0x9C: 0x0153, 0x9D: 0x0161, 0x9E: 0x017E, 0xA0: 0x20AC,
+}
+
+// pdfDocEncodingReverse maps runes to the PDFDocEncoding character codes which differ from ISO Latin-1.
+var pdfDocEncodingReverse = func() map[rune]byte {
+	m := map[rune]byte{}
+	for code, r := range pdfDocEncodingSpecial {
+		m[r] = code
+	}
+	return m
+}()
+
+// Decoded returns the text string as a Go unicode string, decoding UTF-16BE (with byte order marker)
+// or PDFDocEncoding as appropriate.
+func (str *PdfObjectString) Decoded() string {
+	if str == nil {
+		return ""
+	}
+	b := []byte(str.val)
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		b = b[2:]
+		if len(b)%2 != 0 {
+			b = append(b, 0)
+		}
+		chars := make([]uint16, 0, len(b)/2)
+		for i := 0; i < len(b); i += 2 {
+			chars = append(chars, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(chars))
+	}
+
+	var buf bytes.Buffer
+	for _, c := range b {
+		if r, has := pdfDocEncodingSpecial[c]; has {
+			buf.WriteRune(r)
+		} else {
+			buf.WriteRune(rune(c))
+		}
+	}
+	return buf.String()
+}
+
+// MakeEncodedString creates a PdfObjectString with the Go unicode string `s` encoded as a PDF text string.
+// PDFDocEncoding is used if it can represent all the characters in `s`, otherwise the string is encoded
+// as UTF-16BE with a byte order marker.
+func MakeEncodedString(s string) *PdfObjectString {
+	var buf bytes.Buffer
+	for _, r := range s {
+		if c, has := pdfDocEncodingReverse[r]; has {
+			buf.WriteByte(c)
+			continue
+		}
+		if r > 0xFF || (r >= 0x80 && r <= 0xA0) || r == 0xAD || (r >= 0x18 && r <= 0x1F) {
+			return MakeString(utf16BEString(s))
+		}
+		buf.WriteByte(byte(r))
+	}
+	return MakeString(buf.String())
+}
+
+// utf16BEString returns `s` encoded as UTF-16BE with a leading byte order marker.
+func utf16BEString(s string) string {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xFE, 0xFF})
+	for _, c := range utf16.Encode([]rune(s)) {
+		buf.WriteByte(byte(c >> 8))
+		buf.WriteByte(byte(c & 0xFF))
+	}
+	return buf.String()
+}
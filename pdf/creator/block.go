@@ -39,6 +39,12 @@ type Block struct {
 
 	// Margins to be applied around the block when drawing on Page.
 	margins margins
+
+	// Marked-content sequences of structure elements in the contents.
+	marks []*structMark
+
+	// Positions of the outline items of headings in the contents.
+	anchors []*outlineAnchor
 }
 
 // NewBlock creates a new Block with specified width and height.
@@ -108,6 +114,8 @@ func (blk *Block) duplicate() *Block {
 		dupContents = append(dupContents, op)
 	}
 	dup.contents = &dupContents
+	dup.marks = append([]*structMark{}, blk.marks...)
+	dup.anchors = append([]*outlineAnchor{}, blk.anchors...)
 
 	return dup
 }
@@ -131,6 +139,7 @@ func (blk *Block) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext, er
 		}
 		contents := append(*cc.Operations(), *dup.contents...)
 		dup.contents = &contents
+		dup.anchors = offsetOutlineAnchors(blk.anchors, ctx.X, ctx.Y)
 
 		blocks = append(blocks, dup)
 
@@ -150,6 +159,7 @@ func (blk *Block) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext, er
 		contents := append(*cc.Operations(), *dup.contents...)
 		contents.WrapIfNeeded()
 		dup.contents = &contents
+		dup.anchors = offsetOutlineAnchors(blk.anchors, blk.xPos, blk.yPos)
 
 		blocks = append(blocks, dup)
 	}
@@ -304,6 +314,8 @@ func (blk *Block) Draw(d Drawable) error {
 		if err != nil {
 			return err
 		}
+		blk.marks = append(blk.marks, newBlock.marks...)
+		blk.anchors = append(blk.anchors, newBlock.anchors...)
 	}
 
 	return nil
@@ -325,6 +337,8 @@ func (blk *Block) DrawWithContext(d Drawable, ctx DrawContext) error {
 		if err != nil {
 			return err
 		}
+		blk.marks = append(blk.marks, newBlock.marks...)
+		blk.anchors = append(blk.anchors, newBlock.anchors...)
 	}
 
 	return nil
@@ -333,7 +347,12 @@ func (blk *Block) DrawWithContext(d Drawable, ctx DrawContext) error {
 // Append another block onto the block.
 func (blk *Block) mergeBlocks(toAdd *Block) error {
 	err := mergeContents(blk.contents, blk.resources, toAdd.contents, toAdd.resources)
-	return err
+	if err != nil {
+		return err
+	}
+	blk.marks = append(blk.marks, toAdd.marks...)
+	blk.anchors = append(blk.anchors, toAdd.anchors...)
+	return nil
 }
 
 // Merge contents and content streams.
@@ -344,8 +363,8 @@ func mergeContents(contents *contentstream.ContentStreamOperations, resources *m
 	// To properly add contents from a block, we need to handle the resources that the block is
 	// using and make sure it is accessible in the modified Page.
 	//
-	// Currently supporting: Font, XObject, Colormap, Pattern, Shading, GState resources
-	// from the block.
+	// Currently supporting: Font, XObject, Colormap, Pattern, Shading, GState and Properties
+	// resources from the block.
 	//
 
 	xobjectMap := map[core.PdfObjectName]core.PdfObjectName{}
@@ -354,6 +373,7 @@ func mergeContents(contents *contentstream.ContentStreamOperations, resources *m
 	patternMap := map[core.PdfObjectName]core.PdfObjectName{}
 	shadingMap := map[core.PdfObjectName]core.PdfObjectName{}
 	gstateMap := map[core.PdfObjectName]core.PdfObjectName{}
+	propertiesMap := map[core.PdfObjectName]core.PdfObjectName{}
 
 	for _, op := range *contentsToAdd {
 		switch op.Operand {
@@ -539,6 +559,42 @@ func mergeContents(contents *contentstream.ContentStreamOperations, resources *m
 					op.Params[0] = &useName
 				}
 			}
+		case "BDC":
+			// Property list referred to by name, e.g. optional content.
+			if len(op.Params) == 2 {
+				if name, ok := op.Params[1].(*core.PdfObjectName); ok {
+					if _, processed := propertiesMap[*name]; !processed {
+						var useName core.PdfObjectName
+						// Process if not already processed.
+						props, found := resourcesToAdd.GetPropertiesByName(*name)
+						if found {
+							useName = *name
+							for {
+								props2, found := resources.GetPropertiesByName(useName)
+								if !found || props == props2 {
+									break
+								}
+								useName = useName + "0"
+							}
+
+							err := resources.SetPropertiesByName(useName, props)
+							if err != nil {
+								return err
+							}
+
+							propertiesMap[*name] = useName
+						} else {
+							common.Log.Debug("Property list not found")
+						}
+					}
+
+					if useName, has := propertiesMap[*name]; has {
+						op.Params[1] = &useName
+					} else {
+						common.Log.Debug("Error: Property list %s not found", *name)
+					}
+				}
+			}
 		}
 
 		*contents = append(*contents, op)
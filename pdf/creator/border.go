@@ -368,5 +368,6 @@ func (border *border) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext
 		}
 	}
 
+	block.markContents(nil)
 	return []*Block{block}, ctx, nil
 }
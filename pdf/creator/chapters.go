@@ -10,6 +10,7 @@ import (
 	"fmt"
 
 	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/model"
 	"github.com/unidoc/unidoc/pdf/model/fonts"
 )
 
@@ -30,6 +31,10 @@ type Chapter struct {
 	// Include in TOC.
 	includeInTOC bool
 
+	// Outline item, added to the document outlines when drawn.
+	outlineItem      *model.PdfOutlineItem
+	includeInOutline bool
+
 	// Positioning: relative / absolute.
 	positioning positioning
 
@@ -41,6 +46,9 @@ type Chapter struct {
 
 	// Reference to the creator's TOC.
 	toc *TableOfContents
+
+	// The chapter is the generated table of contents.
+	isTOC bool
 }
 
 // NewChapter creates a new chapter with the specified title as the heading.
@@ -53,11 +61,15 @@ func (c *Creator) NewChapter(title string) *Chapter {
 
 	chap.showNumbering = true
 	chap.includeInTOC = true
+	chap.includeInOutline = true
 
 	heading := fmt.Sprintf("%d. %s", c.chapters, title)
+	chap.outlineItem = model.NewPdfOutlineItem()
+	chap.outlineItem.SetTitle(heading)
 	p := NewParagraph(heading)
 	p.SetFontSize(16)
 	p.SetFont(fonts.NewFontHelvetica()) // bold?
+	p.SetStructureType("H1")
 
 	chap.heading = p
 	chap.contents = []Drawable{}
@@ -73,9 +85,11 @@ func (chap *Chapter) SetShowNumbering(show bool) {
 	if show {
 		heading := fmt.Sprintf("%d. %s", chap.number, chap.title)
 		chap.heading.SetText(heading)
+		chap.outlineItem.SetTitle(heading)
 	} else {
 		heading := fmt.Sprintf("%s", chap.title)
 		chap.heading.SetText(heading)
+		chap.outlineItem.SetTitle(heading)
 	}
 	chap.showNumbering = show
 }
@@ -85,6 +99,18 @@ func (chap *Chapter) SetIncludeInTOC(includeInTOC bool) {
 	chap.includeInTOC = includeInTOC
 }
 
+// SetIncludeInOutline sets a flag to indicate whether or not to add the chapter to the document
+// outlines.
+func (chap *Chapter) SetIncludeInOutline(includeInOutline bool) {
+	chap.includeInOutline = includeInOutline
+}
+
+// GetOutlineItem returns the outline item of the chapter, e.g. to set its style.  The item goes to
+// the chapter heading.
+func (chap *Chapter) GetOutlineItem() *model.PdfOutlineItem {
+	return chap.outlineItem
+}
+
 // GetHeading returns the chapter heading paragraph. Used to give access to address style: font, sizing etc.
 func (chap *Chapter) GetHeading() *Paragraph {
 	return chap.heading
@@ -138,12 +164,19 @@ func (chap *Chapter) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext,
 		ctx.Height -= chap.margins.top
 	}
 
+	headingX, headingY := ctx.X, ctx.Y
 	blocks, ctx, err := chap.heading.GeneratePageBlocks(ctx)
 	if err != nil {
 		return blocks, ctx, err
 	}
 	if len(blocks) > 1 {
 		ctx.Page++ // Did not fit, moved to new Page block.
+		headingY = ctx.Margins.top
+	}
+
+	if chap.includeInOutline {
+		anchor := &outlineAnchor{item: chap.outlineItem, x: headingX, y: headingY}
+		blocks[len(blocks)-1].anchors = append(blocks[len(blocks)-1].anchors, anchor)
 	}
 
 	if chap.includeInTOC {
@@ -167,6 +200,16 @@ func (chap *Chapter) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext,
 		ctx = c
 	}
 
+	// The heading and contents are tagged as a section.
+	sect := model.NewPdfStructElement("Sect")
+	sect.Title = chap.title
+	for _, blk := range blocks {
+		adoptStructElements(sect, blk.marks)
+	}
+	if chap.isTOC {
+		retagTableOfContents(sect)
+	}
+
 	if chap.positioning.isRelative() {
 		// Move back X to same start of line.
 		ctx.X = origCtx.X
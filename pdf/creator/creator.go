@@ -44,6 +44,48 @@ type Creator struct {
 
 	// Forms.
 	acroForm *model.PdfAcroForm
+
+	// Document navigation and display settings.
+	pageLabels        *model.PdfPageLabels
+	namedDests        []creatorDestination
+	viewerPreferences *model.PdfViewerPreferences
+	pageMode          model.PdfPageMode
+	pageLayout        model.PdfPageLayout
+	openDest          *creatorDestination
+
+	// Logical structure of tagged documents.
+	tagged      bool
+	lang        string
+	structRoots []*model.PdfStructElement
+	mcids       map[*model.PdfPage]int
+
+	// Optional content layers.
+	layers []*Layer
+
+	// Outlines of the drawn chapters and subchapters.
+	outlines *model.PdfOutline
+
+	// PDF/A conformance.
+	pdfaConformance model.PdfAConformance
+	fontSubstitutes map[string]*model.PdfFont
+}
+
+// creatorDestination is a position on a page, in the coordinates of the drawing context (origin
+// at the top left corner).
+type creatorDestination struct {
+	name string
+	page *model.PdfPage
+	x, y float64
+	zoom float64
+}
+
+// toPdfDestination returns the destination as a PDF destination to the position.
+func (d *creatorDestination) toPdfDestination() (*model.PdfDestination, error) {
+	mbox, err := d.page.GetMediaBox()
+	if err != nil {
+		return nil, err
+	}
+	return model.NewPdfDestinationXYZ(d.page.GetPageAsIndirectObject(), mbox.Llx+d.x, mbox.Ury-d.y, d.zoom), nil
 }
 
 // SetForms Add Acroforms to a PDF file.  Sets the specified form for writing.
@@ -52,6 +94,52 @@ func (c *Creator) SetForms(form *model.PdfAcroForm) error {
 	return nil
 }
 
+// SetPageLabels sets the page labels of the output document.  The page indices of the label ranges
+// are those of the output document, including the front page and table of contents if generated.
+func (c *Creator) SetPageLabels(labels *model.PdfPageLabels) {
+	c.pageLabels = labels
+}
+
+// AddNamedDestination adds the named destination `name` to position (`x`, `y`) of page `pageNum`
+// (starting from 1), where the position is relative to the top left corner of the page.  The page
+// number refers to the pages created or added so far.
+func (c *Creator) AddNamedDestination(name string, pageNum int, x, y float64) error {
+	if pageNum < 1 || pageNum > len(c.pages) {
+		common.Log.Debug("Named destination %q to page %d out of range", name, pageNum)
+		return errors.New("Page number out of range")
+	}
+	c.namedDests = append(c.namedDests, creatorDestination{name: name, page: c.pages[pageNum-1], x: x, y: y})
+	return nil
+}
+
+// SetViewerPreferences sets the viewer preferences of the output document.
+func (c *Creator) SetViewerPreferences(prefs *model.PdfViewerPreferences) {
+	c.viewerPreferences = prefs
+}
+
+// SetPageMode sets how the output document is displayed when opened, e.g. with the outlines visible.
+func (c *Creator) SetPageMode(mode model.PdfPageMode) {
+	c.pageMode = mode
+}
+
+// SetPageLayout sets the page layout of the output document when opened.
+func (c *Creator) SetPageLayout(layout model.PdfPageLayout) {
+	c.pageLayout = layout
+}
+
+// SetOpenDestination sets the output document to open at position (`x`, `y`) of page `pageNum`
+// (starting from 1) with `zoom`, where a zoom of 0 retains the current zoom.  The position is
+// relative to the top left corner of the page and the page number refers to the pages created or
+// added so far.
+func (c *Creator) SetOpenDestination(pageNum int, x, y, zoom float64) error {
+	if pageNum < 1 || pageNum > len(c.pages) {
+		common.Log.Debug("Open destination page %d out of range", pageNum)
+		return errors.New("Page number out of range")
+	}
+	c.openDest = &creatorDestination{page: c.pages[pageNum-1], x: x, y: y, zoom: zoom}
+	return nil
+}
+
 // FrontpageFunctionArgs holds the input arguments to a front page drawing function.
 // It is designed as a struct, so additional parameters can be added in the future with backwards compatibility.
 type FrontpageFunctionArgs struct {
@@ -94,6 +182,8 @@ func New() *Creator {
 	c.pageMargins.bottom = m
 
 	c.toc = newTableOfContents()
+	c.outlines = model.NewPdfOutline()
+	c.mcids = map[*model.PdfPage]int{}
 
 	return c
 }
@@ -303,8 +393,10 @@ func (c *Creator) finalize() error {
 	}
 
 	hasFrontPage := false
+	numFrontRoots := 0
 	// Generate the front Page.
 	if c.genFrontPageFunc != nil {
+		start := len(c.structRoots)
 		totPages++
 		p := c.newPage()
 		// Place at front.
@@ -317,6 +409,10 @@ func (c *Creator) finalize() error {
 		}
 		c.genFrontPageFunc(args)
 		hasFrontPage = true
+
+		// The front page comes first in the logical structure.
+		c.moveStructRoots(start, 0)
+		numFrontRoots = len(c.structRoots) - start
 	}
 
 	if c.genTableOfContentFunc != nil {
@@ -328,6 +424,9 @@ func (c *Creator) finalize() error {
 		}
 		ch.SetShowNumbering(false)
 		ch.SetIncludeInTOC(false)
+		ch.SetIncludeInOutline(false)
+		ch.isTOC = true
+		start := len(c.structRoots)
 
 		blocks, _, _ := ch.GeneratePageBlocks(c.context)
 		tocpages := []*model.PdfPage{}
@@ -349,6 +448,7 @@ func (c *Creator) finalize() error {
 		} else {
 			c.pages = append(tocpages, c.pages...)
 		}
+		c.moveStructRoots(start, numFrontRoots)
 
 	}
 
@@ -364,6 +464,7 @@ func (c *Creator) finalize() error {
 				TotalPages: totPages,
 			}
 			c.drawHeaderFunc(headerBlock, args)
+			headerBlock.markArtifact("Pagination", "Header")
 			headerBlock.SetPos(0, 0)
 			err := c.Draw(headerBlock)
 			if err != nil {
@@ -382,6 +483,7 @@ func (c *Creator) finalize() error {
 				TotalPages: totPages,
 			}
 			c.drawFooterFunc(footerBlock, args)
+			footerBlock.markArtifact("Pagination", "Footer")
 			footerBlock.SetPos(0, c.pageHeight-footerBlock.height)
 			err := c.Draw(footerBlock)
 			if err != nil {
@@ -441,7 +543,11 @@ func (c *Creator) Draw(d Drawable) error {
 		}
 
 		p := c.getActivePage()
-		err := blk.drawToPage(p)
+		err := c.tagBlock(blk, p).drawToPage(p)
+		if err != nil {
+			return err
+		}
+		err = c.placeOutlineAnchors(blk.anchors, p)
 		if err != nil {
 			return err
 		}
@@ -471,6 +577,54 @@ func (c *Creator) Write(ws io.WriteSeeker) error {
 		}
 	}
 
+	// Page labels, named destinations and viewer settings.
+	pdfWriter.SetPageLabels(c.pageLabels)
+	for _, d := range c.namedDests {
+		dest, err := d.toPdfDestination()
+		if err != nil {
+			common.Log.Debug("Failure: %v", err)
+			return err
+		}
+		pdfWriter.AddNamedDestination(d.name, dest)
+	}
+	pdfWriter.SetViewerPreferences(c.viewerPreferences)
+	pdfWriter.SetPageMode(c.pageMode)
+	pdfWriter.SetPageLayout(c.pageLayout)
+	if c.openDest != nil {
+		dest, err := c.openDest.toPdfDestination()
+		if err != nil {
+			common.Log.Debug("Failure: %v", err)
+			return err
+		}
+		pdfWriter.SetOpenAction(&model.PdfOpenAction{Dest: dest})
+	}
+
+	// Outlines.
+	if c.outlines.First != nil {
+		pdfWriter.SetOutlines(c.outlines)
+	}
+
+	// Logical structure.
+	pdfWriter.SetLanguage(c.lang)
+	if c.tagged {
+		pdfWriter.SetStructTreeRoot(c.newStructTreeRoot())
+	}
+
+	// Layers.
+	if len(c.layers) > 0 {
+		err := pdfWriter.SetOptionalContent(c.newOCProperties())
+		if err != nil {
+			common.Log.Debug("Failure: %v", err)
+			return err
+		}
+	}
+
+	// PDF/A conformance.
+	pdfWriter.SetPdfAConformance(c.pdfaConformance)
+	for baseFont, font := range c.fontSubstitutes {
+		pdfWriter.SetFontSubstitute(baseFont, font)
+	}
+
 	// Pdf Writer access hook.  Can be used to encrypt, etc. via the PdfWriter instance.
 	if c.pdfWriterAccessFunc != nil {
 		err := c.pdfWriterAccessFunc(&pdfWriter)
@@ -513,6 +667,24 @@ func (c *Creator) SetPdfWriterAccessFunc(pdfWriterAccessFunc func(writer *model.
 	c.pdfWriterAccessFunc = pdfWriterAccessFunc
 }
 
+// SetPdfAConformance sets the PDF/A conformance level of the output (see
+// model.PdfWriter.SetPdfAConformance).  Writing fails if the document cannot conform to the level,
+// e.g. if it uses fonts without font program, such as the standard fonts, with no substitute set
+// with SetFontSubstitute (see package pdfafonts for the substitutes of the standard fonts).
+func (c *Creator) SetPdfAConformance(level model.PdfAConformance) {
+	c.pdfaConformance = level
+}
+
+// SetFontSubstitute sets the embedded font `font` replacing the standard font `baseFont` (e.g.
+// Helvetica) in PDF/A output.  The substitute should have the metrics of the standard font, which
+// were used to lay out the text.
+func (c *Creator) SetFontSubstitute(baseFont string, font *model.PdfFont) {
+	if c.fontSubstitutes == nil {
+		c.fontSubstitutes = map[string]*model.PdfFont{}
+	}
+	c.fontSubstitutes[baseFont] = font
+}
+
 // WriteToFile writes the Creator output to file specified by path.
 func (c *Creator) WriteToFile(outputPath string) error {
 	fWrite, err := os.Create(outputPath)
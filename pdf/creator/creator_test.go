@@ -1055,13 +1055,13 @@ func TestTableCellWrapping(t *testing.T) {
 	cell := table.NewCell()
 	p := NewParagraph("A Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore et dolore magna aliqua. Ut enim ad minim veniam, quis nostrud exercitation ullamco laboris nisi ut aliquip ex ea commodo consequat. Duis aute irure dolor in reprehenderit in voluptate velit esse cillum dolore eu fugiat nulla pariatur. Excepteur sint occaecat cupidatat non proident, sunt in culpa qui officia deserunt mollit anim id est laborum.")
 	cell.SetContent(p)
-	cell.SetBorder(CellBorderStyleBox, 1)
+	cell.SetBorder(CellBorderSideAll, CellBorderStyleSingle, 1)
 	p.SetEnableWrap(true)
 	p.SetWidth(cell.Width(c.Context()))
 	p.SetTextAlignment(TextAlignmentJustify)
 
 	cell = table.NewCell()
-	cell.SetBorder(CellBorderStyleBox, 1)
+	cell.SetBorder(CellBorderSideAll, CellBorderStyleSingle, 1)
 	p = NewParagraph("B Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore et dolore magna aliqua. Ut enim ad minim veniam, quis nostrud exercitation ullamco laboris nisi ut aliquip ex ea commodo consequat. Duis aute irure dolor in reprehenderit in voluptate velit esse cillum dolore eu fugiat nulla pariatur.")
 	p.SetEnableWrap(true)
 	p.SetTextAlignment(TextAlignmentRight)
@@ -1071,32 +1071,32 @@ func TestTableCellWrapping(t *testing.T) {
 	p = NewParagraph("C Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore et dolore magna aliqua. Ut enim ad minim veniam, quis nostrud exercitation ullamco laboris nisi ut aliquip ex ea commodo consequat. Duis aute irure dolor in reprehenderit in voluptate velit esse cillum dolore eu fugiat nulla pariatur. Excepteur sint occaecat cupidatat non proident, sunt in culpa qui officia deserunt mollit anim id est laborum.")
 	p.SetEnableWrap(true)
 	cell.SetContent(p)
-	cell.SetBorder(CellBorderStyleBox, 1)
+	cell.SetBorder(CellBorderSideAll, CellBorderStyleSingle, 1)
 
 	cell = table.NewCell()
 	p = NewParagraph("1,4")
 	cell.SetContent(p)
-	cell.SetBorder(CellBorderStyleBox, 1)
+	cell.SetBorder(CellBorderSideAll, CellBorderStyleSingle, 1)
 
 	cell = table.NewCell()
 	p = NewParagraph("2,1")
 	cell.SetContent(p)
-	cell.SetBorder(CellBorderStyleBox, 1)
+	cell.SetBorder(CellBorderSideAll, CellBorderStyleSingle, 1)
 
 	cell = table.NewCell()
 	p = NewParagraph("2,2")
 	cell.SetContent(p)
-	cell.SetBorder(CellBorderStyleBox, 1)
+	cell.SetBorder(CellBorderSideAll, CellBorderStyleSingle, 1)
 
 	cell = table.NewCell()
 	p = NewParagraph("2,2")
 	cell.SetContent(p)
-	cell.SetBorder(CellBorderStyleBox, 1)
+	cell.SetBorder(CellBorderSideAll, CellBorderStyleSingle, 1)
 
 	//table.SkipCells(1) // Skip over 2,3.
 
 	cell = table.NewCell()
-	cell.SetBorder(CellBorderStyleBox, 1)
+	cell.SetBorder(CellBorderSideAll, CellBorderStyleSingle, 1)
 	//p = NewParagraph("D Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore et dolore magna aliqua. Ut enim ad minim veniam, quis nostrud exercitation ullamco laboris nisi ut aliquip ex ea commodo consequat. Duis aute irure dolor in reprehenderit in voluptate velit esse cillum dolore eu fugiat nulla pariatur. Excepteur sint occaecat cupidatat non proident, sunt in culpa qui officia deserunt mollit anim id est laborum.")
 	p = NewParagraph("X")
 	p.SetEnableWrap(true)
@@ -1105,7 +1105,7 @@ func TestTableCellWrapping(t *testing.T) {
 	// Skip over two rows.
 	table.SkipRows(2)
 	cell = table.NewCell()
-	cell.SetBorder(CellBorderStyleBox, 1)
+	cell.SetBorder(CellBorderSideAll, CellBorderStyleSingle, 1)
 	p = NewParagraph("4,4")
 	cell.SetContent(p)
 
@@ -1118,7 +1118,7 @@ func TestTableCellWrapping(t *testing.T) {
 
 	table.SkipRows(1)
 	cell = table.NewCell()
-	cell.SetBorder(CellBorderStyleBox, 1)
+	cell.SetBorder(CellBorderSideAll, CellBorderStyleSingle, 1)
 	p = NewParagraph("This is\nnewline\nwrapped\n\nmulti")
 	p.SetEnableWrap(true)
 	cell.SetContent(p)
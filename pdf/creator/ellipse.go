@@ -90,5 +90,6 @@ func (ell *Ellipse) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext,
 		return nil, ctx, err
 	}
 
+	block.markContents(nil)
 	return []*Block{block}, ctx, nil
 }
@@ -108,5 +108,6 @@ func (this *FilledCurve) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawCont
 	if err != nil {
 		return nil, ctx, err
 	}
+	block.markContents(nil)
 	return []*Block{block}, ctx, nil
 }
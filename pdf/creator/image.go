@@ -50,6 +50,9 @@ type Image struct {
 
 	// Encoder
 	encoder core.StreamEncoder
+
+	// Alternate description of tagged documents.
+	altText string
 }
 
 // NewImage create a new image from a unidoc image (model.Image).
@@ -114,6 +117,12 @@ func (img *Image) SetEncoder(encoder core.StreamEncoder) {
 	img.encoder = encoder
 }
 
+// SetAltText sets the alternate description of the image in tagged documents, describing the
+// image to readers that cannot see it.
+func (img *Image) SetAltText(text string) {
+	img.altText = text
+}
+
 // Height returns Image's document height.
 func (img *Image) Height() float64 {
 	return img.height
@@ -323,10 +332,16 @@ func drawImageOnBlock(blk *Block, img *Image, ctx DrawContext) (DrawContext, err
 		Scale(img.Width(), img.Height()).
 		Add_Do(imgName) // Draw the image.
 
-	ops := contentCreator.Operations()
-	ops.WrapIfNeeded()
+	// The image is tagged as a figure with its bounding box.
+	figure := model.NewPdfStructElement("Figure")
+	figure.Alt = img.altText
+	layout := core.MakeDict()
+	layout.Set("O", core.MakeName("Layout"))
+	layout.Set("BBox", core.MakeArrayFromFloats([]float64{xPos, yPos, xPos + img.Width(), yPos + img.Height()}))
+	figure.Attributes = layout
 
-	blk.addContents(ops)
+	ops := contentCreator.Operations()
+	blk.addMarkedContents(ops, figure)
 
 	if img.positioning.isRelative() {
 		ctx.Y += img.Height()
@@ -0,0 +1,398 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package creator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// ImpositionOrder defines the order in which pages are placed in the cells of the sheets.
+type ImpositionOrder int
+
+const (
+	// ImpositionOrderRows places the pages row by row, from left to right and top to bottom.
+	ImpositionOrderRows ImpositionOrder = iota
+
+	// ImpositionOrderColumns places the pages column by column, from top to bottom and left to right.
+	ImpositionOrderColumns
+
+	// ImpositionOrderBooklet places the pages for a saddle-stitched booklet.  Each sheet side has two
+	// pages side by side, and consecutive output pages are the front and back sides of a sheet.
+	// When the sheets are printed on both sides, stacked, folded in the middle and stitched, the
+	// pages are in reading order.  The number of pages is padded with blank pages to a multiple of 4.
+	ImpositionOrderBooklet
+)
+
+// Imposition defines how pages are placed onto sheets, e.g. for printing 2-up or 4-up handouts or
+// booklets.  The area of the sheet within the margins is divided into a grid of cells with gutters
+// between them.  Each page is scaled to fit its cell and centered in it (in booklet order, the
+// pages are placed against the fold instead of centered horizontally).
+type Imposition struct {
+	sheetSize     PageSize
+	rows, columns int
+	margins       margins
+	columnGutter  float64
+	rowGutter     float64
+	order         ImpositionOrder
+	creep         float64
+	autoRotate    bool
+	sheetRotation int64
+
+	// Crop marks: no marks are drawn if the length is 0.
+	cropMarkLength float64
+	cropMarkOffset float64
+	cropMarkWidth  float64
+}
+
+// NewImposition returns an imposition of `rows` by `columns` pages per sheet of size `sheetSize`,
+// placed in row order.
+func NewImposition(sheetSize PageSize, rows, columns int) *Imposition {
+	return &Imposition{
+		sheetSize:     sheetSize,
+		rows:          rows,
+		columns:       columns,
+		order:         ImpositionOrderRows,
+		cropMarkWidth: 0.25,
+	}
+}
+
+// NewBookletImposition returns an imposition for a saddle-stitched booklet printed on sheets of
+// size `sheetSize`, i.e. two pages side by side per sheet side in booklet order.
+func NewBookletImposition(sheetSize PageSize) *Imposition {
+	imp := NewImposition(sheetSize, 1, 2)
+	imp.order = ImpositionOrderBooklet
+	return imp
+}
+
+// SetMargins sets the sheet margins around the grid of cells.
+func (imp *Imposition) SetMargins(left, right, top, bottom float64) {
+	imp.margins.left = left
+	imp.margins.right = right
+	imp.margins.top = top
+	imp.margins.bottom = bottom
+}
+
+// SetGutters sets the space between the columns and between the rows of cells.  For booklets the
+// column gutter is the space between the two pages at the fold.
+func (imp *Imposition) SetGutters(column, row float64) {
+	imp.columnGutter = column
+	imp.rowGutter = row
+}
+
+// SetOrder sets the order in which the pages are placed.  The booklet order requires a grid of one
+// row and two columns.
+func (imp *Imposition) SetOrder(order ImpositionOrder) {
+	imp.order = order
+}
+
+// SetCreep sets the creep compensation for booklets: the pages on the n-th sheet from the outside
+// (starting from 0) are shifted toward the fold by n times `creep`, to compensate for the inner
+// sheets of a folded booklet sticking out at the fore edge.
+func (imp *Imposition) SetCreep(creep float64) {
+	imp.creep = creep
+}
+
+// SetAutoRotate sets whether pages are rotated by 90 degrees when the orientation of the page
+// (portrait or landscape) differs from the orientation of the cells.
+func (imp *Imposition) SetAutoRotate(autoRotate bool) {
+	imp.autoRotate = autoRotate
+}
+
+// SetSheetRotation sets the rotation of the sheets in degrees (clockwise, a multiple of 90), which
+// is applied as the rotation of the output pages.
+func (imp *Imposition) SetSheetRotation(angleDeg int64) {
+	imp.sheetRotation = angleDeg
+}
+
+// SetCropMarks sets crop marks to be drawn at the corners of the placed pages.  The marks are lines
+// of `length` starting at `offset` from the corners, pointing away from the page.  Marks that would
+// overlap other pages on the sheet are not drawn.  A length of 0 disables the crop marks.
+func (imp *Imposition) SetCropMarks(length, offset float64) {
+	imp.cropMarkLength = length
+	imp.cropMarkOffset = offset
+}
+
+// Impose places `pages` onto new sheets according to `imp` and adds the sheets as pages to the
+// creator.  Each page is added once as a Form XObject, which is reused if the page is placed more
+// than once.  nil pages leave their cells blank.
+func (c *Creator) Impose(pages []*model.PdfPage, imp *Imposition) error {
+	if imp.rows < 1 || imp.columns < 1 {
+		return errors.New("Invalid imposition grid")
+	}
+	if imp.order == ImpositionOrderBooklet && (imp.rows != 1 || imp.columns != 2) {
+		common.Log.Debug("Booklet imposition with %dx%d grid", imp.rows, imp.columns)
+		return errors.New("Booklet imposition requires 1x2 grid")
+	}
+	if imp.sheetRotation%90 != 0 {
+		return errors.New("Sheet rotation not a multiple of 90")
+	}
+
+	sheetWidth, sheetHeight := imp.sheetSize[0], imp.sheetSize[1]
+	cellWidth := (sheetWidth - imp.margins.left - imp.margins.right - float64(imp.columns-1)*imp.columnGutter) /
+		float64(imp.columns)
+	cellHeight := (sheetHeight - imp.margins.top - imp.margins.bottom - float64(imp.rows-1)*imp.rowGutter) /
+		float64(imp.rows)
+	if cellWidth <= 0 || cellHeight <= 0 {
+		return errors.New("Imposition cells too small")
+	}
+
+	xforms := map[*model.PdfPage]*model.XObjectForm{}
+	for _, sheet := range imp.sheets(pages) {
+		page := model.NewPdfPage()
+		page.MediaBox = &model.PdfRectangle{Llx: 0, Lly: 0, Urx: sheetWidth, Ury: sheetHeight}
+		page.Resources = model.NewPdfPageResources()
+		err := c.AddPage(page)
+		if err != nil {
+			return err
+		}
+
+		// Rectangles of the placed pages in sheet coordinates (origin at the lower left corner).
+		placed := []model.PdfRectangle{}
+		for cell, index := range sheet.cells {
+			if index < 0 || pages[index] == nil {
+				continue
+			}
+			srcPage := pages[index]
+			xform, has := xforms[srcPage]
+			if !has {
+				xform, err = model.NewXObjectFormFromPage(srcPage)
+				if err != nil {
+					return err
+				}
+				xforms[srcPage] = xform
+			}
+
+			row, col := cell/imp.columns, cell%imp.columns
+			cellRect := model.PdfRectangle{
+				Llx: imp.margins.left + float64(col)*(cellWidth+imp.columnGutter),
+				Ury: sheetHeight - imp.margins.top - float64(row)*(cellHeight+imp.rowGutter),
+			}
+			cellRect.Urx = cellRect.Llx + cellWidth
+			cellRect.Lly = cellRect.Ury - cellHeight
+
+			rect, err := c.placePage(srcPage, xform, fmt.Sprintf("Page%d", index+1), imp, cellRect, col, sheet.num)
+			if err != nil {
+				return err
+			}
+			placed = append(placed, rect)
+		}
+
+		if imp.cropMarkLength > 0 {
+			err := c.drawCropMarks(placed, imp, sheetHeight)
+			if err != nil {
+				return err
+			}
+		}
+		if imp.sheetRotation != 0 {
+			err := c.RotateDeg(imp.sheetRotation)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// impositionSheet is a side of a sheet with the indices of the pages in its cells (-1 for blank
+// cells), and the number of the sheet from the outside for booklets.
+type impositionSheet struct {
+	cells []int
+	num   int
+}
+
+// sheets returns the sheet sides for placing `pages`.
+func (imp *Imposition) sheets(pages []*model.PdfPage) []impositionSheet {
+	sheets := []impositionSheet{}
+	if imp.order == ImpositionOrderBooklet {
+		n := (len(pages) + 3) / 4 * 4
+		index := func(i int) int {
+			if i >= len(pages) {
+				return -1
+			}
+			return i
+		}
+		for s := 0; s < n/4; s++ {
+			front := impositionSheet{cells: []int{index(n - 1 - 2*s), index(2 * s)}, num: s}
+			back := impositionSheet{cells: []int{index(2*s + 1), index(n - 2 - 2*s)}, num: s}
+			sheets = append(sheets, front, back)
+		}
+		return sheets
+	}
+
+	perSheet := imp.rows * imp.columns
+	for first := 0; first < len(pages); first += perSheet {
+		sheet := impositionSheet{cells: make([]int, perSheet)}
+		for cell := range sheet.cells {
+			i := cell
+			if imp.order == ImpositionOrderColumns {
+				// Cells are numbered in row order.
+				row, col := cell/imp.columns, cell%imp.columns
+				i = col*imp.rows + row
+			}
+			sheet.cells[cell] = -1
+			if first+i < len(pages) {
+				sheet.cells[cell] = first + i
+			}
+		}
+		sheets = append(sheets, sheet)
+	}
+	return sheets
+}
+
+// placePage draws `xform` of `page` scaled to fit `cellRect` on the current sheet.  `col` is the
+// column of the cell and `sheetNum` the number of the sheet from the outside, which are used for
+// booklet alignment and creep.  Returns the rectangle covered by the page.
+func (c *Creator) placePage(page *model.PdfPage, xform *model.XObjectForm, name string, imp *Imposition,
+	cellRect model.PdfRectangle, col int, sheetNum int) (model.PdfRectangle, error) {
+	block, err := newBlockFromXObjectForm(xform, core.PdfObjectName(name))
+	if err != nil {
+		return model.PdfRectangle{}, err
+	}
+
+	// The page rotation is clockwise, the block rotation counterclockwise.
+	angle := int64(0)
+	if page.Rotate != nil {
+		angle = -*page.Rotate
+	}
+	angle = ((angle % 360) + 360) % 360
+	width, height := block.Width(), block.Height()
+	if angle == 90 || angle == 270 {
+		width, height = height, width
+	}
+	cellWidth, cellHeight := cellRect.Urx-cellRect.Llx, cellRect.Ury-cellRect.Lly
+	if imp.autoRotate && width != height && cellWidth != cellHeight && (width > height) != (cellWidth > cellHeight) {
+		angle = (angle + 90) % 360
+		width, height = height, width
+	}
+
+	scale := cellWidth / width
+	if s := cellHeight / height; s < scale {
+		scale = s
+	}
+	block.Scale(scale, scale)
+	block.SetAngle(float64(angle))
+	width *= scale
+	height *= scale
+
+	// Position of the lower left corner of the page on the sheet.
+	x := cellRect.Llx + (cellWidth-width)/2
+	y := cellRect.Lly + (cellHeight-height)/2
+	if imp.order == ImpositionOrderBooklet {
+		shift := float64(sheetNum) * imp.creep
+		if col == 0 {
+			x = cellRect.Urx - width + shift
+		} else {
+			x = cellRect.Llx - shift
+		}
+	}
+
+	// Offset of the lower left corner of the rotated block from the unrotated block, with the
+	// rotation about the upper left corner.
+	dx, dy := 0.0, 0.0
+	bw, bh := block.Width(), block.Height()
+	switch angle {
+	case 90:
+		dy = bh
+	case 180:
+		dx, dy = -bw, bh
+	case 270:
+		dx, dy = -bh, bh-bw
+	}
+	block.SetPos(x-dx, c.context.PageHeight-bh-(y-dy))
+
+	err = c.Draw(block)
+	if err != nil {
+		return model.PdfRectangle{}, err
+	}
+	return model.PdfRectangle{Llx: x, Lly: y, Urx: x + width, Ury: y + height}, nil
+}
+
+// drawCropMarks draws crop marks at the corners of the `placed` pages on the current sheet.
+func (c *Creator) drawCropMarks(placed []model.PdfRectangle, imp *Imposition, sheetHeight float64) error {
+	off, length := imp.cropMarkOffset, imp.cropMarkLength
+
+	// overlaps returns true if the interval [a1,a2] overlaps [b1,b2].  Intervals of zero length
+	// overlap if within [b1,b2], such that marks along the edges of other pages overlap them.
+	overlaps := func(a1, a2, b1, b2 float64) bool {
+		if a1 > a2 {
+			a1, a2 = a2, a1
+		}
+		if a1 == a2 {
+			return a1 >= b1 && a1 <= b2
+		}
+		return a2 > b1 && a1 < b2
+	}
+	// overlapsPage returns true if the line from (x1,y1) to (x2,y2) overlaps a placed page.
+	overlapsPage := func(x1, y1, x2, y2 float64) bool {
+		for _, r := range placed {
+			if overlaps(x1, x2, r.Llx, r.Urx) && overlaps(y1, y2, r.Lly, r.Ury) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, r := range placed {
+		corners := []struct{ x, y, dirX, dirY float64 }{
+			{r.Llx, r.Lly, -1, -1},
+			{r.Urx, r.Lly, 1, -1},
+			{r.Llx, r.Ury, -1, 1},
+			{r.Urx, r.Ury, 1, 1},
+		}
+		for _, corner := range corners {
+			// Horizontal mark along the corner's edge and vertical mark along the other edge.
+			marks := [][4]float64{
+				{corner.x + corner.dirX*off, corner.y, corner.x + corner.dirX*(off+length), corner.y},
+				{corner.x, corner.y + corner.dirY*off, corner.x, corner.y + corner.dirY*(off+length)},
+			}
+			for _, m := range marks {
+				if overlapsPage(m[0], m[1], m[2], m[3]) {
+					continue
+				}
+				line := NewLine(m[0], sheetHeight-m[1], m[2], sheetHeight-m[3])
+				line.SetLineWidth(imp.cropMarkWidth)
+				err := c.Draw(line)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// newBlockFromXObjectForm creates a Block that draws `xform` with the name `name`.  The Block has
+// the size of the form bounding box.
+func newBlockFromXObjectForm(xform *model.XObjectForm, name core.PdfObjectName) (*Block, error) {
+	bboxArr, ok := core.GetArray(xform.BBox)
+	if !ok {
+		return nil, errors.New("Invalid form bounding box")
+	}
+	bbox, err := model.NewPdfRectangle(*bboxArr)
+	if err != nil {
+		return nil, err
+	}
+
+	b := NewBlock(bbox.Urx-bbox.Llx, bbox.Ury-bbox.Lly)
+	err = b.resources.SetXObjectFormByName(name, xform)
+	if err != nil {
+		return nil, err
+	}
+	ops := contentstream.NewContentCreator().
+		Add_q().
+		Translate(-bbox.Llx, -bbox.Lly).
+		Add_Do(name).
+		Add_Q().
+		Operations()
+	b.contents = ops
+	return b, nil
+}
@@ -0,0 +1,177 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package creator
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// makeImpositionPages returns `n` A5 pages, each with a filled rectangle.
+func makeImpositionPages(t *testing.T, n int) []*model.PdfPage {
+	pages := []*model.PdfPage{}
+	for i := 0; i < n; i++ {
+		page := model.NewPdfPage()
+		page.MediaBox = &model.PdfRectangle{Llx: 0, Lly: 0, Urx: PageSizeA5[0], Ury: PageSizeA5[1]}
+		page.Resources = model.NewPdfPageResources()
+		err := page.SetContentStreams([]string{fmt.Sprintf("%.1f g 50 50 100 100 re f", float64(i)/float64(n))}, nil)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// sheetPlacements returns the source page numbers of the XObjects drawn on `page` in order, with the
+// translation of the current transformation matrix when drawing them, and the number of lines.
+func sheetPlacements(t *testing.T, page *model.PdfPage) ([]int, [][2]float64, int) {
+	content, err := page.GetAllContentStreams()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	ops, err := contentstream.NewContentStreamParser(content).Parse()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	nums := []int{}
+	offsets := [][2]float64{}
+	lines := 0
+	processor := contentstream.NewContentStreamProcessor(*ops)
+	processor.AddHandler(contentstream.HandlerConditionEnumAllOperands, "",
+		func(op *contentstream.ContentStreamOperation, gs contentstream.GraphicsState, resources *model.PdfPageResources) error {
+			switch op.Operand {
+			case "Do":
+				name, _ := core.GetNameVal(op.Params[0])
+				num := 0
+				fmt.Sscanf(name, "Page%d", &num)
+				nums = append(nums, num)
+				tx, ty := gs.CTM.Translation()
+				offsets = append(offsets, [2]float64{tx, ty})
+			case "f":
+				// Lines are drawn as filled paths.
+				lines++
+			}
+			return nil
+		})
+	err = processor.Process(page.Resources)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return nums, offsets, lines
+}
+
+// near returns true if the offsets `a` and `b` are equal up to the precision of the content streams.
+func near(a, b [2]float64) bool {
+	return math.Abs(a[0]-b[0]) < 1e-3 && math.Abs(a[1]-b[1]) < 1e-3
+}
+
+// Test the page order, creep and crop marks of a booklet.
+func TestImposeBooklet(t *testing.T) {
+	pages := makeImpositionPages(t, 6)
+	imp := NewBookletImposition(PageSize{2 * PageSizeA5[0], PageSizeA5[1]})
+	imp.SetCreep(2)
+	imp.SetCropMarks(10, 3)
+
+	c := New()
+	err := c.Impose(pages, imp)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(c.pages) != 4 {
+		t.Fatalf("Got %d sheet sides, expected 4", len(c.pages))
+	}
+
+	// 6 pages are padded to 8: pages 7 and 8 are blank.  Pages on the second sheet are shifted
+	// toward the fold by the creep, and the horizontal marks at the fold are not drawn.
+	w := PageSizeA5[0]
+	expected := []struct {
+		nums    []int
+		offsets [][2]float64
+		lines   int
+	}{
+		{[]int{1}, [][2]float64{{w, 0}}, 8},
+		{[]int{2}, [][2]float64{{0, 0}}, 8},
+		{[]int{6, 3}, [][2]float64{{2, 0}, {w - 2, 0}}, 12},
+		{[]int{4, 5}, [][2]float64{{2, 0}, {w - 2, 0}}, 12},
+	}
+	for i, exp := range expected {
+		nums, offsets, lines := sheetPlacements(t, c.pages[i])
+		if fmt.Sprint(nums) != fmt.Sprint(exp.nums) || len(offsets) != len(exp.offsets) || lines != exp.lines {
+			t.Errorf("Sheet side %d: got pages %v with %d lines, expected %v with %d lines", i+1, nums,
+				lines, exp.nums, exp.lines)
+			continue
+		}
+		for j := range offsets {
+			if !near(offsets[j], exp.offsets[j]) {
+				t.Errorf("Sheet side %d: got offsets %v, expected %v", i+1, offsets, exp.offsets)
+			}
+		}
+	}
+
+	err = c.WriteToFile("/tmp/imposition_booklet.pdf")
+	if err != nil {
+		t.Errorf("Fail: %v\n", err)
+	}
+}
+
+// Test 2x2 imposition in column order with rotated source pages and sheet rotation.
+func TestImposeGrid(t *testing.T) {
+	pages := makeImpositionPages(t, 5)
+	rotate := int64(90)
+	pages[1].Rotate = &rotate
+	pages = append(pages, pages[0])
+
+	sheetSize := PageSize{2 * PageSizeA5[0], 2 * PageSizeA5[1]}
+	imp := NewImposition(sheetSize, 2, 2)
+	imp.SetOrder(ImpositionOrderColumns)
+	imp.SetSheetRotation(90)
+
+	c := New()
+	err := c.Impose(pages, imp)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(c.pages) != 2 {
+		t.Fatalf("Got %d sheets, expected 2", len(c.pages))
+	}
+	if c.pages[0].Rotate == nil || *c.pages[0].Rotate != 90 {
+		t.Errorf("Sheet not rotated")
+	}
+
+	nums, offsets, _ := sheetPlacements(t, c.pages[0])
+	if fmt.Sprint(nums) != "[1 3 2 4]" {
+		t.Errorf("Got pages %v", nums)
+	}
+	// Page 1 fills the top left cell.  Page 2 is rotated clockwise and scaled to the cell width,
+	// with its top left corner (the origin of the rotated page) at the top of the cell.
+	w, h := PageSizeA5[0], PageSizeA5[1]
+	scale := w / h
+	if !near(offsets[0], [2]float64{0, h}) {
+		t.Errorf("Incorrect page 1 offset %v", offsets[0])
+	}
+	top := h - (h-w*scale)/2
+	if !near(offsets[2], [2]float64{0, top}) {
+		t.Errorf("Incorrect page 2 offset %v, expected (0, %v)", offsets[2], top)
+	}
+
+	// The duplicated page uses the same XObject.
+	nums, _, _ = sheetPlacements(t, c.pages[1])
+	if fmt.Sprint(nums) != "[5 6]" {
+		t.Errorf("Got pages %v", nums)
+	}
+	xobj1, _ := c.pages[0].Resources.GetXObjectByName("Page1")
+	xobj6, _ := c.pages[1].Resources.GetXObjectByName("Page6")
+	if xobj1 == nil || xobj1 != xobj6 {
+		t.Errorf("Duplicated page XObject not shared")
+	}
+}
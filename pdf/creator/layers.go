@@ -0,0 +1,111 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package creator
+
+import (
+	"fmt"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Layer is an optional content group of the document.  Contents drawn in a layer can be shown or
+// hidden by viewers, and are listed in the layer panel in the order the layers are created.
+type Layer struct {
+	ocg     *model.PdfOptionalContentGroup
+	visible bool
+}
+
+// NewLayer creates a new layer named `name`, visible by default.
+func (c *Creator) NewLayer(name string) *Layer {
+	layer := &Layer{ocg: model.NewPdfOptionalContentGroup(name), visible: true}
+	c.layers = append(c.layers, layer)
+	return layer
+}
+
+// Name returns the name of the layer.
+func (l *Layer) Name() string {
+	return l.ocg.Name
+}
+
+// SetVisible sets whether the layer is initially visible when the document is opened.
+func (l *Layer) SetVisible(visible bool) {
+	l.visible = visible
+}
+
+// Wrap returns a drawable drawing `d` in the layer.
+func (l *Layer) Wrap(d Drawable) Drawable {
+	return &layerDrawable{layer: l, drawable: d}
+}
+
+// DrawInLayer draws `d` in the layer `layer`.
+func (c *Creator) DrawInLayer(d Drawable, layer *Layer) error {
+	return c.Draw(layer.Wrap(d))
+}
+
+// layerDrawable is a drawable whose contents are drawn in a layer.
+type layerDrawable struct {
+	layer    *Layer
+	drawable Drawable
+}
+
+// GeneratePageBlocks generates the blocks of the wrapped drawable, with their contents enclosed in
+// optional content marked-content sequences of the layer.
+func (ld *layerDrawable) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext, error) {
+	blocks, ctx, err := ld.drawable.GeneratePageBlocks(ctx)
+	if err != nil {
+		return nil, ctx, err
+	}
+	for _, blk := range blocks {
+		if len(*blk.contents) == 0 {
+			continue
+		}
+		if err := ld.layer.markBlock(blk); err != nil {
+			return nil, ctx, err
+		}
+	}
+	return blocks, ctx, nil
+}
+
+// markBlock encloses the contents of `blk` in a marked-content sequence /OC /name BDC ... EMC,
+// where name refers to the layer group in the Properties resources of the block.
+func (l *Layer) markBlock(blk *Block) error {
+	group := l.ocg.GetContainingPdfObject()
+	var name core.PdfObjectName
+	for i := 0; ; i++ {
+		name = core.PdfObjectName(fmt.Sprintf("OC%d", i))
+		obj, found := blk.resources.GetPropertiesByName(name)
+		if !found || obj == group {
+			break
+		}
+	}
+	if err := blk.resources.SetPropertiesByName(name, group); err != nil {
+		return err
+	}
+
+	blk.contents.WrapIfNeeded()
+	marked := contentstream.ContentStreamOperations{
+		{Operand: "BDC", Params: []core.PdfObject{core.MakeName("OC"), core.MakeName(string(name))}},
+	}
+	marked = append(marked, *blk.contents...)
+	marked = append(marked, &contentstream.ContentStreamOperation{Operand: "EMC"})
+	blk.contents = &marked
+	return nil
+}
+
+// newOCProperties returns the optional content properties of the layers, with the hidden layers
+// turned off.
+func (c *Creator) newOCProperties() *model.PdfOCProperties {
+	props := model.NewPdfOCProperties()
+	for _, layer := range c.layers {
+		props.AddGroup(layer.ocg)
+		if !layer.visible {
+			props.D.OFF = append(props.D.OFF, layer.ocg)
+		}
+	}
+	return props
+}
@@ -0,0 +1,85 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package creator
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Test drawing in layers, with a hidden layer, and removing the hidden layer content.
+func TestLayers(t *testing.T) {
+	c := New()
+	background := c.NewLayer("Background")
+	background.SetVisible(false)
+	text := c.NewLayer("Text")
+
+	rect := NewRectangle(10, 10, 100, 50)
+	rect.SetFillColor(ColorRGBFromHex("#dddddd"))
+	if err := c.DrawInLayer(rect, background); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := c.DrawInLayer(NewParagraph("Hiddenword"), background); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := c.Draw(text.Wrap(NewParagraph("Shownword"))); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := c.WriteToFile("/tmp/layers.pdf"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	f, err := os.Open("/tmp/layers.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer f.Close()
+	reader, err := model.NewPdfReader(f)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	props, err := reader.GetOptionalContent()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if props == nil || len(props.OCGs) != 2 || len(props.D.Order) != 2 || props.D.Order[1].Group.Name != "Text" {
+		t.Fatalf("Invalid optional content properties %+v", props)
+	}
+	if len(props.D.OFF) != 1 || props.D.OFF[0].Name != "Background" {
+		t.Errorf("Background layer not turned off: %+v", props.D.OFF)
+	}
+
+	page, err := reader.GetPage(1)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	content, err := page.GetAllContentStreams()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if strings.Count(content, "/OC /OC0 BDC") != 2 || strings.Count(content, "/OC /OC") != 3 {
+		t.Errorf("Invalid layer marks in %q", content)
+	}
+	oc0, _ := page.Resources.GetPropertiesByName("OC0")
+	if props.LoadGroup(oc0) != props.GetGroupByName("Background") {
+		t.Errorf("OC0 not the background layer")
+	}
+
+	if err := contentstream.RemoveHiddenOptionalContent(page, props.NewState()); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	content, err = page.GetAllContentStreams()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if strings.Contains(content, "Hiddenword") || !strings.Contains(content, "Shownword") {
+		t.Errorf("Invalid filtered content %q", content)
+	}
+}
@@ -86,5 +86,6 @@ func (l *Line) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext, error
 		return nil, ctx, err
 	}
 
+	block.markContents(nil)
 	return []*Block{block}, ctx, nil
 }
@@ -0,0 +1,59 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package creator
+
+import (
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// outlineAnchor is the position of a chapter or subchapter heading in block contents.  The outline
+// item goes to this position and is added to the document outlines when the block is drawn on a
+// page.
+type outlineAnchor struct {
+	item   *model.PdfOutlineItem
+	parent *model.PdfOutlineItem // Nil for top level items.
+	x, y   float64               // Position in the drawing context (origin at the top left).
+}
+
+// GetOutlines returns the document outlines, with the items of the chapters and subchapters drawn
+// so far.  The outlines can be edited, e.g. to add items or change their style, before writing.
+func (c *Creator) GetOutlines() *model.PdfOutline {
+	return c.outlines
+}
+
+// offsetOutlineAnchors returns copies of `anchors` moved by (`dx`, `dy`).
+func offsetOutlineAnchors(anchors []*outlineAnchor, dx, dy float64) []*outlineAnchor {
+	moved := []*outlineAnchor{}
+	for _, a := range anchors {
+		moved = append(moved, &outlineAnchor{item: a.item, parent: a.parent, x: a.x + dx, y: a.y + dy})
+	}
+	return moved
+}
+
+// placeOutlineAnchors sets the destinations of the outline items of `anchors` to their positions
+// on `page`, and adds the items to the document outlines.  Items of subchapters are added to the
+// items of their chapters, or at the top level if the chapter has no outline item.
+func (c *Creator) placeOutlineAnchors(anchors []*outlineAnchor, page *model.PdfPage) error {
+	for _, a := range anchors {
+		d := &creatorDestination{page: page, x: a.x, y: a.y}
+		dest, err := d.toPdfDestination()
+		if err != nil {
+			return err
+		}
+		a.item.SetDestination(dest)
+
+		parent := &c.outlines.PdfOutlineTreeNode
+		if a.parent != nil && a.parent.Parent != nil {
+			parent = &a.parent.PdfOutlineTreeNode
+		}
+		if err := parent.AddChild(a.item); err != nil {
+			common.Log.Debug("Unable to add outline item %q: %v", a.item.GetTitle(), err)
+			return err
+		}
+	}
+	return nil
+}
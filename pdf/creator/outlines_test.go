@@ -0,0 +1,88 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package creator
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Test the outlines generated from chapters and subchapters.
+func TestChapterOutlines(t *testing.T) {
+	c := New()
+	c.CreateFrontPage(func(args FrontpageFunctionArgs) {
+		c.Draw(NewParagraph("Front page"))
+	})
+	c.CreateTableOfContents(func(toc *TableOfContents) (*Chapter, error) {
+		ch := c.NewChapter("Contents")
+		for _, entry := range toc.Entries() {
+			ch.Add(NewParagraph(entry.Title))
+		}
+		return ch, nil
+	})
+
+	intro := c.NewChapter("Introduction")
+	intro.Add(NewParagraph("Introduction text."))
+	details := c.NewSubchapter(intro, "Details")
+	details.Add(NewParagraph("Details text."))
+	hidden := c.NewSubchapter(intro, "Hidden")
+	hidden.SetIncludeInOutline(false)
+	if err := c.Draw(intro); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	c.NewPage()
+	end := c.NewChapter("End")
+	end.GetOutlineItem().SetFlags(model.OutlineItemBold)
+	if err := c.Draw(end); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := c.WriteToFile("/tmp/chapter_outlines.pdf"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	f, err := os.Open("/tmp/chapter_outlines.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer f.Close()
+	reader, err := model.NewPdfReader(f)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	titles := []string{}
+	pages := []int{}
+	err = reader.GetOutlines().Walk(func(item *model.PdfOutlineItem, depth int) error {
+		titles = append(titles, strings.Repeat("-", depth)+item.GetTitle())
+		dest, err := item.GetDestination()
+		if err != nil || dest == nil {
+			t.Fatalf("Invalid destination of %s (%v)", item.GetTitle(), err)
+		}
+		for i, page := range reader.PageList {
+			if page.GetPageAsIndirectObject() == dest.Page {
+				pages = append(pages, i+1)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if s := strings.Join(titles, " "); s != "1. Introduction -1.1 Details 2. End" {
+		t.Errorf("Invalid outlines %s", s)
+	}
+	// Front page, table of contents, then the chapters.
+	if len(pages) != 3 || pages[0] != 3 || pages[1] != 3 || pages[2] != 4 {
+		t.Errorf("Invalid destination pages %v", pages)
+	}
+	items := reader.GetOutlines().Children()
+	if len(items) == 2 && items[1].GetFlags() != model.OutlineItemBold {
+		t.Errorf("Chapter style not kept")
+	}
+}
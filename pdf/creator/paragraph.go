@@ -65,6 +65,9 @@ type Paragraph struct {
 
 	// Text lines after wrapping to available width.
 	textLines []string
+
+	// Structure type of tagged documents.
+	structType string
 }
 
 // NewParagraph create a new text paragraph. Uses default parameters: Helvetica, WinAnsiEncoding and wrap enabled
@@ -88,6 +91,7 @@ func NewParagraph(text string) *Paragraph {
 	p.scaleY = 1
 
 	p.positioning = positionRelative
+	p.structType = "P"
 
 	return p
 }
@@ -127,6 +131,12 @@ func (p *Paragraph) Text() string {
 	return p.text
 }
 
+// SetStructureType sets the structure type of the Paragraph in tagged documents (P default), e.g. H1
+// for a heading.
+func (p *Paragraph) SetStructureType(structType string) {
+	p.structType = structType
+}
+
 // SetEnableWrap sets the line wrapping enabled flag.
 func (p *Paragraph) SetEnableWrap(enableWrap bool) {
 	p.enableWrap = enableWrap
@@ -508,9 +518,7 @@ func drawParagraphOnBlock(blk *Block, p *Paragraph, ctx DrawContext) (DrawContex
 	cc.Add_Q()
 
 	ops := cc.Operations()
-	ops.WrapIfNeeded()
-
-	blk.addContents(ops)
+	blk.addMarkedContents(ops, model.NewPdfStructElement(p.structType))
 
 	if p.positioning.isRelative() {
 		ctx.Y += p.Height() + p.margins.bottom
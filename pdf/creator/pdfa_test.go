@@ -0,0 +1,98 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package creator
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+	"github.com/unidoc/unidoc/pdf/model/pdfafonts"
+)
+
+// Test PDF/A-1b output with the standard fonts replaced by the Liberation and by custom embedded
+// fonts.
+func TestPdfAOutput(t *testing.T) {
+	newDocument := func() *Creator {
+		c := New()
+		c.SetPdfAConformance(model.PdfA1B)
+		ch := c.NewChapter("Archive")
+		ch.Add(NewParagraph("Archived text."))
+		if err := c.Draw(ch); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		return c
+	}
+
+	// The standard fonts are not embedded.
+	c := newDocument()
+	if err := c.WriteToFile("/tmp/pdfa_default_fonts.pdf"); err == nil || !strings.Contains(err.Error(), "not embedded") {
+		t.Errorf("Expected unembedded font error, got %v", err)
+	}
+
+	// Helvetica is replaced by its metric compatible Liberation substitute.
+	c = newDocument()
+	if err := pdfafonts.SetSubstitutes(c); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := c.WriteToFile("/tmp/pdfa_default_fonts.pdf"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f, err := os.Open("/tmp/pdfa_default_fonts.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer f.Close()
+	reader, err := model.NewPdfReader(f)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	fontDict, ok := core.GetDict(reader.PageList[0].Resources.Font)
+	if !ok {
+		t.Fatalf("Page fonts missing")
+	}
+	fontNames := []string{}
+	for _, name := range fontDict.Keys() {
+		fontObj, _ := reader.PageList[0].Resources.GetFontByName(name)
+		font, err := model.NewPdfFontFromPdfObject(fontObj)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		fontNames = append(fontNames, font.BaseFont())
+	}
+	if s := strings.Join(fontNames, " "); !strings.Contains(s, "LiberationSans") || strings.Contains(s, "Helvetica") {
+		t.Errorf("Fonts not substituted: %s", s)
+	}
+
+	font, err := model.NewPdfFontFromTTFFile("./testdata/FreeSans.ttf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	c = newDocument()
+	c.SetFontSubstitute("Helvetica", font)
+	if err := c.WriteToFile("/tmp/pdfa1b.pdf"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	f, err = os.Open("/tmp/pdfa1b.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer f.Close()
+	reader, err = model.NewPdfReader(f)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if version := reader.PdfVersion(); version != "1.4" {
+		t.Errorf("Version %s", version)
+	}
+	intents, err := reader.GetOutputIntents()
+	if err != nil || len(intents) != 1 {
+		t.Errorf("Output intents not read (%v)", err)
+	}
+}
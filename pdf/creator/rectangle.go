@@ -89,5 +89,6 @@ func (rect *Rectangle) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContex
 		return nil, ctx, err
 	}
 
+	block.markContents(nil)
 	return []*Block{block}, ctx, nil
 }
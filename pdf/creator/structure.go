@@ -0,0 +1,245 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package creator
+
+import (
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// structMark is a marked-content sequence of block contents, identified by its property list.
+// The marked-content identifier is assigned when the block is drawn on a page.
+type structMark struct {
+	props *core.PdfObjectDictionary
+	elem  *model.PdfStructElement // Nil for artifacts.
+}
+
+// SetTagged sets whether the creator produces a tagged document, with a structure tree describing
+// the logical structure of the drawn contents.  Paragraphs are tagged as P (chapter headings as
+// H1 and H2), chapters as Sect, tables as Table with TR and TD elements, images as Figure and the
+// generated table of contents as TOC with TOCI elements.  Lines, shapes, cell borders, headers and
+// footers are marked as artifacts.
+func (c *Creator) SetTagged(tagged bool) {
+	c.tagged = tagged
+}
+
+// SetLanguage sets the natural language of the document, e.g. en-US.
+func (c *Creator) SetLanguage(lang string) {
+	c.lang = lang
+}
+
+// markOperations returns `ops` enclosed in a marked-content sequence of the structure element
+// `elem`, or of an artifact if `elem` is nil, and records the sequence in the block.
+func (blk *Block) markOperations(ops *contentstream.ContentStreamOperations, elem *model.PdfStructElement) *contentstream.ContentStreamOperations {
+	tag := "Artifact"
+	if elem != nil {
+		tag = elem.Type
+	}
+	props := core.MakeDict()
+	blk.marks = append(blk.marks, &structMark{props: props, elem: elem})
+
+	marked := contentstream.ContentStreamOperations{
+		{Operand: "BDC", Params: []core.PdfObject{core.MakeName(tag), props}},
+	}
+	marked = append(marked, *ops...)
+	marked = append(marked, &contentstream.ContentStreamOperation{Operand: "EMC"})
+	return &marked
+}
+
+// addMarkedContents adds `ops` to the block contents as a marked-content sequence of the
+// structure element `elem`, or of an artifact if `elem` is nil.
+func (blk *Block) addMarkedContents(ops *contentstream.ContentStreamOperations, elem *model.PdfStructElement) {
+	ops.WrapIfNeeded()
+	blk.addContents(blk.markOperations(ops, elem))
+}
+
+// markContents encloses all the block contents in a marked-content sequence of the structure
+// element `elem`, or of an artifact if `elem` is nil.
+func (blk *Block) markContents(elem *model.PdfStructElement) {
+	if len(*blk.contents) == 0 {
+		return
+	}
+	blk.contents.WrapIfNeeded()
+	blk.contents = blk.markOperations(blk.contents, elem)
+}
+
+// markArtifact replaces the marked-content sequences of the block by a single artifact sequence
+// of `artifactType` (e.g. Pagination) and `subtype` (e.g. Header).
+func (blk *Block) markArtifact(artifactType, subtype string) {
+	ops := stripMarks(blk.contents, blk.marks)
+	blk.contents = &ops
+	blk.marks = nil
+	if len(ops) == 0 {
+		return
+	}
+	blk.markContents(nil)
+	props := blk.marks[0].props
+	props.Set("Type", core.MakeName(artifactType))
+	props.Set("Subtype", core.MakeName(subtype))
+}
+
+// stripMarks returns `ops` without the marked-content operators of the sequences `marks`.
+func stripMarks(ops *contentstream.ContentStreamOperations, marks []*structMark) contentstream.ContentStreamOperations {
+	isMark := map[*core.PdfObjectDictionary]bool{}
+	for _, m := range marks {
+		isMark[m.props] = true
+	}
+
+	stripped := contentstream.ContentStreamOperations{}
+	stack := []bool{}
+	for _, op := range *ops {
+		switch op.Operand {
+		case "BMC", "BDC":
+			marked := false
+			if op.Operand == "BDC" && len(op.Params) == 2 {
+				props, ok := op.Params[1].(*core.PdfObjectDictionary)
+				marked = ok && isMark[props]
+			}
+			stack = append(stack, marked)
+			if marked {
+				continue
+			}
+		case "EMC":
+			if len(stack) > 0 {
+				marked := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if marked {
+					continue
+				}
+			}
+		}
+		stripped = append(stripped, op)
+	}
+	return stripped
+}
+
+// tagBlock returns the block `blk` prepared to be drawn on `page`.  For tagged documents, the
+// marked-content sequences of structure elements are assigned the next marked-content identifiers
+// of the page, and the top level structure elements are added to the document structure.
+// Otherwise the marked-content sequences are removed.
+func (c *Creator) tagBlock(blk *Block, page *model.PdfPage) *Block {
+	dup := blk.duplicate()
+	if !c.tagged {
+		ops := stripMarks(blk.contents, blk.marks)
+		dup.contents = &ops
+		dup.marks = nil
+		return dup
+	}
+
+	elems := map[*core.PdfObjectDictionary]*model.PdfStructElement{}
+	for _, m := range blk.marks {
+		if m.elem != nil {
+			elems[m.props] = m.elem
+		}
+	}
+
+	// The operations are copied with new property lists, as the block may be drawn several times.
+	ops := contentstream.ContentStreamOperations{}
+	for _, op := range *blk.contents {
+		if op.Operand == "BDC" && len(op.Params) == 2 {
+			props, _ := op.Params[1].(*core.PdfObjectDictionary)
+			if elem, has := elems[props]; has {
+				mcid := c.mcids[page]
+				c.mcids[page]++
+				elem.AddMarkedContent(page.GetPageAsIndirectObject(), mcid)
+				c.addStructRoot(elem)
+
+				props := core.MakeDict()
+				props.Set("MCID", core.MakeInteger(int64(mcid)))
+				op = &contentstream.ContentStreamOperation{Operand: "BDC", Params: []core.PdfObject{op.Params[0], props}}
+			}
+		}
+		ops = append(ops, op)
+	}
+	dup.contents = &ops
+	dup.marks = nil
+	return dup
+}
+
+// addStructRoot adds the top level structure element of `elem` to the document structure, unless
+// already added.
+func (c *Creator) addStructRoot(elem *model.PdfStructElement) {
+	top := topStructElement(elem)
+	for _, root := range c.structRoots {
+		if root == top {
+			return
+		}
+	}
+	c.structRoots = append(c.structRoots, top)
+}
+
+// moveStructRoots moves the document structure elements from index `start` to index `pos`, e.g.
+// to place the elements of the generated front page and table of contents first.
+func (c *Creator) moveStructRoots(start, pos int) {
+	moved := append([]*model.PdfStructElement{}, c.structRoots[start:]...)
+	roots := append([]*model.PdfStructElement{}, c.structRoots[:pos]...)
+	roots = append(roots, moved...)
+	c.structRoots = append(roots, c.structRoots[pos:start]...)
+}
+
+// newStructTreeRoot returns the structure tree root of the document, with a Document element
+// holding the top level elements of the drawn contents.
+func (c *Creator) newStructTreeRoot() *model.PdfStructTreeRoot {
+	doc := model.NewPdfStructElement("Document")
+	doc.Lang = c.lang
+	for _, elem := range c.structRoots {
+		doc.AddKid(elem)
+	}
+	root := model.NewPdfStructTreeRoot()
+	root.AddKid(doc)
+	return root
+}
+
+// topStructElement returns the top level ancestor of `elem`, or `elem` if it has no parent.
+func topStructElement(elem *model.PdfStructElement) *model.PdfStructElement {
+	for elem.Parent != nil {
+		elem = elem.Parent
+	}
+	return elem
+}
+
+// adoptStructElements adds the top level structure elements of the marked-content sequences
+// `marks` to the kids of `parent`, unless they are already in the tree of `parent`.
+func adoptStructElements(parent *model.PdfStructElement, marks []*structMark) {
+	for _, m := range marks {
+		if m.elem == nil {
+			continue
+		}
+		top := topStructElement(m.elem)
+		if top != topStructElement(parent) {
+			parent.AddKid(top)
+		}
+	}
+}
+
+// retagTableOfContents converts the tables of the section `sect` of a generated table of contents
+// to TOC elements, with a TOCI element for each row holding the contents of the row cells.
+func retagTableOfContents(sect *model.PdfStructElement) {
+	for _, table := range sect.ChildElements() {
+		if table.Type != "Table" {
+			continue
+		}
+		table.Type = "TOC"
+		for _, row := range table.ChildElements() {
+			row.Type = "TOCI"
+			kids := []*model.PdfStructKid{}
+			for _, kid := range row.Kids {
+				if kid.Element == nil || kid.Element.Type != "TD" {
+					kids = append(kids, kid)
+					continue
+				}
+				for _, cellKid := range kid.Element.Kids {
+					if cellKid.Element != nil {
+						cellKid.Element.Parent = row
+					}
+					kids = append(kids, cellKid)
+				}
+			}
+			row.Kids = kids
+		}
+	}
+}
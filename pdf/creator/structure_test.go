@@ -0,0 +1,175 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package creator
+
+import (
+	"fmt"
+	goimage "image"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// structOutline returns the structure types of the elements of `root` as nested lists, e.g.
+// "Document[Sect[H1 P]]".
+func structOutline(elems []*model.PdfStructElement) string {
+	parts := []string{}
+	for _, elem := range elems {
+		part := elem.Type
+		if kids := elem.ChildElements(); len(kids) > 0 {
+			part += "[" + structOutline(kids) + "]"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// makeTaggedDocument returns a creator with a table of contents, a chapter with a paragraph, a
+// table and an image, and a subchapter.
+func makeTaggedDocument(t *testing.T, tagged bool) *Creator {
+	c := New()
+	c.SetTagged(tagged)
+	c.SetLanguage("en-US")
+
+	c.DrawHeader(func(header *Block, args HeaderFunctionArgs) {
+		p := NewParagraph(fmt.Sprintf("Page %d", args.PageNum))
+		p.SetPos(50, 20)
+		header.Draw(p)
+	})
+	c.CreateTableOfContents(func(toc *TableOfContents) (*Chapter, error) {
+		ch := c.NewChapter("Contents")
+		table := NewTable(2)
+		for _, entry := range toc.Entries() {
+			cell := table.NewCell()
+			cell.SetContent(NewParagraph(entry.Title))
+			cell = table.NewCell()
+			cell.SetContent(NewParagraph(fmt.Sprintf("%d", entry.PageNumber)))
+		}
+		ch.Add(table)
+		return ch, nil
+	})
+
+	ch := c.NewChapter("Introduction")
+	ch.Add(NewParagraph("Some text."))
+
+	table := NewTable(2)
+	for i := 0; i < 4; i++ {
+		cell := table.NewCell()
+		cell.SetBorder(CellBorderSideAll, CellBorderStyleSingle, 1)
+		cell.SetContent(NewParagraph(fmt.Sprintf("Cell %d", i+1)))
+	}
+	ch.Add(table)
+
+	img, err := NewImageFromGoImage(goimage.NewRGBA(goimage.Rect(0, 0, 10, 10)))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	img.SetAltText("A black square")
+	ch.Add(img)
+
+	sub := c.NewSubchapter(ch, "Details")
+	sub.Add(NewParagraph("More text."))
+
+	if err := c.Draw(ch); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return c
+}
+
+// Test the structure tree of a tagged document and the marked content of the pages.
+func TestTaggedDocument(t *testing.T) {
+	c := makeTaggedDocument(t, true)
+	if err := c.WriteToFile("/tmp/tagged.pdf"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	f, err := os.Open("/tmp/tagged.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer f.Close()
+	reader, err := model.NewPdfReader(f)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if tagged, _ := reader.IsTagged(); !tagged {
+		t.Errorf("Document not marked as tagged")
+	}
+	if lang, _ := reader.GetLanguage(); lang != "en-US" {
+		t.Errorf("Language: %q", lang)
+	}
+
+	root, err := reader.GetStructTreeRoot()
+	if err != nil || root == nil {
+		t.Fatalf("Structure tree not read: %v", err)
+	}
+	expected := "Document[Sect[H1 TOC[TOCI[P P] TOCI[P P]]] " +
+		"Sect[H1 P Table[TR[TD[P] TD[P]] TR[TD[P] TD[P]]] Figure Sect[H2 P]]]"
+	if outline := structOutline(root.Kids); outline != expected {
+		t.Errorf("Got structure %s, expected %s", outline, expected)
+	}
+
+	figures := 0
+	root.Walk(func(elem *model.PdfStructElement, depth int) error {
+		if elem.Type == "Figure" {
+			figures++
+			if elem.Alt != "A black square" || depth != 2 {
+				t.Errorf("Figure alternate description %q at depth %d", elem.Alt, depth)
+			}
+		}
+		return nil
+	})
+	if figures != 1 {
+		t.Errorf("Got %d figures", figures)
+	}
+
+	// Each marked-content sequence of the pages maps to a structure element, except for the
+	// header artifacts.
+	for i, page := range reader.PageList {
+		content, err := page.GetAllContentStreams()
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		ops, err := contentstream.NewContentStreamParser(content).Parse()
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		marked := ops.GetMarkedContent(page.Resources)
+		elems := root.GetMarkedContentElements(page.GetPageAsIndirectObject())
+		if len(marked) == 0 || len(marked) != len(elems) {
+			t.Errorf("Page %d: %d marked-content sequences and %d elements", i+1, len(marked), len(elems))
+		}
+		for mcid, mc := range marked {
+			elem := elems[mcid]
+			if elem == nil || string(mc.Tag) != elem.Type {
+				t.Errorf("Page %d: marked content %d %s tagged as %v", i+1, mcid, mc.Tag, elem)
+			}
+		}
+		if !strings.Contains(content, "/Artifact <<") || !strings.Contains(content, "/Header") {
+			t.Errorf("Page %d: header not marked as an artifact", i+1)
+		}
+	}
+}
+
+// Test that untagged documents have no marked content.
+func TestUntaggedDocument(t *testing.T) {
+	c := makeTaggedDocument(t, false)
+	if err := c.finalize(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	for i, page := range c.pages {
+		content, err := page.GetAllContentStreams()
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if strings.Contains(content, "BDC") || strings.Contains(content, "EMC") {
+			t.Errorf("Page %d: marked content in untagged document", i+1)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/model"
 	"github.com/unidoc/unidoc/pdf/model/fonts"
 )
 
@@ -28,6 +29,11 @@ type Subchapter struct {
 	// Include in TOC.
 	includeInTOC bool
 
+	// Outline item, added below the outline item of the chapter when drawn.
+	outlineItem        *model.PdfOutlineItem
+	chapterOutlineItem *model.PdfOutlineItem
+	includeInOutline   bool
+
 	// Positioning: relative / absolute.
 	positioning positioning
 
@@ -57,9 +63,14 @@ func (c *Creator) NewSubchapter(ch *Chapter, title string) *Subchapter {
 
 	p.SetFontSize(14)
 	p.SetFont(fonts.NewFontHelvetica()) // bold?
+	p.SetStructureType("H2")
 
 	subchap.showNumbering = true
 	subchap.includeInTOC = true
+	subchap.outlineItem = model.NewPdfOutlineItem()
+	subchap.outlineItem.SetTitle(heading)
+	subchap.chapterOutlineItem = ch.outlineItem
+	subchap.includeInOutline = true
 
 	subchap.heading = p
 	subchap.contents = []Drawable{}
@@ -78,9 +89,11 @@ func (subchap *Subchapter) SetShowNumbering(show bool) {
 	if show {
 		heading := fmt.Sprintf("%d.%d. %s", subchap.chapterNum, subchap.subchapterNum, subchap.title)
 		subchap.heading.SetText(heading)
+		subchap.outlineItem.SetTitle(heading)
 	} else {
 		heading := fmt.Sprintf("%s", subchap.title)
 		subchap.heading.SetText(heading)
+		subchap.outlineItem.SetTitle(heading)
 	}
 	subchap.showNumbering = show
 }
@@ -90,6 +103,18 @@ func (subchap *Subchapter) SetIncludeInTOC(includeInTOC bool) {
 	subchap.includeInTOC = includeInTOC
 }
 
+// SetIncludeInOutline sets a flag to indicate whether or not to add the subchapter to the document
+// outlines.
+func (subchap *Subchapter) SetIncludeInOutline(includeInOutline bool) {
+	subchap.includeInOutline = includeInOutline
+}
+
+// GetOutlineItem returns the outline item of the subchapter, e.g. to set its style.  The item goes
+// to the subchapter heading.
+func (subchap *Subchapter) GetOutlineItem() *model.PdfOutlineItem {
+	return subchap.outlineItem
+}
+
 // GetHeading returns the Subchapter's heading Paragraph to address style (font type, size, etc).
 func (subchap *Subchapter) GetHeading() *Paragraph {
 	return subchap.heading
@@ -144,12 +169,19 @@ func (subchap *Subchapter) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawCo
 		ctx.Height -= subchap.margins.top
 	}
 
+	headingX, headingY := ctx.X, ctx.Y
 	blocks, ctx, err := subchap.heading.GeneratePageBlocks(ctx)
 	if err != nil {
 		return blocks, ctx, err
 	}
 	if len(blocks) > 1 {
 		ctx.Page++ // did not fit - moved to next Page.
+		headingY = ctx.Margins.top
+	}
+
+	if subchap.includeInOutline {
+		anchor := &outlineAnchor{item: subchap.outlineItem, parent: subchap.chapterOutlineItem, x: headingX, y: headingY}
+		blocks[len(blocks)-1].anchors = append(blocks[len(blocks)-1].anchors, anchor)
 	}
 	if subchap.includeInTOC {
 		// Add to TOC.
@@ -172,6 +204,13 @@ func (subchap *Subchapter) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawCo
 		ctx = c
 	}
 
+	// The heading and contents are tagged as a section.
+	sect := model.NewPdfStructElement("Sect")
+	sect.Title = subchap.title
+	for _, blk := range blocks {
+		adoptStructElements(sect, blk.marks)
+	}
+
 	if subchap.positioning.isRelative() {
 		// Move back X to same start of line.
 		ctx.X = origCtx.X
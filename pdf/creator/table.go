@@ -9,6 +9,7 @@ import (
 	"errors"
 
 	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/core"
 	"github.com/unidoc/unidoc/pdf/model"
 	"github.com/unidoc/unidoc/pdf/contentstream/draw"
 )
@@ -212,6 +213,10 @@ func (table *Table) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext,
 		}
 	}
 
+	// Tagged as a table with a row element for each row.
+	tableElem := model.NewPdfStructElement("Table")
+	rowElems := map[int]*model.PdfStructElement{}
+
 	// Draw cells.
 	// row height, cell height
 	for _, cell := range table.cells {
@@ -298,6 +303,22 @@ func (table *Table) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext,
 			common.Log.Debug("Error: %v\n", err)
 		}
 
+		rowElem, has := rowElems[cell.row]
+		if !has {
+			rowElem = model.NewPdfStructElement("TR")
+			tableElem.AddKid(rowElem)
+			rowElems[cell.row] = rowElem
+		}
+		cellElem := model.NewPdfStructElement("TD")
+		if cell.rowspan > 1 || cell.colspan > 1 {
+			attrs := core.MakeDict()
+			attrs.Set("O", core.MakeName("Table"))
+			attrs.Set("RowSpan", core.MakeInteger(int64(cell.rowspan)))
+			attrs.Set("ColSpan", core.MakeInteger(int64(cell.colspan)))
+			cellElem.Attributes = attrs
+		}
+		rowElem.AddKid(cellElem)
+
 		if cell.content != nil {
 			// Account for horizontal alignment:
 			cw := cell.content.Width() // content width.
@@ -338,10 +359,12 @@ func (table *Table) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext,
 				}
 			}
 
+			numMarks := len(block.marks)
 			err := block.DrawWithContext(cell.content, ctx)
 			if err != nil {
 				common.Log.Debug("Error: %v\n", err)
 			}
+			adoptStructElements(cellElem, block.marks[numMarks:])
 		}
 
 		ctx.Y += h
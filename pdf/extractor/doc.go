@@ -5,6 +5,11 @@
 
 //
 // Package extractor is used for quickly extracting PDF content through a simple interface.
-// Currently offers functionality for extracting textual content.
+// Currently offers functionality for extracting textual content, along with the positions, fonts
+// and rendering of the extracted text spans and glyphs, the layout of the text in reading order
+// and tables, and the images and vector paths drawn by the pages.  The text can be searched, and
+// the hits highlighted with annotations.  Text hidden by its rendering mode, clipping, covering
+// content or background color can be told apart from visible text or excluded.  The layouts of the
+// pages of a document can be exported as hOCR, ALTO XML or JSON.
 //
 package extractor
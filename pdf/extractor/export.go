@@ -0,0 +1,441 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// nativeTextConfidence is the recognition confidence of the words exported, which are native text
+// rather than the result of OCR.
+const nativeTextConfidence = 1.0
+
+// LayoutPage is the layout of a page of a document, as written by the exporters WriteHOCR,
+// WriteALTO and WriteLayoutJSON.
+type LayoutPage struct {
+	PageNum  int // Number of the page, starting from 1.
+	MediaBox model.PdfRectangle
+	Layout   *PageLayout
+}
+
+// ExtractDocumentLayout returns the layouts of the pages of the document of `reader` (see
+// Extractor.ExtractLayout).
+func ExtractDocumentLayout(reader *model.PdfReader) ([]*LayoutPage, error) {
+	numPages, err := reader.GetNumPages()
+	if err != nil {
+		return nil, err
+	}
+	pages := []*LayoutPage{}
+	for pageNum := 1; pageNum <= numPages; pageNum++ {
+		page, err := reader.GetPage(pageNum)
+		if err != nil {
+			return nil, err
+		}
+		mediaBox, err := page.GetMediaBox()
+		if err != nil {
+			return nil, err
+		}
+		e, err := New(page)
+		if err != nil {
+			return nil, err
+		}
+		layout, err := e.ExtractLayout()
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, &LayoutPage{PageNum: pageNum, MediaBox: *mediaBox, Layout: layout})
+	}
+	return pages, nil
+}
+
+// topDownBox returns the corners x0 y0 x1 y1 of `r` in the coordinates of the page used by hOCR
+// and ALTO: in points from the top left corner of the media box, with y pointing down.
+func (p *LayoutPage) topDownBox(r model.PdfRectangle) (x0, y0, x1, y1 float64) {
+	return r.Llx - p.MediaBox.Llx, p.MediaBox.Ury - r.Ury, r.Urx - p.MediaBox.Llx, p.MediaBox.Ury - r.Lly
+}
+
+//
+// hOCR
+//
+
+// hocrDocument is the XHTML document of an hOCR file (hOCR - OCR Workflow and Output embedded in
+// HTML, version 1.2).
+type hocrDocument struct {
+	XMLName   xml.Name       `xml:"html"`
+	Namespace string         `xml:"xmlns,attr"`
+	Lang      string         `xml:"xml:lang,attr"`
+	Title     string         `xml:"head>title"`
+	Meta      []hocrMeta     `xml:"head>meta"`
+	Pages     []*hocrElement `xml:"body>div"`
+}
+
+// hocrMeta is a meta element of the head of an hOCR document.
+type hocrMeta struct {
+	HTTPEquiv string `xml:"http-equiv,attr,omitempty"`
+	Name      string `xml:"name,attr,omitempty"`
+	Content   string `xml:"content,attr"`
+}
+
+// hocrElement is an element of the body of an hOCR document, with its properties in its title.
+type hocrElement struct {
+	XMLName xml.Name
+	Class   string `xml:"class,attr"`
+	ID      string `xml:"id,attr"`
+	Title   string `xml:"title,attr"`
+	Text    string `xml:",chardata"`
+	Kids    []*hocrElement
+}
+
+// hocrBBox returns the hOCR bbox property of `r` on page `p`, rounded outwards to integers.
+func (p *LayoutPage) hocrBBox(r model.PdfRectangle) string {
+	x0, y0, x1, y1 := p.topDownBox(r)
+	return fmt.Sprintf("bbox %d %d %d %d", int(math.Floor(x0)), int(math.Floor(y0)),
+		int(math.Ceil(x1)), int(math.Ceil(y1)))
+}
+
+// WriteHOCR writes the layouts of `pages` to `w` as a single hOCR document, with an ocr_page per
+// page, an ocr_carea per column, an ocr_par per block, then ocr_line and ocrx_word elements.  The
+// coordinates are in points from the top left corner of the pages and the word confidence
+// (x_wconf) is 100.
+func WriteHOCR(w io.Writer, pages []*LayoutPage) error {
+	doc := hocrDocument{
+		Namespace: "http://www.w3.org/1999/xhtml",
+		Lang:      "en",
+		Meta: []hocrMeta{
+			{HTTPEquiv: "Content-Type", Content: "text/html; charset=utf-8"},
+			{Name: "ocr-system", Content: "unidoc"},
+			{Name: "ocr-capabilities", Content: "ocr_page ocr_carea ocr_par ocr_line ocrx_word ocrp_wconf ocrp_font"},
+		},
+	}
+	for _, p := range pages {
+		page := &hocrElement{
+			XMLName: xml.Name{Local: "div"},
+			Class:   "ocr_page",
+			ID:      fmt.Sprintf("page_%d", p.PageNum),
+			Title:   fmt.Sprintf("%s; ppageno %d", p.hocrBBox(p.MediaBox), p.PageNum-1),
+		}
+		var numBlocks, numLines, numWords int
+		for i, c := range p.Layout.Columns {
+			area := &hocrElement{
+				XMLName: xml.Name{Local: "div"},
+				Class:   "ocr_carea",
+				ID:      fmt.Sprintf("block_%d_%d", p.PageNum, i+1),
+				Title:   p.hocrBBox(c.BBox),
+			}
+			for _, b := range c.Blocks {
+				numBlocks++
+				par := &hocrElement{
+					XMLName: xml.Name{Local: "p"},
+					Class:   "ocr_par",
+					ID:      fmt.Sprintf("par_%d_%d", p.PageNum, numBlocks),
+					Title:   p.hocrBBox(b.BBox),
+				}
+				for _, l := range b.Lines {
+					numLines++
+					line := &hocrElement{
+						XMLName: xml.Name{Local: "span"},
+						Class:   "ocr_line",
+						ID:      fmt.Sprintf("line_%d_%d", p.PageNum, numLines),
+						Title:   p.hocrBBox(l.BBox),
+					}
+					if b.Orientation != 0 {
+						line.Title += fmt.Sprintf("; textangle %d", b.Orientation)
+					}
+					for _, word := range l.Words {
+						numWords++
+						line.Kids = append(line.Kids, &hocrElement{
+							XMLName: xml.Name{Local: "span"},
+							Class:   "ocrx_word",
+							ID:      fmt.Sprintf("word_%d_%d", p.PageNum, numWords),
+							Title: fmt.Sprintf("%s; x_wconf %d; x_font %q; x_fsize %g", p.hocrBBox(word.BBox),
+								int(nativeTextConfidence*100), word.FontName, round2(word.FontSize)),
+							Text: word.Text,
+						})
+					}
+					par.Kids = append(par.Kids, line)
+				}
+				area.Kids = append(area.Kids, par)
+			}
+			page.Kids = append(page.Kids, area)
+		}
+		doc.Pages = append(doc.Pages, page)
+	}
+
+	header := xml.Header + `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" ` +
+		`"http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">` + "\n"
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	return writeXML(w, doc)
+}
+
+//
+// ALTO
+//
+
+// ALTO version 4 namespace and schema.
+const (
+	altoNamespace = "http://www.loc.gov/standards/alto/ns-v4#"
+	altoSchema    = "http://www.loc.gov/standards/alto/v4/alto-4-2.xsd"
+)
+
+// altoDocument is the root element of an ALTO document (Analyzed Layout and Text Object, version
+// 4.2).
+type altoDocument struct {
+	XMLName         xml.Name         `xml:"alto"`
+	Namespace       string           `xml:"xmlns,attr"`
+	XSI             string           `xml:"xmlns:xsi,attr"`
+	SchemaLocation  string           `xml:"xsi:schemaLocation,attr"`
+	MeasurementUnit string           `xml:"Description>MeasurementUnit"`
+	Styles          []*altoTextStyle `xml:"Styles>TextStyle"`
+	Pages           []*altoPage      `xml:"Layout>Page"`
+}
+
+// altoTextStyle is a font of the words of an ALTO document.
+type altoTextStyle struct {
+	ID         string  `xml:"ID,attr"`
+	FontFamily string  `xml:"FONTFAMILY,attr"`
+	FontSize   float64 `xml:"FONTSIZE,attr"`
+}
+
+// altoBox is the position of an element of an ALTO page, from the top left corner of the page.
+type altoBox struct {
+	HPos   float64 `xml:"HPOS,attr"`
+	VPos   float64 `xml:"VPOS,attr"`
+	Width  float64 `xml:"WIDTH,attr"`
+	Height float64 `xml:"HEIGHT,attr"`
+}
+
+// altoPage is a page of an ALTO document.
+type altoPage struct {
+	ID            string         `xml:"ID,attr"`
+	PhysicalImgNr int            `xml:"PHYSICAL_IMG_NR,attr"`
+	Width         float64        `xml:"WIDTH,attr"`
+	Height        float64        `xml:"HEIGHT,attr"`
+	PrintSpace    altoPrintSpace `xml:"PrintSpace"`
+}
+
+// altoPrintSpace is the printed area of an ALTO page.
+type altoPrintSpace struct {
+	altoBox
+	Blocks []*altoTextBlock `xml:"TextBlock"`
+}
+
+// altoTextBlock is a block of lines of an ALTO page.
+type altoTextBlock struct {
+	ID string `xml:"ID,attr"`
+	altoBox
+	Rotation int             `xml:"ROTATION,attr,omitempty"`
+	Lines    []*altoTextLine `xml:"TextLine"`
+}
+
+// altoTextLine is a line of an ALTO page, made of String and SP elements.
+type altoTextLine struct {
+	ID string `xml:"ID,attr"`
+	altoBox
+	Items []interface{}
+}
+
+// altoString is a word of an ALTO page.
+type altoString struct {
+	XMLName xml.Name `xml:"String"`
+	ID      string   `xml:"ID,attr"`
+	altoBox
+	Content   string  `xml:"CONTENT,attr"`
+	WC        float64 `xml:"WC,attr"`
+	StyleRefs string  `xml:"STYLEREFS,attr"`
+}
+
+// altoSpace is the white space between two words of an ALTO line.
+type altoSpace struct {
+	XMLName xml.Name `xml:"SP"`
+	HPos    float64  `xml:"HPOS,attr"`
+	VPos    float64  `xml:"VPOS,attr"`
+	Width   float64  `xml:"WIDTH,attr"`
+}
+
+// altoBox returns the ALTO position of `r` on page `p`.
+func (p *LayoutPage) altoBox(r model.PdfRectangle) altoBox {
+	x0, y0, x1, y1 := p.topDownBox(r)
+	return altoBox{HPos: round2(x0), VPos: round2(y0), Width: round2(x1 - x0), Height: round2(y1 - y0)}
+}
+
+// WriteALTO writes the layouts of `pages` to `w` as a single ALTO v4 document, with a Page per page
+// and a TextBlock per block.  The measurement unit is the pixel, with one pixel per point, from the
+// top left corner of the pages.  The word confidence (WC) is 1 and the fonts of the words are
+// referred to by TextStyle.
+func WriteALTO(w io.Writer, pages []*LayoutPage) error {
+	doc := altoDocument{
+		Namespace:       altoNamespace,
+		XSI:             "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation:  altoNamespace + " " + altoSchema,
+		MeasurementUnit: "pixel",
+	}
+	// Identifiers of the text styles by font name and size.
+	styles := map[string]string{}
+	styleID := func(fontName string, fontSize float64) string {
+		key := fmt.Sprintf("%s %g", fontName, round2(fontSize))
+		if id, ok := styles[key]; ok {
+			return id
+		}
+		id := fmt.Sprintf("font%d", len(styles))
+		styles[key] = id
+		doc.Styles = append(doc.Styles, &altoTextStyle{ID: id, FontFamily: fontName, FontSize: round2(fontSize)})
+		return id
+	}
+
+	for _, p := range pages {
+		page := &altoPage{
+			ID:            fmt.Sprintf("page_%d", p.PageNum),
+			PhysicalImgNr: p.PageNum,
+			Width:         round2(p.MediaBox.Width()),
+			Height:        round2(p.MediaBox.Height()),
+		}
+		page.PrintSpace.altoBox = p.altoBox(p.MediaBox)
+		var numLines, numWords int
+		for i, b := range p.Layout.Blocks() {
+			block := &altoTextBlock{
+				ID:       fmt.Sprintf("block_%d_%d", p.PageNum, i+1),
+				altoBox:  p.altoBox(b.BBox),
+				Rotation: b.Orientation,
+			}
+			for _, l := range b.Lines {
+				numLines++
+				line := &altoTextLine{
+					ID:      fmt.Sprintf("line_%d_%d", p.PageNum, numLines),
+					altoBox: p.altoBox(l.BBox),
+				}
+				for j, word := range l.Words {
+					numWords++
+					box := p.altoBox(word.BBox)
+					if j > 0 {
+						prev := line.Items[len(line.Items)-1].(*altoString)
+						end := prev.HPos + prev.Width
+						line.Items = append(line.Items, &altoSpace{HPos: end, VPos: box.VPos,
+							Width: round2(math.Max(box.HPos-end, 0))})
+					}
+					line.Items = append(line.Items, &altoString{
+						ID:        fmt.Sprintf("string_%d_%d", p.PageNum, numWords),
+						altoBox:   box,
+						Content:   word.Text,
+						WC:        nativeTextConfidence,
+						StyleRefs: styleID(word.FontName, word.FontSize),
+					})
+				}
+				block.Lines = append(block.Lines, line)
+			}
+			page.PrintSpace.Blocks = append(page.PrintSpace.Blocks, block)
+		}
+		doc.Pages = append(doc.Pages, page)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return writeXML(w, doc)
+}
+
+// writeXML writes the XML encoding of `v`, indented, followed by a newline to `w`.
+func writeXML(w io.Writer, v interface{}) error {
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", " ")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// round2 returns `x` rounded to 2 decimals.
+func round2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
+
+//
+// JSON
+//
+
+// jsonPage, jsonBlock, jsonLine and jsonWord are the JSON encodings of the layout written by
+// WriteLayoutJSON.
+type jsonPage struct {
+	Page   int          `json:"page"`
+	Width  float64      `json:"width"`
+	Height float64      `json:"height"`
+	Blocks []*jsonBlock `json:"blocks"`
+}
+
+type jsonBlock struct {
+	BBox        [4]float64  `json:"bbox"`
+	Orientation int         `json:"orientation"`
+	MCID        int         `json:"mcid"`
+	Lines       []*jsonLine `json:"lines"`
+}
+
+type jsonLine struct {
+	BBox  [4]float64  `json:"bbox"`
+	Words []*jsonWord `json:"words"`
+}
+
+type jsonWord struct {
+	Text       string     `json:"text"`
+	BBox       [4]float64 `json:"bbox"`
+	Font       string     `json:"font"`
+	FontSize   float64    `json:"font_size"`
+	Confidence float64    `json:"confidence"`
+}
+
+// WriteLayoutJSON writes the layouts of `pages` to `w` as a single JSON document of the form
+//
+//	{"pages": [{"page": 1, "width": 612, "height": 792, "blocks": [
+//	  {"bbox": [72, 698, 150, 708], "orientation": 0, "mcid": -1, "lines": [
+//	    {"bbox": [72, 698, 150, 708], "words": [
+//	      {"text": "Hello", "bbox": [72, 698, 102, 708], "font": "Courier", "font_size": 10,
+//	       "confidence": 1}]}]}]}]}
+//
+// with the blocks of each page in reading order.  Bounding boxes are [llx, lly, urx, ury] in the
+// default user space of the page, i.e. in points with y pointing up, as the other positions of the
+// package.  orientation is the direction of the text in degrees, mcid the marked-content identifier
+// of the blocks of tagged pages (-1 otherwise), and confidence is 1 for the native text of PDFs.
+func WriteLayoutJSON(w io.Writer, pages []*LayoutPage) error {
+	doc := struct {
+		Pages []*jsonPage `json:"pages"`
+	}{Pages: []*jsonPage{}}
+	for _, p := range pages {
+		page := &jsonPage{
+			Page:   p.PageNum,
+			Width:  p.MediaBox.Width(),
+			Height: p.MediaBox.Height(),
+			Blocks: []*jsonBlock{},
+		}
+		for _, b := range p.Layout.Blocks() {
+			block := &jsonBlock{BBox: jsonRect(b.BBox), Orientation: b.Orientation, MCID: b.MCID}
+			for _, l := range b.Lines {
+				line := &jsonLine{BBox: jsonRect(l.BBox)}
+				for _, word := range l.Words {
+					line.Words = append(line.Words, &jsonWord{
+						Text:       word.Text,
+						BBox:       jsonRect(word.BBox),
+						Font:       word.FontName,
+						FontSize:   word.FontSize,
+						Confidence: nativeTextConfidence,
+					})
+				}
+				block.Lines = append(block.Lines, line)
+			}
+			page.Blocks = append(page.Blocks, block)
+		}
+		doc.Pages = append(doc.Pages, page)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", " ")
+	return encoder.Encode(doc)
+}
@@ -0,0 +1,129 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// makeLayoutPages returns two pages with the layouts of `contents`.
+func makeLayoutPages(t *testing.T, contents ...string) []*LayoutPage {
+	pages := []*LayoutPage{}
+	for i, c := range contents {
+		e := Extractor{contents: c}
+		layout, err := e.ExtractLayout()
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		pages = append(pages, &LayoutPage{
+			PageNum:  i + 1,
+			MediaBox: model.PdfRectangle{Urx: 612, Ury: 792},
+			Layout:   layout,
+		})
+	}
+	return pages
+}
+
+// checkXML checks that `data` is well-formed XML.
+func checkXML(t *testing.T, data []byte) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("Invalid XML: %v\n%s", err, data)
+		}
+	}
+}
+
+// Test exporting the layouts of two pages to hOCR, ALTO and JSON.
+func TestExportLayout(t *testing.T) {
+	pages := makeLayoutPages(t,
+		"BT /UniDocCourier 10 Tf 1 0 0 1 72 700 Tm (Hello <World>) Tj ET",
+		"BT /UniDocCourier 20 Tf 1 0 0 1 100 500 Tm (Second) Tj ET")
+
+	var buf bytes.Buffer
+	if err := WriteHOCR(&buf, pages); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	checkXML(t, buf.Bytes())
+	hocr := buf.String()
+	for _, s := range []string{
+		`<div class="ocr_page" id="page_1" title="bbox 0 0 612 792; ppageno 0">`,
+		`<div class="ocr_page" id="page_2" title="bbox 0 0 612 792; ppageno 1">`,
+		`<span class="ocr_line" id="line_1_1" title="bbox 72 84 150 94">`,
+		`title="bbox 108 84 150 94; x_wconf 100; x_font &#34;Courier&#34;; x_fsize 10">&lt;World&gt;</span>`,
+	} {
+		if !strings.Contains(hocr, s) {
+			t.Errorf("%s missing from hOCR\n%s", s, hocr)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteALTO(&buf, pages); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	checkXML(t, buf.Bytes())
+	alto := buf.String()
+	for _, s := range []string{
+		`<alto xmlns="http://www.loc.gov/standards/alto/ns-v4#"`,
+		`<TextStyle ID="font0" FONTFAMILY="Courier" FONTSIZE="10"></TextStyle>`,
+		`<TextStyle ID="font1" FONTFAMILY="Courier" FONTSIZE="20"></TextStyle>`,
+		`<String ID="string_1_1" HPOS="72" VPOS="84" WIDTH="30" HEIGHT="10" CONTENT="Hello" WC="1" STYLEREFS="font0">`,
+		`<SP HPOS="102" VPOS="84" WIDTH="6"></SP>`,
+		`<Page ID="page_2" PHYSICAL_IMG_NR="2" WIDTH="612" HEIGHT="792">`,
+	} {
+		if !strings.Contains(alto, s) {
+			t.Errorf("%s missing from ALTO\n%s", s, alto)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteLayoutJSON(&buf, pages); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	var doc struct {
+		Pages []struct {
+			Page   int
+			Blocks []struct {
+				MCID  int
+				Lines []struct {
+					Words []struct {
+						Text       string
+						BBox       []float64
+						Font       string
+						FontSize   float64 `json:"font_size"`
+						Confidence float64
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Error: %v\n%s", err, buf.String())
+	}
+	if len(doc.Pages) != 2 || doc.Pages[1].Page != 2 || len(doc.Pages[0].Blocks) != 1 {
+		t.Fatalf("Invalid JSON pages\n%s", buf.String())
+	}
+	words := doc.Pages[0].Blocks[0].Lines[0].Words
+	if len(words) != 2 || words[1].Text != "<World>" || words[1].Font != "Courier" || words[1].FontSize != 10 ||
+		words[1].Confidence != 1 || len(words[1].BBox) != 4 || words[1].BBox[1] != 698 {
+		t.Errorf("Invalid JSON words %+v", words)
+	}
+	if doc.Pages[0].Blocks[0].MCID != -1 {
+		t.Errorf("Invalid JSON MCID %d", doc.Pages[0].Blocks[0].MCID)
+	}
+}
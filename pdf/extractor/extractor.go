@@ -5,12 +5,24 @@
 
 package extractor
 
-import "github.com/unidoc/unidoc/pdf/model"
+import (
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
 
 // Extractor stores and offers functionality for extracting content from PDF pages.
 type Extractor struct {
 	contents  string
 	resources *model.PdfPageResources
+
+	annotations        []*model.PdfAnnotation
+	includeAnnotations bool
+
+	excludedVisibility map[Visibility]bool
+	excludeArtifacts   bool
+
+	page       *core.PdfIndirectObject
+	structTree *model.PdfStructTreeRoot
 }
 
 // New returns an Extractor instance for extracting content from the input PDF page.
@@ -25,6 +37,38 @@ func New(page *model.PdfPage) (*Extractor, error) {
 	// fmt.Printf("%s\n", contents)
 	// fmt.Println("========================= ::: =========================")
 
-	e := &Extractor{contents: contents, resources: page.Resources}
+	e := &Extractor{
+		contents:    contents,
+		resources:   page.Resources,
+		annotations: page.Annotations,
+		page:        page.GetPageAsIndirectObject(),
+	}
 	return e, nil
 }
+
+// SetIncludeAnnotations sets whether the text of the normal appearances of the page annotations,
+// e.g. FreeText annotations and filled form fields, is extracted along with the page contents.
+// Hidden annotations are skipped.
+func (e *Extractor) SetIncludeAnnotations(include bool) {
+	e.includeAnnotations = include
+}
+
+// SetIncludeVisibility sets whether the text spans with visibility `v` are extracted.  All text is
+// extracted by default, e.g. SetIncludeVisibility(VisibilityInvisible, false) skips the invisible
+// text layer of OCR'd scans.
+func (e *Extractor) SetIncludeVisibility(v Visibility, include bool) {
+	if include {
+		delete(e.excludedVisibility, v)
+		return
+	}
+	if e.excludedVisibility == nil {
+		e.excludedVisibility = map[Visibility]bool{}
+	}
+	e.excludedVisibility[v] = true
+}
+
+// SetIncludeArtifacts sets whether the text of Artifact marked-content sequences, e.g. page numbers,
+// running headers and footers of tagged pages, is extracted.  Artifacts are extracted by default.
+func (e *Extractor) SetIncludeArtifacts(include bool) {
+	e.excludeArtifacts = !include
+}
@@ -0,0 +1,316 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"errors"
+	"fmt"
+	goimage "image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// ImageMark is an image drawn on a page, by an image XObject or an inline image.
+type ImageMark struct {
+	// Image is the decoded image, or nil if it could not be decoded.
+	Image      *model.Image
+	Colorspace model.PdfColorspace
+	Filter     core.StreamEncoder
+
+	// Name is the name of the image XObject in the resources of the content stream drawing it,
+	// and XObject the image XObject.  Both are empty for inline images.
+	Name    core.PdfObjectName
+	XObject *model.XObjectImage
+	// Inline is the inline image, or nil for image XObjects.
+	Inline *contentstream.ContentStreamInlineImage
+
+	// CTM maps the unit square of the image to the default user space of the page.
+	CTM contentstream.Matrix
+	// BBox is the bounding box of the image on the page.
+	BBox model.PdfRectangle
+	// DPIX and DPIY are the effective resolutions of the image along its width and height.
+	DPIX, DPIY float64
+
+	// IsMask is true for stencil masks (ImageMask), which paint the fill color through the image.
+	IsMask bool
+	// SMask is the soft mask image XObject of the image, if any.
+	SMask *model.XObjectImage
+	// Mask is the Mask entry of the image: a stencil mask image stream or a color key array.
+	Mask core.PdfObject
+}
+
+// ExtractImages returns the images drawn by the page, in content stream order, including the images
+// drawn by form XObjects and inline images.
+func (e *Extractor) ExtractImages() ([]*ImageMark, error) {
+	marks := []*ImageMark{}
+	err := e.extractImages(e.contents, e.resources, contentstream.IdentityMatrix(), &marks,
+		map[*core.PdfObjectStream]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return marks, nil
+}
+
+// extractImages appends the images drawn by the content stream `contents` using `resources` to
+// `marks`.  `base` maps the user space of the content stream to the default user space of the page
+// and `visited` holds the form XObjects being processed, to protect against cycles.
+func (e *Extractor) extractImages(contents string, resources *model.PdfPageResources,
+	base contentstream.Matrix, marks *[]*ImageMark, visited map[*core.PdfObjectStream]bool) error {
+	cstreamParser := contentstream.NewContentStreamParser(contents)
+	operations, err := cstreamParser.Parse()
+	if err != nil {
+		common.Log.Debug("extractImages: parse failed. err=%v", err)
+		return err
+	}
+
+	processor := contentstream.NewContentStreamProcessor(*operations)
+	processor.AddHandler(contentstream.HandlerConditionEnumAllOperands, "",
+		func(op *contentstream.ContentStreamOperation, gs contentstream.GraphicsState,
+			resources *model.PdfPageResources) error {
+			ctm := gs.CTM.Mult(base)
+			switch op.Operand {
+			case "BI":
+				if len(op.Params) != 1 {
+					common.Log.Debug("ERROR: BI op=%s invalid params", op)
+					return nil
+				}
+				inline, ok := op.Params[0].(*contentstream.ContentStreamInlineImage)
+				if !ok {
+					return nil
+				}
+				*marks = append(*marks, newInlineImageMark(inline, resources, ctm))
+			case "Do":
+				if len(op.Params) != 1 || resources == nil {
+					return nil
+				}
+				name, ok := core.GetNameVal(op.Params[0])
+				if !ok {
+					common.Log.Debug("ERROR: Do op=%s GetNameVal failed", op)
+					return nil
+				}
+				stream, xtype := resources.GetXObjectByName(core.PdfObjectName(name))
+				switch xtype {
+				case model.XObjectTypeImage:
+					mark, err := newXObjectImageMark(core.PdfObjectName(name), stream, ctm)
+					if err != nil {
+						common.Log.Debug("ERROR: Invalid image XObject %s. err=%v", name, err)
+						return nil
+					}
+					*marks = append(*marks, mark)
+				case model.XObjectTypeForm:
+					return e.extractFormImages(stream, resources, ctm, marks, visited)
+				}
+			}
+			return nil
+		})
+
+	err = processor.Process(resources)
+	if err != nil {
+		common.Log.Error("ERROR: Processing: err=%v", err)
+	}
+	return err
+}
+
+// extractFormImages appends the images drawn by the form XObject `stream`, drawn with `ctm`, to
+// `marks`.
+func (e *Extractor) extractFormImages(stream *core.PdfObjectStream, parentResources *model.PdfPageResources,
+	ctm contentstream.Matrix, marks *[]*ImageMark, visited map[*core.PdfObjectStream]bool) error {
+	if visited[stream] {
+		common.Log.Debug("ERROR: extractFormImages: form XObject loop")
+		return nil
+	}
+	visited[stream] = true
+	defer delete(visited, stream)
+
+	xform, err := model.NewXObjectFormFromStream(stream)
+	if err != nil {
+		common.Log.Debug("ERROR: extractFormImages: invalid form XObject. err=%v", err)
+		return nil
+	}
+	contents, err := xform.GetContentStream()
+	if err != nil {
+		common.Log.Debug("ERROR: extractFormImages: content stream. err=%v", err)
+		return nil
+	}
+	resources := xform.Resources
+	if resources == nil {
+		resources = parentResources
+	}
+	return e.extractImages(string(contents), resources, formMatrix(xform).Mult(ctm), marks, visited)
+}
+
+// newXObjectImageMark returns the mark of the image XObject `stream` named `name`, drawn with
+// `ctm`.
+func newXObjectImageMark(name core.PdfObjectName, stream *core.PdfObjectStream,
+	ctm contentstream.Matrix) (*ImageMark, error) {
+	ximg, err := model.NewXObjectImageFromStream(stream)
+	if err != nil {
+		return nil, err
+	}
+	mark := &ImageMark{
+		Colorspace: ximg.ColorSpace,
+		Filter:     ximg.Filter,
+		Name:       name,
+		XObject:    ximg,
+		Mask:       ximg.Mask,
+	}
+	if isMask, ok := core.TraceToDirectObject(ximg.ImageMask).(*core.PdfObjectBool); ok && bool(*isMask) {
+		mark.IsMask = true
+		if ximg.BitsPerComponent == nil {
+			// Stencil masks have 1 bit per component (8.9.6.2 Stencil Masking p. 218).
+			bpc := int64(1)
+			ximg.BitsPerComponent = &bpc
+		}
+	}
+	if smask, ok := core.TraceToDirectObject(ximg.SMask).(*core.PdfObjectStream); ok {
+		mark.SMask, err = model.NewXObjectImageFromStream(smask)
+		if err != nil {
+			common.Log.Debug("ERROR: Invalid soft mask. err=%v", err)
+		}
+	}
+	mark.Image, err = ximg.ToImage()
+	if err != nil {
+		common.Log.Debug("ERROR: Image %s could not be decoded. err=%v", name, err)
+	}
+	mark.setPlacement(ctm, *ximg.Width, *ximg.Height)
+	return mark, nil
+}
+
+// newInlineImageMark returns the mark of the inline image `inline`, drawn with `ctm`.
+func newInlineImageMark(inline *contentstream.ContentStreamInlineImage, resources *model.PdfPageResources,
+	ctm contentstream.Matrix) *ImageMark {
+	mark := &ImageMark{Inline: inline}
+	var err error
+	if mark.IsMask, err = inline.IsMask(); err != nil {
+		common.Log.Debug("ERROR: Invalid inline image mask flag. err=%v", err)
+	}
+	if mark.Colorspace, err = inline.GetColorSpace(resources); err != nil {
+		common.Log.Debug("ERROR: Invalid inline image colorspace. err=%v", err)
+	}
+	if mark.Filter, err = inline.GetEncoder(); err != nil {
+		common.Log.Debug("ERROR: Invalid inline image filter. err=%v", err)
+	}
+	if mark.Image, err = inline.ToImage(resources); err != nil {
+		common.Log.Debug("ERROR: Inline image could not be decoded. err=%v", err)
+	}
+	width, _ := core.GetIntVal(inline.Width)
+	height, _ := core.GetIntVal(inline.Height)
+	mark.setPlacement(ctm, int64(width), int64(height))
+	return mark
+}
+
+// setPlacement sets the placement of the image of `width` x `height` samples drawn with `ctm`.
+func (m *ImageMark) setPlacement(ctm contentstream.Matrix, width, height int64) {
+	m.CTM = ctm
+	m.BBox = transformRect(ctm, model.PdfRectangle{Urx: 1, Ury: 1})
+	// The lengths of the sides of the image on the page, in inches.
+	if w := ctm.ScalingFactorX() / 72; w > 0 {
+		m.DPIX = float64(width) / w
+	}
+	if h := ctm.ScalingFactorY() / 72; h > 0 {
+		m.DPIY = float64(height) / h
+	}
+}
+
+// encodedData returns the encoded data of the image.
+func (m *ImageMark) encodedData() []byte {
+	if m.Inline != nil {
+		return m.Inline.GetEncodedData()
+	}
+	if m.XObject != nil {
+		return m.XObject.Stream
+	}
+	return nil
+}
+
+// Format returns the file format of the image written by WriteImage: "jpg" for images encoded with
+// the DCTDecode filter alone, "png" otherwise.
+func (m *ImageMark) Format() string {
+	if _, ok := m.Filter.(*core.DCTEncoder); ok && len(m.encodedData()) > 0 {
+		return "jpg"
+	}
+	return "png"
+}
+
+// WriteImage writes the image to `w` in the format returned by Format: the JPEG data of DCT images
+// as found in the PDF, or a PNG of the decoded image converted to gray or RGB.
+func (m *ImageMark) WriteImage(w io.Writer) error {
+	if m.Format() == "jpg" {
+		_, err := w.Write(m.encodedData())
+		return err
+	}
+	goimg, err := m.toGoImage()
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, goimg)
+}
+
+// toGoImage returns the image converted to a gray or RGB Go image with 8 or 16 bits per
+// component.
+func (m *ImageMark) toGoImage() (goimage.Image, error) {
+	if m.Image == nil {
+		return nil, errors.New("Image not decoded")
+	}
+	img := *m.Image
+	if !m.IsMask && m.Colorspace != nil && img.ColorComponents != 1 {
+		if _, isRGB := m.Colorspace.(*model.PdfColorspaceDeviceRGB); !isRGB {
+			rgb, err := m.Colorspace.ImageToRGB(img)
+			if err != nil {
+				return nil, err
+			}
+			img = rgb
+		}
+	}
+	if img.BitsPerComponent < 8 {
+		img = scaleTo8Bits(img)
+	}
+	return img.ToGoImage()
+}
+
+// scaleTo8Bits returns `img`, of less than 8 bits per component, with its samples scaled to 8
+// bits.
+func scaleTo8Bits(img model.Image) model.Image {
+	samples := img.GetSamples()
+	maxVal := uint32(1)<<uint(img.BitsPerComponent) - 1
+	data := make([]byte, len(samples))
+	for i, s := range samples {
+		data[i] = byte(s * 255 / maxVal)
+	}
+	img.Data = data
+	img.BitsPerComponent = 8
+	return img
+}
+
+// SaveImages saves the images of `marks` in the directory `dir`, as files named <prefix>-<n>.<ext>
+// with n the index of the image and ext its format (see ImageMark.Format).  Returns the paths of
+// the files.
+func SaveImages(marks []*ImageMark, dir, prefix string) ([]string, error) {
+	paths := []string{}
+	for i, m := range marks {
+		path := filepath.Join(dir, fmt.Sprintf("%s-%d.%s", prefix, i+1, m.Format()))
+		f, err := os.Create(path)
+		if err != nil {
+			return paths, err
+		}
+		err = m.WriteImage(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
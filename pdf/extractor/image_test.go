@@ -0,0 +1,113 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"bytes"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Test extracting an inline image and a DCT image XObject with a soft mask drawn by a form.
+func TestExtractImages(t *testing.T) {
+	photo := &model.Image{Width: 4, Height: 4, BitsPerComponent: 8, ColorComponents: 3,
+		Data: bytes.Repeat([]byte{0x20, 0x80, 0xe0}, 16)}
+	encoder := core.NewDCTEncoder()
+	encoder.Width, encoder.Height = 4, 4
+	ximg, err := model.NewXObjectImageFromImage(photo, nil, encoder)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	alpha := &model.Image{Width: 4, Height: 4, BitsPerComponent: 8, ColorComponents: 1,
+		Data: bytes.Repeat([]byte{0x80}, 16)}
+	smask, err := model.NewXObjectImageFromImage(alpha, nil, nil)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	ximg.SMask = smask.ToPdfObject()
+
+	formResources := model.NewPdfPageResources()
+	if err := formResources.SetXObjectImageByName("Im1", ximg); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	form := makeForm(t, "q 200 0 0 100 0 0 cm /Im1 Do Q", []float64{0, 0, 200, 100},
+		[]float64{1, 0, 0, 1, 300, 400}, formResources)
+	resources := model.NewPdfPageResources()
+	if err := resources.SetXObjectFormByName("Fm1", form); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// A 2 x 2 inline image: red, green, blue and white.
+	e := Extractor{
+		contents: "q 100 0 0 50 72 600 cm BI /W 2 /H 2 /BPC 8 /CS /RGB /F /AHx ID " +
+			"FF000000FF000000FFFFFFFF> EI Q /Fm1 Do",
+		resources: resources,
+	}
+	marks, err := e.ExtractImages()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(marks) != 2 {
+		t.Fatalf("Invalid number of images %d", len(marks))
+	}
+
+	inline := marks[0]
+	if inline.Inline == nil || inline.XObject != nil || rectString(inline.BBox) != "72.0 600.0 172.0 650.0" {
+		t.Errorf("Invalid inline image %+v", inline)
+	}
+	if math.Abs(inline.DPIX-1.44) > 1e-6 || math.Abs(inline.DPIY-2.88) > 1e-6 {
+		t.Errorf("Invalid inline image resolution %.2f x %.2f", inline.DPIX, inline.DPIY)
+	}
+	if _, ok := inline.Colorspace.(*model.PdfColorspaceDeviceRGB); !ok || inline.Format() != "png" {
+		t.Errorf("Invalid inline image colorspace %T or format %s", inline.Colorspace, inline.Format())
+	}
+	var buf bytes.Buffer
+	if err := inline.WriteImage(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	decoded, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if r, g, b, _ := decoded.At(1, 0).RGBA(); r != 0 || g != 0xffff || b != 0 {
+		t.Errorf("Invalid PNG pixel %d %d %d", r, g, b)
+	}
+
+	xobj := marks[1]
+	if xobj.Name != "Im1" || xobj.XObject == nil || rectString(xobj.BBox) != "300.0 400.0 500.0 500.0" {
+		t.Errorf("Invalid image XObject %s %s", xobj.Name, rectString(xobj.BBox))
+	}
+	if xobj.Image == nil || xobj.Image.Width != 4 || xobj.SMask == nil || xobj.Format() != "jpg" {
+		t.Errorf("Invalid image XObject %+v", xobj)
+	}
+	if math.Abs(xobj.DPIX-1.44) > 1e-6 || math.Abs(xobj.DPIY-2.88) > 1e-6 {
+		t.Errorf("Invalid image XObject resolution %.2f x %.2f", xobj.DPIX, xobj.DPIY)
+	}
+
+	dir, err := ioutil.TempDir("", "unidoc-images")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	paths, err := SaveImages(marks, dir, "page1")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(paths) != 2 || filepath.Base(paths[0]) != "page1-1.png" || filepath.Base(paths[1]) != "page1-2.jpg" {
+		t.Fatalf("Invalid paths %v", paths)
+	}
+	data, err := ioutil.ReadFile(paths[1])
+	if err != nil || !bytes.HasPrefix(data, []byte{0xff, 0xd8}) {
+		t.Errorf("Invalid JPEG file (%v)", err)
+	}
+}
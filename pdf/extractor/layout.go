@@ -0,0 +1,497 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Layout analysis thresholds, as fractions of the font size.
+const (
+	// Horizontal gap between glyphs that separates words.
+	wordGapFactor = 0.15
+	// Horizontal gap between words that separates lines, e.g. in different columns.
+	lineGapFactor = 1.2
+	// Vertical gap between the first two lines of a block that separates blocks.
+	blockGapFactor = 1.0
+	// Minimum whitespace separating the regions of an XY-cut.
+	cutGapFactor = 0.5
+)
+
+// TextWord is a word of a page layout: a run of glyphs of a line without white space or gaps
+// between them.
+type TextWord struct {
+	Text     string
+	BBox     model.PdfRectangle // Device space bounding box.
+	FontName string
+	FontSize float64
+	Glyphs   []XYGlyph
+
+	box model.PdfRectangle // Bounding box in the text direction frame.
+}
+
+// TextLine is a line of words of a page layout, in reading order.
+type TextLine struct {
+	Words []*TextWord
+	BBox  model.PdfRectangle
+
+	box  model.PdfRectangle
+	size float64 // Largest font size of the words.
+}
+
+// TextBlock is a block of lines of a page layout, typically a paragraph or a heading.
+type TextBlock struct {
+	Lines       []*TextLine
+	BBox        model.PdfRectangle
+	Orientation int // Direction of the text in device space: 0, 90, 180 or 270 degrees.
+	MCID        int // Marked-content identifier of the block for tagged pages, -1 otherwise.
+
+	box     model.PdfRectangle
+	lineGap float64 // Vertical gap between the lines, -1 for single line blocks.
+}
+
+// TextColumn is a region of a page layout whose blocks are read from top to bottom, e.g. a column
+// of a multi-column page or a full width header.
+type TextColumn struct {
+	Blocks []*TextBlock
+	BBox   model.PdfRectangle
+}
+
+// PageLayout is the result of the layout analysis of a page: the text in reading order, as
+// columns of blocks of lines of words.
+type PageLayout struct {
+	Columns []*TextColumn
+}
+
+// ExtractLayout extracts the text of the page and returns its layout in reading order.
+// For tagged pages, the blocks are the marked-content sequences of the page, ordered by the
+// structure tree set with SetStructTreeRoot, or in content stream order without a structure
+// tree.  Otherwise the glyphs are grouped by position into words, lines, blocks and columns.
+func (e *Extractor) ExtractLayout() (*PageLayout, error) {
+	textList, _, _, err := e.ExtractXYText()
+	if err != nil {
+		return nil, err
+	}
+	order := e.structureOrder()
+	for _, t := range textList.Spans() {
+		if t.MCID >= 0 && len(t.Glyphs) > 0 {
+			return textList.taggedLayout(order), nil
+		}
+	}
+	return textList.Layout(), nil
+}
+
+// SetStructTreeRoot sets the structure tree of the document of the page, used to order the text
+// of tagged pages by ExtractLayout.
+func (e *Extractor) SetStructTreeRoot(root *model.PdfStructTreeRoot) {
+	e.structTree = root
+}
+
+// structureOrder returns the ranks of the marked-content identifiers of the page in the depth
+// first order of the structure tree, or nil if there is no structure tree.
+func (e *Extractor) structureOrder() map[int]int {
+	if e.structTree == nil || e.page == nil {
+		return nil
+	}
+	mcidElems := e.structTree.GetMarkedContentElements(e.page)
+	order := map[int]int{}
+	e.structTree.Walk(func(elem *model.PdfStructElement, depth int) error {
+		for _, kid := range elem.Kids {
+			if kid.MCID < 0 || mcidElems[kid.MCID] != elem {
+				continue
+			}
+			if _, ok := order[kid.MCID]; !ok {
+				order[kid.MCID] = len(order)
+			}
+		}
+		return nil
+	})
+	return order
+}
+
+// Layout returns the layout of the text of `tl` in reading order, grouping the glyphs by position
+// into words, lines, blocks and columns.  The columns are found by recursively cutting the page
+// along the widest gaps between blocks (XY-cut), preferring vertical cuts.  Text in other
+// directions than upright follows the upright text.
+func (tl *TextList) Layout() *PageLayout {
+	layout := &PageLayout{}
+	for _, group := range tl.orientationGroups() {
+		blocks := makeBlocks(makeLines(makeWords(group.glyphs)))
+		for _, b := range blocks {
+			b.Orientation = group.orientation
+		}
+		for _, blocks := range xyCut(blocks) {
+			layout.Columns = append(layout.Columns, newTextColumn(blocks))
+		}
+	}
+	return layout
+}
+
+// taggedLayout returns the layout of the marked-content sequences of `tl` as blocks, ordered by
+// the ranks `order` of their identifiers, then in content stream order.  Text that is not marked
+// content, e.g. artifacts, follows in a separate column.
+func (tl *TextList) taggedLayout(order map[int]int) *PageLayout {
+	groups := map[int]*TextList{}
+	ids := []int{}
+	untagged := &TextList{}
+	for _, t := range tl.Spans() {
+		if t.MCID < 0 {
+			*untagged = append(*untagged, t)
+			continue
+		}
+		if _, ok := groups[t.MCID]; !ok {
+			groups[t.MCID] = &TextList{}
+			ids = append(ids, t.MCID)
+		}
+		*groups[t.MCID] = append(*groups[t.MCID], t)
+	}
+	rank := func(mcid int) int {
+		if r, ok := order[mcid]; ok {
+			return r
+		}
+		return len(order)
+	}
+	sort.SliceStable(ids, func(i, j int) bool { return rank(ids[i]) < rank(ids[j]) })
+
+	column := &TextColumn{}
+	for _, mcid := range ids {
+		for _, group := range groups[mcid].orientationGroups() {
+			lines := makeLines(makeWords(group.glyphs))
+			if len(lines) == 0 {
+				continue
+			}
+			block := newTextBlock(lines[0])
+			for _, line := range lines[1:] {
+				block.addLine(line)
+			}
+			block.Orientation = group.orientation
+			block.MCID = mcid
+			column.Blocks = append(column.Blocks, block)
+		}
+	}
+	layout := &PageLayout{}
+	if len(column.Blocks) > 0 {
+		layout.Columns = append(layout.Columns, newTextColumn(column.Blocks))
+	}
+	layout.Columns = append(layout.Columns, untagged.Layout().Columns...)
+	return layout
+}
+
+// Text returns the text of the layout, with the words of lines separated by spaces, the lines of
+// blocks by newlines and blocks by empty lines.
+func (l *PageLayout) Text() string {
+	parts := []string{}
+	for _, b := range l.Blocks() {
+		parts = append(parts, b.Text())
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Blocks returns the blocks of the layout in reading order.
+func (l *PageLayout) Blocks() []*TextBlock {
+	blocks := []*TextBlock{}
+	for _, c := range l.Columns {
+		blocks = append(blocks, c.Blocks...)
+	}
+	return blocks
+}
+
+// Text returns the lines of the block separated by newlines.
+func (b *TextBlock) Text() string {
+	lines := []string{}
+	for _, line := range b.Lines {
+		lines = append(lines, line.Text())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Text returns the words of the line separated by spaces.
+func (line *TextLine) Text() string {
+	words := []string{}
+	for _, w := range line.Words {
+		words = append(words, w.Text)
+	}
+	return strings.Join(words, " ")
+}
+
+// layoutGlyph is a glyph with the properties of its span used for the layout analysis.
+type layoutGlyph struct {
+	XYGlyph
+	box      model.PdfRectangle // Bounding box in the text direction frame.
+	fontName string
+	fontSize float64
+}
+
+// orientationGroup is the glyphs of a text direction.
+type orientationGroup struct {
+	orientation int
+	glyphs      []layoutGlyph
+}
+
+// orientationGroups returns the glyphs of `tl` grouped by text direction, upright text first.
+// The glyph boxes are rotated so that the text of each group runs from left to right.
+func (tl *TextList) orientationGroups() []orientationGroup {
+	groups := map[int][]layoutGlyph{}
+	for _, t := range tl.Spans() {
+		for _, g := range t.Glyphs {
+			groups[t.Orientation] = append(groups[t.Orientation], layoutGlyph{
+				XYGlyph:  g,
+				box:      rotateRect(g.BBox, t.Orientation),
+				fontName: t.FontName,
+				fontSize: t.FontSize,
+			})
+		}
+	}
+	result := []orientationGroup{}
+	for _, o := range []int{0, 90, 180, 270} {
+		if len(groups[o]) > 0 {
+			result = append(result, orientationGroup{orientation: o, glyphs: groups[o]})
+		}
+	}
+	return result
+}
+
+// rotateRect returns the bounding box of `r` rotated by -`angle` degrees, so that text in the
+// direction `angle` runs from left to right.
+func rotateRect(r model.PdfRectangle, angle int) model.PdfRectangle {
+	switch angle {
+	case 90:
+		return model.PdfRectangle{Llx: r.Lly, Lly: -r.Urx, Urx: r.Ury, Ury: -r.Llx}
+	case 180:
+		return model.PdfRectangle{Llx: -r.Urx, Lly: -r.Ury, Urx: -r.Llx, Ury: -r.Lly}
+	case 270:
+		return model.PdfRectangle{Llx: -r.Ury, Lly: r.Llx, Urx: -r.Lly, Ury: r.Urx}
+	}
+	return r
+}
+
+// makeWords groups `glyphs`, in content stream order, into words.  Words end at white space, at
+// horizontal gaps and where the text moves to another line.
+func makeWords(glyphs []layoutGlyph) []*TextWord {
+	words := []*TextWord{}
+	var word *TextWord
+	var prev layoutGlyph
+	for _, g := range glyphs {
+		if g.Text != "" && strings.TrimSpace(g.Text) == "" {
+			word = nil
+			continue
+		}
+		if word != nil {
+			size := math.Max(prev.fontSize, g.fontSize)
+			gap := g.box.Llx - prev.box.Urx
+			if !overlapsVertically(prev.box, g.box) || gap > wordGapFactor*size || gap < -0.5*size {
+				word = nil
+			}
+		}
+		if word == nil {
+			word = &TextWord{BBox: g.BBox, FontName: g.fontName, FontSize: g.fontSize, box: g.box}
+			words = append(words, word)
+		} else {
+			word.BBox = unionRect(word.BBox, g.BBox)
+			word.box = unionRect(word.box, g.box)
+			word.FontSize = math.Max(word.FontSize, g.fontSize)
+		}
+		word.Text += g.Text
+		word.Glyphs = append(word.Glyphs, g.XYGlyph)
+		prev = g
+	}
+	return words
+}
+
+// overlapsVertically returns true if `a` and `b` overlap vertically by at least half the height
+// of the lower of them, i.e. are on the same line.
+func overlapsVertically(a, b model.PdfRectangle) bool {
+	overlap := math.Min(a.Ury, b.Ury) - math.Max(a.Lly, b.Lly)
+	return overlap >= 0.5*math.Min(a.Height(), b.Height())
+}
+
+// makeLines groups `words` into lines, sorted from top to bottom.  A word joins a line that it
+// overlaps vertically if it is close to the line horizontally.
+func makeLines(words []*TextWord) []*TextLine {
+	lines := []*TextLine{}
+	for _, w := range words {
+		var best *TextLine
+		bestDist := 0.0
+		for _, line := range lines {
+			if !overlapsVertically(line.box, w.box) {
+				continue
+			}
+			dist := math.Max(w.box.Llx-line.box.Urx, line.box.Llx-w.box.Urx)
+			if dist > lineGapFactor*math.Max(line.size, w.FontSize) {
+				continue
+			}
+			if best == nil || dist < bestDist {
+				best, bestDist = line, dist
+			}
+		}
+		if best == nil {
+			lines = append(lines, &TextLine{Words: []*TextWord{w}, BBox: w.BBox, box: w.box, size: w.FontSize})
+			continue
+		}
+		best.Words = append(best.Words, w)
+		best.BBox = unionRect(best.BBox, w.BBox)
+		best.box = unionRect(best.box, w.box)
+		best.size = math.Max(best.size, w.FontSize)
+	}
+	for _, line := range lines {
+		sort.SliceStable(line.Words, func(i, j int) bool { return line.Words[i].box.Llx < line.Words[j].box.Llx })
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		if overlapsVertically(lines[i].box, lines[j].box) {
+			return lines[i].box.Llx < lines[j].box.Llx
+		}
+		return lines[i].box.Ury > lines[j].box.Ury
+	})
+	return lines
+}
+
+// makeBlocks groups `lines`, sorted from top to bottom, into blocks.  A line joins the block
+// above it if they overlap horizontally, have similar font sizes and the gap between them is
+// consistent with the line spacing of the block.
+func makeBlocks(lines []*TextLine) []*TextBlock {
+	blocks := []*TextBlock{}
+	for _, line := range lines {
+		var joined bool
+		for i := len(blocks) - 1; i >= 0 && !joined; i-- {
+			if blocks[i].accepts(line) {
+				blocks[i].addLine(line)
+				joined = true
+			}
+		}
+		if !joined {
+			blocks = append(blocks, newTextBlock(line))
+		}
+	}
+	return blocks
+}
+
+// newTextBlock returns a block with the single line `line`.
+func newTextBlock(line *TextLine) *TextBlock {
+	return &TextBlock{
+		Lines:   []*TextLine{line},
+		BBox:    line.BBox,
+		MCID:    -1,
+		box:     line.box,
+		lineGap: -1,
+	}
+}
+
+// accepts returns true if `line` continues the block.
+func (b *TextBlock) accepts(line *TextLine) bool {
+	last := b.Lines[len(b.Lines)-1]
+	if math.Min(b.box.Urx, line.box.Urx) <= math.Max(b.box.Llx, line.box.Llx) {
+		return false
+	}
+	if ratio := line.size / last.size; ratio > 1.3 || ratio < 1/1.3 {
+		return false
+	}
+	gap := last.box.Lly - line.box.Ury
+	size := math.Max(line.size, last.size)
+	if gap < -0.5*size {
+		return false
+	}
+	if b.lineGap < 0 {
+		return gap <= blockGapFactor*size
+	}
+	return gap <= math.Max(1.5*b.lineGap, b.lineGap+0.3*size)
+}
+
+// addLine appends `line` to the block.
+func (b *TextBlock) addLine(line *TextLine) {
+	last := b.Lines[len(b.Lines)-1]
+	if b.lineGap < 0 {
+		b.lineGap = math.Max(last.box.Lly-line.box.Ury, 0)
+	}
+	b.Lines = append(b.Lines, line)
+	b.BBox = unionRect(b.BBox, line.BBox)
+	b.box = unionRect(b.box, line.box)
+}
+
+// newTextColumn returns a column of `blocks`.
+func newTextColumn(blocks []*TextBlock) *TextColumn {
+	c := &TextColumn{Blocks: blocks}
+	for i, b := range blocks {
+		if i == 0 {
+			c.BBox = b.BBox
+		} else {
+			c.BBox = unionRect(c.BBox, b.BBox)
+		}
+	}
+	return c
+}
+
+// xyCut returns `blocks` split into columns in reading order.  The blocks are cut in two along
+// the widest gap between their projections on the x axis (a vertical cut, the left part is read
+// first) or the y axis (a horizontal cut, the top part is read first), recursively, until the
+// parts can't be split into columns.  The blocks of each column are sorted from top to bottom.
+func xyCut(blocks []*TextBlock) [][]*TextBlock {
+	if len(blocks) == 0 {
+		return nil
+	}
+	if hasVerticalCut(blocks) {
+		first, second, gap := cutBlocks(blocks, false)
+		if hFirst, hSecond, hGap := cutBlocks(blocks, true); hGap > gap {
+			first, second = hFirst, hSecond
+		}
+		return append(xyCut(first), xyCut(second)...)
+	}
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].box.Ury > blocks[j].box.Ury })
+	return [][]*TextBlock{blocks}
+}
+
+// hasVerticalCut returns true if `blocks`, or a part of them split by horizontal cuts, can be cut
+// vertically into columns.
+func hasVerticalCut(blocks []*TextBlock) bool {
+	minGap := math.Inf(1)
+	for _, b := range blocks {
+		minGap = math.Min(minGap, cutGapFactor*b.Lines[0].size)
+	}
+	if _, _, gap := cutBlocks(blocks, false); gap >= minGap {
+		return true
+	}
+	first, second, gap := cutBlocks(blocks, true)
+	if gap < minGap {
+		return false
+	}
+	return hasVerticalCut(first) || hasVerticalCut(second)
+}
+
+// cutBlocks returns `blocks` split at the widest gap between their projections on the x axis, or
+// on the y axis if `horizontal` is true, and the width of the gap.  The first part is to the left
+// of the gap, or above it.  The gap is -Inf if the projections don't have gaps.
+func cutBlocks(blocks []*TextBlock, horizontal bool) ([]*TextBlock, []*TextBlock, float64) {
+	// Project onto the cut axis, with coordinates increasing in reading order.
+	span := func(b *TextBlock) (float64, float64) {
+		if horizontal {
+			return -b.box.Ury, -b.box.Lly
+		}
+		return b.box.Llx, b.box.Urx
+	}
+	sorted := append([]*TextBlock{}, blocks...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		si, _ := span(sorted[i])
+		sj, _ := span(sorted[j])
+		return si < sj
+	})
+
+	best, bestGap := 0, math.Inf(-1)
+	end := math.Inf(-1)
+	for i, b := range sorted {
+		start, stop := span(b)
+		if i > 0 && start-end > bestGap {
+			best, bestGap = i, start-end
+		}
+		end = math.Max(end, stop)
+	}
+	if best == 0 {
+		return sorted, nil, math.Inf(-1)
+	}
+	return sorted[:best], sorted[best:], bestGap
+}
@@ -0,0 +1,91 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Two columns shown row by row, with a title, a word gap made by a TJ adjustment and rotated text.
+const testLayoutContents = `
+    BT /UniDocCourier 20 Tf 1 0 0 1 72 750 Tm (Two Columns Of Text Per Page) Tj ET
+    BT /UniDocCourier 10 Tf
+    1 0 0 1 72 700 Tm (left one) Tj
+    1 0 0 1 320 700 Tm (right one) Tj
+    1 0 0 1 72 688 Tm (left two) Tj
+    1 0 0 1 320 688 Tm (right two) Tj
+    1 0 0 1 72 676 Tm (left three) Tj
+    1 0 0 1 320 676 Tm (right three) Tj
+    1 0 0 1 72 640 Tm [(left) -300 (para)] TJ
+    ET
+    q 0 1 -1 0 30 100 cm BT /UniDocCourier 10 Tf (Side note) Tj ET Q
+`
+
+// Test the reading order of the layout of an untagged page.
+func TestLayout(t *testing.T) {
+	e := Extractor{contents: testLayoutContents}
+	layout, err := e.ExtractLayout()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	expected := "Two Columns Of Text Per Page\n\nleft one\nleft two\nleft three\n\nleft para\n\n" +
+		"right one\nright two\nright three\n\nSide note"
+	if s := layout.Text(); s != expected {
+		t.Errorf("Invalid layout text %q", s)
+	}
+	if len(layout.Columns) != 4 {
+		t.Errorf("Invalid columns %d", len(layout.Columns))
+	}
+	blocks := layout.Blocks()
+	if b := blocks[len(blocks)-1]; b.Orientation != 90 || rectString(b.BBox) != "22.0 100.0 32.0 154.0" {
+		t.Errorf("Invalid rotated block %d %s", b.Orientation, rectString(b.BBox))
+	}
+	if words := blocks[2].Lines[0].Words; len(words) != 2 || rectString(words[1].BBox) != "99.0 638.0 123.0 648.0" {
+		t.Errorf("Invalid words %d %s", len(words), rectString(words[len(words)-1].BBox))
+	}
+}
+
+const testTaggedContents = `
+    /P <</MCID 1>> BDC BT /UniDocCourier 10 Tf 1 0 0 1 72 700 Tm (Second) Tj ET EMC
+    /Artifact BMC BT /UniDocCourier 10 Tf 1 0 0 1 72 50 Tm (Footer) Tj ET EMC
+    /P <</MCID 0>> BDC BT /UniDocCourier 10 Tf 1 0 0 1 72 600 Tm (First) Tj ET EMC
+`
+
+// Test the reading order of tagged pages, in marked-content and structure order.
+func TestTaggedLayout(t *testing.T) {
+	page := core.MakeIndirectObject(core.MakeDict())
+	e := Extractor{contents: testTaggedContents, page: page}
+	layout, err := e.ExtractLayout()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if s := layout.Text(); s != "Second\n\nFirst\n\nFooter" {
+		t.Errorf("Invalid marked-content order %q", s)
+	}
+
+	root := model.NewPdfStructTreeRoot()
+	doc := model.NewPdfStructElement("Document")
+	root.AddKid(doc)
+	for mcid := 0; mcid < 2; mcid++ {
+		p := model.NewPdfStructElement("P")
+		p.AddMarkedContent(page, mcid)
+		doc.AddKid(p)
+	}
+	e.SetStructTreeRoot(root)
+	layout, err = e.ExtractLayout()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if s := layout.Text(); s != "First\n\nSecond\n\nFooter" {
+		t.Errorf("Invalid structure order %q", s)
+	}
+	if blocks := layout.Blocks(); blocks[0].MCID != 0 || blocks[2].MCID != -1 {
+		t.Errorf("Invalid block identifiers")
+	}
+}
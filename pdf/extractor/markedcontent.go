@@ -0,0 +1,179 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// MarkedContent is a marked-content sequence, begun by a BMC or BDC operator and ended by EMC
+// (14.6 Marked Content p. 550).
+type MarkedContent struct {
+	Tag  string // Tag of the sequence, e.g. P, Span or Artifact.
+	MCID int    // Marked-content identifier, -1 if none.
+
+	// ActualText is the replacement text of the content of the sequence (14.9.4 Replacement Text
+	// p. 630), e.g. for ligatures, hyphens and formulas, or nil if none.
+	ActualText *string
+	// Alt is the alternate description of the content (14.9.3 p. 629), or nil if none.
+	Alt *string
+	// E is the expansion of the abbreviation shown by the sequence (14.9.5 p. 631), or nil if none.
+	E *string
+
+	// Properties is the property list of a BDC operator, nil for BMC.
+	Properties *core.PdfObjectDictionary
+}
+
+// newMarkedContent returns the marked-content sequence begun by the BMC or BDC operation `op`.
+// Property lists referred to by name are looked up in the Properties of `resources`.
+func newMarkedContent(op *contentstream.ContentStreamOperation, resources *model.PdfPageResources) *MarkedContent {
+	mc := &MarkedContent{MCID: -1}
+	if len(op.Params) > 0 {
+		if tag, ok := core.GetNameVal(op.Params[0]); ok {
+			mc.Tag = tag
+		}
+	}
+	if op.Operand != "BDC" || len(op.Params) != 2 {
+		return mc
+	}
+	props := op.Params[1]
+	if name, ok := props.(*core.PdfObjectName); ok {
+		if resources == nil {
+			return mc
+		}
+		props, _ = resources.GetPropertiesByName(*name)
+	}
+	dict, ok := core.GetDict(props)
+	if !ok {
+		return mc
+	}
+	mc.Properties = dict
+	if mcid, ok := core.GetIntVal(dict.Get("MCID")); ok {
+		mc.MCID = mcid
+	}
+	mc.ActualText = textStringVal(dict.Get("ActualText"))
+	mc.Alt = textStringVal(dict.Get("Alt"))
+	mc.E = textStringVal(dict.Get("E"))
+	return mc
+}
+
+// textStringVal returns the decoded value of the text string `obj`, or nil if it isn't a string.
+func textStringVal(obj core.PdfObject) *string {
+	str, ok := core.GetString(obj)
+	if !ok {
+		return nil
+	}
+	s := str.Decoded()
+	return &s
+}
+
+// markedContentID returns the marked-content identifier of the innermost sequence of `marked` with
+// one, or -1 if none.
+func markedContentID(marked []*MarkedContent) int {
+	for i := len(marked) - 1; i >= 0; i-- {
+		if marked[i].MCID >= 0 {
+			return marked[i].MCID
+		}
+	}
+	return -1
+}
+
+// IsArtifact returns true if `t` is in an Artifact marked-content sequence, e.g. a page number,
+// running header or footer (14.8.2.2 Real Content and Artifacts p. 599).
+func (t *XYText) IsArtifact() bool {
+	for _, mc := range t.MarkedContent {
+		if mc.Tag == "Artifact" {
+			return true
+		}
+	}
+	return false
+}
+
+// actualText returns the outermost marked-content sequence of `t` with an ActualText, which
+// replaces the text of the nested sequences, or nil if none.
+func (t *XYText) actualText() *MarkedContent {
+	for _, mc := range t.MarkedContent {
+		if mc.ActualText != nil {
+			return mc
+		}
+	}
+	return nil
+}
+
+// replaceActualText returns `textList` with the spans of each marked-content sequence with an
+// ActualText replaced by a single span whose text is the ActualText.  The span has a single glyph
+// covering the replaced glyphs, or none if the ActualText is empty.
+func replaceActualText(textList TextList) TextList {
+	result := TextList{}
+	var current *MarkedContent // Sequence of the last span of `result` if it has an ActualText.
+	var separators TextList    // Separators following the last span.
+	replaced := []int{}        // Indexes of the spans replaced in `result`.
+	for _, t := range textList {
+		if !t.shown {
+			separators = append(separators, t)
+			continue
+		}
+		mc := t.actualText()
+		if mc != nil && mc == current {
+			// The separators between the spans of a sequence are part of the replaced text.
+			separators = nil
+			last := &result[len(result)-1]
+			last.BBox = unionRect(last.BBox, t.BBox)
+			last.Glyphs = append(last.Glyphs, t.Glyphs...)
+			continue
+		}
+		result = append(result, separators...)
+		separators = nil
+		current = mc
+		if mc != nil {
+			t.Text = *mc.ActualText
+			t.Glyphs = append([]XYGlyph{}, t.Glyphs...)
+			replaced = append(replaced, len(result))
+		}
+		result = append(result, t)
+	}
+	result = append(result, separators...)
+
+	for _, i := range replaced {
+		t := &result[i]
+		glyphs := t.Glyphs
+		t.Glyphs = nil
+		if t.Text == "" || len(glyphs) == 0 {
+			continue
+		}
+		first, last := glyphs[0], glyphs[len(glyphs)-1]
+		glyph := XYGlyph{Text: t.Text, Code: first.Code, BBox: first.BBox}
+		for _, g := range glyphs[1:] {
+			glyph.BBox = unionRect(glyph.BBox, g.BBox)
+		}
+		if len(first.Quad) == 8 && len(last.Quad) == 8 {
+			glyph.Quad = []float64{
+				first.Quad[0], first.Quad[1], last.Quad[2], last.Quad[3],
+				first.Quad[4], first.Quad[5], last.Quad[6], last.Quad[7],
+			}
+		}
+		t.Glyphs = []XYGlyph{glyph}
+	}
+	return result
+}
+
+// filterArtifacts returns `textList` without the artifacts if they are excluded by `e` (see
+// SetIncludeArtifacts).
+func (e *Extractor) filterArtifacts(textList TextList) TextList {
+	if !e.excludeArtifacts {
+		return textList
+	}
+	filtered := TextList{}
+	for _, t := range textList {
+		if t.shown && t.IsArtifact() {
+			continue
+		}
+		filtered = append(filtered, t)
+	}
+	return filtered
+}
@@ -0,0 +1,83 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// A tagged paragraph with ActualText replacements for a ligature shown as two strings, with a
+// UTF-16 text string, and for a hyphen, a footer artifact and a form drawn in a paragraph.
+const testMarkedContents = `
+    /Artifact BMC BT /UniDocCourier 10 Tf 1 0 0 1 72 50 Tm (Page 1) Tj ET EMC
+    /P <</MCID 0>> BDC BT /UniDocCourier 10 Tf 1 0 0 1 72 700 Tm (E) Tj
+    /Span <</ActualText <FEFF00660069>>> BDC (f) Tj /Span BMC (i) Tj EMC EMC
+    (nd) Tj /Span <</ActualText ()>> BDC (-) Tj EMC ET EMC
+    /P <</MCID 1>> BDC /Fm1 Do EMC
+`
+
+// Test extracting marked content with ActualText replacements and artifacts.
+func TestMarkedContent(t *testing.T) {
+	form := makeForm(t, "/Span <</Alt (Logo)>> BDC BT /UniDocCourier 10 Tf 1 0 0 1 72 600 Tm (Acme) Tj ET EMC",
+		[]float64{0, 0, 612, 792}, nil, nil)
+	resources := model.NewPdfPageResources()
+	if err := resources.SetXObjectFormByName("Fm1", form); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	e := Extractor{contents: testMarkedContents, resources: resources}
+	textList, _, _, err := e.ExtractXYText()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	spans := textList.Spans()
+	texts := []string{}
+	for _, span := range spans {
+		texts = append(texts, span.Text)
+	}
+	if s := strings.Join(texts, ","); s != "Page 1,E,fi,nd,,Acme" {
+		t.Fatalf("Invalid spans %q", s)
+	}
+
+	if !spans[0].IsArtifact() || spans[0].MCID != -1 || spans[1].IsArtifact() || spans[1].MCID != 0 {
+		t.Errorf("Invalid artifact %+v", spans[0].MarkedContent)
+	}
+	ligature := spans[2]
+	if len(ligature.Glyphs) != 1 || rectString(ligature.Glyphs[0].BBox) != "77.0 698.0 88.0 708.0" ||
+		rectString(ligature.BBox) != "77.0 698.0 88.0 708.0" {
+		t.Errorf("Invalid ligature glyphs %+v", ligature.Glyphs)
+	}
+	if len(ligature.MarkedContent) != 2 || ligature.MarkedContent[1].Tag != "Span" {
+		t.Errorf("Invalid ligature marked content %+v", ligature.MarkedContent)
+	}
+	if len(spans[4].Glyphs) != 0 {
+		t.Errorf("Invalid empty replacement %+v", spans[4])
+	}
+	acme := spans[5]
+	if acme.MCID != 1 || len(acme.MarkedContent) != 2 || acme.MarkedContent[0].Tag != "P" {
+		t.Fatalf("Invalid form marked content %d %+v", acme.MCID, acme.MarkedContent)
+	}
+	if alt := acme.MarkedContent[1].Alt; alt == nil || *alt != "Logo" {
+		t.Errorf("Invalid Alt %v", alt)
+	}
+
+	layout, err := e.ExtractLayout()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if s := layout.Text(); s != "Efind\n\nAcme\n\nPage 1" {
+		t.Errorf("Invalid layout text %q", s)
+	}
+	e.SetIncludeArtifacts(false)
+	if layout, err = e.ExtractLayout(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if s := layout.Text(); s != "Efind\n\nAcme" {
+		t.Errorf("Invalid layout text without artifacts %q", s)
+	}
+}
@@ -0,0 +1,456 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"math"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// FillRule is the rule determining the inside of a path for filling and clipping (8.5.3.3 Filling
+// p. 136).
+type FillRule int
+
+const (
+	FillRuleNonZero FillRule = iota
+	FillRuleEvenOdd
+)
+
+// PathSegment is a segment of a subpath in device space: a straight line or a cubic Bézier curve.
+// Straight lines are represented as curves with their control points at their end points, i.e.
+// P1 = P0 and P2 = P3.
+type PathSegment struct {
+	draw.CubicBezierCurve
+	IsCurve bool
+}
+
+// Subpath is a sequence of connected segments of a path, in device space.
+type Subpath struct {
+	Start    draw.Point
+	Segments []PathSegment
+	Closed   bool // True if the subpath is closed by a straight line back to Start.
+}
+
+// Path returns the points of the subpath: the start point followed by the end points of the
+// segments.
+func (sp *Subpath) Path() draw.Path {
+	path := draw.NewPath().AppendPoint(sp.Start)
+	for _, seg := range sp.Segments {
+		path = path.AppendPoint(seg.P3)
+	}
+	return path
+}
+
+// currentPoint returns the end point of the subpath.
+func (sp *Subpath) currentPoint() draw.Point {
+	if len(sp.Segments) == 0 {
+		return sp.Start
+	}
+	return sp.Segments[len(sp.Segments)-1].P3
+}
+
+// ClipPath is a path intersected with the clipping path by a W or W* operator.
+type ClipPath struct {
+	Subpaths []*Subpath
+	FillRule FillRule
+}
+
+// PaintedPath is a path painted by a path painting operator (8.5.3 Path-Painting Operators p. 134),
+// in device space, with the graphics state parameters used to paint it.
+type PaintedPath struct {
+	Subpaths []*Subpath
+	Stroke   bool
+	Fill     bool
+	FillRule FillRule
+
+	// StrokeColor and FillColor are the colors of the stroke and the fill, or nil if they are not
+	// painted or can't be converted to RGB, e.g. pattern colors.
+	StrokeColor *model.PdfColorDeviceRGB
+	FillColor   *model.PdfColorDeviceRGB
+
+	LineWidth  float64 // Line width in device space.
+	LineCap    int
+	LineJoin   int
+	MiterLimit float64
+	DashArray  []float64 // Dash lengths in device space, empty for solid lines.
+	DashPhase  float64
+
+	// Clip holds the paths intersected to make the clipping path of the painted path.  The path is
+	// only clipped by the page if it is empty.
+	Clip []*ClipPath
+}
+
+// BBox returns the bounding box of the points and control points of `p`, ignoring the line width.
+func (p *PaintedPath) BBox() model.PdfRectangle {
+	return subpathsBBox(p.Subpaths)
+}
+
+// BBox returns the bounding box of the points and control points of `c`.
+func (c *ClipPath) BBox() model.PdfRectangle {
+	return subpathsBBox(c.Subpaths)
+}
+
+// subpathsBBox returns the bounding box of the points and control points of `subpaths`.
+func subpathsBBox(subpaths []*Subpath) model.PdfRectangle {
+	bbox := model.PdfRectangle{Llx: math.Inf(1), Lly: math.Inf(1), Urx: math.Inf(-1), Ury: math.Inf(-1)}
+	for _, sp := range subpaths {
+		points := []draw.Point{sp.Start}
+		for _, seg := range sp.Segments {
+			points = append(points, seg.P1, seg.P2, seg.P3)
+		}
+		for _, pt := range points {
+			bbox = unionRect(bbox, model.PdfRectangle{Llx: pt.X, Lly: pt.Y, Urx: pt.X, Ury: pt.Y})
+		}
+	}
+	return bbox
+}
+
+// ExtractPaths returns the paths painted by the page in content stream order, including the paths
+// painted by form XObjects.
+func (e *Extractor) ExtractPaths() ([]*PaintedPath, error) {
+	pb := &pathBuilder{}
+	err := e.extractPaths(e.contents, e.resources, contentstream.IdentityMatrix(), pb,
+		map[*core.PdfObjectStream]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return pb.paths, nil
+}
+
+// pathBuilder collects the paths painted by a content stream and tracks the clipping path.
+type pathBuilder struct {
+	current  []*Subpath
+	clipping bool     // A W or W* operator applies to the current path.
+	clipRule FillRule // Fill rule of the W or W* operator.
+
+	clip      []*ClipPath
+	clipStack [][]*ClipPath
+
+	paths []*PaintedPath
+}
+
+// point returns the current point of the path being constructed, and false if there is none.
+func (pb *pathBuilder) point() (draw.Point, bool) {
+	if len(pb.current) == 0 {
+		return draw.Point{}, false
+	}
+	return pb.current[len(pb.current)-1].currentPoint(), true
+}
+
+// moveTo begins a new subpath at `p`.
+func (pb *pathBuilder) moveTo(p draw.Point) {
+	pb.current = append(pb.current, &Subpath{Start: p})
+}
+
+// addSegment appends `seg`, starting at the current point, to the current subpath.
+func (pb *pathBuilder) addSegment(seg PathSegment) {
+	sp := pb.current[len(pb.current)-1]
+	if sp.Closed {
+		// Segments after h start a new subpath at the start of the closed one.
+		pb.moveTo(sp.Start)
+		sp = pb.current[len(pb.current)-1]
+	}
+	sp.Segments = append(sp.Segments, seg)
+}
+
+// lineTo appends a straight line to `p` to the current subpath.  A point without a current
+// point begins a new subpath.
+func (pb *pathBuilder) lineTo(p draw.Point) {
+	p0, ok := pb.point()
+	if !ok {
+		common.Log.Debug("No current point - starting a new subpath")
+		pb.moveTo(p)
+		return
+	}
+	pb.addSegment(PathSegment{CubicBezierCurve: draw.CubicBezierCurve{P0: p0, P1: p0, P2: p, P3: p}})
+}
+
+// curveTo appends a Bézier curve with control points `p1` and `p2` ending at `p3` to the current
+// subpath.  nil control points are at the current point (p1, v operator) or at `p3` (p2, y
+// operator).
+func (pb *pathBuilder) curveTo(p1, p2 *draw.Point, p3 draw.Point) {
+	p0, ok := pb.point()
+	if !ok {
+		common.Log.Debug("No current point - starting a new subpath")
+		pb.moveTo(p3)
+		return
+	}
+	if p1 == nil {
+		p1 = &p0
+	}
+	if p2 == nil {
+		p2 = &p3
+	}
+	curve := draw.CubicBezierCurve{P0: p0, P1: *p1, P2: *p2, P3: p3}
+	pb.addSegment(PathSegment{CubicBezierCurve: curve, IsCurve: true})
+}
+
+// closePath closes the current subpath.
+func (pb *pathBuilder) closePath() {
+	if len(pb.current) > 0 {
+		pb.current[len(pb.current)-1].Closed = true
+	}
+}
+
+// paint ends the path being constructed with the painting operator `operand` and the graphics
+// state `gs` whose CTM maps to device space.  The path is kept if it is stroked or filled, and
+// intersected with the clipping path if a W or W* operator preceded the operator.
+func (pb *pathBuilder) paint(operand string, gs contentstream.GraphicsState) {
+	switch operand {
+	case "s", "b", "b*":
+		pb.closePath()
+	}
+	stroke := operand == "S" || operand == "s" || operand[0] == 'B' || operand[0] == 'b'
+	fill := operand != "S" && operand != "s" && operand != "n"
+	rule := FillRuleNonZero
+	if operand == "f*" || operand == "B*" || operand == "b*" {
+		rule = FillRuleEvenOdd
+	}
+
+	if (stroke || fill) && len(pb.current) > 0 {
+		scale := (gs.CTM.ScalingFactorX() + gs.CTM.ScalingFactorY()) / 2
+		path := &PaintedPath{
+			Subpaths:   pb.current,
+			Stroke:     stroke,
+			Fill:       fill,
+			FillRule:   rule,
+			LineWidth:  gs.LineWidth * scale,
+			LineCap:    gs.LineCap,
+			LineJoin:   gs.LineJoin,
+			MiterLimit: gs.MiterLimit,
+			DashPhase:  gs.DashPhase * scale,
+			Clip:       pb.clip,
+		}
+		for _, d := range gs.DashArray {
+			path.DashArray = append(path.DashArray, d*scale)
+		}
+		if stroke {
+			path.StrokeColor = toRGB(gs.ColorspaceStroking, gs.ColorStroking)
+		}
+		if fill {
+			path.FillColor = toRGB(gs.ColorspaceNonStroking, gs.ColorNonStroking)
+		}
+		pb.paths = append(pb.paths, path)
+	}
+	if pb.clipping && len(pb.current) > 0 {
+		pb.intersectClip(&ClipPath{Subpaths: pb.current, FillRule: pb.clipRule})
+	}
+	pb.current = nil
+	pb.clipping = false
+}
+
+// intersectClip intersects the clipping path with `clip`.
+func (pb *pathBuilder) intersectClip(clip *ClipPath) {
+	// The clipping paths are shared by the painted paths and the saved states.
+	clips := make([]*ClipPath, len(pb.clip), len(pb.clip)+1)
+	copy(clips, pb.clip)
+	pb.clip = append(clips, clip)
+}
+
+// toRGB returns `color` of `cs` converted to RGB, or nil if it can't be converted.
+func toRGB(cs model.PdfColorspace, color model.PdfColor) *model.PdfColorDeviceRGB {
+	if cs == nil || color == nil {
+		return nil
+	}
+	rgb, err := cs.ColorToRGB(color)
+	if err != nil {
+		common.Log.Debug("Color conversion to RGB failed. err=%v", err)
+		return nil
+	}
+	c, _ := rgb.(*model.PdfColorDeviceRGB)
+	return c
+}
+
+// extractPaths appends the paths painted by the content stream `contents` using `resources` to
+// `pb`, including the paths of the form XObjects it draws.  `base` maps the user space of the
+// content stream to the default user space of the page and `visited` holds the form XObjects being
+// processed, to protect against cycles.
+func (e *Extractor) extractPaths(contents string, resources *model.PdfPageResources,
+	base contentstream.Matrix, pb *pathBuilder, visited map[*core.PdfObjectStream]bool) error {
+	cstreamParser := contentstream.NewContentStreamParser(contents)
+	operations, err := cstreamParser.Parse()
+	if err != nil {
+		common.Log.Debug("extractPaths: parse failed. err=%v", err)
+		return err
+	}
+
+	processor := contentstream.NewContentStreamProcessor(*operations)
+	processor.AddHandler(contentstream.HandlerConditionEnumAllOperands, "",
+		func(op *contentstream.ContentStreamOperation, gs contentstream.GraphicsState,
+			resources *model.PdfPageResources) error {
+			gs.CTM = gs.CTM.Mult(base)
+			pb.handleOp(op, gs)
+			switch op.Operand {
+			case "Do":
+				if len(op.Params) != 1 || resources == nil {
+					return nil
+				}
+				name, ok := core.GetNameVal(op.Params[0])
+				if !ok {
+					common.Log.Debug("ERROR: Do op=%s GetNameVal failed", op)
+					return nil
+				}
+				stream, xtype := resources.GetXObjectByName(core.PdfObjectName(name))
+				if xtype != model.XObjectTypeForm {
+					return nil
+				}
+				return e.extractFormPaths(stream, resources, gs.CTM, pb, visited)
+			}
+			return nil
+		})
+
+	err = processor.Process(resources)
+	if err != nil {
+		common.Log.Error("ERROR: Processing: err=%v", err)
+	}
+	return err
+}
+
+// handleOp updates the path being constructed and the clipping path with the path construction,
+// path painting and clipping operation `op`, and the q and Q operations.  The CTM of `gs` maps to
+// device space.
+func (pb *pathBuilder) handleOp(op *contentstream.ContentStreamOperation, gs contentstream.GraphicsState) {
+	vals, _ := core.GetNumbersAsFloat(op.Params)
+	// points returns the operands as points in device space.
+	points := func(n int) []draw.Point {
+		if len(vals) != 2*n {
+			common.Log.Debug("ERROR: %s op=%s invalid params", op.Operand, op)
+			return nil
+		}
+		pts := make([]draw.Point, n)
+		for i := range pts {
+			pts[i].X, pts[i].Y = gs.CTM.Transform(vals[2*i], vals[2*i+1])
+		}
+		return pts
+	}
+
+	switch op.Operand {
+	case "q":
+		pb.clipStack = append(pb.clipStack, pb.clip)
+	case "Q":
+		if n := len(pb.clipStack); n > 0 {
+			pb.clip = pb.clipStack[n-1]
+			pb.clipStack = pb.clipStack[:n-1]
+		}
+	case "m":
+		if pts := points(1); pts != nil {
+			pb.moveTo(pts[0])
+		}
+	case "l":
+		if pts := points(1); pts != nil {
+			pb.lineTo(pts[0])
+		}
+	case "c":
+		if pts := points(3); pts != nil {
+			pb.curveTo(&pts[0], &pts[1], pts[2])
+		}
+	case "v":
+		if pts := points(2); pts != nil {
+			pb.curveTo(nil, &pts[0], pts[1])
+		}
+	case "y":
+		if pts := points(2); pts != nil {
+			pb.curveTo(&pts[0], nil, pts[1])
+		}
+	case "h":
+		pb.closePath()
+	case "re":
+		if len(vals) != 4 {
+			common.Log.Debug("ERROR: re op=%s invalid params", op)
+			return
+		}
+		pb.addRect(gs.CTM, vals[0], vals[1], vals[2], vals[3])
+	case "W", "W*":
+		pb.clipping = true
+		pb.clipRule = FillRuleNonZero
+		if op.Operand == "W*" {
+			pb.clipRule = FillRuleEvenOdd
+		}
+	case "S", "s", "f", "F", "f*", "B", "B*", "b", "b*", "n":
+		pb.paint(op.Operand, gs)
+	}
+}
+
+// addRect appends the rectangle (`x`, `y`, `w`, `h`) in the user space of `ctm` to the path as a
+// closed subpath (re operator, 8.5.2.1 p. 133).
+func (pb *pathBuilder) addRect(ctm contentstream.Matrix, x, y, w, h float64) {
+	corner := func(x, y float64) draw.Point {
+		var p draw.Point
+		p.X, p.Y = ctm.Transform(x, y)
+		return p
+	}
+	pb.moveTo(corner(x, y))
+	pb.lineTo(corner(x+w, y))
+	pb.lineTo(corner(x+w, y+h))
+	pb.lineTo(corner(x, y+h))
+	pb.closePath()
+}
+
+// extractFormPaths appends the paths painted by the form XObject `stream`, drawn with `ctm`, to
+// `pb`.  The form is clipped by its bounding box.
+func (e *Extractor) extractFormPaths(stream *core.PdfObjectStream, parentResources *model.PdfPageResources,
+	ctm contentstream.Matrix, pb *pathBuilder, visited map[*core.PdfObjectStream]bool) error {
+	if visited[stream] {
+		common.Log.Debug("ERROR: extractFormPaths: form XObject loop")
+		return nil
+	}
+	visited[stream] = true
+	defer delete(visited, stream)
+
+	xform, err := model.NewXObjectFormFromStream(stream)
+	if err != nil {
+		common.Log.Debug("ERROR: extractFormPaths: invalid form XObject. err=%v", err)
+		return nil
+	}
+	contents, err := xform.GetContentStream()
+	if err != nil {
+		common.Log.Debug("ERROR: extractFormPaths: content stream. err=%v", err)
+		return nil
+	}
+	resources := xform.Resources
+	if resources == nil {
+		resources = parentResources
+	}
+	base := formMatrix(xform).Mult(ctm)
+	saved := pb.beginForm(xform, base)
+	err = e.extractPaths(string(contents), resources, base, pb, visited)
+	pb.endForm(saved)
+	return err
+}
+
+// pathState is the state of a pathBuilder saved while painting a form XObject.
+type pathState struct {
+	current   []*Subpath
+	clip      []*ClipPath
+	clipStack [][]*ClipPath
+}
+
+// beginForm prepares `pb` for the form XObject `xform` painted with `base` mapping form space to
+// device space, and returns the state of the parent to restore with endForm.  The form is painted
+// with the graphics state saved and the path of the parent unchanged, and clipped by its bounding
+// box.
+func (pb *pathBuilder) beginForm(xform *model.XObjectForm, base contentstream.Matrix) pathState {
+	saved := pathState{current: pb.current, clip: pb.clip, clipStack: pb.clipStack}
+	pb.current, pb.clipStack = nil, nil
+	if arr, ok := core.GetArray(xform.BBox); ok {
+		if bbox, err := model.NewPdfRectangle(*arr); err == nil {
+			pb.addRect(base, bbox.Llx, bbox.Lly, bbox.Width(), bbox.Height())
+			pb.intersectClip(&ClipPath{Subpaths: pb.current, FillRule: FillRuleNonZero})
+			pb.current = nil
+		}
+	}
+	return saved
+}
+
+// endForm restores the state `saved` of the parent of a form XObject.
+func (pb *pathBuilder) endForm(saved pathState) {
+	pb.current, pb.clip, pb.clipStack = saved.current, saved.clip, saved.clipStack
+}
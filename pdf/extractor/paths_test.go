@@ -0,0 +1,106 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// rgbString returns a string describing `c`.
+func rgbString(c *model.PdfColorDeviceRGB) string {
+	if c == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%.1f %.1f %.1f", c.R(), c.G(), c.B())
+}
+
+// Test extracting painted paths with their colors, line styles and clipping paths.
+func TestExtractPaths(t *testing.T) {
+	gsDict := core.MakeDict()
+	gsDict.Set("LW", core.MakeInteger(4))
+	gsDict.Set("D", core.MakeArray(core.MakeArrayFromIntegers([]int{2}), core.MakeInteger(1)))
+	resources := model.NewPdfPageResources()
+	if err := resources.AddExtGState("GS1", gsDict); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	form := makeForm(t, "0 0 20 20 re f", []float64{0, 0, 10, 10}, []float64{1, 0, 0, 1, 300, 300}, nil)
+	if err := resources.SetXObjectFormByName("Fm1", form); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	e := Extractor{
+		contents: `
+            q 1 0 0 RG 2 w [3 1] 0 d 1 J 2 0 0 2 0 0 cm 10 10 m 50 10 l 50 30 10 40 10 10 c S Q
+            q 100 100 50 50 re W n 0.5 g 90 90 100 100 re f* Q
+            0 1 0 rg /GS1 gs 200 200 m 250 200 l 250 250 225 250 y h B
+            /Fm1 Do`,
+		resources: resources,
+	}
+	paths, err := e.ExtractPaths()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(paths) != 4 {
+		t.Fatalf("Invalid number of paths %d", len(paths))
+	}
+
+	p := paths[0]
+	if !p.Stroke || p.Fill || rgbString(p.StrokeColor) != "1.0 0.0 0.0" || p.FillColor != nil {
+		t.Errorf("Invalid stroked path %+v", p)
+	}
+	if p.LineWidth != 4 || p.LineCap != 1 || len(p.DashArray) != 2 || p.DashArray[0] != 6 || len(p.Clip) != 0 {
+		t.Errorf("Invalid line style %+v", p)
+	}
+	if len(p.Subpaths) != 1 || len(p.Subpaths[0].Segments) != 2 {
+		t.Fatalf("Invalid subpaths %+v", p.Subpaths)
+	}
+	line, curve := p.Subpaths[0].Segments[0], p.Subpaths[0].Segments[1]
+	if line.IsCurve || line.P0.String() != "(20.0,20.0)" || line.P3.String() != "(100.0,20.0)" {
+		t.Errorf("Invalid line %+v", line)
+	}
+	if !curve.IsCurve || curve.P1.String() != "(100.0,60.0)" || curve.P2.String() != "(20.0,80.0)" ||
+		curve.P3.String() != "(20.0,20.0)" {
+		t.Errorf("Invalid curve %+v", curve)
+	}
+
+	p = paths[1]
+	if p.Stroke || !p.Fill || p.FillRule != FillRuleEvenOdd || rgbString(p.FillColor) != "0.5 0.5 0.5" {
+		t.Errorf("Invalid filled path %+v", p)
+	}
+	if len(p.Clip) != 1 || len(p.Clip[0].Subpaths) != 1 || !p.Clip[0].Subpaths[0].Closed {
+		t.Fatalf("Invalid clipping path %+v", p.Clip)
+	}
+	if s := p.Clip[0].Subpaths[0].Path().Points; len(s) != 4 || s[2].String() != "(150.0,150.0)" {
+		t.Errorf("Invalid clipping rectangle %v", s)
+	}
+
+	p = paths[2]
+	if !p.Stroke || !p.Fill || p.FillRule != FillRuleNonZero || len(p.Clip) != 0 {
+		t.Errorf("Invalid stroked and filled path %+v", p)
+	}
+	if rgbString(p.FillColor) != "0.0 1.0 0.0" || rgbString(p.StrokeColor) != "0.0 0.0 0.0" {
+		t.Errorf("Invalid colors %s %s", rgbString(p.FillColor), rgbString(p.StrokeColor))
+	}
+	if p.LineWidth != 4 || len(p.DashArray) != 1 || p.DashArray[0] != 2 || p.DashPhase != 1 {
+		t.Errorf("Invalid ExtGState line style %+v", p)
+	}
+	if sp := p.Subpaths[0]; !sp.Closed || sp.Segments[1].P2 != sp.Segments[1].P3 ||
+		rectString(p.BBox()) != "200.0 200.0 250.0 250.0" {
+		t.Errorf("Invalid closed subpath %+v", sp)
+	}
+
+	p = paths[3]
+	if rectString(p.BBox()) != "300.0 300.0 320.0 320.0" || len(p.Clip) != 1 {
+		t.Errorf("Invalid form path %s %+v", rectString(p.BBox()), p.Clip)
+	}
+	if s := p.Clip[0].Subpaths[0].Path().Points; s[2].String() != "(310.0,310.0)" {
+		t.Errorf("Invalid form clipping path %v", s)
+	}
+}
@@ -0,0 +1,255 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/unidoc/unidoc/pdf/annotator"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// SearchOptions are the options of a text search.
+type SearchOptions struct {
+	// CaseInsensitive makes letters match regardless of case.
+	CaseInsensitive bool
+	// Regexp makes the search pattern a regular expression (RE2 syntax, see package regexp) rather
+	// than plain text.
+	Regexp bool
+}
+
+// SearchHit is an occurrence of a search pattern in the text of a page.
+type SearchHit struct {
+	PageNum int    // Number of the page, starting from 1.  0 for hits of Extractor.Search.
+	Text    string // Matched text, with line breaks replaced by spaces and hyphenation removed.
+	// QuadPoints holds the quadrilaterals covering the matched text, one per line, as 8 values x1
+	// y1 x2 y2 x3 y3 x4 y4 each with the upper left, upper right, lower left and lower right corners
+	// in device space.  This is the format of the QuadPoints of text markup annotations.
+	QuadPoints []float64
+	BBox       model.PdfRectangle
+}
+
+// Search returns the occurrences of `pattern` in the text of the page, in reading order (see
+// ExtractLayout).  The lines of a block are searched as a single line of text, with the words of
+// hyphenated line breaks joined.  Runs of white space in plain text patterns match any white space.
+func (e *Extractor) Search(pattern string, opts SearchOptions) ([]*SearchHit, error) {
+	re, err := searchRegexp(pattern, opts)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := e.ExtractLayout()
+	if err != nil {
+		return nil, err
+	}
+	return newSearchText(layout).search(re), nil
+}
+
+// SearchDocument returns the occurrences of `pattern` in the pages of the document of `reader`
+// (see Extractor.Search).
+func SearchDocument(reader *model.PdfReader, pattern string, opts SearchOptions) ([]*SearchHit, error) {
+	re, err := searchRegexp(pattern, opts)
+	if err != nil {
+		return nil, err
+	}
+	numPages, err := reader.GetNumPages()
+	if err != nil {
+		return nil, err
+	}
+	hits := []*SearchHit{}
+	for pageNum := 1; pageNum <= numPages; pageNum++ {
+		page, err := reader.GetPage(pageNum)
+		if err != nil {
+			return nil, err
+		}
+		e, err := New(page)
+		if err != nil {
+			return nil, err
+		}
+		layout, err := e.ExtractLayout()
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range newSearchText(layout).search(re) {
+			hit.PageNum = pageNum
+			hits = append(hits, hit)
+		}
+	}
+	return hits, nil
+}
+
+// searchRegexp returns the regular expression matching `pattern` with `opts`.
+func searchRegexp(pattern string, opts SearchOptions) (*regexp.Regexp, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, errors.New("Empty search pattern")
+	}
+	expr := pattern
+	if !opts.Regexp {
+		words := strings.Fields(pattern)
+		for i, w := range words {
+			words[i] = regexp.QuoteMeta(w)
+		}
+		expr = strings.Join(words, `\s+`)
+	}
+	if opts.CaseInsensitive {
+		expr = "(?i)" + expr
+	}
+	return regexp.Compile(expr)
+}
+
+// HighlightAnnotation returns a highlight annotation, with an appearance stream, covering the text
+// of `hit`.  The color defaults to yellow if `color` is nil.  `opacity` is the alpha value (0-1),
+// 0 being fully transparent.
+func (hit *SearchHit) HighlightAnnotation(color *model.PdfColorDeviceRGB, opacity float64) (*model.PdfAnnotation, error) {
+	return annotator.CreateHighlightAnnotation(annotator.TextMarkupAnnotationDef{
+		QuadPoints: hit.QuadPoints,
+		Color:      color,
+		Opacity:    opacity,
+	})
+}
+
+// AddHighlights adds highlight annotations of `hits`, found in the document of `reader` by
+// SearchDocument, to the annotations of their pages (see SearchHit.HighlightAnnotation).
+func AddHighlights(reader *model.PdfReader, hits []*SearchHit, color *model.PdfColorDeviceRGB,
+	opacity float64) error {
+	for _, hit := range hits {
+		page, err := reader.GetPage(hit.PageNum)
+		if err != nil {
+			return err
+		}
+		annotation, err := hit.HighlightAnnotation(color, opacity)
+		if err != nil {
+			return err
+		}
+		page.Annotations = append(page.Annotations, annotation)
+	}
+	return nil
+}
+
+// searchText is the text of a page layout prepared for searching, with the glyph of each byte.
+type searchText struct {
+	text   strings.Builder
+	glyphs []*searchGlyph // Glyph of each byte of text, nil for the separators.
+}
+
+// searchGlyph is a glyph of the searched text with the line it belongs to.
+type searchGlyph struct {
+	XYGlyph
+	line *TextLine
+}
+
+// newSearchText returns the text of `layout` for searching.  The lines of a block are separated
+// by spaces, or joined without their trailing hyphen where a word is hyphenated, and the blocks are
+// separated by newlines.
+func newSearchText(layout *PageLayout) *searchText {
+	st := &searchText{}
+	for i, b := range layout.Blocks() {
+		if i > 0 {
+			st.add("\n", nil)
+		}
+		hyphenated := false
+		for j, line := range b.Lines {
+			if j > 0 && !hyphenated {
+				st.add(" ", nil)
+			}
+			hyphenated = j+1 < len(b.Lines) && isHyphenated(line, b.Lines[j+1])
+			for k, w := range line.Words {
+				if k > 0 {
+					st.add(" ", nil)
+				}
+				glyphs := w.Glyphs
+				if hyphenated && k == len(line.Words)-1 {
+					glyphs = glyphs[:len(glyphs)-1]
+				}
+				for _, g := range glyphs {
+					st.add(g.Text, &searchGlyph{XYGlyph: g, line: line})
+				}
+			}
+		}
+	}
+	return st
+}
+
+// add appends `s` shown by `g` to the text.
+func (st *searchText) add(s string, g *searchGlyph) {
+	st.text.WriteString(s)
+	for i := 0; i < len(s); i++ {
+		st.glyphs = append(st.glyphs, g)
+	}
+}
+
+// isHyphenated returns true if `line` ends with a word broken by a hyphen that `next` continues:
+// the last word ends with a hyphen following a letter, and the next line starts with a lower
+// case letter.
+func isHyphenated(line, next *TextLine) bool {
+	if len(line.Words) == 0 || len(next.Words) == 0 {
+		return false
+	}
+	word := line.Words[len(line.Words)-1].Text
+	if !strings.HasSuffix(word, "-") && !strings.HasSuffix(word, "­") && !strings.HasSuffix(word, "‐") {
+		return false
+	}
+	_, size := utf8.DecodeLastRuneInString(word)
+	before, _ := utf8.DecodeLastRuneInString(word[:len(word)-size])
+	after, _ := utf8.DecodeRuneInString(next.Words[0].Text)
+	return unicode.IsLetter(before) && unicode.IsLower(after)
+}
+
+// search returns the hits of `re` in the text.
+func (st *searchText) search(re *regexp.Regexp) []*SearchHit {
+	text := st.text.String()
+	hits := []*SearchHit{}
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		hit := &SearchHit{Text: text[loc[0]:loc[1]]}
+		// The glyphs of the hit, grouped by line.
+		var lineGlyphs []*searchGlyph
+		var prev *searchGlyph
+		for _, g := range st.glyphs[loc[0]:loc[1]] {
+			if g == nil || g == prev {
+				continue
+			}
+			if len(lineGlyphs) > 0 && lineGlyphs[0].line != g.line {
+				hit.addQuad(lineGlyphs)
+				lineGlyphs = nil
+			}
+			lineGlyphs = append(lineGlyphs, g)
+			prev = g
+		}
+		hit.addQuad(lineGlyphs)
+		if len(hit.QuadPoints) > 0 {
+			hits = append(hits, hit)
+		}
+	}
+	return hits
+}
+
+// addQuad adds the quadrilateral covering `glyphs`, of a line in reading order, to the hit.
+func (hit *SearchHit) addQuad(glyphs []*searchGlyph) {
+	if len(glyphs) == 0 {
+		return
+	}
+	first, last := glyphs[0], glyphs[len(glyphs)-1]
+	if len(first.Quad) != 8 || len(last.Quad) != 8 {
+		return
+	}
+	quad := []float64{
+		first.Quad[0], first.Quad[1], last.Quad[2], last.Quad[3],
+		first.Quad[4], first.Quad[5], last.Quad[6], last.Quad[7],
+	}
+	if len(hit.QuadPoints) == 0 {
+		hit.BBox = first.BBox
+	}
+	for _, g := range glyphs {
+		hit.BBox = unionRect(hit.BBox, g.BBox)
+	}
+	hit.QuadPoints = append(hit.QuadPoints, quad...)
+}
@@ -0,0 +1,94 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// A paragraph with a hyphenated word and words differing in case.
+const testSearchContents = `
+    BT /UniDocCourier 10 Tf
+    1 0 0 1 72 700 Tm (Searching for text in a docu-) Tj
+    1 0 0 1 72 688 Tm (ment is easy. Find the LAZY dog) Tj
+    1 0 0 1 72 676 Tm (and the lazy cat.) Tj
+    ET
+`
+
+// quadString returns a string describing the quadrilaterals `quads`.
+func quadString(quads []float64) string {
+	parts := []string{}
+	for _, v := range quads {
+		parts = append(parts, fmt.Sprintf("%.0f", v))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Test plain, case-insensitive and regular expression searches across line breaks.
+func TestSearch(t *testing.T) {
+	e := Extractor{contents: testSearchContents}
+	tests := []struct {
+		pattern string
+		opts    SearchOptions
+		hits    int
+	}{
+		{"lazy", SearchOptions{}, 1},
+		{"lazy", SearchOptions{CaseInsensitive: true}, 2},
+		{`l\w+ (dog|cat)`, SearchOptions{CaseInsensitive: true, Regexp: true}, 2},
+		{"docu-", SearchOptions{}, 0},
+		{"a.", SearchOptions{}, 0},
+	}
+	for _, test := range tests {
+		hits, err := e.Search(test.pattern, test.opts)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if len(hits) != test.hits {
+			t.Errorf("%q %+v: %d hits, expected %d", test.pattern, test.opts, len(hits), test.hits)
+		}
+	}
+
+	hits, err := e.Search("document  is", SearchOptions{})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(hits) != 1 {
+		t.Fatalf("Invalid number of hits %d", len(hits))
+	}
+	hit := hits[0]
+	if hit.Text != "document is" || hit.PageNum != 0 {
+		t.Errorf("Invalid hit %q on page %d", hit.Text, hit.PageNum)
+	}
+	if s := quadString(hit.QuadPoints); s != "216 708 240 708 216 698 240 698 72 696 114 696 72 686 114 686" {
+		t.Errorf("Invalid quads %s", s)
+	}
+	if rectString(hit.BBox) != "72.0 686.0 240.0 708.0" {
+		t.Errorf("Invalid hit bbox %s", rectString(hit.BBox))
+	}
+
+	hits, err = e.Search("dog and", SearchOptions{})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(hits) != 1 || len(hits[0].QuadPoints) != 16 {
+		t.Fatalf("Invalid hits %+v", hits)
+	}
+	annotation, err := hits[0].HighlightAnnotation(nil, 0.5)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if _, ok := annotation.GetContext().(*model.PdfAnnotationHighlight); !ok || annotation.AP == nil {
+		t.Errorf("Invalid highlight annotation %T", annotation.GetContext())
+	}
+
+	if _, err := e.Search(" ", SearchOptions{}); err == nil {
+		t.Errorf("Empty pattern should fail")
+	}
+}
@@ -0,0 +1,675 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Table detection thresholds.
+const (
+	// Distance in device space within which ruling lines are aligned or connected.
+	rulingTolerance = 2.0
+	// Maximum thickness of filled rectangles that are ruling lines.
+	maxRulingThickness = 3.0
+	// Horizontal gap between words that separates the columns of borderless tables, as a fraction
+	// of the font size.
+	tableColumnGapFactor = 1.0
+	// Vertical gap between the rows of borderless tables, as a fraction of the font size.
+	tableRowGapFactor = 1.5
+	// Minimum numbers of rows and columns of borderless tables.
+	minBorderlessRows    = 2
+	minBorderlessColumns = 3
+)
+
+// Table is a table of a page, as a grid of cells.
+type Table struct {
+	BBox  model.PdfRectangle // Device space bounding box.
+	Rows  int
+	Cols  int
+	Ruled bool // True if the table was detected from ruling lines, false if from text alignment.
+
+	// Cells of the table in row major order.  Cells spanning several rows or columns appear once.
+	Cells []*TableCell
+
+	grid [][]*TableCell
+}
+
+// TableCell is a cell of a Table.  The cell covers the rows Row to Row+RowSpan-1 and the columns
+// Col to Col+ColSpan-1 of the table.
+type TableCell struct {
+	Row     int
+	Col     int
+	RowSpan int
+	ColSpan int
+	BBox    model.PdfRectangle // Device space bounding box.
+	Text    string             // Lines of the cell separated by "\n".
+
+	words []*TextWord
+}
+
+// ExtractTables returns the tables of the page, from top to bottom.  Ruled tables are detected
+// from the horizontal and vertical ruling lines drawn by the page, stroked lines or thin filled
+// rectangles, and borderless tables from the alignment of the remaining text in columns.
+func (e *Extractor) ExtractTables() ([]*Table, error) {
+	textList, _, _, err := e.ExtractXYText()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := e.ExtractPaths()
+	if err != nil {
+		return nil, err
+	}
+	return findTables(textList.uprightWords(), makeRulings(paths)), nil
+}
+
+// Cell returns the cell covering row `row` and column `col` of the table, or nil if there is none.
+func (t *Table) Cell(row, col int) *TableCell {
+	if row < 0 || row >= t.Rows || col < 0 || col >= t.Cols {
+		return nil
+	}
+	return t.grid[row][col]
+}
+
+// Records returns the text of the cells of the table as rows of columns.  The text of a spanned
+// cell is in its first row and column, the other positions it covers are empty.
+func (t *Table) Records() [][]string {
+	records := make([][]string, t.Rows)
+	for row := range records {
+		records[row] = make([]string, t.Cols)
+		for col := range records[row] {
+			if cell := t.grid[row][col]; cell != nil && cell.Row == row && cell.Col == col {
+				records[row][col] = cell.Text
+			}
+		}
+	}
+	return records
+}
+
+// WriteCSV writes the table to `w` as CSV, one record per row (see Records).
+func (t *Table) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(t.Records()); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// WriteJSON writes the table to `w` as JSON.
+func (t *Table) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(t)
+}
+
+// jsonRect is a rectangle as the JSON array [llx, lly, urx, ury].
+func jsonRect(r model.PdfRectangle) [4]float64 {
+	return [4]float64{r.Llx, r.Lly, r.Urx, r.Ury}
+}
+
+// MarshalJSON returns the JSON encoding of the table: its bounding box, size, detection method and
+// cells.
+func (t *Table) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		BBox  [4]float64   `json:"bbox"`
+		Rows  int          `json:"rows"`
+		Cols  int          `json:"cols"`
+		Ruled bool         `json:"ruled"`
+		Cells []*TableCell `json:"cells"`
+	}{jsonRect(t.BBox), t.Rows, t.Cols, t.Ruled, t.Cells})
+}
+
+// MarshalJSON returns the JSON encoding of the cell.
+func (c *TableCell) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Row     int        `json:"row"`
+		Col     int        `json:"col"`
+		RowSpan int        `json:"row_span"`
+		ColSpan int        `json:"col_span"`
+		BBox    [4]float64 `json:"bbox"`
+		Text    string     `json:"text"`
+	}{c.Row, c.Col, c.RowSpan, c.ColSpan, jsonRect(c.BBox), c.Text})
+}
+
+// uprightWords returns the words of the upright text of `tl`.
+func (tl *TextList) uprightWords() []*TextWord {
+	for _, group := range tl.orientationGroups() {
+		if group.orientation == 0 {
+			return makeWords(group.glyphs)
+		}
+	}
+	return nil
+}
+
+// ruling is a horizontal or vertical ruling line, at `pos` on the y (horizontal) or x (vertical)
+// axis, from `lo` to `hi` on the other axis.
+type ruling struct {
+	horizontal bool
+	pos        float64
+	lo, hi     float64
+}
+
+// makeRulings returns the merged ruling lines of `paths`: the horizontal and vertical segments of
+// stroked paths, and the filled rectangles that are thin enough to be lines.
+func makeRulings(paths []*PaintedPath) []*ruling {
+	rulings := []*ruling{}
+	for _, path := range paths {
+		for _, sp := range path.Subpaths {
+			if path.Stroke {
+				for _, seg := range sp.Segments {
+					if r := segmentRuling(seg); r != nil {
+						rulings = append(rulings, r)
+					}
+				}
+				if sp.Closed {
+					p0, p1 := sp.currentPoint(), sp.Start
+					closing := draw.CubicBezierCurve{P0: p0, P1: p0, P2: p1, P3: p1}
+					if r := segmentRuling(PathSegment{CubicBezierCurve: closing}); r != nil {
+						rulings = append(rulings, r)
+					}
+				}
+			} else if r := rectRuling(sp); r != nil {
+				rulings = append(rulings, r)
+			}
+		}
+	}
+	return mergeRulings(rulings)
+}
+
+// segmentRuling returns the ruling of the segment from `a` to `b`, or nil if it is not a
+// horizontal or vertical line.
+func segmentRuling(seg PathSegment) *ruling {
+	if seg.IsCurve {
+		return nil
+	}
+	a, b := seg.P0, seg.P3
+	dx, dy := math.Abs(b.X-a.X), math.Abs(b.Y-a.Y)
+	switch {
+	case dy <= rulingTolerance/2 && dx > rulingTolerance:
+		return &ruling{horizontal: true, pos: (a.Y + b.Y) / 2, lo: math.Min(a.X, b.X), hi: math.Max(a.X, b.X)}
+	case dx <= rulingTolerance/2 && dy > rulingTolerance:
+		return &ruling{pos: (a.X + b.X) / 2, lo: math.Min(a.Y, b.Y), hi: math.Max(a.Y, b.Y)}
+	}
+	return nil
+}
+
+// rectRuling returns the ruling of the filled subpath `sp` if it is an axis aligned rectangle
+// thinner than maxRulingThickness, or nil otherwise.
+func rectRuling(sp *Subpath) *ruling {
+	for _, seg := range sp.Segments {
+		if seg.IsCurve {
+			return nil
+		}
+	}
+	points := sp.Path().Points
+	if n := len(points); n == 5 && points[4] == points[0] {
+		points = points[:4]
+	}
+	if len(points) != 4 {
+		return nil
+	}
+	box := model.PdfRectangle{Llx: math.Inf(1), Lly: math.Inf(1), Urx: math.Inf(-1), Ury: math.Inf(-1)}
+	for _, p := range points {
+		box = unionRect(box, model.PdfRectangle{Llx: p.X, Lly: p.Y, Urx: p.X, Ury: p.Y})
+	}
+	// Each point must be a corner of the bounding box.
+	for _, p := range points {
+		onX := math.Abs(p.X-box.Llx) < 0.1 || math.Abs(p.X-box.Urx) < 0.1
+		onY := math.Abs(p.Y-box.Lly) < 0.1 || math.Abs(p.Y-box.Ury) < 0.1
+		if !onX || !onY {
+			return nil
+		}
+	}
+	w, h := box.Width(), box.Height()
+	switch {
+	case h <= maxRulingThickness && w > h:
+		return &ruling{horizontal: true, pos: (box.Lly + box.Ury) / 2, lo: box.Llx, hi: box.Urx}
+	case w <= maxRulingThickness && h > w:
+		return &ruling{pos: (box.Llx + box.Urx) / 2, lo: box.Lly, hi: box.Ury}
+	}
+	return nil
+}
+
+// mergeRulings returns `rulings` with the collinear rulings that overlap or touch merged.
+func mergeRulings(rulings []*ruling) []*ruling {
+	sort.Slice(rulings, func(i, j int) bool {
+		a, b := rulings[i], rulings[j]
+		if a.horizontal != b.horizontal {
+			return a.horizontal
+		}
+		if a.pos != b.pos {
+			return a.pos < b.pos
+		}
+		return a.lo < b.lo
+	})
+	merged := []*ruling{}
+	for _, r := range rulings {
+		joined := false
+		for i := len(merged) - 1; i >= 0; i-- {
+			m := merged[i]
+			if m.horizontal != r.horizontal || r.pos-m.pos > rulingTolerance {
+				break
+			}
+			if r.lo <= m.hi+rulingTolerance && r.hi >= m.lo-rulingTolerance {
+				m.lo, m.hi = math.Min(m.lo, r.lo), math.Max(m.hi, r.hi)
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			c := *r
+			merged = append(merged, &c)
+		}
+	}
+	return merged
+}
+
+// crosses returns true if the horizontal ruling `h` and the vertical ruling `v` intersect or
+// touch.
+func crosses(h, v *ruling) bool {
+	return v.pos >= h.lo-rulingTolerance && v.pos <= h.hi+rulingTolerance &&
+		h.pos >= v.lo-rulingTolerance && h.pos <= v.hi+rulingTolerance
+}
+
+// covers returns true if the ruling `r` lies at `pos` and covers `at` on the other axis.
+func (r *ruling) covers(pos, at float64) bool {
+	return math.Abs(r.pos-pos) <= rulingTolerance && at >= r.lo && at <= r.hi
+}
+
+// findTables returns the ruled tables of `rulings` and the borderless tables of the `words`
+// outside them, sorted from top to bottom.
+func findTables(words []*TextWord, rulings []*ruling) []*Table {
+	tables := []*Table{}
+	for _, group := range rulingGroups(rulings) {
+		if t := newRuledTable(group); t != nil {
+			tables = append(tables, t)
+		}
+	}
+	remaining := []*TextWord{}
+	for _, w := range words {
+		if !placeWord(tables, w) {
+			remaining = append(remaining, w)
+		}
+	}
+	tables = append(tables, findBorderlessTables(remaining)...)
+	for _, t := range tables {
+		for _, cell := range t.Cells {
+			cell.Text = cellText(cell.words)
+		}
+	}
+	sort.SliceStable(tables, func(i, j int) bool { return tables[i].BBox.Ury > tables[j].BBox.Ury })
+	return tables
+}
+
+// rulingGroups returns the sets of connected horizontal and vertical rulings of `rulings`.
+func rulingGroups(rulings []*ruling) [][]*ruling {
+	parent := make([]int, len(rulings))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i, a := range rulings {
+		for j := i + 1; j < len(rulings); j++ {
+			b := rulings[j]
+			if a.horizontal == b.horizontal {
+				continue
+			}
+			h, v := a, b
+			if !h.horizontal {
+				h, v = b, a
+			}
+			if crosses(h, v) {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+	groups := [][]*ruling{}
+	index := map[int]int{}
+	for i, r := range rulings {
+		root := find(i)
+		k, ok := index[root]
+		if !ok {
+			k = len(groups)
+			index[root] = k
+			groups = append(groups, nil)
+		}
+		groups[k] = append(groups[k], r)
+	}
+	return groups
+}
+
+// clusterPositions returns the sorted positions of `rulings`, with the positions closer than
+// rulingTolerance merged.
+func clusterPositions(rulings []*ruling) []float64 {
+	positions := []float64{}
+	for _, r := range rulings {
+		positions = append(positions, r.pos)
+	}
+	sort.Float64s(positions)
+	clustered := []float64{}
+	for _, p := range positions {
+		if n := len(clustered); n > 0 && p-clustered[n-1] <= rulingTolerance {
+			continue
+		}
+		clustered = append(clustered, p)
+	}
+	return clustered
+}
+
+// newRuledTable returns the table of the connected rulings `group`, or nil if they don't make a
+// grid of at least two cells.  Neighbouring grid cells that are not separated by a ruling are
+// merged into spanned cells.
+func newRuledTable(group []*ruling) *Table {
+	var hs, vs []*ruling
+	for _, r := range group {
+		if r.horizontal {
+			hs = append(hs, r)
+		} else {
+			vs = append(vs, r)
+		}
+	}
+	if len(hs) < 2 || len(vs) < 2 {
+		return nil
+	}
+	xs := clusterPositions(vs)
+	ys := clusterPositions(hs)
+	// Rows are numbered from the top.
+	for i, j := 0, len(ys)-1; i < j; i, j = i+1, j-1 {
+		ys[i], ys[j] = ys[j], ys[i]
+	}
+	rows, cols := len(ys)-1, len(xs)-1
+	if rows < 1 || cols < 1 || rows*cols < 2 {
+		// A single cell is a frame, not a table.
+		return nil
+	}
+
+	// Whether grid cells are separated from their right and lower neighbours.
+	hasRuling := func(rulings []*ruling, pos, at float64) bool {
+		for _, r := range rulings {
+			if r.covers(pos, at) {
+				return true
+			}
+		}
+		return false
+	}
+	rightOpen := func(row, col int) bool {
+		return col+1 < cols && !hasRuling(vs, xs[col+1], (ys[row]+ys[row+1])/2)
+	}
+	belowOpen := func(row, col int) bool {
+		return row+1 < rows && !hasRuling(hs, ys[row+1], (xs[col]+xs[col+1])/2)
+	}
+
+	t := &Table{
+		BBox:  model.PdfRectangle{Llx: xs[0], Lly: ys[rows], Urx: xs[cols], Ury: ys[0]},
+		Rows:  rows,
+		Cols:  cols,
+		Ruled: true,
+		grid:  make([][]*TableCell, rows),
+	}
+	for row := range t.grid {
+		t.grid[row] = make([]*TableCell, cols)
+	}
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			if t.grid[row][col] != nil {
+				continue
+			}
+			// The spanned cell is the bounding range of the grid cells connected to (row, col).
+			cell := &TableCell{}
+			minRow, maxRow, minCol, maxCol := row, row, col, col
+			stack := [][2]int{{row, col}}
+			t.grid[row][col] = cell
+			for len(stack) > 0 {
+				r, c := stack[len(stack)-1][0], stack[len(stack)-1][1]
+				stack = stack[:len(stack)-1]
+				minRow, maxRow = minInt(minRow, r), maxInt(maxRow, r)
+				minCol, maxCol = minInt(minCol, c), maxInt(maxCol, c)
+				neighbours := [][2]int{}
+				if rightOpen(r, c) {
+					neighbours = append(neighbours, [2]int{r, c + 1})
+				}
+				if c > 0 && rightOpen(r, c-1) {
+					neighbours = append(neighbours, [2]int{r, c - 1})
+				}
+				if belowOpen(r, c) {
+					neighbours = append(neighbours, [2]int{r + 1, c})
+				}
+				if r > 0 && belowOpen(r-1, c) {
+					neighbours = append(neighbours, [2]int{r - 1, c})
+				}
+				for _, n := range neighbours {
+					if t.grid[n[0]][n[1]] == nil {
+						t.grid[n[0]][n[1]] = cell
+						stack = append(stack, n)
+					}
+				}
+			}
+			cell.Row, cell.RowSpan = minRow, maxRow-minRow+1
+			cell.Col, cell.ColSpan = minCol, maxCol-minCol+1
+			t.Cells = append(t.Cells, cell)
+		}
+	}
+	for _, cell := range t.Cells {
+		cell.BBox = model.PdfRectangle{
+			Llx: xs[cell.Col],
+			Lly: ys[cell.Row+cell.RowSpan],
+			Urx: xs[cell.Col+cell.ColSpan],
+			Ury: ys[cell.Row],
+		}
+	}
+	return t
+}
+
+// placeWord adds `w` to the cell of `tables` containing its center.  Returns false if there is
+// none.
+func placeWord(tables []*Table, w *TextWord) bool {
+	x, y := (w.BBox.Llx+w.BBox.Urx)/2, (w.BBox.Lly+w.BBox.Ury)/2
+	for _, t := range tables {
+		for _, cell := range t.Cells {
+			if x >= cell.BBox.Llx && x <= cell.BBox.Urx && y >= cell.BBox.Lly && y <= cell.BBox.Ury {
+				cell.words = append(cell.words, w)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cellText returns the text of the lines of `words`.
+func cellText(words []*TextWord) string {
+	lines := []string{}
+	for _, line := range makeLines(words) {
+		lines = append(lines, line.Text())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tableRow is a row of text of a borderless table candidate: the words of a line, grouped into
+// segments separated by column gaps.
+type tableRow struct {
+	segments [][]*TextWord
+	boxes    []model.PdfRectangle // Bounding boxes of the segments.
+	box      model.PdfRectangle
+	size     float64
+}
+
+// findBorderlessTables returns the tables made of consecutive rows of `words` whose segments are
+// aligned in columns.
+func findBorderlessTables(words []*TextWord) []*Table {
+	tables := []*Table{}
+	run := []*tableRow{}
+	flush := func() {
+		if t := newBorderlessTable(run); t != nil {
+			tables = append(tables, t)
+		}
+		run = nil
+	}
+	for _, row := range makeTableRows(words) {
+		if len(row.segments) < 2 {
+			flush()
+			continue
+		}
+		if n := len(run); n > 0 {
+			last := run[n-1]
+			if last.box.Lly-row.box.Ury > tableRowGapFactor*math.Max(last.size, row.size) {
+				flush()
+			}
+		}
+		run = append(run, row)
+	}
+	flush()
+	return tables
+}
+
+// makeTableRows returns the rows of `words` from top to bottom.  Unlike the lines of a layout, the
+// rows span the whole page.
+func makeTableRows(words []*TextWord) []*tableRow {
+	sorted := make([]*TextWord, len(words))
+	copy(sorted, words)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].BBox.Ury > sorted[j].BBox.Ury })
+
+	rows := []*tableRow{}
+	var line []*TextWord
+	var lineBox model.PdfRectangle
+	flush := func() {
+		if len(line) > 0 {
+			rows = append(rows, newTableRow(line))
+		}
+		line = nil
+	}
+	for _, w := range sorted {
+		if len(line) > 0 && !overlapsVertically(lineBox, w.BBox) {
+			flush()
+		}
+		if len(line) == 0 {
+			lineBox = w.BBox
+		} else {
+			lineBox = unionRect(lineBox, w.BBox)
+		}
+		line = append(line, w)
+	}
+	flush()
+	return rows
+}
+
+// newTableRow returns the row of the words `line`, split into segments at the column gaps.
+func newTableRow(line []*TextWord) *tableRow {
+	sort.SliceStable(line, func(i, j int) bool { return line[i].BBox.Llx < line[j].BBox.Llx })
+	row := &tableRow{box: line[0].BBox}
+	for i, w := range line {
+		row.size = math.Max(row.size, w.FontSize)
+		row.box = unionRect(row.box, w.BBox)
+		n := len(row.segments)
+		if i == 0 || w.BBox.Llx-row.boxes[n-1].Urx > tableColumnGapFactor*w.FontSize {
+			row.segments = append(row.segments, []*TextWord{w})
+			row.boxes = append(row.boxes, w.BBox)
+			continue
+		}
+		row.segments[n-1] = append(row.segments[n-1], w)
+		row.boxes[n-1] = unionRect(row.boxes[n-1], w.BBox)
+	}
+	return row
+}
+
+// newBorderlessTable returns the table of the rows `run`, or nil if they are too few or their
+// segments don't make enough columns.  The columns are the ranges of the projection of the
+// segments on the x axis.
+func newBorderlessTable(run []*tableRow) *Table {
+	if len(run) < minBorderlessRows {
+		return nil
+	}
+	type span struct{ lo, hi float64 }
+	spans := []span{}
+	for _, row := range run {
+		for _, box := range row.boxes {
+			spans = append(spans, span{box.Llx, box.Urx})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].lo < spans[j].lo })
+	columns := []span{spans[0]}
+	for _, s := range spans[1:] {
+		last := &columns[len(columns)-1]
+		if s.lo <= last.hi {
+			last.hi = math.Max(last.hi, s.hi)
+		} else {
+			columns = append(columns, s)
+		}
+	}
+	if len(columns) < minBorderlessColumns {
+		return nil
+	}
+
+	t := &Table{Rows: len(run), Cols: len(columns), grid: make([][]*TableCell, len(run))}
+	for i, row := range run {
+		t.grid[i] = make([]*TableCell, len(columns))
+		top, bottom := row.box.Ury, row.box.Lly
+		if i > 0 {
+			top = (run[i-1].box.Lly + row.box.Ury) / 2
+		}
+		if i+1 < len(run) {
+			bottom = (row.box.Lly + run[i+1].box.Ury) / 2
+		}
+		for col, c := range columns {
+			left, right := c.lo, c.hi
+			if col > 0 {
+				left = (columns[col-1].hi + c.lo) / 2
+			}
+			if col+1 < len(columns) {
+				right = (c.hi + columns[col+1].lo) / 2
+			}
+			cell := &TableCell{
+				Row:     i,
+				Col:     col,
+				RowSpan: 1,
+				ColSpan: 1,
+				BBox:    model.PdfRectangle{Llx: left, Lly: bottom, Urx: right, Ury: top},
+			}
+			t.grid[i][col] = cell
+			t.Cells = append(t.Cells, cell)
+		}
+		for s, box := range row.boxes {
+			for col, c := range columns {
+				if box.Llx >= c.lo && box.Urx <= c.hi {
+					t.grid[i][col].words = append(t.grid[i][col].words, row.segments[s]...)
+					break
+				}
+			}
+		}
+	}
+	t.BBox = model.PdfRectangle{
+		Llx: t.grid[0][0].BBox.Llx,
+		Lly: t.grid[t.Rows-1][0].BBox.Lly,
+		Urx: t.grid[0][t.Cols-1].BBox.Urx,
+		Ury: t.grid[0][0].BBox.Ury,
+	}
+	return t
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
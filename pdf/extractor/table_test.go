@@ -0,0 +1,109 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// A ruled table with a header cell spanning two columns and a cell spanning two rows, drawn with
+// stroked lines and a thin filled rectangle, a borderless table and a page frame.
+const testTableContents = `
+    0.5 w 20 20 572 752 re S
+    72 700 m 372 700 l S 72 680 m 372 680 l S 72 640 m 372 640 l S 72 660 m 272 660 l S
+    72 640 m 72 700 l S 172 640 m 172 700 l S 272 640 m 272 680 l S
+    371.5 640 1 60 re f
+    BT /UniDocCourier 10 Tf
+    1 0 0 1 75 686 Tm (Date) Tj 1 0 0 1 175 686 Tm (Description and amount) Tj
+    1 0 0 1 75 666 Tm (01/02) Tj 1 0 0 1 175 666 Tm (Coffee) Tj 1 0 0 1 275 656 Tm (3.50) Tj
+    1 0 0 1 75 646 Tm (01/03) Tj 1 0 0 1 175 646 Tm (Tea) Tj
+    1 0 0 1 72 500 Tm (Item) Tj 1 0 0 1 200 500 Tm (Qty) Tj 1 0 0 1 320 500 Tm (Price) Tj
+    1 0 0 1 72 486 Tm (Apple pie) Tj 1 0 0 1 200 486 Tm (2) Tj 1 0 0 1 320 486 Tm (3.00) Tj
+    1 0 0 1 72 472 Tm (Banana) Tj 1 0 0 1 200 472 Tm (10) Tj 1 0 0 1 320 472 Tm (0.25) Tj
+    1 0 0 1 72 440 Tm (Just a line of text) Tj
+    ET
+`
+
+// Test detecting ruled and borderless tables and exporting them.
+func TestExtractTables(t *testing.T) {
+	e := Extractor{contents: testTableContents}
+	tables, err := e.ExtractTables()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("Invalid number of tables %d", len(tables))
+	}
+
+	ruled := tables[0]
+	if !ruled.Ruled || ruled.Rows != 3 || ruled.Cols != 3 || rectString(ruled.BBox) != "72.0 640.0 372.0 700.0" {
+		t.Errorf("Invalid ruled table %d x %d %s", ruled.Rows, ruled.Cols, rectString(ruled.BBox))
+	}
+	expected := [][]string{
+		{"Date", "Description and amount", ""},
+		{"01/02", "Coffee", "3.50"},
+		{"01/03", "Tea", ""},
+	}
+	if records := ruled.Records(); !reflect.DeepEqual(records, expected) {
+		t.Errorf("Invalid ruled records %q", records)
+	}
+	if c := ruled.Cell(0, 2); c == nil || c.Col != 1 || c.ColSpan != 2 {
+		t.Errorf("Invalid header cell %+v", c)
+	}
+	if c := ruled.Cell(2, 2); c == nil || c.Row != 1 || c.RowSpan != 2 || rectString(c.BBox) != "272.0 640.0 372.0 680.0" {
+		t.Errorf("Invalid spanned cell %+v", c)
+	}
+	if len(ruled.Cells) != 7 {
+		t.Errorf("Invalid number of cells %d", len(ruled.Cells))
+	}
+
+	var buf bytes.Buffer
+	if err := ruled.WriteCSV(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if s := buf.String(); s != "Date,Description and amount,\n01/02,Coffee,3.50\n01/03,Tea,\n" {
+		t.Errorf("Invalid CSV %q", s)
+	}
+
+	borderless := tables[1]
+	expected = [][]string{
+		{"Item", "Qty", "Price"},
+		{"Apple pie", "2", "3.00"},
+		{"Banana", "10", "0.25"},
+	}
+	if records := borderless.Records(); borderless.Ruled || !reflect.DeepEqual(records, expected) {
+		t.Errorf("Invalid borderless records %q", records)
+	}
+
+	buf.Reset()
+	if err := borderless.WriteJSON(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	var decoded struct {
+		Rows  int
+		Cols  int
+		Ruled bool
+		Cells []struct {
+			Row     int
+			Col     int
+			RowSpan int `json:"row_span"`
+			BBox    []float64
+			Text    string
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if decoded.Rows != 3 || decoded.Cols != 3 || decoded.Ruled || len(decoded.Cells) != 9 {
+		t.Fatalf("Invalid JSON %s", buf.String())
+	}
+	if c := decoded.Cells[4]; c.Row != 1 || c.Col != 1 || c.RowSpan != 1 || c.Text != "2" || len(c.BBox) != 4 {
+		t.Errorf("Invalid JSON cell %+v", c)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/unidoc/unidoc/common"
@@ -41,17 +42,45 @@ func (e *Extractor) ExtractText2() (string, int, int, error) {
 }
 
 // ExtractXYText returns the text contents of `e` as a TextList.
+// The text of the form XObjects drawn by the page is included where the forms are drawn, and the
+// text of the annotation appearances follows the page text if enabled with SetIncludeAnnotations.
+// The spans are classified by visibility, and hidden spans can be excluded with
+// SetIncludeVisibility.  The text of marked-content sequences with an ActualText is replaced by a
+// single span of the ActualText, and artifacts can be excluded with SetIncludeArtifacts.
 func (e *Extractor) ExtractXYText() (*TextList, int, int, error) {
 	textList := &TextList{}
 	state := newTextState()
+	visited := map[*core.PdfObjectStream]bool{}
+
+	err := e.extractXYText(e.contents, e.resources, contentstream.IdentityMatrix(), nil, textList, &state,
+		visited)
+	if err == nil && e.includeAnnotations {
+		err = e.extractAnnotationText(textList, &state, visited)
+	}
+	state.setCovered(*textList)
+	*textList = replaceActualText(*textList)
+	*textList = e.filterVisibility(e.filterArtifacts(*textList))
+	return textList, state.numChars, state.numMisses, err
+}
+
+// extractXYText appends the text of the content stream `contents` using `resources` to `textList`.
+// `base` maps the user space of the content stream to the default user space of the page, `marked`
+// holds the marked-content sequences of the page contents drawing the content stream, outermost
+// first, and `visited` holds the form XObjects being extracted, to protect against cycles.
+func (e *Extractor) extractXYText(contents string, resources *model.PdfPageResources,
+	base contentstream.Matrix, marked []*MarkedContent, textList *TextList, state *textState,
+	visited map[*core.PdfObjectStream]bool) error {
 	fontStack := fontStacker{}
 	var to *textObject
+	// Enclosing marked-content sequences, outermost first.  The stack is copied on push as the
+	// texts keep it.
+	mcStack := marked
 
-	cstreamParser := contentstream.NewContentStreamParser(e.contents)
+	cstreamParser := contentstream.NewContentStreamParser(contents)
 	operations, err := cstreamParser.Parse()
 	if err != nil {
 		common.Log.Debug("ExtractXYText: parse failed. err=%v", err)
-		return textList, state.numChars, state.numMisses, err
+		return err
 	}
 
 	processor := contentstream.NewContentStreamProcessor(*operations)
@@ -61,6 +90,28 @@ func (e *Extractor) ExtractXYText() (*TextList, int, int, error) {
 			resources *model.PdfPageResources) error {
 
 			operand := op.Operand
+			switch operand {
+			case "BMC", "BDC":
+				mcStack = append(mcStack[:len(mcStack):len(mcStack)], newMarkedContent(op, resources))
+			case "EMC":
+				if len(mcStack) > len(marked) {
+					mcStack = mcStack[:len(mcStack)-1]
+				}
+			}
+			// Track the painted areas and the clipping path, in device space.
+			gsDevice := gs
+			gsDevice.CTM = gs.CTM.Mult(base)
+			numPaths := len(state.paths.paths)
+			state.paths.handleOp(op, gsDevice)
+			if len(state.paths.paths) > numPaths {
+				state.addPathArea(state.paths.paths[numPaths])
+			}
+
+			if to != nil {
+				// Keep the colors, CTM, text state and marked content of the operation.
+				to.gs = gs
+				to.marked = mcStack
+			}
 
 			switch operand {
 			case "q":
@@ -92,7 +143,9 @@ func (e *Extractor) ExtractXYText() (*TextList, int, int, error) {
 				if to != nil {
 					common.Log.Debug("BT called while in a text object")
 				}
-				to = newTextObject(e, gs, &state, &fontStack)
+				to = newTextObject(e, gs, state, &fontStack)
+				to.base = base
+				to.resources = resources
 			case "ET": // End Text
 				*textList = append(*textList, to.Texts...)
 				to = nil
@@ -125,6 +178,7 @@ func (e *Extractor) ExtractXYText() (*TextList, int, int, error) {
 					common.Log.Debug("ERROR: Tj op=%s GetStringBytes failed", op)
 					return core.ErrTypeError
 				}
+				to.setGlyphs(op)
 				return to.showText(charcodes)
 			case "TJ": // Show text with adjustable spacing
 				if ok, err := to.checkOp(op, 1, true); !ok {
@@ -136,6 +190,7 @@ func (e *Extractor) ExtractXYText() (*TextList, int, int, error) {
 					common.Log.Debug("ERROR: Tj op=%s GetArrayVal failed", op)
 					return err
 				}
+				to.setGlyphs(op)
 				return to.showTextAdjusted(args)
 			case "'": // Move to next line and show text
 				if ok, err := to.checkOp(op, 1, true); !ok {
@@ -148,6 +203,7 @@ func (e *Extractor) ExtractXYText() (*TextList, int, int, error) {
 					return core.ErrTypeError
 				}
 				to.nextLine()
+				to.setGlyphs(op)
 				return to.showText(charcodes)
 			case `"`: // Set word and character spacing, move to next line, and show text
 				if ok, err := to.checkOp(op, 1, true); !ok {
@@ -160,6 +216,7 @@ func (e *Extractor) ExtractXYText() (*TextList, int, int, error) {
 					return core.ErrTypeError
 				}
 				to.nextLine()
+				to.setGlyphs(op)
 				return to.showText(charcodes)
 			case "TL": // Set text leading
 				y, err := floatParam(op)
@@ -250,16 +307,35 @@ func (e *Extractor) ExtractXYText() (*TextList, int, int, error) {
 					return err
 				}
 				to.setHorizScaling(y)
+			case "BI": // Inline image
+				if len(op.Params) == 1 {
+					if inline, ok := op.Params[0].(*contentstream.ContentStreamInlineImage); ok {
+						isMask, _ := inline.IsMask()
+						state.addImageArea(gsDevice.CTM, !isMask)
+					}
+				}
+			case "Do": // Draw XObject
+				if len(op.Params) != 1 {
+					common.Log.Debug("ERROR: Do op=%s invalid params", op)
+					return nil
+				}
+				name, ok := core.GetNameVal(op.Params[0])
+				if !ok {
+					common.Log.Debug("ERROR: Do op=%s GetNameVal failed", op)
+					return nil
+				}
+				return e.extractXObjectText(core.PdfObjectName(name), resources, gs.CTM.Mult(base),
+					mcStack, textList, state, visited)
 			}
 
 			return nil
 		})
 
-	err = processor.Process(e.resources)
+	err = processor.Process(resources)
 	if err != nil {
 		common.Log.Error("ERROR: Processing: err=%v", err)
 	}
-	return textList, state.numChars, state.numMisses, err
+	return err
 }
 
 //
@@ -325,12 +401,12 @@ func (to *textObject) setTextMatrix(f []float64) {
 
 // showText "Tj" Show a text string
 func (to *textObject) showText(charcodes []byte) error {
-	return to.renderText(charcodes)
+	return to.renderText(charcodes, to.stringGlyphs(0))
 }
 
 // showTextAdjusted "TJ" Show text with adjustable spacing
 func (to *textObject) showTextAdjusted(args *core.PdfObjectArray) error {
-	for _, o := range args.Elements() {
+	for i, o := range args.Elements() {
 		switch o.(type) {
 		case *core.PdfObjectFloat, *core.PdfObjectInteger:
 			// Not implemented yet
@@ -345,7 +421,7 @@ func (to *textObject) showTextAdjusted(args *core.PdfObjectArray) error {
 				common.Log.Debug("ERROR: showTextAdjusted: GetStringBytes failed. args=%+v", args)
 				return core.ErrTypeError
 			}
-			err := to.renderText(charcodes)
+			err := to.renderText(charcodes, to.stringGlyphs(i))
 			if err != nil {
 				common.Log.Debug("showTextAdjusted: renderText failed. args=%+v err=%v", args, err)
 				return err
@@ -519,6 +595,10 @@ type textState struct {
 	// Tmode RenderMode     // Text rendering mode
 	// Trise float64        // Text rise. Unscaled text space units. Set by Ts
 	Tf *model.PdfFont // Text font
+
+	paths pathBuilder   // Clipping path and painted paths of the page, in device space.
+	areas []paintedArea // Areas painted opaque, in painting order.
+
 	// For debugging
 	numChars  int
 	numMisses int
@@ -546,6 +626,12 @@ type textObject struct {
 	// Tlm   contentstream.Matrix // Text line matrix. For the start of line pointer.
 	Texts []XYText // Text gets written here.
 
+	glyphs []contentstream.TextGlyph // Glyphs of the current text showing operation.
+	base   contentstream.Matrix      // Maps user space to the default user space of the page.
+	marked []*MarkedContent          // Marked-content sequences of the current operation.
+
+	resources *model.PdfPageResources // Resources of the content stream.
+
 	// These fields are used to implement existing UniDoc behaviour.
 	xPos, yPos float64
 }
@@ -561,6 +647,7 @@ func newTextObject(e *Extractor, gs contentstream.GraphicsState, state *textStat
 	fontStack *fontStacker) *textObject {
 	return &textObject{
 		e:         e,
+		base:      contentstream.IdentityMatrix(),
 		gs:        gs,
 		fontStack: fontStack,
 		State:     state,
@@ -571,11 +658,37 @@ func newTextObject(e *Extractor, gs contentstream.GraphicsState, state *textStat
 
 // renderRawText writes `text` directly to the extracted text
 func (to *textObject) renderRawText(text string) {
-	to.Texts = append(to.Texts, XYText{text})
+	to.Texts = append(to.Texts, XYText{Text: text})
+}
+
+// setGlyphs sets the glyphs of the text showing operation `op`, positioned with the graphics
+// state of the operation and the current font of the text object.
+func (to *textObject) setGlyphs(op *contentstream.ContentStreamOperation) {
+	gs := to.gs
+	gs.CTM = gs.CTM.Mult(to.base)
+	if font := to.fontStack.peek(); font != nil {
+		gs.Text.Font = font
+	}
+	glyphs, _, err := gs.TextGlyphs(op)
+	if err != nil {
+		common.Log.Debug("setGlyphs: %s failed. err=%v", op.Operand, err)
+	}
+	to.glyphs = glyphs
+}
+
+// stringGlyphs returns the glyphs of the `idx`'th operand of the current text showing operation.
+func (to *textObject) stringGlyphs(idx int) []contentstream.TextGlyph {
+	glyphs := []contentstream.TextGlyph{}
+	for _, g := range to.glyphs {
+		if g.StringIndex == idx {
+			glyphs = append(glyphs, g)
+		}
+	}
+	return glyphs
 }
 
-// renderText emits byte array `data` to the calling program
-func (to *textObject) renderText(data []byte) error {
+// renderText emits byte array `data`, shown as `glyphs`, to the calling program
+func (to *textObject) renderText(data []byte, glyphs []contentstream.TextGlyph) error {
 	text := ""
 	if len(*to.fontStack) == 0 {
 		common.Log.Debug("ERROR: No font defined. data=%#q", string(data))
@@ -588,14 +701,92 @@ func (to *textObject) renderText(data []byte) error {
 	to.State.numChars += numChars
 	to.State.numMisses += numMisses
 
-	to.Texts = append(to.Texts, XYText{text})
+	t := XYText{
+		Text:           text,
+		Font:           font,
+		FontName:       font.BaseFont(),
+		RenderMode:     RenderMode(to.gs.Text.RenderMode),
+		FillColorspace: to.gs.ColorspaceNonStroking,
+		FillColor:      to.gs.ColorNonStroking,
+		MCID:           markedContentID(to.marked),
+		MarkedContent:  to.marked,
+		shown:          true,
+	}
+	if t.FontName == "" {
+		t.FontName = to.gs.Text.FontName
+	}
+	for i, g := range glyphs {
+		glyphText, _, _ := font.CharcodeBytesToUnicode(g.Data)
+		glyph := XYGlyph{Text: glyphText, Code: g.Code, BBox: g.BBox(), Quad: g.Quad()}
+		t.Glyphs = append(t.Glyphs, glyph)
+		if i == 0 {
+			t.BBox = glyph.BBox
+			t.FontSize = g.Trm.ScalingFactorY()
+			t.Orientation = orientation(g.Trm)
+		} else {
+			t.BBox = unionRect(t.BBox, glyph.BBox)
+		}
+	}
+
+	t.Visibility = to.State.visibility(&t)
+	t.numAreas = len(to.State.areas)
+
+	to.Texts = append(to.Texts, t)
 	return nil
 }
 
-// XYText represents text and its position in device coordinates
+// RenderMode is the text rendering mode (9.3.6 Text Rendering Mode, Table 106 p. 246).
+type RenderMode int
+
+const (
+	RenderModeFill RenderMode = iota
+	RenderModeStroke
+	RenderModeFillStroke
+	RenderModeInvisible
+	RenderModeFillClip
+	RenderModeStrokeClip
+	RenderModeFillStrokeClip
+	RenderModeClip
+)
+
+// XYText represents text and its position in device coordinates.
+// The texts shown by the text showing operators are spans, the text of a string operand of Tj, TJ,
+// ' or ", with the glyphs of the string.  The other texts are the separators (spaces and newlines)
+// inserted between spans, which have no position or rendering fields.
 type XYText struct {
 	Text string
-	// Position and rendering fields. Not implemented yet
+
+	BBox           model.PdfRectangle // Device space bounding box.
+	Font           *model.PdfFont
+	FontName       string     // Base font name.
+	FontSize       float64    // Font size in device space units.
+	FillColorspace model.PdfColorspace
+	FillColor      model.PdfColor
+	RenderMode     RenderMode
+	Orientation    int // Direction of the text in device space: 0, 90, 180 or 270 degrees.
+	// MCID is the marked-content identifier of the innermost enclosing marked-content sequence with
+	// one, -1 if none.  Text of form XObjects has the identifier of the page contents drawing it.
+	MCID int
+	// MarkedContent holds the enclosing marked-content sequences, outermost first, including those
+	// of the page contents drawing a form XObject.
+	MarkedContent []*MarkedContent
+	// Visibility tells whether the span is visible or how it is hidden on the rendered page.
+	Visibility Visibility
+
+	Glyphs []XYGlyph
+
+	shown    bool
+	numAreas int // Number of areas painted before the span.
+}
+
+// XYGlyph represents a glyph of a text span and its position in device coordinates.
+type XYGlyph struct {
+	Text string // Text of the glyph, possibly empty or several characters.
+	Code uint16 // Character code.
+	BBox model.PdfRectangle
+	// Quad is the device space quadrilateral of the glyph as 8 values x1 y1 x2 y2 x3 y3 x4 y4 with
+	// the upper left, upper right, lower left and lower right corners (in the text direction).
+	Quad []float64
 }
 
 // String returns a string describing `t`
@@ -603,6 +794,29 @@ func (t *XYText) String() string {
 	return truncate(t.Text, 100)
 }
 
+// IsShown returns true if `t` is text shown by a text showing operator rather than a separator
+// inserted between spans.
+func (t *XYText) IsShown() bool {
+	return t.shown
+}
+
+// FillRGB returns the fill color of `t` as RGB components in the range 0-1.  The bool flag is
+// false if the color can't be converted, e.g. for pattern colors.
+func (t *XYText) FillRGB() (r, g, b float64, ok bool) {
+	if t.FillColorspace == nil || t.FillColor == nil {
+		return 0, 0, 0, false
+	}
+	color, err := t.FillColorspace.ColorToRGB(t.FillColor)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	rgb, isRGB := color.(*model.PdfColorDeviceRGB)
+	if !isRGB {
+		return 0, 0, 0, false
+	}
+	return rgb.R(), rgb.G(), rgb.B(), true
+}
+
 // TextList is a list of texts and their position on a pdf page
 type TextList []XYText
 
@@ -620,6 +834,47 @@ func (tl *TextList) ToText() string {
 	return buf.String()
 }
 
+// Spans returns the texts of `tl` shown by text showing operators, without the separators.
+func (tl *TextList) Spans() []XYText {
+	spans := []XYText{}
+	for _, t := range *tl {
+		if t.shown {
+			spans = append(spans, t)
+		}
+	}
+	return spans
+}
+
+// Glyphs returns the glyphs of the spans of `tl`, in content stream order.
+func (tl *TextList) Glyphs() []XYGlyph {
+	glyphs := []XYGlyph{}
+	for _, t := range *tl {
+		glyphs = append(glyphs, t.Glyphs...)
+	}
+	return glyphs
+}
+
+// orientation returns the direction of the x axis of the text rendering matrix `trm`, rounded to
+// a multiple of 90 degrees.
+func orientation(trm contentstream.Matrix) int {
+	angle := math.Atan2(trm[1], trm[0]) * 180 / math.Pi
+	o := int(math.Floor(angle/90+0.5)) * 90
+	if o < 0 {
+		o += 360
+	}
+	return o % 360
+}
+
+// unionRect returns the smallest rectangle containing `a` and `b`.
+func unionRect(a, b model.PdfRectangle) model.PdfRectangle {
+	return model.PdfRectangle{
+		Llx: math.Min(a.Llx, b.Llx),
+		Lly: math.Min(a.Lly, b.Lly),
+		Urx: math.Max(a.Urx, b.Urx),
+		Ury: math.Max(a.Ury, b.Ury),
+	}
+}
+
 // getFont returns the font named `name` if it exists in the page's resources or an error if it
 // doesn't.
 func (to *textObject) getFont(name string) (*model.PdfFont, error) {
@@ -640,11 +895,11 @@ func (to *textObject) getFont(name string) (*model.PdfFont, error) {
 	return font, err
 }
 
-// getFontDict returns the font object called `name` if it exists in the page's Font resources or
-// an error if it doesn't.
+// getFontDict returns the font object called `name` if it exists in the Font resources of the
+// content stream or an error if it doesn't.
 // XXX: TODO: Can we cache font values?
 func (to *textObject) getFontDict(name string) (fontObj core.PdfObject, err error) {
-	resources := to.e.resources
+	resources := to.resources
 	if resources == nil {
 		common.Log.Debug("getFontDict. No resources. name=%#q", name)
 		return nil, nil
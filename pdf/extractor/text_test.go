@@ -7,7 +7,10 @@ package extractor
 
 import (
 	"flag"
+	"fmt"
 	"testing"
+
+	"github.com/unidoc/unidoc/pdf/model"
 )
 
 func init() {
@@ -42,3 +45,72 @@ func TestTextExtraction1(t *testing.T) {
 		return
 	}
 }
+
+const testContents2 = `
+    BT
+    /UniDocCourier 10 Tf
+    1 0 0 1 100 200 Tm
+    (AB)Tj
+    ET
+    q
+    0 1 -1 0 300 400 cm
+    1 0 0 rg
+    3 Tr
+    BT
+    /UniDocCourier 20 Tf
+    [(C) -1000 (D)]TJ
+    ET
+    Q
+`
+
+// rectString returns a string describing `r` with rounded coordinates.
+func rectString(r model.PdfRectangle) string {
+	return fmt.Sprintf("%.1f %.1f %.1f %.1f", r.Llx, r.Lly, r.Urx, r.Ury)
+}
+
+// Test the positions and rendering fields of extracted spans and glyphs.
+func TestTextPositions(t *testing.T) {
+	e := Extractor{}
+	e.contents = testContents2
+
+	textList, _, _, err := e.ExtractXYText()
+	if err != nil {
+		t.Fatalf("Error extracting text: %v", err)
+	}
+	spans := textList.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("Invalid spans %v", spans)
+	}
+
+	span := spans[0]
+	if span.Text != "AB" || span.FontName != "Courier" || span.FontSize != 10 || span.Orientation != 0 {
+		t.Errorf("Invalid span %+v", span)
+	}
+	if s := rectString(span.BBox); s != "100.0 198.0 112.0 208.0" {
+		t.Errorf("Invalid span bbox %s", s)
+	}
+	if len(span.Glyphs) != 2 || span.Glyphs[1].Text != "B" || rectString(span.Glyphs[1].BBox) != "106.0 198.0 112.0 208.0" {
+		t.Errorf("Invalid glyphs %+v", span.Glyphs)
+	}
+	if r, g, b, ok := span.FillRGB(); !ok || r != 0 || g != 0 || b != 0 || span.RenderMode != RenderModeFill {
+		t.Errorf("Invalid rendering %v %v %v %v %d", r, g, b, ok, span.RenderMode)
+	}
+
+	// Rotated text, with a position adjustment of a font size between the strings.
+	span = spans[1]
+	if span.Text != "C" || span.FontSize != 20 || span.Orientation != 90 || span.RenderMode != RenderModeInvisible {
+		t.Errorf("Invalid rotated span %+v", span)
+	}
+	if s := rectString(span.BBox); s != "284.0 400.0 304.0 412.0" {
+		t.Errorf("Invalid rotated span bbox %s", s)
+	}
+	if s := rectString(spans[2].BBox); s != "284.0 432.0 304.0 444.0" {
+		t.Errorf("Invalid adjusted span bbox %s", s)
+	}
+	if r, g, b, ok := span.FillRGB(); !ok || r != 1 || g != 0 || b != 0 {
+		t.Errorf("Invalid fill color %v %v %v %v", r, g, b, ok)
+	}
+	if glyphs := textList.Glyphs(); len(glyphs) != 4 || glyphs[3].Text != "D" {
+		t.Errorf("Invalid glyphs %+v", glyphs)
+	}
+}
@@ -0,0 +1,201 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"math"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Visibility is the visibility of a text span on the rendered page.  A span hidden in several ways
+// has the first of VisibilityInvisible, VisibilityClipped, VisibilityCovered and
+// VisibilityBackground that applies.
+type Visibility int
+
+const (
+	// VisibilityVisible is for text that is painted and not hidden.
+	VisibilityVisible Visibility = iota
+	// VisibilityInvisible is for text that is neither filled nor stroked: rendering mode 3
+	// (invisible), used for the text layer of OCR'd scans, or 7 (add to clipping path).
+	VisibilityInvisible
+	// VisibilityClipped is for text whose center is outside the clipping path.
+	VisibilityClipped
+	// VisibilityCovered is for text covered by an opaque path fill or image painted after it.
+	VisibilityCovered
+	// VisibilityBackground is for text filled with the color of the path fill painted below it,
+	// or white where nothing is painted below it.
+	VisibilityBackground
+)
+
+// String returns a string describing `v`.
+func (v Visibility) String() string {
+	switch v {
+	case VisibilityVisible:
+		return "visible"
+	case VisibilityInvisible:
+		return "invisible"
+	case VisibilityClipped:
+		return "clipped"
+	case VisibilityCovered:
+		return "covered"
+	case VisibilityBackground:
+		return "background"
+	}
+	return "unknown"
+}
+
+// colorTolerance is the largest difference of the RGB components of text and background colors
+// considered to be the same color.
+const colorTolerance = 0.05
+
+// paintedArea is an area of the page painted opaque by a path fill or an image.
+type paintedArea struct {
+	bbox model.PdfRectangle
+	// color is the fill color of a path, nil for images and colors that can't be converted to RGB.
+	color *model.PdfColorDeviceRGB
+}
+
+// addPathArea adds the area filled by `path`, the last path painted, to the painted areas.
+func (state *textState) addPathArea(path *PaintedPath) {
+	if !path.Fill {
+		return
+	}
+	bbox, ok := intersectRect(path.BBox(), clipBBox(path.Clip))
+	if !ok {
+		return
+	}
+	state.areas = append(state.areas, paintedArea{bbox: bbox, color: path.FillColor})
+}
+
+// addImageArea adds the area of an image painted with `ctm` to the painted areas, if `opaque`.
+// Stencil masks and images with soft masks don't hide what is below them.
+func (state *textState) addImageArea(ctm contentstream.Matrix, opaque bool) {
+	if !opaque {
+		return
+	}
+	bbox := transformRect(ctm, model.PdfRectangle{Urx: 1, Ury: 1})
+	bbox, ok := intersectRect(bbox, clipBBox(state.paths.clip))
+	if !ok {
+		return
+	}
+	state.areas = append(state.areas, paintedArea{bbox: bbox})
+}
+
+// isOpaqueImage returns true if the image XObject `stream` paints its whole bounding box.
+func isOpaqueImage(stream *core.PdfObjectStream) bool {
+	if isMask, _ := core.GetBoolVal(stream.Get("ImageMask")); isMask {
+		return false
+	}
+	return stream.Get("SMask") == nil
+}
+
+// visibility returns the visibility of `t`, as far as it is known when `t` is shown: all but
+// VisibilityCovered, which is set by setCovered once the page is painted.
+func (state *textState) visibility(t *XYText) Visibility {
+	switch t.RenderMode {
+	case RenderModeInvisible, RenderModeClip:
+		return VisibilityInvisible
+	}
+	x, y := (t.BBox.Llx+t.BBox.Urx)/2, (t.BBox.Lly+t.BBox.Ury)/2
+	if len(state.paths.clip) > 0 && !rectContains(clipBBox(state.paths.clip), x, y) {
+		return VisibilityClipped
+	}
+	if t.RenderMode != RenderModeFill && t.RenderMode != RenderModeFillClip {
+		return VisibilityVisible
+	}
+	r, g, b, ok := t.FillRGB()
+	if !ok {
+		return VisibilityVisible
+	}
+	background := model.NewPdfColorDeviceRGB(1, 1, 1)
+	for i := len(state.areas) - 1; i >= 0; i-- {
+		if area := state.areas[i]; rectContains(area.bbox, x, y) {
+			if area.color == nil {
+				return VisibilityVisible
+			}
+			background = area.color
+			break
+		}
+	}
+	if math.Abs(r-background.R()) <= colorTolerance && math.Abs(g-background.G()) <= colorTolerance &&
+		math.Abs(b-background.B()) <= colorTolerance {
+		return VisibilityBackground
+	}
+	return VisibilityVisible
+}
+
+// setCovered sets the visibility of the spans of `textList` covered by the areas painted after
+// them to VisibilityCovered.
+func (state *textState) setCovered(textList TextList) {
+	for i := range textList {
+		t := &textList[i]
+		if !t.shown || t.Visibility == VisibilityInvisible || t.Visibility == VisibilityClipped {
+			continue
+		}
+		for _, area := range state.areas[t.numAreas:] {
+			if rectContains(area.bbox, t.BBox.Llx, t.BBox.Lly) && rectContains(area.bbox, t.BBox.Urx, t.BBox.Ury) {
+				t.Visibility = VisibilityCovered
+				break
+			}
+		}
+	}
+}
+
+// filterVisibility returns `textList` without the spans whose visibility isn't included by
+// `e` (see SetIncludeVisibility).
+func (e *Extractor) filterVisibility(textList TextList) TextList {
+	if len(e.excludedVisibility) == 0 {
+		return textList
+	}
+	filtered := TextList{}
+	for _, t := range textList {
+		if t.shown && e.excludedVisibility[t.Visibility] {
+			continue
+		}
+		filtered = append(filtered, t)
+	}
+	return filtered
+}
+
+// clipBBox returns the bounding box of the clipping path made of `clip`, which is infinite if
+// `clip` is empty.
+func clipBBox(clip []*ClipPath) model.PdfRectangle {
+	bbox := model.PdfRectangle{Llx: math.Inf(-1), Lly: math.Inf(-1), Urx: math.Inf(1), Ury: math.Inf(1)}
+	for _, c := range clip {
+		bbox, _ = intersectRect(bbox, c.BBox())
+	}
+	return bbox
+}
+
+// intersectRect returns the intersection of `a` and `b`, and false if it is empty.
+func intersectRect(a, b model.PdfRectangle) (model.PdfRectangle, bool) {
+	r := model.PdfRectangle{
+		Llx: math.Max(a.Llx, b.Llx),
+		Lly: math.Max(a.Lly, b.Lly),
+		Urx: math.Min(a.Urx, b.Urx),
+		Ury: math.Min(a.Ury, b.Ury),
+	}
+	return r, r.Llx <= r.Urx && r.Lly <= r.Ury
+}
+
+// rectContains returns true if the point (`x`, `y`) is inside `r`, allowing for rounding errors.
+func rectContains(r model.PdfRectangle, x, y float64) bool {
+	const tol = 1e-6
+	return r.Llx-tol <= x && x <= r.Urx+tol && r.Lly-tol <= y && y <= r.Ury+tol
+}
+
+// transformRect returns the bounding box of `r` transformed by `m`.
+func transformRect(m contentstream.Matrix, r model.PdfRectangle) model.PdfRectangle {
+	bbox := model.PdfRectangle{Llx: math.Inf(1), Lly: math.Inf(1), Urx: math.Inf(-1), Ury: math.Inf(-1)}
+	for _, p := range [][2]float64{{r.Llx, r.Lly}, {r.Urx, r.Lly}, {r.Llx, r.Ury}, {r.Urx, r.Ury}} {
+		x, y := m.Transform(p[0], p[1])
+		bbox = unionRect(bbox, model.PdfRectangle{Llx: x, Lly: y, Urx: x, Ury: y})
+	}
+	return bbox
+}
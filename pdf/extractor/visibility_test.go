@@ -0,0 +1,69 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"strings"
+	"testing"
+)
+
+// Text hidden in the different ways, each span on its own line.
+const testVisibilityContents = `
+    BT /UniDocCourier 10 Tf 1 0 0 1 72 700 Tm (Visible) Tj ET
+    BT /UniDocCourier 10 Tf 3 Tr 1 0 0 1 72 680 Tm (OCR layer) Tj 0 Tr ET
+    q 0 0 10 10 re W n BT /UniDocCourier 10 Tf 1 0 0 1 72 660 Tm (Clipped) Tj ET Q
+    BT /UniDocCourier 10 Tf 1 0 0 1 72 640 Tm (Covered) Tj ET
+    0 0 1 rg 70 630 200 30 re f
+    BT /UniDocCourier 10 Tf 1 g 1 0 0 1 72 600 Tm (White) Tj ET
+    0 g 70 570 200 30 re f
+    BT /UniDocCourier 10 Tf 1 0 0 1 72 580 Tm (Dark) Tj 1 0 0 rg 1 0 0 1 150 580 Tm (Red) Tj ET
+    0 g BT /UniDocCourier 10 Tf 1 0 0 1 72 520 Tm (Photo) Tj ET
+    q 200 0 0 30 70 510 cm BI /W 1 /H 1 /BPC 8 /CS /G /F /AHx ID 80> EI Q
+`
+
+// Test classifying text spans by visibility and excluding hidden text.
+func TestTextVisibility(t *testing.T) {
+	e := Extractor{contents: testVisibilityContents}
+	textList, _, _, err := e.ExtractXYText()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	expected := map[string]Visibility{
+		"Visible":   VisibilityVisible,
+		"OCR layer": VisibilityInvisible,
+		"Clipped":   VisibilityClipped,
+		"Covered":   VisibilityCovered,
+		"White":     VisibilityBackground,
+		"Dark":      VisibilityBackground,
+		"Red":       VisibilityVisible,
+		"Photo":     VisibilityCovered,
+	}
+	spans := textList.Spans()
+	if len(spans) != len(expected) {
+		t.Fatalf("Invalid number of spans %d", len(spans))
+	}
+	for _, span := range spans {
+		if v, ok := expected[span.Text]; !ok || span.Visibility != v {
+			t.Errorf("%q: visibility %s, expected %s", span.Text, span.Visibility, v)
+		}
+	}
+
+	e.SetIncludeVisibility(VisibilityInvisible, false)
+	e.SetIncludeVisibility(VisibilityBackground, false)
+	e.SetIncludeVisibility(VisibilityCovered, false)
+	e.SetIncludeVisibility(VisibilityCovered, true)
+	textList, _, _, err = e.ExtractXYText()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	texts := []string{}
+	for _, span := range textList.Spans() {
+		texts = append(texts, span.Text)
+	}
+	if s := strings.Join(texts, ","); s != "Visible,Clipped,Covered,Red,Photo" {
+		t.Errorf("Invalid visible spans %q", s)
+	}
+}
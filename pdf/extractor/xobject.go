@@ -0,0 +1,171 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Annotation flags of annotations that are not displayed (12.5.3 Table 165).
+const (
+	annotationFlagHidden = 1 << 1
+	annotationFlagNoView = 1 << 5
+)
+
+// extractXObjectText appends the text of the XObject `name` in `resources` to `textList`, if it is
+// a form XObject, or adds the area it paints to `state` if it is an image XObject.  `ctm` maps the user space of the form's parent to the default user space.
+func (e *Extractor) extractXObjectText(name core.PdfObjectName, resources *model.PdfPageResources,
+	ctm contentstream.Matrix, marked []*MarkedContent, textList *TextList, state *textState,
+	visited map[*core.PdfObjectStream]bool) error {
+	if resources == nil {
+		common.Log.Debug("extractXObjectText. No resources. name=%#q", name)
+		return nil
+	}
+	stream, xtype := resources.GetXObjectByName(name)
+	switch xtype {
+	case model.XObjectTypeImage:
+		state.addImageArea(ctm, isOpaqueImage(stream))
+		return nil
+	case model.XObjectTypeForm:
+	default:
+		return nil
+	}
+	return e.extractFormText(stream, resources, contentstream.IdentityMatrix(), ctm, marked, textList,
+		state, visited)
+}
+
+// extractFormText appends the text of the form XObject `stream` to `textList`.  The form matrix
+// is followed by `placement`, then by `ctm`.  The form is drawn with `parentResources` if it has
+// no resources of its own.
+func (e *Extractor) extractFormText(stream *core.PdfObjectStream, parentResources *model.PdfPageResources,
+	placement, ctm contentstream.Matrix, marked []*MarkedContent, textList *TextList, state *textState,
+	visited map[*core.PdfObjectStream]bool) error {
+	if visited[stream] {
+		common.Log.Debug("ERROR: extractFormText: form XObject loop")
+		return nil
+	}
+	visited[stream] = true
+	defer delete(visited, stream)
+
+	xform, err := model.NewXObjectFormFromStream(stream)
+	if err != nil {
+		common.Log.Debug("ERROR: extractFormText: invalid form XObject. err=%v", err)
+		return nil
+	}
+	contents, err := xform.GetContentStream()
+	if err != nil {
+		common.Log.Debug("ERROR: extractFormText: content stream. err=%v", err)
+		return nil
+	}
+	resources := xform.Resources
+	if resources == nil {
+		resources = parentResources
+	}
+	base := formMatrix(xform).Mult(placement).Mult(ctm)
+	saved := state.paths.beginForm(xform, base)
+	err = e.extractXYText(string(contents), resources, base, marked, textList, state, visited)
+	state.paths.endForm(saved)
+	return err
+}
+
+// extractAnnotationText appends the text of the normal appearances of the annotations of `e` to
+// `textList`, following the page text.
+func (e *Extractor) extractAnnotationText(textList *TextList, state *textState,
+	visited map[*core.PdfObjectStream]bool) error {
+	for _, annot := range e.annotations {
+		if flags, ok := core.GetIntVal(annot.F); ok && flags&(annotationFlagHidden|annotationFlagNoView) != 0 {
+			continue
+		}
+		stream := normalAppearance(annot)
+		if stream == nil {
+			continue
+		}
+		placement, ok := appearancePlacement(annot, stream)
+		if !ok {
+			continue
+		}
+		*textList = append(*textList, XYText{Text: "\n"})
+		err := e.extractFormText(stream, e.resources, contentstream.IdentityMatrix(), placement, nil,
+			textList, state, visited)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalAppearance returns the normal appearance stream of `annot` for its appearance state, or
+// nil if it has none.
+func normalAppearance(annot *model.PdfAnnotation) *core.PdfObjectStream {
+	ap, ok := core.GetDict(annot.AP)
+	if !ok {
+		return nil
+	}
+	switch t := core.TraceToDirectObject(ap.Get("N")).(type) {
+	case *core.PdfObjectStream:
+		return t
+	case *core.PdfObjectDictionary:
+		state, ok := core.GetNameVal(annot.AS)
+		if !ok {
+			return nil
+		}
+		stream, _ := core.TraceToDirectObject(t.Get(core.PdfObjectName(state))).(*core.PdfObjectStream)
+		return stream
+	}
+	return nil
+}
+
+// appearancePlacement returns the matrix mapping the appearance `stream` of `annot`, transformed
+// by its form matrix, onto the annotation rectangle (12.5.5 Appearance Streams p. 395).
+func appearancePlacement(annot *model.PdfAnnotation, stream *core.PdfObjectStream) (contentstream.Matrix, bool) {
+	rectArr, ok := core.GetArray(annot.Rect)
+	if !ok {
+		return contentstream.Matrix{}, false
+	}
+	rect, err := model.NewPdfRectangle(*rectArr)
+	if err != nil {
+		return contentstream.Matrix{}, false
+	}
+	xform, err := model.NewXObjectFormFromStream(stream)
+	if err != nil {
+		return contentstream.Matrix{}, false
+	}
+	bboxArr, ok := core.GetArray(xform.BBox)
+	if !ok {
+		return contentstream.Matrix{}, false
+	}
+	bbox, err := model.NewPdfRectangle(*bboxArr)
+	if err != nil {
+		return contentstream.Matrix{}, false
+	}
+
+	// Bounding box of the transformed form bounding box.
+	box := transformRect(formMatrix(xform), *bbox)
+	if box.Width() == 0 || box.Height() == 0 {
+		return contentstream.Matrix{}, false
+	}
+	a := contentstream.TranslationMatrix(-box.Llx, -box.Lly).
+		Mult(contentstream.ScaleMatrix(rect.Width()/box.Width(), rect.Height()/box.Height())).
+		Mult(contentstream.TranslationMatrix(rect.Llx, rect.Lly))
+	return a, true
+}
+
+// formMatrix returns the form matrix of `xform`, mapping form space to the user space of its
+// parent.
+func formMatrix(xform *model.XObjectForm) contentstream.Matrix {
+	arr, ok := core.GetArray(xform.Matrix)
+	if !ok {
+		return contentstream.IdentityMatrix()
+	}
+	vals, err := core.GetNumbersAsFloat(arr.Elements())
+	if err != nil || len(vals) != 6 {
+		return contentstream.IdentityMatrix()
+	}
+	return contentstream.NewMatrix(vals[0], vals[1], vals[2], vals[3], vals[4], vals[5])
+}
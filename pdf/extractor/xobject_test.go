@@ -0,0 +1,91 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// makeForm returns a form XObject with `contents`, `bbox`, `matrix` and `resources`.
+func makeForm(t *testing.T, contents string, bbox []float64, matrix []float64,
+	resources *model.PdfPageResources) *model.XObjectForm {
+	xform := model.NewXObjectForm()
+	xform.BBox = core.MakeArrayFromFloats(bbox)
+	if matrix != nil {
+		xform.Matrix = core.MakeArrayFromFloats(matrix)
+	}
+	xform.Resources = resources
+	if err := xform.SetContentStream([]byte(contents), core.NewRawEncoder()); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return xform
+}
+
+// Test extracting text of form XObjects, including nested and recursive forms, and of annotation
+// appearances.
+func TestFormAndAnnotationText(t *testing.T) {
+	footerResources := model.NewPdfPageResources()
+	footer := makeForm(t, "BT /UniDocCourier 10 Tf (Footer) Tj ET /Fm2 Do",
+		[]float64{0, 0, 100, 20}, []float64{1, 0, 0, 1, 0, 10}, footerResources)
+	// The footer draws itself.
+	if err := footerResources.SetXObjectFormByName("Fm2", footer); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	header := makeForm(t, "BT /UniDocCourier 10 Tf 1 0 0 1 0 700 Tm (Header) Tj ET /Fm2 Do",
+		[]float64{0, 0, 612, 792}, []float64{1, 0, 0, 1, 50, 0}, footerResources)
+
+	resources := model.NewPdfPageResources()
+	if err := resources.SetXObjectFormByName("Fm1", header); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	note := makeForm(t, "BT /UniDocCourier 10 Tf 2 5 Td (Note) Tj ET", []float64{0, 0, 100, 20}, nil, nil)
+	annot := model.NewPdfAnnotationFreeText()
+	annot.Rect = core.MakeArrayFromFloats([]float64{200, 300, 400, 340})
+	ap := core.MakeDict()
+	ap.Set("N", note.ToPdfObject())
+	annot.AP = ap
+	hidden := model.NewPdfAnnotationFreeText()
+	hidden.Rect = annot.Rect
+	hidden.AP = ap
+	hidden.F = core.MakeInteger(annotationFlagHidden)
+
+	e := Extractor{
+		contents:    "q 1 0 0 1 0 -100 cm /Fm1 Do Q BT /UniDocCourier 10 Tf 1 0 0 1 72 72 Tm (Body) Tj ET",
+		resources:   resources,
+		annotations: []*model.PdfAnnotation{annot.PdfAnnotation, hidden.PdfAnnotation},
+	}
+	textList, _, _, err := e.ExtractXYText()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	spans := textList.Spans()
+	if len(spans) != 3 || spans[0].Text != "Header" || spans[1].Text != "Footer" || spans[2].Text != "Body" {
+		t.Fatalf("Invalid spans %v", spans)
+	}
+	if s := rectString(spans[0].BBox); s != "50.0 598.0 86.0 608.0" {
+		t.Errorf("Invalid header bbox %s", s)
+	}
+	if s := rectString(spans[1].BBox); s != "50.0 -92.0 86.0 -82.0" {
+		t.Errorf("Invalid footer bbox %s", s)
+	}
+
+	e.SetIncludeAnnotations(true)
+	textList, _, _, err = e.ExtractXYText()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	spans = textList.Spans()
+	if len(spans) != 4 || spans[3].Text != "Note" {
+		t.Fatalf("Invalid spans with annotations %v", spans)
+	}
+	if s := rectString(spans[3].BBox); s != "204.0 306.0 252.0 326.0" || spans[3].FontSize != 20 {
+		t.Errorf("Invalid annotation text bbox %s size %v", s, spans[3].FontSize)
+	}
+}
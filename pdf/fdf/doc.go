@@ -0,0 +1,12 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+// Package fdf provides support for importing and exporting interactive form data in the
+// Forms Data Format (FDF, section 12.7.7) and its XML counterpart XFDF.
+//
+// Exported data can be loaded back into a document's AcroForm through PdfAcroForm.Fill or
+// PdfAcroForm.FillWithAppearance, as Data implements model.FieldValueProvider.
+// Fields are identified by their fully qualified names.
+package fdf
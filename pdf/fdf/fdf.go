@@ -0,0 +1,326 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package fdf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Data represents form field data, i.e. a set of values keyed by fully qualified field names.
+// Implements model.FieldValueProvider.
+type Data struct {
+	names  []string
+	values map[string]core.PdfObject
+}
+
+// NewData returns a new empty Data instance.
+func NewData() *Data {
+	return &Data{values: map[string]core.PdfObject{}}
+}
+
+// NewFromAcroForm returns the field values of the terminal fields of `form`.
+// Signature fields, fields flagged as NoExport and fields without a value are skipped.
+func NewFromAcroForm(form *model.PdfAcroForm) (*Data, error) {
+	data := NewData()
+	if form == nil {
+		return data, nil
+	}
+
+	for _, field := range form.TerminalFields() {
+		if field.GetFieldType() == "Sig" || field.GetFieldFlags().Has(model.FieldFlagNoExport) {
+			continue
+		}
+		val := core.TraceToDirectObject(field.GetValue())
+		if val == nil || core.IsNullObject(val) {
+			continue
+		}
+		name, err := field.FullName()
+		if err != nil {
+			return nil, err
+		}
+		data.SetValue(name, val)
+	}
+	return data, nil
+}
+
+// SetValue sets the value of the field with fully qualified name `name`.
+func (d *Data) SetValue(name string, val core.PdfObject) {
+	if _, has := d.values[name]; !has {
+		d.names = append(d.names, name)
+	}
+	d.values[name] = val
+}
+
+// GetValue returns the value of the field with fully qualified name `name`.
+// The bool flag indicates whether the value was found.
+func (d *Data) GetValue(name string) (core.PdfObject, bool) {
+	val, has := d.values[name]
+	return val, has
+}
+
+// FieldNames returns the fully qualified names of the fields in the order they were added.
+func (d *Data) FieldNames() []string {
+	return append([]string{}, d.names...)
+}
+
+// FieldValues returns the field values keyed by fully qualified field names.
+func (d *Data) FieldValues() (map[string]core.PdfObject, error) {
+	values := make(map[string]core.PdfObject, len(d.values))
+	for name, val := range d.values {
+		values[name] = val
+	}
+	return values, nil
+}
+
+var reFdfVersion = regexp.MustCompile(`%FDF-(\d)\.(\d)`)
+var reIndirectObject = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj`)
+var reTrailer = regexp.MustCompile(`trailer\s*<<`)
+
+// LoadFromPath loads FDF data from the file at `path`.
+func LoadFromPath(path string) (*Data, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Load loads FDF data from `r`.
+func Load(r io.Reader) (*Data, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !reFdfVersion.Match(content) {
+		return nil, errors.New("Missing FDF header")
+	}
+
+	// Parse all indirect objects.  FDF files commonly lack a cross reference table, so the objects
+	// are located by scanning for their signatures.
+	objects := map[int64]core.PdfObject{}
+	for _, loc := range reIndirectObject.FindAllIndex(content, -1) {
+		parser := core.NewParserFromString(string(content[loc[0]:]))
+		obj, err := parser.ParseIndirectObject()
+		if err != nil {
+			common.Log.Debug("FDF: Unable to parse object at %d: %v", loc[0], err)
+			continue
+		}
+		switch t := obj.(type) {
+		case *core.PdfIndirectObject:
+			objects[t.ObjectNumber] = t
+		case *core.PdfObjectStream:
+			objects[t.ObjectNumber] = t
+		}
+	}
+
+	resolver := &objectResolver{objects: objects}
+
+	// Locate the catalog via the trailer, otherwise look for an object with an FDF entry.
+	var catalog *core.PdfObjectDictionary
+	if locs := reTrailer.FindAllIndex(content, -1); len(locs) > 0 {
+		loc := locs[len(locs)-1]
+		parser := core.NewParserFromString(string(content[loc[1]-2:]))
+		trailer, err := parser.ParseDict()
+		if err == nil {
+			catalog, _ = core.GetDict(resolver.resolve(trailer.Get("Root")))
+		}
+	}
+	if catalog == nil {
+		nums := make([]int64, 0, len(objects))
+		for num := range objects {
+			nums = append(nums, num)
+		}
+		sort.Slice(nums, func(i, j int) bool { return nums[i] < nums[j] })
+		for _, num := range nums {
+			if dict, ok := core.GetDict(objects[num]); ok && dict.Get("FDF") != nil {
+				catalog = dict
+				break
+			}
+		}
+	}
+	if catalog == nil {
+		return nil, errors.New("FDF catalog not found")
+	}
+
+	fdfDict, ok := core.GetDict(resolver.resolve(catalog.Get("FDF")))
+	if !ok {
+		return nil, errors.New("Missing FDF dictionary")
+	}
+
+	data := NewData()
+	fields, ok := core.GetArray(resolver.resolve(fdfDict.Get("Fields")))
+	if !ok {
+		// No fields.
+		return data, nil
+	}
+	err = data.loadFields(fields, "", resolver, 0)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Maximum depth of the FDF field hierarchy.
+const maxFieldDepth = 32
+
+// loadFields loads the field values of the FDF field dictionaries in `fields` with names prefixed by `prefix`.
+func (d *Data) loadFields(fields *core.PdfObjectArray, prefix string, resolver *objectResolver, depth int) error {
+	if depth > maxFieldDepth {
+		return errors.New("FDF field hierarchy too deep")
+	}
+
+	for _, obj := range fields.Elements() {
+		dict, ok := core.GetDict(resolver.resolve(obj))
+		if !ok {
+			common.Log.Debug("FDF: Field not a dictionary (%T)", obj)
+			continue
+		}
+
+		name := prefix
+		if str, ok := core.GetString(resolver.resolve(dict.Get("T"))); ok {
+			if len(name) > 0 {
+				name += "."
+			}
+			name += str.Decoded()
+		}
+
+		if kids, ok := core.GetArray(resolver.resolve(dict.Get("Kids"))); ok {
+			err := d.loadFields(kids, name, resolver, depth+1)
+			if err != nil {
+				return err
+			}
+		}
+
+		if val := dict.Get("V"); val != nil && len(name) > 0 {
+			d.SetValue(name, resolver.resolveAll(val, 0))
+		}
+	}
+	return nil
+}
+
+// objectResolver resolves references to the indirect objects of an FDF file.
+type objectResolver struct {
+	objects map[int64]core.PdfObject
+}
+
+// resolve returns the direct object referred to by `obj`.
+func (r *objectResolver) resolve(obj core.PdfObject) core.PdfObject {
+	for i := 0; i < core.TraceMaxDepth; i++ {
+		ref, isRef := obj.(*core.PdfObjectReference)
+		if !isRef {
+			break
+		}
+		obj = r.objects[ref.ObjectNumber]
+	}
+	return core.TraceToDirectObject(obj)
+}
+
+// resolveAll returns `obj` with all references within arrays and dictionaries resolved.
+func (r *objectResolver) resolveAll(obj core.PdfObject, depth int) core.PdfObject {
+	obj = r.resolve(obj)
+	if depth > core.TraceMaxDepth {
+		return obj
+	}
+	switch t := obj.(type) {
+	case *core.PdfObjectArray:
+		arr := core.MakeArray()
+		for _, elem := range t.Elements() {
+			arr.Append(r.resolveAll(elem, depth+1))
+		}
+		return arr
+	case *core.PdfObjectDictionary:
+		dict := core.MakeDict()
+		for _, key := range t.Keys() {
+			dict.Set(key, r.resolveAll(t.Get(key), depth+1))
+		}
+		return dict
+	}
+	return obj
+}
+
+// fieldNode is a node in the field hierarchy used for output.
+type fieldNode struct {
+	name  string
+	value core.PdfObject
+	kids  []*fieldNode
+}
+
+// child returns the child node with partial name `name`, creating it if it does not exist.
+func (node *fieldNode) child(name string) *fieldNode {
+	for _, kid := range node.kids {
+		if kid.name == name {
+			return kid
+		}
+	}
+	kid := &fieldNode{name: name}
+	node.kids = append(node.kids, kid)
+	return kid
+}
+
+// fieldTree builds the field hierarchy from the fully qualified field names.
+func (d *Data) fieldTree() *fieldNode {
+	root := &fieldNode{}
+	for _, name := range d.names {
+		node := root
+		for _, part := range strings.Split(name, ".") {
+			node = node.child(part)
+		}
+		node.value = d.values[name]
+	}
+	return root
+}
+
+// toPdfObject returns the FDF field dictionary representing the node.
+func (node *fieldNode) toPdfObject() *core.PdfObjectDictionary {
+	dict := core.MakeDict()
+	dict.Set("T", core.MakeEncodedString(node.name))
+	if len(node.kids) > 0 {
+		kids := core.MakeArray()
+		for _, kid := range node.kids {
+			kids.Append(kid.toPdfObject())
+		}
+		dict.Set("Kids", kids)
+	}
+	dict.SetIfNotNil("V", node.value)
+	return dict
+}
+
+// WriteFDF writes the field data to `w` in FDF format.
+func (d *Data) WriteFDF(w io.Writer) error {
+	fields := core.MakeArray()
+	for _, node := range d.fieldTree().kids {
+		fields.Append(node.toPdfObject())
+	}
+	fdfDict := core.MakeDict()
+	fdfDict.Set("Fields", fields)
+	catalog := core.MakeDict()
+	catalog.Set("FDF", fdfDict)
+
+	var buf bytes.Buffer
+	buf.WriteString("%FDF-1.2\n")
+	buf.WriteString("%\xe2\xe3\xcf\xd3\n")
+	buf.WriteString("1 0 obj\n")
+	buf.WriteString(catalog.DefaultWriteString())
+	buf.WriteString("\nendobj\n")
+	buf.WriteString("trailer\n")
+	buf.WriteString("<</Root 1 0 R>>\n")
+	buf.WriteString("%%EOF\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
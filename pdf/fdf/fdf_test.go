@@ -0,0 +1,167 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package fdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+func TestFDFRoundTrip(t *testing.T) {
+	data := NewData()
+	data.SetValue("name", core.MakeEncodedString("Jöhn Smíth"))
+	data.SetValue("address.street", core.MakeString("Main Street 1"))
+	data.SetValue("address.city", core.MakeEncodedString("Zürich ✓"))
+	data.SetValue("agree", core.MakeName("Yes"))
+
+	var buf bytes.Buffer
+	err := data.WriteFDF(&buf)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := map[string]string{
+		"name":           "Jöhn Smíth",
+		"address.street": "Main Street 1",
+		"address.city":   "Zürich ✓",
+	}
+	for name, text := range expected {
+		val, has := loaded.GetValue(name)
+		if !has {
+			t.Fatalf("Missing field %s", name)
+		}
+		str, ok := core.GetString(val)
+		if !ok {
+			t.Fatalf("Field %s not a string (%T)", name, val)
+		}
+		if str.Decoded() != text {
+			t.Errorf("Field %s: %q != %q", name, str.Decoded(), text)
+		}
+	}
+	val, _ := loaded.GetValue("agree")
+	if name, ok := core.GetNameVal(val); !ok || name != "Yes" {
+		t.Errorf("Incorrect agree value: %v", val)
+	}
+}
+
+func TestFDFLoad(t *testing.T) {
+	fdfData := `%FDF-1.2
+1 0 obj
+<< /FDF << /Fields [ << /T (person) /Kids [ << /T (first) /V (Ann) >> << /T (last) /V 2 0 R >> ] >> ] >> >>
+endobj
+2 0 obj
+(Jones)
+endobj
+trailer
+<< /Root 1 0 R >>
+%%EOF
+`
+	data, err := Load(strings.NewReader(fdfData))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	names := data.FieldNames()
+	if len(names) != 2 || names[0] != "person.first" || names[1] != "person.last" {
+		t.Fatalf("Incorrect field names: %v", names)
+	}
+	val, _ := data.GetValue("person.last")
+	if str, ok := core.GetString(val); !ok || str.Str() != "Jones" {
+		t.Errorf("Incorrect value: %v", val)
+	}
+}
+
+func TestXFDFRoundTrip(t *testing.T) {
+	data := NewData()
+	data.SetValue("a.b", core.MakeEncodedString("Hello & <world>"))
+	data.SetValue("list", core.MakeArray(core.MakeString("one"), core.MakeString("two")))
+
+	var buf bytes.Buffer
+	err := data.WriteXFDF(&buf)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !strings.Contains(buf.String(), xfdfNamespace) {
+		t.Errorf("Missing XFDF namespace")
+	}
+
+	loaded, err := LoadXFDF(&buf)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	val, has := loaded.GetValue("a.b")
+	if !has {
+		t.Fatalf("Missing field a.b")
+	}
+	if str, ok := core.GetString(val); !ok || str.Decoded() != "Hello & <world>" {
+		t.Errorf("Incorrect value: %v", val)
+	}
+	val, _ = loaded.GetValue("list")
+	arr, ok := core.GetArray(val)
+	if !ok || arr.Len() != 2 {
+		t.Errorf("Incorrect list value: %v", val)
+	}
+}
+
+// Test exporting and filling a form with non-ASCII field names, stored as PDFDocEncoding and
+// UTF-16BE text strings.
+func TestNonASCIIFieldNames(t *testing.T) {
+	parent := model.NewPdfField()
+	parent.T = core.MakeEncodedString("Größe")
+	field := model.NewPdfField()
+	field.Parent = parent
+	field.FT = core.MakeName("Tx")
+	field.T = core.MakeEncodedString("Maß ✓")
+	field.V = core.MakeString("42")
+	parent.KidsF = append(parent.KidsF, field)
+
+	form := model.NewPdfAcroForm()
+	form.Fields = &[]*model.PdfField{parent}
+
+	data, err := NewFromAcroForm(form)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	const name = "Größe.Maß ✓"
+	if names := data.FieldNames(); len(names) != 1 || names[0] != name {
+		t.Fatalf("Invalid field names %q", names)
+	}
+
+	var buf bytes.Buffer
+	if err := data.WriteFDF(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	buf.Reset()
+	if err := loaded.WriteXFDF(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if loaded, err = LoadXFDF(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if _, has := loaded.GetValue(name); !has {
+		t.Fatalf("Missing field %s", name)
+	}
+
+	loaded.SetValue(name, core.MakeString("43"))
+	if err := form.Fill(loaded); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if str, ok := core.GetStringVal(field.V); !ok || str != "43" {
+		t.Errorf("Field not filled: %v", field.V)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package fdf
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/unidoc/unidoc/pdf/core"
+)
+
+// XFDF namespace (XML Forms Data Format Specification version 3.0).
+const xfdfNamespace = "http://ns.adobe.com/xfdf/"
+
+// xfdfDocument represents the root element of an XFDF document.
+type xfdfDocument struct {
+	XMLName   xml.Name     `xml:"xfdf"`
+	Namespace string       `xml:"xmlns,attr,omitempty"`
+	Space     string       `xml:"xml:space,attr,omitempty"`
+	Fields    []*xfdfField `xml:"fields>field"`
+}
+
+// xfdfField represents a field element of an XFDF document.  Fields can be nested, mirroring the
+// form field hierarchy.
+type xfdfField struct {
+	Name   string       `xml:"name,attr"`
+	Values []string     `xml:"value"`
+	Fields []*xfdfField `xml:"field"`
+}
+
+// LoadXFDF loads field data in XFDF format from `r`.
+func LoadXFDF(r io.Reader) (*Data, error) {
+	doc := xfdfDocument{}
+	err := xml.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	data := NewData()
+	var load func(fields []*xfdfField, prefix string)
+	load = func(fields []*xfdfField, prefix string) {
+		for _, field := range fields {
+			name := field.Name
+			if len(prefix) > 0 {
+				name = prefix + "." + name
+			}
+			load(field.Fields, name)
+
+			switch len(field.Values) {
+			case 0:
+				continue
+			case 1:
+				data.SetValue(name, core.MakeEncodedString(field.Values[0]))
+			default:
+				arr := core.MakeArray()
+				for _, val := range field.Values {
+					arr.Append(core.MakeEncodedString(val))
+				}
+				data.SetValue(name, arr)
+			}
+		}
+	}
+	load(doc.Fields, "")
+
+	return data, nil
+}
+
+// toXfdfField returns the XFDF field element representing the node.
+func (node *fieldNode) toXfdfField() *xfdfField {
+	field := &xfdfField{Name: node.name}
+	for _, kid := range node.kids {
+		field.Fields = append(field.Fields, kid.toXfdfField())
+	}
+	if node.value != nil {
+		field.Values = xfdfValues(node.value)
+	}
+	return field
+}
+
+// xfdfValues returns the XFDF text values representing the field value `val`.
+func xfdfValues(val core.PdfObject) []string {
+	switch t := core.TraceToDirectObject(val).(type) {
+	case *core.PdfObjectString:
+		return []string{t.Decoded()}
+	case *core.PdfObjectName:
+		return []string{string(*t)}
+	case *core.PdfObjectArray:
+		values := []string{}
+		for _, elem := range t.Elements() {
+			values = append(values, xfdfValues(elem)...)
+		}
+		return values
+	case *core.PdfObjectInteger, *core.PdfObjectFloat, *core.PdfObjectBool:
+		return []string{t.DefaultWriteString()}
+	}
+	return nil
+}
+
+// WriteXFDF writes the field data to `w` in XFDF format.
+func (d *Data) WriteXFDF(w io.Writer) error {
+	doc := xfdfDocument{Namespace: xfdfNamespace, Space: "preserve"}
+	for _, node := range d.fieldTree().kids {
+		doc.Fields = append(doc.Fields, node.toXfdfField())
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(doc)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
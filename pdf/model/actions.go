@@ -0,0 +1,596 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfActionType is the type of an action (S entry of the action dictionary, 12.6.4 Table 198).
+type PdfActionType string
+
+const (
+	ActionTypeGoTo        PdfActionType = "GoTo"
+	ActionTypeGoToR       PdfActionType = "GoToR"
+	ActionTypeGoToE       PdfActionType = "GoToE"
+	ActionTypeLaunch      PdfActionType = "Launch"
+	ActionTypeThread      PdfActionType = "Thread"
+	ActionTypeURI         PdfActionType = "URI"
+	ActionTypeSound       PdfActionType = "Sound"
+	ActionTypeMovie       PdfActionType = "Movie"
+	ActionTypeHide        PdfActionType = "Hide"
+	ActionTypeNamed       PdfActionType = "Named"
+	ActionTypeSubmitForm  PdfActionType = "SubmitForm"
+	ActionTypeResetForm   PdfActionType = "ResetForm"
+	ActionTypeImportData  PdfActionType = "ImportData"
+	ActionTypeJavaScript  PdfActionType = "JavaScript"
+	ActionTypeSetOCGState PdfActionType = "SetOCGState"
+	ActionTypeRendition   PdfActionType = "Rendition"
+	ActionTypeTrans       PdfActionType = "Trans"
+	ActionTypeGoTo3DView  PdfActionType = "GoTo3DView"
+)
+
+// PdfAction represents an action (12.6 Actions p. 414).  The entries specific to the action type
+// are held by the context, e.g. a *PdfActionURI for URI actions.  Actions of other types keep the
+// entries of the loaded dictionary.
+type PdfAction struct {
+	S PdfActionType
+
+	// Next holds the actions performed after this action, in order.
+	Next []*PdfAction
+
+	// invalidNext holds the Next actions that could not be loaded.  They are dropped from Next.
+	invalidNext []PdfObject
+
+	context   pdfActionContext
+	container *PdfIndirectObject
+}
+
+// pdfActionContext is implemented by the action types to write their specific entries.
+type pdfActionContext interface {
+	PdfModel
+	appendToPdfDictionary(d *PdfObjectDictionary)
+}
+
+// PdfActionGoTo represents a GoTo action, going to a destination in the current document.
+type PdfActionGoTo struct {
+	*PdfAction
+	D PdfObject // Explicit destination array, or name of a named destination.
+}
+
+// PdfActionGoToR represents a GoToR action, going to a destination in another document.
+type PdfActionGoToR struct {
+	*PdfAction
+	F         PdfObject // File specification.
+	D         PdfObject // Destination (the page is a page number) or named destination.
+	NewWindow *bool
+}
+
+// PdfActionLaunch represents a Launch action, launching an application or opening a document.
+type PdfActionLaunch struct {
+	*PdfAction
+	F         PdfObject // File specification.
+	Win       PdfObject // Windows specific launch parameters.
+	Mac       PdfObject
+	Unix      PdfObject
+	NewWindow *bool
+}
+
+// PdfActionURI represents a URI action, resolving a uniform resource identifier.
+type PdfActionURI struct {
+	*PdfAction
+	URI   string
+	IsMap bool
+}
+
+// PdfActionNamed represents a Named action, e.g. NextPage.
+type PdfActionNamed struct {
+	*PdfAction
+	N string
+}
+
+// PdfActionJavaScript represents a JavaScript action.
+type PdfActionJavaScript struct {
+	*PdfAction
+	JS string // Script, decoded if loaded from a stream.
+}
+
+// PdfActionSubmitForm represents a SubmitForm action, sending form field values to a URL.
+type PdfActionSubmitForm struct {
+	*PdfAction
+	F      PdfObject // URL file specification.
+	Fields PdfObject // Fields to submit or exclude, all fields if nil.
+	Flags  int64
+}
+
+// PdfActionResetForm represents a ResetForm action, resetting form fields to their defaults.
+type PdfActionResetForm struct {
+	*PdfAction
+	Fields PdfObject // Fields to reset or exclude, all fields if nil.
+	Flags  int64
+}
+
+// pdfActionOther holds the entries of actions of types without a specific model.
+type pdfActionOther struct {
+	*PdfAction
+	dict *PdfObjectDictionary
+}
+
+// newPdfAction returns an action of type `s` with the specific entries of `context`.
+func newPdfAction(s PdfActionType, context pdfActionContext) *PdfAction {
+	return &PdfAction{S: s, context: context, container: MakeIndirectObject(MakeDict())}
+}
+
+// NewPdfActionGoTo returns a GoTo action to the explicit destination `dest`.
+func NewPdfActionGoTo(dest *PdfDestination) *PdfActionGoTo {
+	return NewPdfActionGoToObject(dest.ToPdfObject())
+}
+
+// NewPdfActionGoToNamed returns a GoTo action to the named destination `name`.
+func NewPdfActionGoToNamed(name string) *PdfActionGoTo {
+	return NewPdfActionGoToObject(MakeString(name))
+}
+
+// NewPdfActionGoToObject returns a GoTo action to the destination object `d`, an explicit
+// destination array or the name of a named destination.
+func NewPdfActionGoToObject(d PdfObject) *PdfActionGoTo {
+	goTo := &PdfActionGoTo{D: d}
+	goTo.PdfAction = newPdfAction(ActionTypeGoTo, goTo)
+	return goTo
+}
+
+// NewPdfActionGoToR returns a GoToR action to the destination `dest` of the document `file`.  The
+// page of `dest` is a page number, starting from 0.
+func NewPdfActionGoToR(file string, dest *PdfDestination) *PdfActionGoToR {
+	goToR := &PdfActionGoToR{F: MakeString(file), D: dest.ToPdfObject()}
+	goToR.PdfAction = newPdfAction(ActionTypeGoToR, goToR)
+	return goToR
+}
+
+// NewPdfActionLaunch returns a Launch action opening `file`.
+func NewPdfActionLaunch(file string) *PdfActionLaunch {
+	launch := &PdfActionLaunch{F: MakeString(file)}
+	launch.PdfAction = newPdfAction(ActionTypeLaunch, launch)
+	return launch
+}
+
+// NewPdfActionURI returns a URI action resolving `uri`.
+func NewPdfActionURI(uri string) *PdfActionURI {
+	action := &PdfActionURI{URI: uri}
+	action.PdfAction = newPdfAction(ActionTypeURI, action)
+	return action
+}
+
+// NewPdfActionNamed returns a Named action performing `name`, e.g. "NextPage", "PrevPage",
+// "FirstPage" or "LastPage".
+func NewPdfActionNamed(name string) *PdfActionNamed {
+	named := &PdfActionNamed{N: name}
+	named.PdfAction = newPdfAction(ActionTypeNamed, named)
+	return named
+}
+
+// NewPdfActionJavaScript returns a JavaScript action executing `js`.
+func NewPdfActionJavaScript(js string) *PdfActionJavaScript {
+	action := &PdfActionJavaScript{JS: js}
+	action.PdfAction = newPdfAction(ActionTypeJavaScript, action)
+	return action
+}
+
+// NewPdfActionSubmitForm returns a SubmitForm action submitting all fields to `url`.
+func NewPdfActionSubmitForm(url string) *PdfActionSubmitForm {
+	fs := MakeDict()
+	fs.Set("FS", MakeName("URL"))
+	fs.Set("F", MakeString(url))
+	submit := &PdfActionSubmitForm{F: fs}
+	submit.PdfAction = newPdfAction(ActionTypeSubmitForm, submit)
+	return submit
+}
+
+// NewPdfActionResetForm returns a ResetForm action resetting all fields.
+func NewPdfActionResetForm() *PdfActionResetForm {
+	reset := &PdfActionResetForm{}
+	reset.PdfAction = newPdfAction(ActionTypeResetForm, reset)
+	return reset
+}
+
+// NewPdfActionFromObject loads the action dictionary `obj`, including the actions of its Next
+// chain.  Actions occurring more than once in the chain are only loaded once and shared.  Next
+// actions leading back to an action of their own chain are dropped.
+func NewPdfActionFromObject(obj PdfObject) (*PdfAction, error) {
+	return newPdfActionFromObject(obj, map[PdfObject]*PdfAction{}, map[PdfObject]bool{})
+}
+
+// newPdfActionFromObject loads the action `obj`.  `loaded` maps the action dictionaries loaded so
+// far to their actions, `path` holds the action dictionaries of the chain leading to `obj`.
+func newPdfActionFromObject(obj PdfObject, loaded map[PdfObject]*PdfAction, path map[PdfObject]bool) (*PdfAction, error) {
+	container, isIndirect := obj.(*PdfIndirectObject)
+	dict, ok := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !ok {
+		return nil, errors.New("Action not a dictionary")
+	}
+	if path[dict] {
+		return nil, errors.New("Action chain loop")
+	}
+	if action, has := loaded[dict]; has {
+		return action, nil
+	}
+	path[dict] = true
+	defer delete(path, dict)
+	if !isIndirect {
+		container = MakeIndirectObject(dict)
+	}
+
+	s, ok := GetNameVal(dict.Get("S"))
+	if !ok {
+		return nil, errors.New("Action type missing")
+	}
+	action := &PdfAction{S: PdfActionType(s), container: container}
+	newWindow := func() *bool {
+		if b, ok := GetBoolVal(dict.Get("NewWindow")); ok {
+			return &b
+		}
+		return nil
+	}
+
+	switch action.S {
+	case ActionTypeGoTo:
+		action.context = &PdfActionGoTo{PdfAction: action, D: dict.Get("D")}
+	case ActionTypeGoToR:
+		action.context = &PdfActionGoToR{PdfAction: action, F: dict.Get("F"), D: dict.Get("D"),
+			NewWindow: newWindow()}
+	case ActionTypeLaunch:
+		action.context = &PdfActionLaunch{PdfAction: action, F: dict.Get("F"), Win: dict.Get("Win"),
+			Mac: dict.Get("Mac"), Unix: dict.Get("Unix"), NewWindow: newWindow()}
+	case ActionTypeURI:
+		uri, _ := GetStringVal(dict.Get("URI"))
+		isMap, _ := GetBoolVal(dict.Get("IsMap"))
+		action.context = &PdfActionURI{PdfAction: action, URI: uri, IsMap: isMap}
+	case ActionTypeNamed:
+		n, _ := GetNameVal(dict.Get("N"))
+		action.context = &PdfActionNamed{PdfAction: action, N: n}
+	case ActionTypeJavaScript:
+		js, err := loadJavaScript(dict.Get("JS"))
+		if err != nil {
+			return nil, err
+		}
+		action.context = &PdfActionJavaScript{PdfAction: action, JS: js}
+	case ActionTypeSubmitForm:
+		flags, _ := GetIntVal(dict.Get("Flags"))
+		action.context = &PdfActionSubmitForm{PdfAction: action, F: dict.Get("F"),
+			Fields: dict.Get("Fields"), Flags: int64(flags)}
+	case ActionTypeResetForm:
+		flags, _ := GetIntVal(dict.Get("Flags"))
+		action.context = &PdfActionResetForm{PdfAction: action, Fields: dict.Get("Fields"),
+			Flags: int64(flags)}
+	default:
+		other := MakeDict()
+		other.Merge(dict)
+		action.context = &pdfActionOther{PdfAction: action, dict: other}
+	}
+
+	loaded[dict] = action
+
+	next := TraceToDirectObject(dict.Get("Next"))
+	nextObjs := []PdfObject{}
+	switch t := next.(type) {
+	case *PdfObjectDictionary:
+		nextObjs = append(nextObjs, dict.Get("Next"))
+	case *PdfObjectArray:
+		nextObjs = append(nextObjs, t.Elements()...)
+	}
+	for _, obj := range nextObjs {
+		n, err := newPdfActionFromObject(obj, loaded, path)
+		if err != nil {
+			common.Log.Debug("Invalid next action: %v", err)
+			action.invalidNext = append(action.invalidNext, obj)
+			continue
+		}
+		action.Next = append(action.Next, n)
+	}
+	return action, nil
+}
+
+// loadJavaScript returns the script `obj`, a text string or a stream.
+func loadJavaScript(obj PdfObject) (string, error) {
+	obj = TraceToDirectObject(obj)
+	if stream, ok := obj.(*PdfObjectStream); ok {
+		data, err := DecodeStream(stream)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	js, _ := GetStringVal(obj)
+	return js, nil
+}
+
+// GetContext returns the model of the type specific entries, e.g. a *PdfActionURI.
+func (a *PdfAction) GetContext() PdfModel {
+	return a.context
+}
+
+// GetContainingPdfObject implements interface PdfModel.
+func (a *PdfAction) GetContainingPdfObject() PdfObject {
+	return a.container
+}
+
+// ToPdfObject implements interface PdfModel.  Returns the action dictionary in its indirect
+// object, with the type specific entries and the Next chain.
+func (a *PdfAction) ToPdfObject() PdfObject {
+	d := MakeDict()
+	if other, ok := a.context.(*pdfActionOther); ok {
+		d.Merge(other.dict)
+		d.Remove("Next")
+	}
+	d.Set("Type", MakeName("Action"))
+	d.Set("S", MakeName(string(a.S)))
+	if a.context != nil {
+		a.context.appendToPdfDictionary(d)
+	}
+	switch len(a.Next) {
+	case 0:
+	case 1:
+		d.Set("Next", a.Next[0].ToPdfObject())
+	default:
+		next := MakeArray()
+		for _, n := range a.Next {
+			next.Append(n.ToPdfObject())
+		}
+		d.Set("Next", next)
+	}
+	a.container.PdfObject = d
+	return a.container
+}
+
+// Append adds `actions` to the Next chain of the action and returns the action.
+func (a *PdfAction) Append(actions ...*PdfAction) *PdfAction {
+	a.Next = append(a.Next, actions...)
+	return a
+}
+
+// Walk calls `fn` for the action and the actions of its Next chain, depth first.  Actions shared
+// in the chain are visited once.
+func (a *PdfAction) Walk(fn func(action *PdfAction)) {
+	a.walk(fn, map[*PdfAction]bool{})
+}
+
+func (a *PdfAction) walk(fn func(action *PdfAction), visited map[*PdfAction]bool) {
+	if visited[a] {
+		return
+	}
+	visited[a] = true
+	fn(a)
+	for _, n := range a.Next {
+		n.walk(fn, visited)
+	}
+}
+
+func (a *PdfActionGoTo) appendToPdfDictionary(d *PdfObjectDictionary) {
+	d.SetIfNotNil("D", a.D)
+}
+
+// GetDestination returns the explicit destination of the action.  Returns nil if the action goes
+// to a named destination.
+func (a *PdfActionGoTo) GetDestination() (*PdfDestination, error) {
+	if _, isArray := TraceToDirectObject(a.D).(*PdfObjectArray); !isArray {
+		return nil, nil
+	}
+	return NewPdfDestinationFromObject(a.D)
+}
+
+// GetNamedDestination returns the named destination of the action.  The bool flag indicates
+// whether the action goes to a named destination.
+func (a *PdfActionGoTo) GetNamedDestination() (string, bool) {
+	switch t := TraceToDirectObject(a.D).(type) {
+	case *PdfObjectString:
+		return t.Str(), true
+	case *PdfObjectName:
+		return string(*t), true
+	}
+	return "", false
+}
+
+func (a *PdfActionGoToR) appendToPdfDictionary(d *PdfObjectDictionary) {
+	d.SetIfNotNil("F", a.F)
+	d.SetIfNotNil("D", a.D)
+	if a.NewWindow != nil {
+		d.Set("NewWindow", MakeBool(*a.NewWindow))
+	}
+}
+
+func (a *PdfActionLaunch) appendToPdfDictionary(d *PdfObjectDictionary) {
+	d.SetIfNotNil("F", a.F)
+	d.SetIfNotNil("Win", a.Win)
+	d.SetIfNotNil("Mac", a.Mac)
+	d.SetIfNotNil("Unix", a.Unix)
+	if a.NewWindow != nil {
+		d.Set("NewWindow", MakeBool(*a.NewWindow))
+	}
+}
+
+func (a *PdfActionURI) appendToPdfDictionary(d *PdfObjectDictionary) {
+	d.Set("URI", MakeString(a.URI))
+	if a.IsMap {
+		d.Set("IsMap", MakeBool(true))
+	}
+}
+
+func (a *PdfActionNamed) appendToPdfDictionary(d *PdfObjectDictionary) {
+	d.Set("N", MakeName(a.N))
+}
+
+func (a *PdfActionJavaScript) appendToPdfDictionary(d *PdfObjectDictionary) {
+	d.Set("JS", MakeString(a.JS))
+}
+
+func (a *PdfActionSubmitForm) appendToPdfDictionary(d *PdfObjectDictionary) {
+	d.SetIfNotNil("F", a.F)
+	d.SetIfNotNil("Fields", a.Fields)
+	if a.Flags != 0 {
+		d.Set("Flags", MakeInteger(a.Flags))
+	}
+}
+
+func (a *PdfActionResetForm) appendToPdfDictionary(d *PdfObjectDictionary) {
+	d.SetIfNotNil("Fields", a.Fields)
+	if a.Flags != 0 {
+		d.Set("Flags", MakeInteger(a.Flags))
+	}
+}
+
+func (a *pdfActionOther) appendToPdfDictionary(d *PdfObjectDictionary) {}
+
+// PdfAdditionalActions represents an additional-actions dictionary (AA entry, 12.6.3 Trigger
+// Events), mapping trigger events such as "E" (cursor enters) or "K" (keystroke) to actions.
+type PdfAdditionalActions map[PdfObjectName]*PdfAction
+
+// NewPdfAdditionalActionsFromObject loads the additional-actions dictionary `obj`.  Invalid
+// actions are ignored.
+func NewPdfAdditionalActionsFromObject(obj PdfObject) (PdfAdditionalActions, error) {
+	dict, ok := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !ok {
+		return nil, ErrTypeError
+	}
+	actions := PdfAdditionalActions{}
+	for _, key := range dict.Keys() {
+		action, err := NewPdfActionFromObject(dict.Get(key))
+		if err != nil {
+			common.Log.Debug("Invalid %s additional action: %v", key, err)
+			continue
+		}
+		actions[key] = action
+	}
+	return actions, nil
+}
+
+// ToPdfObject returns the additional-actions dictionary.
+func (actions PdfAdditionalActions) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	for _, key := range sortedNames(actions) {
+		dict.Set(key, actions[key].ToPdfObject())
+	}
+	return dict
+}
+
+// sortedNames returns the triggers of `actions` in a stable order.
+func sortedNames(actions PdfAdditionalActions) []PdfObjectName {
+	keys := []PdfObjectName{}
+	for key := range actions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// loadAction loads the action `obj`, returning nil if `obj` is nil.
+func loadAction(obj PdfObject) (*PdfAction, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	return NewPdfActionFromObject(obj)
+}
+
+// actionObject returns the action dictionary of `action`, or nil for no action.
+func actionObject(action *PdfAction) PdfObject {
+	if action == nil {
+		return nil
+	}
+	return action.ToPdfObject()
+}
+
+// setOrRemove sets `key` of `container` to `val`, or removes it if `val` is nil.  Used when
+// changing entries of models that are written to the dictionaries they were loaded from.
+func setOrRemove(container PdfObject, key PdfObjectName, val PdfObject) {
+	d, ok := TraceToDirectObject(container).(*PdfObjectDictionary)
+	if !ok {
+		return
+	}
+	if val == nil {
+		d.Remove(key)
+	} else {
+		d.Set(key, val)
+	}
+}
+
+// GetAction returns the action of the link annotation.  Returns nil if the link has none.
+func (link *PdfAnnotationLink) GetAction() (*PdfAction, error) {
+	return loadAction(link.A)
+}
+
+// SetAction sets the action of the link annotation, replacing its destination.  A nil `action`
+// removes the action.
+func (link *PdfAnnotationLink) SetAction(action *PdfAction) {
+	link.A = actionObject(action)
+	setOrRemove(link.container, "A", link.A)
+	if action != nil {
+		link.Dest = nil
+		setOrRemove(link.container, "Dest", nil)
+	}
+}
+
+// GetAction returns the action performed when the widget annotation is activated.  Returns nil if
+// the widget has none.
+func (widget *PdfAnnotationWidget) GetAction() (*PdfAction, error) {
+	return loadAction(widget.A)
+}
+
+// SetAction sets the action performed when the widget annotation is activated.  A nil `action`
+// removes the action.
+func (widget *PdfAnnotationWidget) SetAction(action *PdfAction) {
+	widget.A = actionObject(action)
+	setOrRemove(widget.container, "A", widget.A)
+}
+
+// GetAdditionalActions returns the additional actions of the widget annotation.  Returns nil if
+// the widget has none.
+func (widget *PdfAnnotationWidget) GetAdditionalActions() (PdfAdditionalActions, error) {
+	if widget.AA == nil {
+		return nil, nil
+	}
+	return NewPdfAdditionalActionsFromObject(widget.AA)
+}
+
+// SetAdditionalActions sets the additional actions of the widget annotation.  Empty `actions`
+// remove the additional actions.
+func (widget *PdfAnnotationWidget) SetAdditionalActions(actions PdfAdditionalActions) {
+	widget.AA = nil
+	if len(actions) > 0 {
+		widget.AA = actions.ToPdfObject()
+	}
+	setOrRemove(widget.container, "AA", widget.AA)
+}
+
+// GetAction returns the action of the outline item.  Returns nil if the item has none.
+func (this *PdfOutlineItem) GetAction() (*PdfAction, error) {
+	return loadAction(this.A)
+}
+
+// SetAction sets the action of the outline item, replacing its destination.
+func (this *PdfOutlineItem) SetAction(action *PdfAction) {
+	this.A = actionObject(action)
+	if action != nil {
+		this.Dest = nil
+	}
+}
+
+// NewPdfOpenActionFromAction returns an OpenAction performing `action` when the document is
+// opened.
+func NewPdfOpenActionFromAction(action *PdfAction) *PdfOpenAction {
+	return &PdfOpenAction{Action: action.ToPdfObject()}
+}
+
+// GetAction returns the action performed when the document is opened.  Returns nil if the
+// OpenAction is a destination.
+func (action *PdfOpenAction) GetAction() (*PdfAction, error) {
+	if action.Dest != nil {
+		return nil, nil
+	}
+	return loadAction(action.Action)
+}
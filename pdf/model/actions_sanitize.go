@@ -0,0 +1,271 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// IsUnsafeAction returns true if `action` runs scripts or programs in the viewer: JavaScript and
+// Launch actions, and URI actions with a javascript: URI.
+func IsUnsafeAction(action *PdfAction) bool {
+	switch t := action.GetContext().(type) {
+	case *PdfActionJavaScript, *PdfActionLaunch:
+		return true
+	case *PdfActionURI:
+		return isJavaScriptURI(t.URI)
+	}
+	return false
+}
+
+// isJavaScriptURI returns true if `uri` is a javascript: URI.
+func isJavaScriptURI(uri string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(uri)), "javascript:")
+}
+
+// isUnsafeActionDict returns true if the action dictionary `dict` is unsafe (see IsUnsafeAction) or
+// is a Rendition action with a script.  Only the raw entries are checked, so that actions which
+// cannot be loaded, e.g. with scripts in undecodable streams, are detected too.
+func isUnsafeActionDict(dict *PdfObjectDictionary) bool {
+	s, _ := GetNameVal(dict.Get("S"))
+	switch PdfActionType(s) {
+	case ActionTypeJavaScript, ActionTypeLaunch:
+		return true
+	case ActionTypeURI:
+		uri, _ := GetStringVal(dict.Get("URI"))
+		return isJavaScriptURI(uri)
+	case ActionTypeRendition:
+		return dict.Get("JS") != nil
+	}
+	return false
+}
+
+// countUnsafeActionDicts returns the number of unsafe action dictionaries (see isUnsafeActionDict)
+// in the Next chain of the action dictionary `obj`, without loading the actions.
+func countUnsafeActionDicts(obj PdfObject, visited map[PdfObject]bool) int {
+	dict, ok := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !ok || visited[dict] {
+		return 0
+	}
+	visited[dict] = true
+	count := 0
+	if isUnsafeActionDict(dict) {
+		count++
+	}
+	switch t := TraceToDirectObject(dict.Get("Next")).(type) {
+	case *PdfObjectDictionary:
+		count += countUnsafeActionDicts(t, visited)
+	case *PdfObjectArray:
+		for _, elem := range t.Elements() {
+			count += countUnsafeActionDicts(elem, visited)
+		}
+	}
+	return count
+}
+
+// SanitizeAction returns `action` with the unsafe actions removed from it and its Next chain.
+// An unsafe action is replaced by the safe actions of its Next chain, performed in the same order.
+// Next actions that could not be loaded are removed too.  Returns nil if no safe action remains.
+func SanitizeAction(action *PdfAction) *PdfAction {
+	return sanitizeAction(action, map[*PdfAction]*PdfAction{})
+}
+
+// sanitizeAction sanitizes `action`.  `sanitized` maps the actions sanitized so far to the
+// results, for the actions shared in the chain.
+func sanitizeAction(action *PdfAction, sanitized map[*PdfAction]*PdfAction) *PdfAction {
+	if action == nil {
+		return nil
+	}
+	if result, has := sanitized[action]; has {
+		return result
+	}
+	sanitized[action] = action
+	action.invalidNext = nil
+	next := []*PdfAction{}
+	for _, n := range action.Next {
+		if n = sanitizeAction(n, sanitized); n != nil {
+			next = append(next, n)
+		}
+	}
+	if other, ok := action.context.(*pdfActionOther); ok && action.S == ActionTypeRendition {
+		// Rendition actions can execute scripts too.
+		other.dict.Remove("JS")
+	}
+	if !IsUnsafeAction(action) {
+		action.Next = next
+		return action
+	}
+	var result *PdfAction
+	if len(next) > 0 {
+		result = next[0]
+		result.Next = append(result.Next, next[1:]...)
+	}
+	sanitized[action] = result
+	return result
+}
+
+// countRemovedActions returns the number of actions removed by SanitizeAction from the Next chain
+// of `action`: the unsafe actions, including the scripts of Rendition actions, and the Next actions
+// that could not be loaded.
+func countRemovedActions(action *PdfAction) int {
+	count := 0
+	action.Walk(func(a *PdfAction) {
+		if IsUnsafeAction(a) {
+			count++
+		} else if other, ok := a.context.(*pdfActionOther); ok && a.S == ActionTypeRendition {
+			if other.dict.Get("JS") != nil {
+				count++
+			}
+		}
+		for _, obj := range a.invalidNext {
+			if n := countUnsafeActionDicts(obj, map[PdfObject]bool{}); n > 0 {
+				count += n
+			} else {
+				count++
+			}
+		}
+	})
+	return count
+}
+
+// SanitizeActions removes the unsafe actions (see IsUnsafeAction) from the objects reachable from
+// `obj`, typically the document catalog of an untrusted document.  The A, OpenAction and AA
+// entries are sanitized with SanitizeAction, and the JavaScript name tree is removed from the name
+// dictionary.  Returns the number of removed actions.
+func SanitizeActions(obj PdfObject) int {
+	return sanitizeObjectActions(obj, map[PdfObject]bool{})
+}
+
+func sanitizeObjectActions(obj PdfObject, visited map[PdfObject]bool) int {
+	if io, isIndirect := obj.(*PdfIndirectObject); isIndirect {
+		obj = io.PdfObject
+	}
+	if visited[obj] {
+		return 0
+	}
+	switch t := obj.(type) {
+	case *PdfObjectArray:
+		visited[obj] = true
+		count := 0
+		for _, elem := range t.Elements() {
+			count += sanitizeObjectActions(elem, visited)
+		}
+		return count
+	case *PdfObjectStream:
+		visited[obj] = true
+		return sanitizeObjectActions(t.PdfObjectDictionary, visited)
+	case *PdfObjectDictionary:
+		visited[obj] = true
+		count := sanitizeDictActions(t)
+		for _, key := range t.Keys() {
+			count += sanitizeObjectActions(t.Get(key), visited)
+		}
+		return count
+	}
+	return 0
+}
+
+// sanitizeDictActions sanitizes the action entries of `dict`.
+func sanitizeDictActions(dict *PdfObjectDictionary) int {
+	count := 0
+	for _, key := range []PdfObjectName{"A", "OpenAction"} {
+		if removed, action := sanitizeActionEntry(dict.Get(key)); removed > 0 {
+			setOrRemove(dict, key, actionObject(action))
+			count += removed
+		}
+	}
+	if aa, ok := TraceToDirectObject(dict.Get("AA")).(*PdfObjectDictionary); ok {
+		for _, key := range aa.Keys() {
+			if removed, action := sanitizeActionEntry(aa.Get(key)); removed > 0 {
+				setOrRemove(aa, key, actionObject(action))
+				count += removed
+			}
+		}
+		if len(aa.Keys()) == 0 {
+			dict.Remove("AA")
+		}
+	}
+	if names, ok := TraceToDirectObject(dict.Get("Names")).(*PdfObjectDictionary); ok {
+		if js := names.Get("JavaScript"); js != nil {
+			tree, err := NewPdfNameTreeFromObject(js)
+			if err == nil {
+				count += tree.Len()
+			}
+			names.Remove("JavaScript")
+		}
+	}
+	return count
+}
+
+// sanitizeActionEntry sanitizes the action `obj`.  Returns the number of removed actions and the
+// sanitized action.  Objects that are not action dictionaries, e.g. the destination arrays of
+// OpenAction entries, are left as they are.  Actions that cannot be loaded are removed entirely if
+// any action of their Next chain is unsafe.
+func sanitizeActionEntry(obj PdfObject) (int, *PdfAction) {
+	dict, ok := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !ok || dict.Get("S") == nil {
+		return 0, nil
+	}
+	action, err := NewPdfActionFromObject(obj)
+	if err != nil {
+		common.Log.Debug("Invalid action: %v", err)
+		return countUnsafeActionDicts(obj, map[PdfObject]bool{}), nil
+	}
+	count := countRemovedActions(action)
+	if count == 0 {
+		return 0, nil
+	}
+	return count, SanitizeAction(action)
+}
+
+// SanitizeActions removes the unsafe actions (see IsUnsafeAction) from the document, including the
+// actions of pages, annotations, form fields and outline items and the document level scripts, so
+// that they are not written when the pages are added to a writer.  Returns the number of removed
+// actions.
+func (this *PdfReader) SanitizeActions() (int, error) {
+	if err := this.traverseObjectData(this.catalog); err != nil {
+		return 0, err
+	}
+	count := SanitizeActions(this.catalog)
+
+	// Update the models loaded from the sanitized dictionaries.
+	for _, page := range this.PageList {
+		page.AA = page.pageDict.Get("AA")
+		for _, annot := range page.Annotations {
+			d, ok := annot.container.PdfObject.(*PdfObjectDictionary)
+			if !ok {
+				continue
+			}
+			switch t := annot.GetContext().(type) {
+			case *PdfAnnotationLink:
+				t.A = d.Get("A")
+			case *PdfAnnotationWidget:
+				t.A = d.Get("A")
+				t.AA = d.Get("AA")
+			case *PdfAnnotationScreen:
+				t.A = d.Get("A")
+				t.AA = d.Get("AA")
+			}
+		}
+	}
+	if this.AcroForm != nil {
+		for _, field := range this.AcroForm.AllFields() {
+			if d, ok := field.primitive.PdfObject.(*PdfObjectDictionary); ok {
+				field.AA = d.Get("AA")
+			}
+		}
+	}
+	err := this.GetOutlines().Walk(func(item *PdfOutlineItem, depth int) error {
+		if d, ok := item.primitive.PdfObject.(*PdfObjectDictionary); ok {
+			item.A = d.Get("A")
+		}
+		return nil
+	})
+	return count, err
+}
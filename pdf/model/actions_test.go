@@ -0,0 +1,353 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// actionTypes returns the types of `action` and its Next chain, e.g. "JavaScript URI".
+func actionTypes(action *PdfAction) string {
+	if action == nil {
+		return ""
+	}
+	types := []string{}
+	action.Walk(func(a *PdfAction) {
+		types = append(types, string(a.S))
+	})
+	return strings.Join(types, " ")
+}
+
+// Test serializing and loading actions of each type, with Next chains.
+func TestActionRoundTrip(t *testing.T) {
+	page := MakeIndirectObject(MakeDict())
+	yes := true
+	goToR := NewPdfActionGoToR("other.pdf", NewPdfDestinationFit(MakeInteger(2)))
+	goToR.NewWindow = &yes
+	uri := NewPdfActionURI("https://unidoc.io")
+	uri.IsMap = true
+	submit := NewPdfActionSubmitForm("https://unidoc.io/submit")
+	submit.Flags = 4
+
+	action := NewPdfActionGoTo(NewPdfDestinationFit(page)).PdfAction
+	action.Append(goToR.PdfAction, NewPdfActionNamed("NextPage").Append(
+		NewPdfActionJavaScript("app.alert(1);").PdfAction, NewPdfActionLaunch("calc.exe").PdfAction))
+	action.Append(uri.PdfAction, submit.PdfAction, NewPdfActionResetForm().PdfAction,
+		NewPdfActionGoToNamed("chapter1").PdfAction)
+
+	loaded, err := NewPdfActionFromObject(action.ToPdfObject())
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if s := actionTypes(loaded); s != "GoTo GoToR Named JavaScript Launch URI SubmitForm ResetForm GoTo" {
+		t.Fatalf("Invalid action chain %s", s)
+	}
+	dest, err := loaded.GetContext().(*PdfActionGoTo).GetDestination()
+	if err != nil || dest == nil || dest.Page != page || dest.Fit != DestinationFit {
+		t.Errorf("Invalid GoTo destination %+v (%v)", dest, err)
+	}
+	r := loaded.Next[0].GetContext().(*PdfActionGoToR)
+	if f, _ := GetStringVal(r.F); f != "other.pdf" || r.NewWindow == nil || !*r.NewWindow {
+		t.Errorf("Invalid GoToR action %+v", r)
+	}
+	named := loaded.Next[1].GetContext().(*PdfActionNamed)
+	if named.N != "NextPage" || len(named.Next) != 2 {
+		t.Errorf("Invalid Named action %+v", named)
+	}
+	if js := named.Next[0].GetContext().(*PdfActionJavaScript); js.JS != "app.alert(1);" {
+		t.Errorf("Invalid script %q", js.JS)
+	}
+	if u := loaded.Next[2].GetContext().(*PdfActionURI); u.URI != "https://unidoc.io" || !u.IsMap {
+		t.Errorf("Invalid URI action %+v", u)
+	}
+	if s := loaded.Next[3].GetContext().(*PdfActionSubmitForm); s.Flags != 4 {
+		t.Errorf("Invalid SubmitForm flags %d", s.Flags)
+	}
+	if name, ok := loaded.Next[5].GetContext().(*PdfActionGoTo).GetNamedDestination(); !ok || name != "chapter1" {
+		t.Errorf("Invalid named destination %q", name)
+	}
+
+	// Scripts in streams, other action types and Next loops.
+	stream, err := MakeStream([]byte("this.print();"), NewFlateEncoder())
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	stream.Set("Filter", MakeName(StreamEncodingFilterNameFlate))
+	jsDict := MakeDict()
+	jsDict.Set("S", MakeName("JavaScript"))
+	jsDict.Set("JS", stream)
+	hide := MakeDict()
+	hide.Set("S", MakeName("Hide"))
+	hide.Set("T", MakeString("field"))
+	hide.Set("Next", jsDict)
+	jsDict.Set("Next", hide)
+	loaded, err = NewPdfActionFromObject(hide)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if s := actionTypes(loaded); s != "Hide JavaScript" {
+		t.Fatalf("Invalid action chain %s", s)
+	}
+	if js := loaded.Next[0].GetContext().(*PdfActionJavaScript); js.JS != "this.print();" {
+		t.Errorf("Invalid stream script %q", js.JS)
+	}
+	d := TraceToDirectObject(loaded.ToPdfObject()).(*PdfObjectDictionary)
+	if s, _ := GetStringVal(d.Get("T")); s != "field" {
+		t.Errorf("Hide action entries not kept: %s", d)
+	}
+}
+
+// Test sanitizing typed action chains.
+func TestSanitizeAction(t *testing.T) {
+	js := NewPdfActionJavaScript("app.alert(1);").PdfAction
+	js.Append(NewPdfActionURI("javascript:alert(1)").PdfAction,
+		NewPdfActionNamed("NextPage").Append(NewPdfActionLaunch("calc.exe").PdfAction),
+		NewPdfActionURI("https://unidoc.io").PdfAction)
+	if s := actionTypes(SanitizeAction(js)); s != "Named URI" {
+		t.Errorf("Invalid sanitized chain %s", s)
+	}
+	if a := SanitizeAction(NewPdfActionLaunch("calc.exe").PdfAction); a != nil {
+		t.Errorf("Unsafe action kept")
+	}
+}
+
+// Test the action accessors of annotations, outline items and the open action, and sanitizing a
+// document.
+func TestSanitizeDocumentActions(t *testing.T) {
+	page := NewPdfPage()
+	page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 612, Ury: 792}
+	page.Resources = NewPdfPageResources()
+	pageAA := MakeDict()
+	pageAA.Set("O", NewPdfActionJavaScript("app.alert('open');").ToPdfObject())
+	page.AA = pageAA
+
+	link := NewPdfAnnotationLink()
+	link.Rect = MakeArrayFromFloats([]float64{10, 10, 100, 30})
+	action := NewPdfActionJavaScript("app.alert(1);").PdfAction
+	action.Append(NewPdfActionURI("https://unidoc.io").PdfAction)
+	link.SetAction(action)
+	widget := NewPdfAnnotationWidget()
+	widget.Rect = MakeArrayFromFloats([]float64{10, 50, 100, 70})
+	widget.SetAction(NewPdfActionResetForm().PdfAction)
+	widget.SetAdditionalActions(PdfAdditionalActions{
+		"K": NewPdfActionJavaScript("AFNumber_Keystroke();").PdfAction,
+		"E": NewPdfActionNamed("NextPage").PdfAction,
+	})
+	page.Annotations = []*PdfAnnotation{link.PdfAnnotation, widget.PdfAnnotation}
+
+	outlines := NewPdfOutline()
+	item := NewPdfOutlineItem()
+	item.SetTitle("Calculator")
+	item.SetAction(NewPdfActionLaunch("calc.exe").PdfAction)
+	if err := outlines.AddChild(item); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	writer := NewPdfWriter()
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	writer.SetOutlines(outlines)
+	open := NewPdfActionJavaScript("this.print();").PdfAction
+	open.Append(NewPdfActionGoTo(NewPdfDestinationFit(page.GetPageAsIndirectObject())).PdfAction)
+	writer.SetOpenAction(NewPdfOpenActionFromAction(open))
+	names := NewPdfNames()
+	scripts := NewPdfNameTree()
+	scripts.Set("init", NewPdfActionJavaScript("app.alert('init');").ToPdfObject())
+	names.SetTree("JavaScript", scripts)
+	writer.SetNames(names)
+	var buf bytes.Buffer
+	if err := writer.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	reader, err := NewPdfReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	annots := reader.PageList[0].Annotations
+	if len(annots) != 2 {
+		t.Fatalf("Invalid annotations %v", annots)
+	}
+	loadedLink := annots[0].GetContext().(*PdfAnnotationLink)
+	a, err := loadedLink.GetAction()
+	if err != nil || actionTypes(a) != "JavaScript URI" {
+		t.Errorf("Invalid link action %s (%v)", actionTypes(a), err)
+	}
+	loadedWidget := annots[1].GetContext().(*PdfAnnotationWidget)
+	aa, err := loadedWidget.GetAdditionalActions()
+	if err != nil || len(aa) != 2 || aa["K"].S != ActionTypeJavaScript {
+		t.Errorf("Invalid widget additional actions %v (%v)", aa, err)
+	}
+	a, err = reader.GetOutlines().Children()[0].GetAction()
+	if err != nil || actionTypes(a) != "Launch" {
+		t.Errorf("Invalid outline action %s (%v)", actionTypes(a), err)
+	}
+	openAction, err := reader.GetOpenAction()
+	if err != nil || openAction == nil {
+		t.Fatalf("Invalid open action (%v)", err)
+	}
+	if a, err = openAction.GetAction(); err != nil || actionTypes(a) != "JavaScript GoTo" {
+		t.Errorf("Invalid open action %s (%v)", actionTypes(a), err)
+	}
+
+	count, err := reader.SanitizeActions()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	// Page open, link, widget keystroke, outline item, open action and document level scripts.
+	if count != 6 {
+		t.Errorf("Invalid number of removed actions %d", count)
+	}
+
+	writer = NewPdfWriter()
+	for _, page := range reader.PageList {
+		if err := writer.AddPage(page); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+	writer.SetOutlines(reader.GetOutlines())
+	if openAction, err = reader.GetOpenAction(); err != nil || openAction == nil {
+		t.Fatalf("Open action removed (%v)", err)
+	}
+	writer.SetOpenAction(openAction)
+	if names, err = reader.GetNames(); err != nil || names.GetTree("JavaScript") != nil {
+		t.Errorf("Document level scripts not removed (%v)", err)
+	}
+	buf.Reset()
+	if err := writer.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if data := buf.String(); strings.Contains(data, "JavaScript") || strings.Contains(data, "Launch") {
+		t.Errorf("Unsafe actions written")
+	}
+
+	reader, err = NewPdfReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	annots = reader.PageList[0].Annotations
+	if a, err = annots[0].GetContext().(*PdfAnnotationLink).GetAction(); err != nil || actionTypes(a) != "URI" {
+		t.Errorf("Invalid sanitized link action %s (%v)", actionTypes(a), err)
+	}
+	widgetAA, err := annots[1].GetContext().(*PdfAnnotationWidget).GetAdditionalActions()
+	if err != nil || len(widgetAA) != 1 || widgetAA["E"] == nil {
+		t.Errorf("Invalid sanitized widget additional actions %v (%v)", widgetAA, err)
+	}
+	if a, _ = annots[1].GetContext().(*PdfAnnotationWidget).GetAction(); actionTypes(a) != "ResetForm" {
+		t.Errorf("Safe widget action removed")
+	}
+	if a, err = reader.GetOutlines().Children()[0].GetAction(); err != nil || a != nil {
+		t.Errorf("Outline action not removed")
+	}
+	openAction, err = reader.GetOpenAction()
+	if err != nil || openAction == nil {
+		t.Fatalf("Invalid open action (%v)", err)
+	}
+	if a, err = openAction.GetAction(); err != nil || actionTypes(a) != "GoTo" {
+		t.Errorf("Invalid sanitized open action %s (%v)", actionTypes(a), err)
+	}
+}
+
+// Test sanitizing actions that cannot be loaded: scripts in streams that cannot be decoded, chained
+// after a URI action and as the action itself.
+func TestSanitizeInvalidActions(t *testing.T) {
+	makeJS := func(filter string) *PdfObjectDictionary {
+		stream, err := MakeStream([]byte("not a flate stream"), nil)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		stream.Set("Filter", MakeName(filter))
+		js := MakeDict()
+		js.Set("S", MakeName("JavaScript"))
+		js.Set("JS", stream)
+		return js
+	}
+	if _, err := NewPdfActionFromObject(makeJS(StreamEncodingFilterNameFlate)); err == nil {
+		t.Fatalf("Undecodable script loaded")
+	}
+
+	uri := MakeDict()
+	uri.Set("S", MakeName("URI"))
+	uri.Set("URI", MakeString("https://unidoc.io"))
+	uri.Set("Next", makeJS(StreamEncodingFilterNameFlate))
+	annot := MakeDict()
+	annot.Set("A", MakeIndirectObject(uri))
+	annot.Set("AA", MakeDict())
+	annot.Get("AA").(*PdfObjectDictionary).Set("E", makeJS("UnsupportedDecode"))
+	catalog := MakeDict()
+	catalog.Set("OpenAction", makeJS(StreamEncodingFilterNameFlate))
+	catalog.Set("Annots", MakeArray(annot))
+
+	if count := SanitizeActions(catalog); count != 3 {
+		t.Errorf("Invalid number of removed actions %d", count)
+	}
+	if catalog.Get("OpenAction") != nil || annot.Get("AA") != nil {
+		t.Errorf("Invalid scripts not removed: %s", catalog)
+	}
+	a, err := NewPdfActionFromObject(annot.Get("A"))
+	if err != nil || actionTypes(a) != "URI" {
+		t.Fatalf("Invalid sanitized action %s (%v)", actionTypes(a), err)
+	}
+	if d := TraceToDirectObject(annot.Get("A")).(*PdfObjectDictionary); d.Get("Next") != nil {
+		t.Errorf("Invalid Next action kept: %s", d)
+	}
+}
+
+// Test loading and sanitizing chains with Next actions shared by several actions, which are not
+// loops.
+func TestSharedNextActions(t *testing.T) {
+	makeAction := func(s string, next ...PdfObject) *PdfObjectDictionary {
+		d := MakeDict()
+		d.Set("S", MakeName(s))
+		d.Set("N", MakeName("NextPage"))
+		if len(next) > 0 {
+			d.Set("Next", MakeArray(next...))
+		}
+		return d
+	}
+	shared := MakeIndirectObject(makeAction("Named"))
+	root := makeAction("Named", makeAction("Named", shared), makeAction("Named", shared))
+
+	action, err := NewPdfActionFromObject(root)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(action.Next) != 2 || len(action.Next[0].Next) != 1 || len(action.Next[1].Next) != 1 {
+		t.Fatalf("Invalid action chain %s", actionTypes(action))
+	}
+	if action.Next[0].Next[0] != action.Next[1].Next[0] {
+		t.Errorf("Shared Next action loaded twice")
+	}
+	if count := countRemovedActions(action); count != 0 {
+		t.Errorf("Shared Next action counted as removed (%d)", count)
+	}
+	annot := MakeDict()
+	annot.Set("A", root)
+	if count := SanitizeActions(annot); count != 0 {
+		t.Errorf("Invalid number of removed actions %d", count)
+	}
+	if annot.Get("A") != root {
+		t.Errorf("Action with shared Next action replaced: %s", annot)
+	}
+
+	// A shared unsafe action is counted once and removed from each chain.
+	shared.PdfObject = makeAction("Launch")
+	if count := SanitizeActions(annot); count != 1 {
+		t.Errorf("Invalid number of removed actions %d", count)
+	}
+	sanitized, err := NewPdfActionFromObject(annot.Get("A"))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if s := actionTypes(sanitized); s != "Named Named Named" {
+		t.Errorf("Invalid sanitized chain %s", s)
+	}
+}
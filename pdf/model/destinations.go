@@ -0,0 +1,168 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfDestinationFit is the type of an explicit destination, specifying how the destination page is
+// displayed.
+type PdfDestinationFit string
+
+// Destination types (12.3.2.2 Explicit Destinations p. 366).
+const (
+	DestinationFitXYZ PdfDestinationFit = "XYZ"   // Position Left, Top with Zoom.
+	DestinationFit    PdfDestinationFit = "Fit"   // Whole page.
+	DestinationFitH   PdfDestinationFit = "FitH"  // Page width, with Top at the top of the window.
+	DestinationFitV   PdfDestinationFit = "FitV"  // Page height, with Left at the left of the window.
+	DestinationFitR   PdfDestinationFit = "FitR"  // Rectangle Left, Bottom, Right, Top.
+	DestinationFitB   PdfDestinationFit = "FitB"  // Whole bounding box of the page contents.
+	DestinationFitBH  PdfDestinationFit = "FitBH" // Bounding box width, with Top at the top of the window.
+	DestinationFitBV  PdfDestinationFit = "FitBV" // Bounding box height, with Left at the left of the window.
+)
+
+// PdfDestination represents an explicit destination.  The parameters that apply to the destination
+// type are set, where nil values represent null (the current value is retained by the viewer).
+type PdfDestination struct {
+	// Page is the destination page object, or the page number (starting from 0) for destinations
+	// in remote documents.
+	Page PdfObject
+	Fit  PdfDestinationFit
+
+	Left   *float64
+	Bottom *float64
+	Right  *float64
+	Top    *float64
+	Zoom   *float64
+}
+
+// NewPdfDestinationXYZ returns a destination at position (`left`, `top`) on `page` with `zoom`,
+// where a zoom of 0 retains the current zoom.
+func NewPdfDestinationXYZ(page PdfObject, left, top, zoom float64) *PdfDestination {
+	dest := &PdfDestination{Page: page, Fit: DestinationFitXYZ, Left: &left, Top: &top}
+	if zoom != 0 {
+		dest.Zoom = &zoom
+	}
+	return dest
+}
+
+// NewPdfDestinationFit returns a destination displaying the whole of `page`.
+func NewPdfDestinationFit(page PdfObject) *PdfDestination {
+	return &PdfDestination{Page: page, Fit: DestinationFit}
+}
+
+// NewPdfDestinationFitR returns a destination displaying `rect` of `page`.
+func NewPdfDestinationFitR(page PdfObject, rect PdfRectangle) *PdfDestination {
+	return &PdfDestination{Page: page, Fit: DestinationFitR,
+		Left: &rect.Llx, Bottom: &rect.Lly, Right: &rect.Urx, Top: &rect.Ury}
+}
+
+// NewPdfDestinationFromObject loads an explicit destination from the destination array `obj`, or
+// from the D entry of the dictionary `obj` as used by named destinations.
+func NewPdfDestinationFromObject(obj PdfObject) (*PdfDestination, error) {
+	obj = TraceToDirectObject(obj)
+	if dict, ok := obj.(*PdfObjectDictionary); ok {
+		obj = TraceToDirectObject(dict.Get("D"))
+	}
+	arr, ok := obj.(*PdfObjectArray)
+	if !ok {
+		return nil, errors.New("Destination not an array")
+	}
+	if arr.Len() < 2 {
+		return nil, errors.New("Destination array too short")
+	}
+
+	fit, ok := GetNameVal(arr.Get(1))
+	if !ok {
+		return nil, errors.New("Destination type not a name")
+	}
+	dest := &PdfDestination{Page: arr.Get(0), Fit: PdfDestinationFit(fit)}
+
+	params := dest.params()
+	if params == nil {
+		return nil, errors.New("Invalid destination type")
+	}
+	for i, param := range params {
+		if 2+i >= arr.Len() {
+			break
+		}
+		val, err := GetNumberAsFloat(TraceToDirectObject(arr.Get(2 + i)))
+		if err != nil {
+			// Null or invalid parameters leave the value unchanged.
+			continue
+		}
+		*param = &val
+	}
+	return dest, nil
+}
+
+// params returns the parameters of the destination type in the order of the destination array.
+// Returns nil for unknown destination types.
+func (dest *PdfDestination) params() []**float64 {
+	switch dest.Fit {
+	case DestinationFitXYZ:
+		return []**float64{&dest.Left, &dest.Top, &dest.Zoom}
+	case DestinationFit, DestinationFitB:
+		return []**float64{}
+	case DestinationFitH, DestinationFitBH:
+		return []**float64{&dest.Top}
+	case DestinationFitV, DestinationFitBV:
+		return []**float64{&dest.Left}
+	case DestinationFitR:
+		return []**float64{&dest.Left, &dest.Bottom, &dest.Right, &dest.Top}
+	}
+	return nil
+}
+
+// ToPdfObject returns the destination array.
+func (dest *PdfDestination) ToPdfObject() PdfObject {
+	page := dest.Page
+	if page == nil {
+		page = MakeNull()
+	}
+	arr := MakeArray(page, MakeName(string(dest.Fit)))
+	for _, param := range dest.params() {
+		if *param == nil {
+			arr.Append(MakeNull())
+		} else {
+			arr.Append(MakeFloat(**param))
+		}
+	}
+	return arr
+}
+
+// PdfOpenAction represents the OpenAction entry of the document catalog, which is either a
+// destination or an action performed when the document is opened.
+type PdfOpenAction struct {
+	Dest   *PdfDestination
+	Action PdfObject // Action dictionary, if Dest is nil.
+}
+
+// NewPdfOpenActionFromObject loads the OpenAction entry `obj`.
+func NewPdfOpenActionFromObject(obj PdfObject) (*PdfOpenAction, error) {
+	switch t := TraceToDirectObject(obj).(type) {
+	case *PdfObjectArray:
+		dest, err := NewPdfDestinationFromObject(t)
+		if err != nil {
+			return nil, err
+		}
+		return &PdfOpenAction{Dest: dest}, nil
+	case *PdfObjectDictionary:
+		return &PdfOpenAction{Action: obj}, nil
+	}
+	return nil, ErrTypeError
+}
+
+// ToPdfObject returns the destination array or the action dictionary.
+func (action *PdfOpenAction) ToPdfObject() PdfObject {
+	if action.Dest != nil {
+		return action.Dest.ToPdfObject()
+	}
+	return action.Action
+}
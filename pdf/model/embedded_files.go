@@ -0,0 +1,488 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfAFRelationship specifies the relationship between an associated file and the PDF component
+// that refers to it (AFRelationship entry of the file specification, PDF 2.0).
+type PdfAFRelationship string
+
+// Associated file relationships.
+const (
+	AFRelationshipSource           PdfAFRelationship = "Source"           // Original source of the content.
+	AFRelationshipData             PdfAFRelationship = "Data"             // Data used to derive the content.
+	AFRelationshipAlternative      PdfAFRelationship = "Alternative"      // Alternative representation.
+	AFRelationshipSupplement       PdfAFRelationship = "Supplement"       // Supplemental representation.
+	AFRelationshipEncryptedPayload PdfAFRelationship = "EncryptedPayload" // Encrypted payload document.
+	AFRelationshipFormData         PdfAFRelationship = "FormData"         // Data of an interactive form.
+	AFRelationshipSchema           PdfAFRelationship = "Schema"           // Schema definition.
+	AFRelationshipUnspecified      PdfAFRelationship = "Unspecified"      // Not known or not listed.
+)
+
+// PdfEmbeddedFile represents an embedded file stream (7.11.4 Embedded File Streams p. 104).
+type PdfEmbeddedFile struct {
+	Data         []byte   // Decoded file contents.
+	MIMEType     string   // Subtype, e.g. text/plain.
+	Size         int64    // Uncompressed size in bytes.
+	CreationDate *PdfDate // Nil if not set.
+	ModDate      *PdfDate // Nil if not set.
+	CheckSum     []byte   // MD5 digest of the file contents, nil if not set.
+}
+
+// NewPdfEmbeddedFile returns an embedded file with contents `data` and its size and checksum.
+func NewPdfEmbeddedFile(data []byte, mimeType string) *PdfEmbeddedFile {
+	sum := md5.Sum(data)
+	return &PdfEmbeddedFile{
+		Data:     data,
+		MIMEType: mimeType,
+		Size:     int64(len(data)),
+		CheckSum: sum[:],
+	}
+}
+
+// NewPdfEmbeddedFileFromObject loads the embedded file stream `obj`.  Invalid dates are ignored.
+func NewPdfEmbeddedFileFromObject(obj PdfObject) (*PdfEmbeddedFile, error) {
+	stream, ok := GetStream(obj)
+	if !ok {
+		return nil, ErrTypeError
+	}
+	data, err := DecodeStream(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &PdfEmbeddedFile{Data: data, Size: int64(len(data))}
+	file.MIMEType, _ = GetNameVal(stream.Get("Subtype"))
+
+	if params, ok := GetDict(stream.Get("Params")); ok {
+		if size, ok := GetIntVal(params.Get("Size")); ok {
+			file.Size = int64(size)
+		}
+		if sum, ok := GetStringBytes(params.Get("CheckSum")); ok {
+			file.CheckSum = sum
+		}
+		file.CreationDate = loadDate(params.Get("CreationDate"))
+		file.ModDate = loadDate(params.Get("ModDate"))
+	}
+	return file, nil
+}
+
+// loadDate returns the date of the date string `obj`, or nil if `obj` is not a valid date.
+func loadDate(obj PdfObject) *PdfDate {
+	str, ok := GetStringVal(obj)
+	if !ok {
+		return nil
+	}
+	date, err := NewPdfDate(str)
+	if err != nil {
+		common.Log.Debug("Invalid date %q (%v) - ignoring", str, err)
+		return nil
+	}
+	return &date
+}
+
+// Verify checks the size and checksum of the file contents, if set.
+func (file *PdfEmbeddedFile) Verify() error {
+	if file.Size != int64(len(file.Data)) {
+		return errors.New("Embedded file size mismatch")
+	}
+	if file.CheckSum != nil {
+		sum := md5.Sum(file.Data)
+		if !bytes.Equal(sum[:], file.CheckSum) {
+			return errors.New("Embedded file checksum mismatch")
+		}
+	}
+	return nil
+}
+
+// ToPdfObject returns the embedded file stream, compressed with the flate encoding.
+func (file *PdfEmbeddedFile) ToPdfObject() PdfObject {
+	stream, err := MakeStream(file.Data, NewFlateEncoder())
+	if err != nil {
+		common.Log.Debug("Error encoding embedded file (%v) - storing uncompressed", err)
+		stream, _ = MakeStream(file.Data, nil)
+	}
+	stream.Set("Type", MakeName("EmbeddedFile"))
+	if len(file.MIMEType) > 0 {
+		stream.Set("Subtype", MakeName(file.MIMEType))
+	}
+
+	params := MakeDict()
+	params.Set("Size", MakeInteger(int64(len(file.Data))))
+	if file.CreationDate != nil {
+		params.Set("CreationDate", file.CreationDate.ToPdfObject())
+	}
+	if file.ModDate != nil {
+		params.Set("ModDate", file.ModDate.ToPdfObject())
+	}
+	if file.CheckSum != nil {
+		params.Set("CheckSum", MakeHexString(string(file.CheckSum)))
+	}
+	stream.Set("Params", params)
+	return stream
+}
+
+// PdfFileSpec represents a file specification dictionary (7.11 File Specifications p. 99) of an
+// embedded file or a reference to an external file.
+type PdfFileSpec struct {
+	FileName       string            // F and UF.
+	Description    string            // Desc.
+	AFRelationship PdfAFRelationship // Empty if not set.
+	EmbeddedFile   *PdfEmbeddedFile  // Nil for external files.
+
+	// Collection item dictionary (CI) with the values of the portfolio schema fields.
+	CollectionItem *PdfObjectDictionary
+
+	container *PdfIndirectObject
+}
+
+// NewPdfFileSpec returns a file specification of the file `name` embedding `file`.
+func NewPdfFileSpec(name string, file *PdfEmbeddedFile) *PdfFileSpec {
+	return &PdfFileSpec{
+		FileName:     name,
+		EmbeddedFile: file,
+		container:    MakeIndirectObject(MakeDict()),
+	}
+}
+
+// NewPdfFileSpecFromFile returns a file specification embedding the file at `path`.  The MIME
+// type is determined from the file name extension and the modification date from the file system.
+func NewPdfFileSpecFromFile(path string) (*PdfFileSpec, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := NewPdfEmbeddedFile(data, mime.TypeByExtension(filepath.Ext(path)))
+	modDate := NewPdfDateFromTime(info.ModTime())
+	file.ModDate = &modDate
+	return NewPdfFileSpec(filepath.Base(path), file), nil
+}
+
+// NewPdfFileSpecFromObject loads the file specification `obj`, which is either a file
+// specification dictionary or a file specification string.
+func NewPdfFileSpecFromObject(obj PdfObject) (*PdfFileSpec, error) {
+	if str, ok := GetString(obj); ok {
+		spec := NewPdfFileSpec(str.Decoded(), nil)
+		return spec, nil
+	}
+
+	dict, ok := GetDict(obj)
+	if !ok {
+		return nil, ErrTypeError
+	}
+	spec := &PdfFileSpec{}
+	if ind, ok := obj.(*PdfIndirectObject); ok {
+		spec.container = ind
+	} else {
+		spec.container = MakeIndirectObject(dict)
+	}
+
+	// UF takes precedence over F, followed by the platform-specific names of older versions.
+	for _, key := range []PdfObjectName{"UF", "F", "Unix", "Mac", "DOS"} {
+		if str, ok := GetString(dict.Get(key)); ok {
+			spec.FileName = str.Decoded()
+			break
+		}
+	}
+	if str, ok := GetString(dict.Get("Desc")); ok {
+		spec.Description = str.Decoded()
+	}
+	if rel, ok := GetNameVal(dict.Get("AFRelationship")); ok {
+		spec.AFRelationship = PdfAFRelationship(rel)
+	}
+	if ci, ok := GetDict(dict.Get("CI")); ok {
+		spec.CollectionItem = ci
+	}
+
+	if ef, ok := GetDict(dict.Get("EF")); ok {
+		// The UF and F entries refer to the same file; prefer the Unicode one.
+		for _, key := range []PdfObjectName{"UF", "F"} {
+			if ef.Get(key) == nil {
+				continue
+			}
+			file, err := NewPdfEmbeddedFileFromObject(ef.Get(key))
+			if err != nil {
+				common.Log.Debug("Invalid embedded file %q (%v) - ignoring", spec.FileName, err)
+				continue
+			}
+			spec.EmbeddedFile = file
+			break
+		}
+	}
+	return spec, nil
+}
+
+// SetCollectionItem sets the value of the portfolio schema field `key` of the file to `val`.
+func (spec *PdfFileSpec) SetCollectionItem(key string, val PdfObject) {
+	if spec.CollectionItem == nil {
+		spec.CollectionItem = MakeDict()
+		spec.CollectionItem.Set("Type", MakeName("CollectionItem"))
+	}
+	spec.CollectionItem.Set(PdfObjectName(key), val)
+}
+
+// ToPdfObject returns the file specification dictionary as an indirect object, so that the same
+// object is referred to from the name tree, annotations and associated files.
+func (spec *PdfFileSpec) ToPdfObject() PdfObject {
+	if spec.container == nil {
+		spec.container = MakeIndirectObject(MakeDict())
+	}
+	dict := MakeDict()
+	dict.Set("Type", MakeName("Filespec"))
+	dict.Set("F", MakeString(spec.FileName))
+	dict.Set("UF", MakeEncodedString(spec.FileName))
+	if len(spec.Description) > 0 {
+		dict.Set("Desc", MakeEncodedString(spec.Description))
+	}
+	if len(spec.AFRelationship) > 0 {
+		dict.Set("AFRelationship", MakeName(string(spec.AFRelationship)))
+	}
+	if spec.EmbeddedFile != nil {
+		stream := spec.EmbeddedFile.ToPdfObject()
+		ef := MakeDict()
+		ef.Set("F", stream)
+		ef.Set("UF", stream)
+		dict.Set("EF", ef)
+	}
+	if spec.CollectionItem != nil {
+		dict.Set("CI", spec.CollectionItem)
+	}
+	spec.container.PdfObject = dict
+	return spec.container
+}
+
+// GetFileSpec returns the file specification of the file attachment annotation.
+func (file *PdfAnnotationFileAttachment) GetFileSpec() (*PdfFileSpec, error) {
+	if file.FS == nil {
+		return nil, errors.New("Missing file specification")
+	}
+	return NewPdfFileSpecFromObject(file.FS)
+}
+
+// SetFileSpec sets the file specification of the file attachment annotation to `spec`.
+func (file *PdfAnnotationFileAttachment) SetFileSpec(spec *PdfFileSpec) {
+	file.FS = spec.ToPdfObject()
+}
+
+// PdfCollectionField represents a field of a portfolio schema (12.3.5 Collections p. 376).
+type PdfCollectionField struct {
+	Key      string // Key of the field value in the collection items.
+	Subtype  string // S (text), D (date), N (number) or a file property, e.g. F, Desc or Size.
+	Name     string // Field name displayed in the viewer.
+	Visible  bool
+	Editable bool
+}
+
+// Portfolio field subtypes.
+const (
+	CollectionFieldText         = "S"
+	CollectionFieldDate         = "D"
+	CollectionFieldNumber       = "N"
+	CollectionFieldFileName     = "F"
+	CollectionFieldDescription  = "Desc"
+	CollectionFieldModDate      = "ModDate"
+	CollectionFieldCreationDate = "CreationDate"
+	CollectionFieldSize         = "Size"
+)
+
+// PdfCollection represents a collection dictionary, which makes the document a portfolio of its
+// embedded files (12.3.5 Collections p. 376).
+type PdfCollection struct {
+	Schema          []*PdfCollectionField // Fields in display order.
+	InitialDocument string                // Name of the embedded file displayed initially (D).
+	View            string                // D (details), T (tiles) or H (hidden).
+	Sort            []string              // Field keys to sort by.
+	SortAscending   []bool                // Sort order of each Sort key, ascending if not set.
+}
+
+// Portfolio views.
+const (
+	CollectionViewDetails = "D"
+	CollectionViewTiles   = "T"
+	CollectionViewHidden  = "H"
+)
+
+// NewPdfCollection returns an empty collection in details view.
+func NewPdfCollection() *PdfCollection {
+	return &PdfCollection{View: CollectionViewDetails}
+}
+
+// AddField adds the schema field `key` of `subtype` displayed as `name`.
+func (collection *PdfCollection) AddField(key, subtype, name string) *PdfCollectionField {
+	field := &PdfCollectionField{Key: key, Subtype: subtype, Name: name, Visible: true}
+	collection.Schema = append(collection.Schema, field)
+	return field
+}
+
+// NewPdfCollectionFromObject loads the collection dictionary `obj`.  Invalid schema fields are
+// ignored.
+func NewPdfCollectionFromObject(obj PdfObject) (*PdfCollection, error) {
+	dict, ok := GetDict(obj)
+	if !ok {
+		return nil, ErrTypeError
+	}
+	collection := NewPdfCollection()
+	if view, ok := GetNameVal(dict.Get("View")); ok {
+		collection.View = view
+	}
+	if str, ok := GetString(dict.Get("D")); ok {
+		collection.InitialDocument = str.Decoded()
+	}
+
+	if schema, ok := GetDict(dict.Get("Schema")); ok {
+		orders := map[*PdfCollectionField]int{}
+		for _, key := range schema.Keys() {
+			fieldDict, ok := GetDict(schema.Get(key))
+			if !ok {
+				continue
+			}
+			field := &PdfCollectionField{Key: string(key), Visible: true}
+			field.Subtype, _ = GetNameVal(fieldDict.Get("Subtype"))
+			if str, ok := GetString(fieldDict.Get("N")); ok {
+				field.Name = str.Decoded()
+			}
+			if val, ok := GetBoolVal(fieldDict.Get("V")); ok {
+				field.Visible = val
+			}
+			if val, ok := GetBoolVal(fieldDict.Get("E")); ok {
+				field.Editable = val
+			}
+			orders[field], _ = GetIntVal(fieldDict.Get("O"))
+			collection.Schema = append(collection.Schema, field)
+		}
+		sort.SliceStable(collection.Schema, func(i, j int) bool {
+			return orders[collection.Schema[i]] < orders[collection.Schema[j]]
+		})
+	}
+
+	if sortDict, ok := GetDict(dict.Get("Sort")); ok {
+		keys := sortDict.Get("S")
+		if arr, ok := GetArray(keys); ok {
+			for _, key := range arr.Elements() {
+				if name, ok := GetNameVal(key); ok {
+					collection.Sort = append(collection.Sort, name)
+				}
+			}
+		} else if name, ok := GetNameVal(keys); ok {
+			collection.Sort = []string{name}
+		}
+
+		ascending := sortDict.Get("A")
+		if arr, ok := GetArray(ascending); ok {
+			for _, val := range arr.Elements() {
+				b, _ := GetBoolVal(val)
+				collection.SortAscending = append(collection.SortAscending, b)
+			}
+		} else if b, ok := GetBoolVal(ascending); ok {
+			collection.SortAscending = []bool{b}
+		}
+	}
+	return collection, nil
+}
+
+// ToPdfObject returns the collection dictionary.
+func (collection *PdfCollection) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	dict.Set("Type", MakeName("Collection"))
+
+	if len(collection.Schema) > 0 {
+		schema := MakeDict()
+		schema.Set("Type", MakeName("CollectionSchema"))
+		for i, field := range collection.Schema {
+			fieldDict := MakeDict()
+			fieldDict.Set("Type", MakeName("CollectionField"))
+			fieldDict.Set("Subtype", MakeName(field.Subtype))
+			fieldDict.Set("N", MakeEncodedString(field.Name))
+			fieldDict.Set("O", MakeInteger(int64(i)))
+			fieldDict.Set("V", MakeBool(field.Visible))
+			fieldDict.Set("E", MakeBool(field.Editable))
+			schema.Set(PdfObjectName(field.Key), fieldDict)
+		}
+		dict.Set("Schema", schema)
+	}
+	if len(collection.InitialDocument) > 0 {
+		dict.Set("D", MakeEncodedString(collection.InitialDocument))
+	}
+	if len(collection.View) > 0 {
+		dict.Set("View", MakeName(collection.View))
+	}
+
+	if len(collection.Sort) > 0 {
+		sortDict := MakeDict()
+		sortDict.Set("Type", MakeName("CollectionSort"))
+		keys := MakeArray()
+		ascending := MakeArray()
+		for i, key := range collection.Sort {
+			keys.Append(MakeName(key))
+			asc := true
+			if i < len(collection.SortAscending) {
+				asc = collection.SortAscending[i]
+			}
+			ascending.Append(MakeBool(asc))
+		}
+		sortDict.Set("S", keys)
+		sortDict.Set("A", ascending)
+		dict.Set("Sort", sortDict)
+	}
+	return dict
+}
+
+// PdfAttachment is a file attached to a document, either in the EmbeddedFiles name tree or by a
+// file attachment annotation.
+type PdfAttachment struct {
+	Name       string                       // Key in the EmbeddedFiles name tree or the file name.
+	PageNumber int                          // Page of the annotation (starting from 1), 0 if none.
+	Annotation *PdfAnnotationFileAttachment // Nil for document level attachments.
+	FileSpec   *PdfFileSpec
+}
+
+// GetEmbeddedFiles returns the file specifications of the EmbeddedFiles name tree of the name
+// dictionary, keyed by name.  Invalid file specifications are ignored.
+func (names *PdfNames) GetEmbeddedFiles() map[string]*PdfFileSpec {
+	files := map[string]*PdfFileSpec{}
+	tree := names.trees["EmbeddedFiles"]
+	if tree == nil {
+		return files
+	}
+	for _, key := range tree.Keys() {
+		obj, _ := tree.Get(key)
+		spec, err := NewPdfFileSpecFromObject(obj)
+		if err != nil {
+			common.Log.Debug("Invalid embedded file %q (%v) - ignoring", key, err)
+			continue
+		}
+		files[key] = spec
+	}
+	return files
+}
+
+// SetEmbeddedFile sets the embedded file `name` of the EmbeddedFiles name tree to `spec`.
+func (names *PdfNames) SetEmbeddedFile(name string, spec *PdfFileSpec) {
+	tree := names.trees["EmbeddedFiles"]
+	if tree == nil {
+		tree = NewPdfNameTree()
+		names.trees["EmbeddedFiles"] = tree
+	}
+	tree.Set(name, spec.ToPdfObject())
+}
@@ -0,0 +1,133 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// Test writing and reading embedded files, file attachment annotations and a portfolio.
+func TestEmbeddedFilesRoundTrip(t *testing.T) {
+	page := NewPdfPage()
+	page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 612, Ury: 792}
+	page.Resources = NewPdfPageResources()
+
+	csv := NewPdfFileSpec("data.csv", NewPdfEmbeddedFile([]byte("a,b\n1,2\n"), "text/csv"))
+	modDate := NewPdfDateFromTime(time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC))
+	csv.EmbeddedFile.ModDate = &modDate
+	csv.Description = "Source data æ"
+	csv.AFRelationship = AFRelationshipSource
+	csv.SetCollectionItem("order", MakeInteger(2))
+
+	notes := NewPdfFileSpec("notes.txt", NewPdfEmbeddedFile([]byte("Hello"), "text/plain"))
+	notes.SetCollectionItem("order", MakeInteger(1))
+
+	annot := NewPdfAnnotationFileAttachment()
+	annot.Rect = MakeArrayFromFloats([]float64{10, 10, 30, 30})
+	annot.SetFileSpec(NewPdfFileSpec("page.txt", NewPdfEmbeddedFile([]byte("On the page"), "")))
+	page.Annotations = []*PdfAnnotation{annot.PdfAnnotation}
+
+	collection := NewPdfCollection()
+	collection.AddField("order", CollectionFieldNumber, "Order")
+	collection.AddField("name", CollectionFieldFileName, "Name").Editable = true
+	collection.Sort = []string{"order"}
+	collection.InitialDocument = "notes.txt"
+
+	writer := NewPdfWriter()
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	writer.AddEmbeddedFile("data.csv", csv)
+	writer.AddEmbeddedFile("notes.txt", notes)
+	writer.AddAssociatedFile(csv)
+	writer.SetCollection(collection)
+
+	var buf bytes.Buffer
+	if err := writer.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	reader, err := NewPdfReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	attachments, err := reader.GetAttachments()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(attachments) != 3 {
+		t.Fatalf("Got %d attachments, expected 3", len(attachments))
+	}
+	expected := []struct {
+		name string
+		page int
+		data string
+	}{
+		{"data.csv", 0, "a,b\n1,2\n"},
+		{"notes.txt", 0, "Hello"},
+		{"page.txt", 1, "On the page"},
+	}
+	for i, exp := range expected {
+		a := attachments[i]
+		if a.Name != exp.name || a.PageNumber != exp.page || a.FileSpec.EmbeddedFile == nil ||
+			string(a.FileSpec.EmbeddedFile.Data) != exp.data {
+			t.Errorf("Attachment %d: got %+v", i, a)
+			continue
+		}
+		if err := a.FileSpec.EmbeddedFile.Verify(); err != nil {
+			t.Errorf("Attachment %d: %v", i, err)
+		}
+	}
+	if attachments[2].Annotation == nil {
+		t.Errorf("Annotation of page attachment not set")
+	}
+
+	spec := attachments[0].FileSpec
+	file := spec.EmbeddedFile
+	if spec.Description != "Source data æ" || spec.AFRelationship != AFRelationshipSource ||
+		file.MIMEType != "text/csv" || file.Size != 8 || file.ModDate == nil ||
+		!file.ModDate.ToGoTime().Equal(modDate.ToGoTime()) {
+		t.Errorf("Invalid file specification %+v %+v", spec, file)
+	}
+	if order, _ := GetIntVal(spec.CollectionItem.Get("order")); order != 2 {
+		t.Errorf("Invalid collection item %v", spec.CollectionItem)
+	}
+
+	af, err := reader.GetAssociatedFiles()
+	if err != nil || len(af) != 1 || af[0].FileName != "data.csv" {
+		t.Errorf("Associated files not read: %v %v", af, err)
+	}
+
+	collection, err = reader.GetCollection()
+	if err != nil || collection == nil {
+		t.Fatalf("Collection not read: %v", err)
+	}
+	if len(collection.Schema) != 2 || collection.Schema[0].Key != "order" || collection.Schema[1].Key != "name" ||
+		!collection.Schema[1].Editable || collection.Schema[0].Editable || collection.InitialDocument != "notes.txt" ||
+		len(collection.Sort) != 1 || len(collection.SortAscending) != 1 || !collection.SortAscending[0] {
+		t.Errorf("Invalid collection %+v", collection)
+	}
+}
+
+// Test the size and checksum verification of embedded files.
+func TestEmbeddedFileVerify(t *testing.T) {
+	file := NewPdfEmbeddedFile([]byte("contents"), "")
+	file, err := NewPdfEmbeddedFileFromObject(file.ToPdfObject())
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := file.Verify(); err != nil {
+		t.Errorf("Error: %v", err)
+	}
+	file.Data[0] = 'C'
+	if err := file.Verify(); err == nil {
+		t.Errorf("Modified contents not detected")
+	}
+}
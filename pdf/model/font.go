@@ -159,24 +159,7 @@ func newPdfFontFromPdfObject(fontObj core.PdfObject, allowType0 bool) (*PdfFont,
 func (font PdfFont) CharcodeBytesToUnicode(data []byte) (string, int, int) {
 	common.Log.Trace("showText: data=[% 02x]=%#q", data, data)
 
-	charcodes := make([]uint16, 0, len(data)+len(data)%2)
-	if font.baseFields().isCIDFont() {
-		if len(data) == 1 {
-			data = []byte{0, data[0]}
-		}
-		if len(data)%2 != 0 {
-			common.Log.Debug("ERROR: Padding data=%+v to even length", data)
-			data = append(data, 0)
-		}
-		for i := 0; i < len(data); i += 2 {
-			b := uint16(data[i])<<8 | uint16(data[i+1])
-			charcodes = append(charcodes, b)
-		}
-	} else {
-		for _, b := range data {
-			charcodes = append(charcodes, uint16(b))
-		}
-	}
+	charcodes := font.BytesToCharcodes(data)
 
 	charstrings := make([]string, 0, len(charcodes))
 	numMisses := 0
@@ -215,6 +198,70 @@ func (font PdfFont) CharcodeBytesToUnicode(data []byte) (string, int, int) {
 	return out, len([]rune(out)), numMisses
 }
 
+// IsCID returns true if `font` is a composite (CID-keyed) font, i.e. the character codes of
+// strings shown with the font are 2 bytes long.
+func (font PdfFont) IsCID() bool {
+	base := font.baseFields()
+	if base == nil {
+		return false
+	}
+	return base.isCIDFont()
+}
+
+// BytesToCharcodes converts the bytes of a PDF string shown with `font` to character codes.
+// Simple fonts have single byte character codes, composite fonts 2 byte character codes.
+func (font PdfFont) BytesToCharcodes(data []byte) []uint16 {
+	charcodes := make([]uint16, 0, len(data)+len(data)%2)
+	if font.IsCID() {
+		if len(data) == 1 {
+			data = []byte{0, data[0]}
+		}
+		if len(data)%2 != 0 {
+			common.Log.Debug("ERROR: Padding data=%+v to even length", data)
+			data = append(data, 0)
+		}
+		for i := 0; i < len(data); i += 2 {
+			b := uint16(data[i])<<8 | uint16(data[i+1])
+			charcodes = append(charcodes, b)
+		}
+	} else {
+		for _, b := range data {
+			charcodes = append(charcodes, uint16(b))
+		}
+	}
+	return charcodes
+}
+
+// GetCharMetrics returns the char metrics for character code `code`.  The widths are in glyph
+// space units (1/1000 of text space units).  A bool flag is returned to indicate whether or not
+// the metrics were found.
+func (font PdfFont) GetCharMetrics(code uint16) (fonts.CharMetrics, bool) {
+	switch t := font.context.(type) {
+	case *pdfFontSimple:
+		return t.getCharCodeMetrics(code)
+	case *pdfFontType0:
+		if t.DescendantFont == nil {
+			return fonts.CharMetrics{}, false
+		}
+		return t.DescendantFont.GetCharMetrics(code)
+	case *pdfCIDFontType0:
+		return getCIDWidthMetrics(t.widths, t.defaultWidth, code), true
+	case *pdfCIDFontType2:
+		return getCIDWidthMetrics(t.widths, t.defaultWidth, code), true
+	}
+
+	// Fall back to the glyph metrics.
+	encoder := font.Encoder()
+	if encoder == nil {
+		return fonts.CharMetrics{}, false
+	}
+	glyph, found := encoder.CharcodeToGlyph(code)
+	if !found {
+		return fonts.CharMetrics{}, false
+	}
+	return font.GetGlyphCharMetrics(glyph)
+}
+
 // ToPdfObject converts the PdfFont object to its PDF representation.
 func (font PdfFont) ToPdfObject() core.PdfObject {
 	if t := font.actualFont(); t != nil {
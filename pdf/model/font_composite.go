@@ -196,6 +196,12 @@ type pdfCIDFontType0 struct {
 	// Table 117 – Entries in a CIDFont dictionary (page 269)
 	CIDSystemInfo  *core.PdfObjectDictionary // (Required) Dictionary that defines the character collection of the CIDFont. See Table 116.
 	FontDescriptor core.PdfObject            // (Required) Describes the CIDFont’s default metrics other than its glyph widths
+	DW             core.PdfObject
+	W              core.PdfObject
+
+	// Glyph widths by CID, computed from DW and W.
+	widths       map[uint16]float64
+	defaultWidth float64
 }
 
 // pdfCIDFontType0FromSkeleton returns a pdfCIDFontType0 with its common fields initalized.
@@ -249,6 +255,11 @@ func newPdfCIDFontType0FromPdfObject(d *core.PdfObjectDictionary, base *fontComm
 	}
 	font.CIDSystemInfo = obj
 
+	// Optional attributes.
+	font.DW = d.Get("DW")
+	font.W = d.Get("W")
+	font.widths, font.defaultWidth = parseCIDFontWidths(font.DW, font.W)
+
 	return font, nil
 }
 
@@ -273,6 +284,10 @@ type pdfCIDFontType2 struct {
 
 	// Also mapping between GIDs (glyph index) and width.
 	gidToWidthMap map[uint16]int
+
+	// Glyph widths by CID, computed from DW and W.
+	widths       map[uint16]float64
+	defaultWidth float64
 }
 
 // pdfCIDFontType2FromSkeleton returns a pdfCIDFontType2 with its common fields initalized.
@@ -375,10 +390,70 @@ func newPdfCIDFontType2FromPdfObject(d *core.PdfObjectDictionary, base *fontComm
 	font.DW2 = d.Get("DW2")
 	font.W2 = d.Get("W2")
 	font.CIDToGIDMap = d.Get("CIDToGIDMap")
+	font.widths, font.defaultWidth = parseCIDFontWidths(font.DW, font.W)
 
 	return font, nil
 }
 
+// parseCIDFontWidths returns the glyph widths by CID and the default width of a CIDFont with
+// default width `dw` and widths array `w` (9.7.4.3 Glyph Metrics in CIDFonts p. 271).
+// The widths array consists of entries of the forms
+//   c [w1 w2 ... wn]    (widths of consecutive CIDs starting at c)
+//   cfirst clast w      (same width w for all CIDs in the range)
+func parseCIDFontWidths(dw, w core.PdfObject) (map[uint16]float64, float64) {
+	widths := map[uint16]float64{}
+	defaultWidth := 1000.0
+	if val, err := core.GetNumberAsFloat(core.TraceToDirectObject(dw)); err == nil {
+		defaultWidth = val
+	}
+
+	arr, ok := core.GetArray(w)
+	if !ok {
+		return widths, defaultWidth
+	}
+	elements := arr.Elements()
+	for i := 0; i < len(elements); {
+		first, ok := core.GetIntVal(elements[i])
+		if !ok || i+1 >= len(elements) {
+			common.Log.Debug("ERROR: Invalid CIDFont W array at %d", i)
+			break
+		}
+		if warr, ok := core.GetArray(elements[i+1]); ok {
+			for j, obj := range warr.Elements() {
+				if val, err := core.GetNumberAsFloat(core.TraceToDirectObject(obj)); err == nil {
+					widths[uint16(first+j)] = val
+				}
+			}
+			i += 2
+			continue
+		}
+		if i+2 >= len(elements) {
+			common.Log.Debug("ERROR: Invalid CIDFont W range at %d", i)
+			break
+		}
+		last, ok := core.GetIntVal(elements[i+1])
+		val, err := core.GetNumberAsFloat(core.TraceToDirectObject(elements[i+2]))
+		if !ok || err != nil || last-first > 0xFFFF {
+			common.Log.Debug("ERROR: Invalid CIDFont W range at %d", i)
+			break
+		}
+		for cid := first; cid <= last; cid++ {
+			widths[uint16(cid)] = val
+		}
+		i += 3
+	}
+	return widths, defaultWidth
+}
+
+// getCIDWidthMetrics returns the metrics of the glyph with CID `cid` from `widths`, using
+// `defaultWidth` for glyphs not in `widths`.
+func getCIDWidthMetrics(widths map[uint16]float64, defaultWidth float64, cid uint16) fonts.CharMetrics {
+	if w, has := widths[cid]; has {
+		return fonts.CharMetrics{Wx: w}
+	}
+	return fonts.CharMetrics{Wx: defaultWidth}
+}
+
 // NewCompositePdfFontFromTTFFile loads a composite font from a TTF font file. Composite fonts can
 // be used to represent unicode fonts which can have multi-byte character codes, representing a wide
 // range of values.
@@ -454,6 +529,7 @@ func NewCompositePdfFontFromTTFFile(filePath string) (*PdfFont, error) {
 		i = j
 	}
 	cidfont.W = core.MakeIndirectObject(wArr)
+	cidfont.widths, cidfont.defaultWidth = parseCIDFontWidths(cidfont.DW, cidfont.W)
 
 	// Use identity character id (CID) to glyph id (GID) mapping.
 	cidfont.CIDToGIDMap = core.MakeName("Identity")
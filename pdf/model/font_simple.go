@@ -112,6 +112,36 @@ func (font pdfFontSimple) GetGlyphCharMetrics(glyph string) (fonts.CharMetrics,
 	return metrics, true
 }
 
+// getCharCodeMetrics returns the character metrics for character code `code`.  The widths from
+// the font dictionary are used if available, otherwise the glyph metrics of the font (or the
+// corresponding standard 14 font).  A bool flag is returned to indicate whether or not the metrics
+// were found.
+func (font *pdfFontSimple) getCharCodeMetrics(code uint16) (fonts.CharMetrics, bool) {
+	index := int(code) - font.firstChar
+	if int(code) <= font.lastChar && index >= 0 && index < len(font.charWidths) {
+		return fonts.CharMetrics{Wx: font.charWidths[index]}, true
+	}
+
+	if font.encoder == nil {
+		return fonts.CharMetrics{}, false
+	}
+	glyph, found := font.encoder.CharcodeToGlyph(code)
+	if !found {
+		return fonts.CharMetrics{}, false
+	}
+	metrics := font.fontMetrics
+	if metrics == nil {
+		if std, ok := standard14Fonts[font.basefont]; ok {
+			metrics = std.fontMetrics
+		}
+	}
+	m, found := metrics[glyph]
+	if !found {
+		return fonts.CharMetrics{}, false
+	}
+	return m, true
+}
+
 // newSimpleFontFromPdfObject creates a pdfFontSimple from dictionary `d`. Elements of `d` that
 // are already parsed are contained in `base`.
 // An error is returned if there is a problem with loading.
@@ -309,10 +339,21 @@ func (font *pdfFontSimple) ToPdfObject() core.PdfObject {
 // styling functions.
 // Uses a WinAnsiTextEncoder and loads only character codes 32-255.
 func NewPdfFontFromTTFFile(filePath string) (*PdfFont, error) {
+	ttfBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		common.Log.Debug("ERROR: Unable to read file contents: %v", err)
+		return nil, err
+	}
+	return NewPdfFontFromTTF(ttfBytes)
+}
+
+// NewPdfFontFromTTF loads the TrueType font program `ttfBytes` and returns a PdfFont with the
+// program embedded, using WinAnsiEncoding.
+func NewPdfFontFromTTF(ttfBytes []byte) (*PdfFont, error) {
 	const minCode = 32
 	const maxCode = 255
 
-	ttf, err := fonts.TtfParse(filePath)
+	ttf, err := fonts.TtfParseData(ttfBytes)
 	if err != nil {
 		common.Log.Debug("ERROR: loading ttf font: %v", err)
 		return nil, err
@@ -376,6 +417,7 @@ func NewPdfFontFromTTFFile(filePath string) (*PdfFont, error) {
 	truefont.Encoding = core.MakeName("WinAnsiEncoding")
 
 	descriptor := &PdfFontDescriptor{}
+	descriptor.FontName = core.MakeName(ttf.PostScriptName)
 	descriptor.Ascent = core.MakeFloat(k * float64(ttf.TypoAscender))
 	descriptor.Descent = core.MakeFloat(k * float64(ttf.TypoDescender))
 	descriptor.CapHeight = core.MakeFloat(k * float64(ttf.CapHeight))
@@ -384,12 +426,6 @@ func NewPdfFontFromTTFFile(filePath string) (*PdfFont, error) {
 	descriptor.ItalicAngle = core.MakeFloat(float64(ttf.ItalicAngle))
 	descriptor.MissingWidth = core.MakeFloat(k * float64(ttf.Widths[0]))
 
-	ttfBytes, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		common.Log.Debug("ERROR: Unable to read file contents: %v", err)
-		return nil, err
-	}
-
 	stream, err := core.MakeStream(ttfBytes, core.NewFlateEncoder())
 	if err != nil {
 		common.Log.Debug("ERROR: Unable to make stream: %v", err)
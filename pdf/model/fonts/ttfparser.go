@@ -140,6 +140,12 @@ func TtfParse(fileStr string) (TtfType, error) {
 	return t.Parse()
 }
 
+// TtfParseData returns a TtfType describing the TrueType font file data `data`.
+func TtfParseData(data []byte) (TtfType, error) {
+	t := ttfParser{f: bytes.NewReader(data)}
+	return t.Parse()
+}
+
 // NewFontFile2FromPdfObject returns a TtfType describing the TrueType font file in io.Reader `t`.f.
 func (t *ttfParser) Parse() (TtfType, error) {
 
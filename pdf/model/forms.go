@@ -6,7 +6,9 @@
 package model
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/unidoc/unidoc/common"
 	. "github.com/unidoc/unidoc/pdf/core"
@@ -38,6 +40,20 @@ func NewPdfAcroForm() *PdfAcroForm {
 	return acroForm
 }
 
+// NewPdfAcroFormFromObject loads the interactive form dictionary `obj` together with its fields and
+// widget annotations.  All references must be resolved, as in objects of a loaded document or
+// objects built in memory.
+func NewPdfAcroFormFromObject(obj PdfObject) (*PdfAcroForm, error) {
+	dict, ok := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !ok {
+		return nil, ErrTypeError
+	}
+	r := &PdfReader{}
+	r.traversed = map[PdfObject]bool{}
+	r.modelManager = newModelManager()
+	return r.newPdfAcroFormFromDict(dict)
+}
+
 // Used when loading forms from PDF files.
 func (r *PdfReader) newPdfAcroFormFromDict(d *PdfObjectDictionary) (*PdfAcroForm, error) {
 	acroForm := NewPdfAcroForm()
@@ -187,6 +203,34 @@ func (this *PdfAcroForm) ToPdfObject() PdfObject {
 	return container
 }
 
+// FieldFlag represents form field flags (Ff) which specify various characteristics of a field
+// (Tables 221, 226, 228 and 230).
+type FieldFlag uint32
+
+const (
+	FieldFlagReadOnly        FieldFlag = 1
+	FieldFlagRequired        FieldFlag = 1 << 1
+	FieldFlagNoExport        FieldFlag = 1 << 2
+	FieldFlagMultiline       FieldFlag = 1 << 12
+	FieldFlagPassword        FieldFlag = 1 << 13
+	FieldFlagNoToggleToOff   FieldFlag = 1 << 14
+	FieldFlagRadio           FieldFlag = 1 << 15
+	FieldFlagPushbutton      FieldFlag = 1 << 16
+	FieldFlagCombo           FieldFlag = 1 << 17
+	FieldFlagEdit            FieldFlag = 1 << 18
+	FieldFlagSort            FieldFlag = 1 << 19
+	FieldFlagFileSelect      FieldFlag = 1 << 20
+	FieldFlagMultiSelect     FieldFlag = 1 << 21
+	FieldFlagDoNotSpellCheck FieldFlag = 1 << 22
+	FieldFlagDoNotScroll     FieldFlag = 1 << 23
+	FieldFlagComb            FieldFlag = 1 << 24
+)
+
+// Has returns true if all the flags in `flag` are set.
+func (ff FieldFlag) Has(flag FieldFlag) bool {
+	return ff&flag == flag
+}
+
 // PdfField represents a field of an interactive form.
 // Implements PdfModel interface.
 type PdfField struct {
@@ -417,3 +461,150 @@ func (this *PdfField) ToPdfObject() PdfObject {
 
 	return container
 }
+
+// PartialName returns the partial name of the field (T entry), decoded from PDFDocEncoding or
+// UTF-16BE as a text string, or an empty string if not set.
+func (this *PdfField) PartialName() string {
+	name, ok := GetString(this.T)
+	if !ok {
+		return ""
+	}
+	return name.Decoded()
+}
+
+// FullName returns the fully qualified name of the field, i.e. the partial names of the field and
+// all its ancestors joined by periods (12.7.3.2 Field Names p. 434).
+// Ancestors without a partial name are skipped.
+func (this *PdfField) FullName() (string, error) {
+	parts := []string{}
+	traversed := map[*PdfField]bool{}
+	for field := this; field != nil; field = field.Parent {
+		if traversed[field] {
+			return "", errors.New("Recursive traversal of field parents")
+		}
+		traversed[field] = true
+		if name := field.PartialName(); len(name) > 0 {
+			parts = append([]string{name}, parts...)
+		}
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// IsTerminal returns true if the field is a terminal field, i.e. it does not have any child fields with
+// a partial name.  Kids of terminal fields are widget annotations (possibly stored as nameless fields).
+func (this *PdfField) IsTerminal() bool {
+	for _, kid := range this.KidsF {
+		if field, ok := kid.(*PdfField); ok && field.T != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Widgets returns the widget annotations associated with the field.  Includes widgets
+// merged with the field dictionary and widgets stored in nameless kids.
+func (this *PdfField) Widgets() []*PdfAnnotationWidget {
+	widgets := []*PdfAnnotationWidget{}
+	for _, annot := range this.KidsA {
+		if widget, ok := annot.GetContext().(*PdfAnnotationWidget); ok {
+			widgets = append(widgets, widget)
+		}
+	}
+	for _, kid := range this.KidsF {
+		if field, ok := kid.(*PdfField); ok && field.T == nil {
+			widgets = append(widgets, field.Widgets()...)
+		}
+	}
+	return widgets
+}
+
+// GetFieldType returns the field type (FT) of the field, taking inheritance into account.
+// Returns an empty string if not defined.
+func (this *PdfField) GetFieldType() string {
+	for field := this; field != nil; field = field.Parent {
+		if field.FT != nil {
+			return string(*field.FT)
+		}
+	}
+	return ""
+}
+
+// GetFieldFlags returns the field flags (Ff) of the field, taking inheritance into account.
+func (this *PdfField) GetFieldFlags() FieldFlag {
+	for field := this; field != nil; field = field.Parent {
+		if val, ok := GetIntVal(field.Ff); ok {
+			return FieldFlag(val)
+		}
+	}
+	return 0
+}
+
+// GetValue returns the field value (V) of the field, taking inheritance into account.
+// Returns nil if not defined.
+func (this *PdfField) GetValue() PdfObject {
+	for field := this; field != nil; field = field.Parent {
+		if field.V != nil {
+			return field.V
+		}
+	}
+	return nil
+}
+
+// GetDA returns the default appearance string (DA) of the field taking inheritance into account,
+// or an empty string if not defined.
+func (this *PdfField) GetDA() string {
+	for field := this; field != nil; field = field.Parent {
+		if da, ok := GetStringVal(field.DA); ok {
+			return da
+		}
+	}
+	return ""
+}
+
+// AllFields returns a flattened list of all the fields in the form, in the order they are
+// defined (depth first).  Nameless fields representing widget annotations are not included.
+func (this *PdfAcroForm) AllFields() []*PdfField {
+	fields := []*PdfField{}
+	if this.Fields == nil {
+		return fields
+	}
+
+	var walk func(field *PdfField)
+	walk = func(field *PdfField) {
+		fields = append(fields, field)
+		for _, kid := range field.KidsF {
+			if child, ok := kid.(*PdfField); ok && child.T != nil {
+				walk(child)
+			}
+		}
+	}
+	for _, field := range *this.Fields {
+		walk(field)
+	}
+	return fields
+}
+
+// TerminalFields returns the terminal fields of the form, i.e. the fields that hold values.
+func (this *PdfAcroForm) TerminalFields() []*PdfField {
+	terminal := []*PdfField{}
+	for _, field := range this.AllFields() {
+		if field.IsTerminal() {
+			terminal = append(terminal, field)
+		}
+	}
+	return terminal
+}
+
+// GetFieldByFullName returns the field with the fully qualified name `name`, or nil if not found.
+func (this *PdfAcroForm) GetFieldByFullName(name string) *PdfField {
+	for _, field := range this.AllFields() {
+		fullname, err := field.FullName()
+		if err != nil {
+			continue
+		}
+		if fullname == name {
+			return field
+		}
+	}
+	return nil
+}
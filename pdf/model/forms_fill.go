@@ -0,0 +1,139 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// FieldValueProvider provides field values for filling an interactive form, such as from FDF or
+// XFDF data.  The values are keyed by the fully qualified field names.
+type FieldValueProvider interface {
+	FieldValues() (map[string]PdfObject, error)
+}
+
+// FieldAppearanceGenerator generates appearance streams for form fields.  The generated appearance
+// dictionary is set as the AP entry of the widget annotation `wa` belonging to `field`.
+// Implemented by the annotator package.
+type FieldAppearanceGenerator interface {
+	GenerateAppearanceDict(form *PdfAcroForm, field *PdfField, wa *PdfAnnotationWidget) (*PdfObjectDictionary, error)
+}
+
+// Fill populates the form fields with the values from `provider`.  Fields are matched by their fully
+// qualified names, values for unknown fields are ignored.
+// As the appearance streams of the filled fields are not regenerated, the NeedAppearances flag
+// is set so that viewers regenerate them.  Use FillWithAppearance to generate the appearances.
+func (this *PdfAcroForm) Fill(provider FieldValueProvider) error {
+	filled, err := this.fill(provider)
+	if err != nil {
+		return err
+	}
+	if len(filled) > 0 {
+		this.NeedAppearances = MakeBool(true)
+	}
+	return nil
+}
+
+// FillWithAppearance populates the form fields with the values from `provider` and regenerates
+// the appearance streams of the widget annotations of the filled fields with `appGen`.
+func (this *PdfAcroForm) FillWithAppearance(provider FieldValueProvider, appGen FieldAppearanceGenerator) error {
+	filled, err := this.fill(provider)
+	if err != nil {
+		return err
+	}
+	if appGen == nil {
+		if len(filled) > 0 {
+			this.NeedAppearances = MakeBool(true)
+		}
+		return nil
+	}
+
+	for _, field := range filled {
+		for _, widget := range field.Widgets() {
+			apDict, err := appGen.GenerateAppearanceDict(this, field, widget)
+			if err != nil {
+				return err
+			}
+			if apDict != nil {
+				widget.AP = apDict
+			}
+		}
+	}
+	return nil
+}
+
+// fill sets the values of the terminal fields from `provider` and returns the list of fields
+// that were updated.
+func (this *PdfAcroForm) fill(provider FieldValueProvider) ([]*PdfField, error) {
+	values, err := provider.FieldValues()
+	if err != nil {
+		return nil, err
+	}
+
+	filled := []*PdfField{}
+	for _, field := range this.TerminalFields() {
+		name, err := field.FullName()
+		if err != nil {
+			return nil, err
+		}
+		val, has := values[name]
+		if !has {
+			continue
+		}
+		common.Log.Trace("Filling field %s: %v", name, val)
+		field.setValue(val)
+		filled = append(filled, field)
+	}
+	return filled, nil
+}
+
+// setValue sets the value `val` of the field and updates the widget annotations accordingly.
+// Button values are names, so strings are converted as needed.  The appearance state (AS) of the
+// button widgets is set to the matching state or Off.
+func (this *PdfField) setValue(val PdfObject) {
+	if this.GetFieldType() == "Btn" {
+		if str, isStr := GetString(val); isStr {
+			val = MakeName(str.Str())
+		}
+	}
+	this.V = val
+
+	for _, widget := range this.Widgets() {
+		// Merged field / widget dictionaries carry the value in the widget dictionary also.
+		if dict, ok := widget.container.PdfObject.(*PdfObjectDictionary); ok {
+			if dict.Get("T") != nil || dict.Get("FT") != nil {
+				dict.Set("V", val)
+			}
+		}
+
+		if this.GetFieldType() != "Btn" {
+			continue
+		}
+		state, ok := GetNameVal(val)
+		if !ok {
+			continue
+		}
+		if widget.hasAppearanceState(state) {
+			widget.AS = MakeName(state)
+		} else {
+			widget.AS = MakeName("Off")
+		}
+	}
+}
+
+// hasAppearanceState returns true if the normal appearance of the widget has a state named `state`.
+func (widget *PdfAnnotationWidget) hasAppearanceState(state string) bool {
+	apDict, ok := GetDict(widget.AP)
+	if !ok {
+		return false
+	}
+	nDict, ok := GetDict(apDict.Get("N"))
+	if !ok {
+		return false
+	}
+	return nDict.Get(PdfObjectName(state)) != nil
+}
@@ -0,0 +1,83 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// mapValueProvider provides field values from a map.
+type mapValueProvider map[string]PdfObject
+
+func (m mapValueProvider) FieldValues() (map[string]PdfObject, error) {
+	return m, nil
+}
+
+// Test fully qualified field names and filling of terminal fields.
+func TestFormFill(t *testing.T) {
+	parent := NewPdfField()
+	parent.T = MakeString("person")
+
+	nameField := NewPdfField()
+	nameField.T = MakeString("name")
+	nameField.FT = MakeName("Tx")
+	nameField.Parent = parent
+
+	agreeField := NewPdfField()
+	agreeField.T = MakeString("agree")
+	agreeField.FT = MakeName("Btn")
+	agreeField.Parent = parent
+	widget := NewPdfAnnotationWidget()
+	onDict := MakeDict()
+	onDict.Set("Yes", MakeNull())
+	onDict.Set("Off", MakeNull())
+	apDict := MakeDict()
+	apDict.Set("N", onDict)
+	widget.AP = apDict
+	agreeField.KidsA = append(agreeField.KidsA, widget.PdfAnnotation)
+
+	parent.KidsF = append(parent.KidsF, nameField, agreeField)
+
+	form := NewPdfAcroForm()
+	form.Fields = &[]*PdfField{parent}
+
+	fullname, err := nameField.FullName()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if fullname != "person.name" {
+		t.Fatalf("Incorrect full name: %s", fullname)
+	}
+	if len(form.TerminalFields()) != 2 {
+		t.Fatalf("Incorrect number of terminal fields: %d", len(form.TerminalFields()))
+	}
+	if form.GetFieldByFullName("person.agree") != agreeField {
+		t.Fatalf("Field person.agree not found")
+	}
+
+	err = form.Fill(mapValueProvider{
+		"person.name":  MakeString("John"),
+		"person.agree": MakeString("Yes"),
+		"unknown":      MakeString("ignored"),
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if val, _ := GetStringVal(nameField.V); val != "John" {
+		t.Errorf("Incorrect value: %v", nameField.V)
+	}
+	if val, _ := GetNameVal(agreeField.V); val != "Yes" {
+		t.Errorf("Incorrect button value: %v", agreeField.V)
+	}
+	if state, _ := GetNameVal(widget.AS); state != "Yes" {
+		t.Errorf("Incorrect appearance state: %v", widget.AS)
+	}
+	if b := form.NeedAppearances; b == nil || !bool(*b) {
+		t.Errorf("NeedAppearances not set")
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// CIE XYZ tristimulus values of the D50 white point and of the sRGB primaries adapted to D50, as
+// stored in the sRGB profiles (IEC 61966-2-1).
+var (
+	iccWhitePointD50 = [3]float64{0.9642, 1.0, 0.8249}
+	iccSRGBRed       = [3]float64{0.4361, 0.2225, 0.0139}
+	iccSRGBGreen     = [3]float64{0.3851, 0.7169, 0.0971}
+	iccSRGBBlue      = [3]float64{0.1431, 0.0606, 0.7141}
+)
+
+// Number of entries of the sampled tone reproduction curve of the sRGB profile.
+const iccSRGBCurveSize = 1024
+
+// SRGBICCProfile returns an ICC (version 2.1) display profile of the sRGB color space, e.g. for the
+// destination profile of an output intent.  The tone reproduction curves are sampled from the sRGB
+// transfer function.
+func SRGBICCProfile() []byte {
+	curve := make([]float64, iccSRGBCurveSize)
+	for i := range curve {
+		x := float64(i) / float64(iccSRGBCurveSize-1)
+		if x <= 0.04045 {
+			curve[i] = x / 12.92
+		} else {
+			curve[i] = math.Pow((x+0.055)/1.055, 2.4)
+		}
+	}
+	trc := iccCurveType(curve)
+
+	tags := []struct {
+		sig  string
+		data []byte
+	}{
+		{"desc", iccTextDescriptionType("sRGB IEC61966-2.1")},
+		{"cprt", iccTextType("No copyright, use freely")},
+		{"wtpt", iccXYZType(iccWhitePointD50)},
+		{"rXYZ", iccXYZType(iccSRGBRed)},
+		{"gXYZ", iccXYZType(iccSRGBGreen)},
+		{"bXYZ", iccXYZType(iccSRGBBlue)},
+		{"rTRC", trc},
+		{"gTRC", trc},
+		{"bTRC", trc},
+	}
+
+	// Tag data follows the header and the tag table, aligned on 4 bytes.  Identical tag data is
+	// shared by the tags.
+	table := &bytes.Buffer{}
+	body := &bytes.Buffer{}
+	binary.Write(table, binary.BigEndian, uint32(len(tags)))
+	start := 128 + 4 + 12*len(tags)
+	offsets := map[string]int{}
+	for _, tag := range tags {
+		offset, has := offsets[string(tag.data)]
+		if !has {
+			offset = start + body.Len()
+			offsets[string(tag.data)] = offset
+			body.Write(tag.data)
+			for body.Len()%4 != 0 {
+				body.WriteByte(0)
+			}
+		}
+		table.WriteString(tag.sig)
+		binary.Write(table, binary.BigEndian, uint32(offset))
+		binary.Write(table, binary.BigEndian, uint32(len(tag.data)))
+	}
+
+	header := make([]byte, 128)
+	binary.BigEndian.PutUint32(header[0:], uint32(start+body.Len()))
+	binary.BigEndian.PutUint32(header[8:], 0x02100000) // Version 2.1.
+	copy(header[12:], "mntr")
+	copy(header[16:], "RGB ")
+	copy(header[20:], "XYZ ")
+	for i, v := range []uint16{2018, 1, 1, 0, 0, 0} {
+		binary.BigEndian.PutUint16(header[24+2*i:], v)
+	}
+	copy(header[36:], "acsp")
+	copy(header[68:], iccXYZNumbers(iccWhitePointD50))
+
+	profile := append(header, table.Bytes()...)
+	return append(profile, body.Bytes()...)
+}
+
+// iccXYZNumbers returns the XYZ values `xyz` as s15Fixed16Number values.
+func iccXYZNumbers(xyz [3]float64) []byte {
+	data := make([]byte, 12)
+	for i, v := range xyz {
+		binary.BigEndian.PutUint32(data[4*i:], uint32(int32(math.Floor(v*65536+0.5))))
+	}
+	return data
+}
+
+// iccXYZType returns an XYZType tag of the XYZ values `xyz`.
+func iccXYZType(xyz [3]float64) []byte {
+	data := []byte("XYZ \x00\x00\x00\x00")
+	return append(data, iccXYZNumbers(xyz)...)
+}
+
+// iccTextType returns a textType tag of `text`.
+func iccTextType(text string) []byte {
+	data := []byte("text\x00\x00\x00\x00")
+	data = append(data, text...)
+	return append(data, 0)
+}
+
+// iccTextDescriptionType returns a textDescriptionType tag of the ASCII description `text`, with
+// empty Unicode and ScriptCode descriptions.
+func iccTextDescriptionType(text string) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("desc\x00\x00\x00\x00")
+	binary.Write(buf, binary.BigEndian, uint32(len(text)+1))
+	buf.WriteString(text)
+	buf.WriteByte(0)
+	// Unicode language code and count, ScriptCode code, count and description.
+	buf.Write(make([]byte, 4+4+2+1+67))
+	return buf.Bytes()
+}
+
+// iccCurveType returns a curveType tag sampling the curve `values` in the range [0, 1].
+func iccCurveType(values []float64) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("curv\x00\x00\x00\x00")
+	binary.Write(buf, binary.BigEndian, uint32(len(values)))
+	for _, v := range values {
+		binary.Write(buf, binary.BigEndian, uint16(math.Floor(v*65535+0.5)))
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,184 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfInfo represents the document information dictionary (14.3.3 Document Information Dictionary
+// p. 549).  Empty strings and nil dates represent entries that are not set.
+type PdfInfo struct {
+	Title        string
+	Author       string
+	Subject      string
+	Keywords     string
+	Creator      string // Application that created the original document.
+	Producer     string // Application that converted the document to PDF.
+	CreationDate *PdfDate
+	ModDate      *PdfDate
+	Trapped      string // True, False or Unknown.
+
+	// Custom (non-standard) entries.
+	custom map[string]string
+}
+
+// Keys of the standard document information entries.
+var pdfInfoStandardKeys = map[string]bool{
+	"Title": true, "Author": true, "Subject": true, "Keywords": true, "Creator": true,
+	"Producer": true, "CreationDate": true, "ModDate": true, "Trapped": true,
+}
+
+// NewPdfInfo returns an empty document information dictionary model.
+func NewPdfInfo() *PdfInfo {
+	return &PdfInfo{custom: map[string]string{}}
+}
+
+// NewPdfInfoFromObject loads the document information dictionary `obj`.  Invalid dates are
+// ignored.
+func NewPdfInfoFromObject(obj PdfObject) (*PdfInfo, error) {
+	dict, ok := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !ok {
+		return nil, ErrTypeError
+	}
+
+	info := NewPdfInfo()
+	for _, key := range dict.Keys() {
+		val := TraceToDirectObject(dict.Get(key))
+		switch key {
+		case "CreationDate", "ModDate":
+			str, ok := val.(*PdfObjectString)
+			if !ok {
+				common.Log.Debug("Invalid %s type (%T) - ignoring", key, val)
+				continue
+			}
+			date, err := NewPdfDate(str.Str())
+			if err != nil {
+				common.Log.Debug("Invalid %s (%v) - ignoring", key, err)
+				continue
+			}
+			if key == "CreationDate" {
+				info.CreationDate = &date
+			} else {
+				info.ModDate = &date
+			}
+		case "Trapped":
+			switch t := val.(type) {
+			case *PdfObjectName:
+				info.Trapped = string(*t)
+			case *PdfObjectBool:
+				// Boolean values are used by some older producers.
+				info.Trapped = "False"
+				if *t {
+					info.Trapped = "True"
+				}
+			}
+		default:
+			str, ok := val.(*PdfObjectString)
+			if !ok {
+				common.Log.Debug("Invalid info entry %s type (%T) - ignoring", key, val)
+				continue
+			}
+			info.setString(string(key), str.Decoded())
+		}
+	}
+
+	return info, nil
+}
+
+// setString sets the text string entry `key` to `value`.
+func (info *PdfInfo) setString(key, value string) {
+	switch key {
+	case "Title":
+		info.Title = value
+	case "Author":
+		info.Author = value
+	case "Subject":
+		info.Subject = value
+	case "Keywords":
+		info.Keywords = value
+	case "Creator":
+		info.Creator = value
+	case "Producer":
+		info.Producer = value
+	default:
+		info.custom[key] = value
+	}
+}
+
+// SetCustom sets the custom entry `key` to `value`, or removes it if `value` is empty.  The
+// standard entries cannot be set as custom entries.
+func (info *PdfInfo) SetCustom(key, value string) error {
+	if pdfInfoStandardKeys[key] {
+		return errors.New("Standard info entry")
+	}
+	if len(key) == 0 {
+		return errors.New("Empty key")
+	}
+	if info.custom == nil {
+		info.custom = map[string]string{}
+	}
+	if len(value) == 0 {
+		delete(info.custom, key)
+		return nil
+	}
+	info.custom[key] = value
+	return nil
+}
+
+// GetCustom returns the value of the custom entry `key`.
+func (info *PdfInfo) GetCustom(key string) (string, bool) {
+	val, has := info.custom[key]
+	return val, has
+}
+
+// CustomKeys returns the keys of the custom entries in sorted order.
+func (info *PdfInfo) CustomKeys() []string {
+	keys := []string{}
+	for key := range info.custom {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ToPdfObject returns the document information dictionary.
+func (info *PdfInfo) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	strs := []struct {
+		key   PdfObjectName
+		value string
+	}{
+		{"Title", info.Title},
+		{"Author", info.Author},
+		{"Subject", info.Subject},
+		{"Keywords", info.Keywords},
+		{"Creator", info.Creator},
+		{"Producer", info.Producer},
+	}
+	for _, s := range strs {
+		if len(s.value) > 0 {
+			dict.Set(s.key, MakeEncodedString(s.value))
+		}
+	}
+	if info.CreationDate != nil {
+		dict.Set("CreationDate", info.CreationDate.ToPdfObject())
+	}
+	if info.ModDate != nil {
+		dict.Set("ModDate", info.ModDate.ToPdfObject())
+	}
+	if len(info.Trapped) > 0 {
+		dict.Set("Trapped", MakeName(info.Trapped))
+	}
+	for _, key := range info.CustomKeys() {
+		dict.Set(PdfObjectName(key), MakeEncodedString(info.custom[key]))
+	}
+	return dict
+}
@@ -0,0 +1,111 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPdfDateTime(t *testing.T) {
+	date, err := NewPdfDate("D:20080313232937+01'30'")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	tm := date.ToGoTime()
+	expected := time.Date(2008, 3, 13, 21, 59, 37, 0, time.UTC)
+	if !tm.Equal(expected) {
+		t.Fatalf("Got %v, expected %v", tm, expected)
+	}
+
+	date2 := NewPdfDateFromTime(tm)
+	if str := date2.ToPdfObject().String(); str != "D:20080313232937+01'30'" {
+		t.Fatalf("Round trip: got %s", str)
+	}
+}
+
+// Test writing the document information dictionary and XMP metadata and reading them back.
+func TestPdfInfoMetadata(t *testing.T) {
+	created, _ := NewPdfDate("D:20180102030405Z00'00'")
+	info := NewPdfInfo()
+	info.Title = "Title ‘quoted’"
+	info.Author = "Jane Roe"
+	info.Subject = "Subject"
+	info.Keywords = "one, two"
+	info.CreationDate = &created
+	info.ModDate = &created
+	if err := info.SetCustom("Department", "Legal"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := info.SetCustom("Title", "x"); err == nil {
+		t.Fatalf("Standard entry set as custom entry")
+	}
+
+	// Existing packet with a property set as an attribute, which should be replaced, and an
+	// unrelated property, which should be kept.
+	xmp, err := NewXMPMetadataFromBytes([]byte(`<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:pdf="http://ns.adobe.com/pdf/1.3/" pdf:Keywords="old"/>
+<rdf:Description rdf:about="" xmlns:xmpMM="http://ns.adobe.com/xap/1.0/mm/"><xmpMM:DocumentID>uuid:1</xmpMM:DocumentID></rdf:Description>
+</rdf:RDF></x:xmpmeta>
+<?xpacket end="w"?>`))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	writer := NewPdfWriter()
+	page := NewPdfPage()
+	page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 612, Ury: 792}
+	page.Resources = NewPdfPageResources()
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	writer.SetPdfInfo(info)
+	writer.SetXMPMetadata(xmp)
+
+	var buf bytes.Buffer
+	if err := writer.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	reader, err := NewPdfReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	readInfo, err := reader.GetPdfInfo()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	readXMP, err := reader.GetXMPMetadata()
+	if err != nil || readXMP == nil {
+		t.Fatalf("Error reading XMP: %v", err)
+	}
+
+	for _, got := range []*PdfInfo{readInfo, readXMP.PdfInfo()} {
+		if got.Title != info.Title || got.Author != info.Author || got.Subject != info.Subject ||
+			got.Keywords != info.Keywords {
+			t.Errorf("Mismatch: %+v", got)
+		}
+		if !strings.HasPrefix(got.Producer, "UniDoc") {
+			t.Errorf("Default producer not set: %q", got.Producer)
+		}
+		if got.CreationDate == nil || !got.CreationDate.ToGoTime().Equal(created.ToGoTime()) {
+			t.Errorf("Creation date mismatch: %v", got.CreationDate)
+		}
+		if val, _ := got.GetCustom("Department"); val != "Legal" {
+			t.Errorf("Custom entry mismatch: %q", val)
+		}
+	}
+
+	if val, _ := readXMP.GetProperty("http://ns.adobe.com/xap/1.0/mm/", "DocumentID"); val != "uuid:1" {
+		t.Errorf("Unrelated property not preserved: %q", val)
+	}
+	if strings.Count(string(readXMP.Bytes()), "Keywords") != 2 {
+		t.Errorf("Keywords not replaced: %s", readXMP.Bytes())
+	}
+}
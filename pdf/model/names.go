@@ -0,0 +1,103 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"sort"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfNames represents the name dictionary of the document catalog (7.7.4 Name Dictionary p. 80),
+// holding a name tree per category such as Dests, JavaScript or EmbeddedFiles.
+type PdfNames struct {
+	trees map[PdfObjectName]*PdfNameTree
+}
+
+// NewPdfNames returns an empty name dictionary.
+func NewPdfNames() *PdfNames {
+	return &PdfNames{trees: map[PdfObjectName]*PdfNameTree{}}
+}
+
+// NewPdfNamesFromObject loads the name dictionary `obj`.  Invalid name trees are ignored.
+func NewPdfNamesFromObject(obj PdfObject) (*PdfNames, error) {
+	dict, ok := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !ok {
+		return nil, ErrTypeError
+	}
+	names := NewPdfNames()
+	for _, key := range dict.Keys() {
+		tree, err := NewPdfNameTreeFromObject(dict.Get(key))
+		if err != nil {
+			common.Log.Debug("Invalid %s name tree (%v) - ignoring", key, err)
+			continue
+		}
+		names.trees[key] = tree
+	}
+	return names, nil
+}
+
+// GetTree returns the name tree of `category` (e.g. Dests), or nil if there is none.
+func (names *PdfNames) GetTree(category PdfObjectName) *PdfNameTree {
+	return names.trees[category]
+}
+
+// SetTree sets the name tree of `category` to `tree`, or removes it if `tree` is nil.
+func (names *PdfNames) SetTree(category PdfObjectName, tree *PdfNameTree) {
+	if tree == nil {
+		delete(names.trees, category)
+		return
+	}
+	names.trees[category] = tree
+}
+
+// Categories returns the categories with name trees in sorted order.
+func (names *PdfNames) Categories() []PdfObjectName {
+	keys := make([]PdfObjectName, 0, len(names.trees))
+	for key := range names.trees {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// GetDestination returns the named destination `name` of the Dests name tree.
+func (names *PdfNames) GetDestination(name string) (*PdfDestination, bool) {
+	tree := names.trees["Dests"]
+	if tree == nil {
+		return nil, false
+	}
+	obj, has := tree.Get(name)
+	if !has {
+		return nil, false
+	}
+	dest, err := NewPdfDestinationFromObject(obj)
+	if err != nil {
+		common.Log.Debug("Invalid destination %q (%v)", name, err)
+		return nil, false
+	}
+	return dest, true
+}
+
+// SetDestination sets the named destination `name` of the Dests name tree to `dest`.
+func (names *PdfNames) SetDestination(name string, dest *PdfDestination) {
+	tree := names.trees["Dests"]
+	if tree == nil {
+		tree = NewPdfNameTree()
+		names.trees["Dests"] = tree
+	}
+	tree.Set(name, dest.ToPdfObject())
+}
+
+// ToPdfObject returns the name dictionary.
+func (names *PdfNames) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	for _, key := range names.Categories() {
+		dict.Set(key, names.trees[key].ToPdfObject())
+	}
+	return dict
+}
@@ -0,0 +1,615 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfOptionalContentGroup represents an optional content group (OCG), a collection of graphics
+// that can be made visible or invisible, e.g. a layer (8.11.2 Optional Content Groups p. 218).
+type PdfOptionalContentGroup struct {
+	Name   string
+	Intent []string  // View (default) and/or Design.
+	Usage  PdfObject // Usage dictionary, kept as is.
+
+	container *PdfIndirectObject
+}
+
+// NewPdfOptionalContentGroup returns a new optional content group named `name`.
+func NewPdfOptionalContentGroup(name string) *PdfOptionalContentGroup {
+	return &PdfOptionalContentGroup{Name: name, container: MakeIndirectObject(MakeDict())}
+}
+
+// GetContainingPdfObject returns the indirect object holding the group dictionary.
+func (ocg *PdfOptionalContentGroup) GetContainingPdfObject() PdfObject {
+	return ocg.container
+}
+
+// ToPdfObject returns the group dictionary in an indirect object.
+func (ocg *PdfOptionalContentGroup) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	dict.Set("Type", MakeName("OCG"))
+	dict.Set("Name", MakeString(ocg.Name))
+	if intent := makeNameOrArray(ocg.Intent); intent != nil {
+		dict.Set("Intent", intent)
+	}
+	if ocg.Usage != nil {
+		dict.Set("Usage", ocg.Usage)
+	}
+	ocg.container.PdfObject = dict
+	return ocg.container
+}
+
+// PdfOCPolicy is the visibility policy of an optional content membership dictionary.
+type PdfOCPolicy string
+
+// Visibility policies.
+const (
+	PdfOCPolicyAllOn  PdfOCPolicy = "AllOn"  // Visible if all groups are on.
+	PdfOCPolicyAnyOn  PdfOCPolicy = "AnyOn"  // Visible if any group is on (default).
+	PdfOCPolicyAnyOff PdfOCPolicy = "AnyOff" // Visible if any group is off.
+	PdfOCPolicyAllOff PdfOCPolicy = "AllOff" // Visible if all groups are off.
+)
+
+// PdfOCVisibilityExpression is a visibility expression of an optional content membership
+// dictionary: either a group, or the And, Or or Not operator applied to the operands.
+type PdfOCVisibilityExpression struct {
+	Group    *PdfOptionalContentGroup
+	Operator string
+	Operands []*PdfOCVisibilityExpression
+}
+
+// PdfOptionalContentMembership represents an optional content membership dictionary (OCMD),
+// expressing the visibility of content from the states of several groups (8.11.2.2 p. 220).
+type PdfOptionalContentMembership struct {
+	OCGs   []*PdfOptionalContentGroup
+	Policy PdfOCPolicy                // AnyOn if empty.
+	VE     *PdfOCVisibilityExpression // Takes precedence over OCGs and Policy if set.
+
+	container *PdfIndirectObject
+}
+
+// NewPdfOptionalContentMembership returns a new membership dictionary of `ocgs` with the
+// visibility policy `policy`.
+func NewPdfOptionalContentMembership(policy PdfOCPolicy, ocgs ...*PdfOptionalContentGroup) *PdfOptionalContentMembership {
+	return &PdfOptionalContentMembership{OCGs: ocgs, Policy: policy, container: MakeIndirectObject(MakeDict())}
+}
+
+// GetContainingPdfObject returns the indirect object holding the membership dictionary.
+func (ocmd *PdfOptionalContentMembership) GetContainingPdfObject() PdfObject {
+	return ocmd.container
+}
+
+// ToPdfObject returns the membership dictionary in an indirect object.
+func (ocmd *PdfOptionalContentMembership) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	dict.Set("Type", MakeName("OCMD"))
+	if len(ocmd.OCGs) == 1 {
+		dict.Set("OCGs", ocmd.OCGs[0].ToPdfObject())
+	} else if len(ocmd.OCGs) > 1 {
+		dict.Set("OCGs", ocgsToArray(ocmd.OCGs))
+	}
+	if len(ocmd.Policy) > 0 {
+		dict.Set("P", MakeName(string(ocmd.Policy)))
+	}
+	if ocmd.VE != nil {
+		dict.Set("VE", ocmd.VE.toPdfObject())
+	}
+	ocmd.container.PdfObject = dict
+	return ocmd.container
+}
+
+// toPdfObject returns the expression as a group or an array.
+func (ve *PdfOCVisibilityExpression) toPdfObject() PdfObject {
+	if ve.Group != nil {
+		return ve.Group.ToPdfObject()
+	}
+	arr := MakeArray(MakeName(ve.Operator))
+	for _, operand := range ve.Operands {
+		arr.Append(operand.toPdfObject())
+	}
+	return arr
+}
+
+// PdfOCOrderItem is an item of the presentation order of the groups in a user interface: a group,
+// possibly with nested items, or a label with nested items.
+type PdfOCOrderItem struct {
+	Group *PdfOptionalContentGroup
+	Label string
+	Kids  []*PdfOCOrderItem
+}
+
+// PdfOCConfig represents an optional content configuration dictionary, holding the initial states
+// of the groups and their presentation (8.11.4.3 Optional Content Configuration Dictionaries
+// p. 226).
+type PdfOCConfig struct {
+	Name      string
+	Creator   string
+	BaseState string // ON (default), OFF or Unchanged.
+	ON        []*PdfOptionalContentGroup
+	OFF       []*PdfOptionalContentGroup
+	Intent    []string
+	Order     []*PdfOCOrderItem
+	ListMode  string // AllPages (default) or VisiblePages.
+	RBGroups  [][]*PdfOptionalContentGroup
+	Locked    []*PdfOptionalContentGroup
+	AS        PdfObject // Usage application dictionaries, kept as is.
+}
+
+// PdfOCProperties represents the optional content properties of a document (OCProperties entry of
+// the catalog): the groups, the default configuration and alternate configurations.
+type PdfOCProperties struct {
+	OCGs    []*PdfOptionalContentGroup
+	D       *PdfOCConfig
+	Configs []*PdfOCConfig
+
+	// Loaded groups and membership dictionaries by object.
+	groups      map[PdfObject]*PdfOptionalContentGroup
+	memberships map[PdfObject]*PdfOptionalContentMembership
+}
+
+// NewPdfOCProperties returns optional content properties without groups.
+func NewPdfOCProperties() *PdfOCProperties {
+	return &PdfOCProperties{
+		D:           &PdfOCConfig{},
+		groups:      map[PdfObject]*PdfOptionalContentGroup{},
+		memberships: map[PdfObject]*PdfOptionalContentMembership{},
+	}
+}
+
+// AddGroup adds the group `ocg` to the document, at the end of the presentation order of the
+// default configuration.
+func (props *PdfOCProperties) AddGroup(ocg *PdfOptionalContentGroup) {
+	props.OCGs = append(props.OCGs, ocg)
+	props.groups[ocg.container] = ocg
+	props.D.Order = append(props.D.Order, &PdfOCOrderItem{Group: ocg})
+}
+
+// GetGroupByName returns the first group named `name`, or nil if none.
+func (props *PdfOCProperties) GetGroupByName(name string) *PdfOptionalContentGroup {
+	for _, ocg := range props.OCGs {
+		if ocg.Name == name {
+			return ocg
+		}
+	}
+	return nil
+}
+
+// NewPdfOCPropertiesFromObject loads the optional content properties `obj`.  Invalid groups are
+// ignored.
+func NewPdfOCPropertiesFromObject(obj PdfObject) (*PdfOCProperties, error) {
+	dict, ok := GetDict(obj)
+	if !ok {
+		return nil, ErrTypeError
+	}
+	props := NewPdfOCProperties()
+	if arr, ok := GetArray(dict.Get("OCGs")); ok {
+		for _, elem := range arr.Elements() {
+			if ocg := props.loadGroup(elem); ocg != nil {
+				props.OCGs = append(props.OCGs, ocg)
+			}
+		}
+	}
+	if d, ok := GetDict(dict.Get("D")); ok {
+		props.D = props.loadConfig(d)
+	}
+	if arr, ok := GetArray(dict.Get("Configs")); ok {
+		for _, elem := range arr.Elements() {
+			if config, ok := GetDict(elem); ok {
+				props.Configs = append(props.Configs, props.loadConfig(config))
+			}
+		}
+	}
+	return props, nil
+}
+
+// LoadGroup returns the group `obj` of the properties, e.g. referred to by a property list or the
+// OC entry of an XObject.  Returns nil if `obj` is not a group.
+func (props *PdfOCProperties) LoadGroup(obj PdfObject) *PdfOptionalContentGroup {
+	return props.loadGroup(obj)
+}
+
+// LoadMembership returns the membership dictionary `obj`.  Returns nil if `obj` is not a
+// membership dictionary.
+func (props *PdfOCProperties) LoadMembership(obj PdfObject) *PdfOptionalContentMembership {
+	if ocmd, has := props.memberships[obj]; has {
+		return ocmd
+	}
+	dict, ok := GetDict(obj)
+	if !ok {
+		return nil
+	}
+	if typ, _ := GetNameVal(dict.Get("Type")); typ != "OCMD" {
+		return nil
+	}
+
+	ocmd := &PdfOptionalContentMembership{}
+	if ind, ok := obj.(*PdfIndirectObject); ok {
+		ocmd.container = ind
+	} else {
+		ocmd.container = MakeIndirectObject(dict)
+	}
+	if ocg := props.loadGroup(dict.Get("OCGs")); ocg != nil {
+		ocmd.OCGs = []*PdfOptionalContentGroup{ocg}
+	} else {
+		ocmd.OCGs = props.loadGroupList(dict.Get("OCGs"))
+	}
+	if policy, ok := GetNameVal(dict.Get("P")); ok {
+		ocmd.Policy = PdfOCPolicy(policy)
+	}
+	ocmd.VE = props.loadVisibilityExpression(dict.Get("VE"), 0)
+	props.memberships[obj] = ocmd
+	return ocmd
+}
+
+// loadGroup returns the group `obj`, loading it if not already loaded.
+func (props *PdfOCProperties) loadGroup(obj PdfObject) *PdfOptionalContentGroup {
+	if ocg, has := props.groups[obj]; has {
+		return ocg
+	}
+	dict, ok := GetDict(obj)
+	if !ok {
+		return nil
+	}
+	if typ, _ := GetNameVal(dict.Get("Type")); typ != "OCG" {
+		return nil
+	}
+
+	ocg := &PdfOptionalContentGroup{}
+	if ind, ok := obj.(*PdfIndirectObject); ok {
+		ocg.container = ind
+	} else {
+		ocg.container = MakeIndirectObject(dict)
+	}
+	if name, ok := GetString(dict.Get("Name")); ok {
+		ocg.Name = name.Str()
+	}
+	ocg.Intent = loadNameOrArray(dict.Get("Intent"))
+	ocg.Usage = dict.Get("Usage")
+	props.groups[obj] = ocg
+	return ocg
+}
+
+// loadGroupList returns the groups of the array `obj`.
+func (props *PdfOCProperties) loadGroupList(obj PdfObject) []*PdfOptionalContentGroup {
+	ocgs := []*PdfOptionalContentGroup{}
+	if arr, ok := GetArray(obj); ok {
+		for _, elem := range arr.Elements() {
+			if ocg := props.loadGroup(elem); ocg != nil {
+				ocgs = append(ocgs, ocg)
+			}
+		}
+	}
+	return ocgs
+}
+
+// Maximum nesting of visibility expressions and presentation orders.
+const ocMaxDepth = 20
+
+// loadVisibilityExpression returns the visibility expression `obj` at nesting level `depth`.
+func (props *PdfOCProperties) loadVisibilityExpression(obj PdfObject, depth int) *PdfOCVisibilityExpression {
+	if ocg := props.loadGroup(obj); ocg != nil {
+		return &PdfOCVisibilityExpression{Group: ocg}
+	}
+	arr, ok := GetArray(obj)
+	if !ok || arr.Len() == 0 || depth > ocMaxDepth {
+		return nil
+	}
+	op, ok := GetNameVal(arr.Get(0))
+	if !ok {
+		common.Log.Debug("Invalid visibility expression operator %v", arr.Get(0))
+		return nil
+	}
+	ve := &PdfOCVisibilityExpression{Operator: op}
+	for _, elem := range arr.Elements()[1:] {
+		if operand := props.loadVisibilityExpression(elem, depth+1); operand != nil {
+			ve.Operands = append(ve.Operands, operand)
+		}
+	}
+	return ve
+}
+
+// loadConfig loads the configuration dictionary `dict`.
+func (props *PdfOCProperties) loadConfig(dict *PdfObjectDictionary) *PdfOCConfig {
+	config := &PdfOCConfig{}
+	if str, ok := GetString(dict.Get("Name")); ok {
+		config.Name = str.Str()
+	}
+	if str, ok := GetString(dict.Get("Creator")); ok {
+		config.Creator = str.Str()
+	}
+	config.BaseState, _ = GetNameVal(dict.Get("BaseState"))
+	config.ON = props.loadGroupList(dict.Get("ON"))
+	config.OFF = props.loadGroupList(dict.Get("OFF"))
+	config.Intent = loadNameOrArray(dict.Get("Intent"))
+	config.Order = props.loadOrder(dict.Get("Order"), 0)
+	config.ListMode, _ = GetNameVal(dict.Get("ListMode"))
+	if arr, ok := GetArray(dict.Get("RBGroups")); ok {
+		for _, elem := range arr.Elements() {
+			config.RBGroups = append(config.RBGroups, props.loadGroupList(elem))
+		}
+	}
+	config.Locked = props.loadGroupList(dict.Get("Locked"))
+	config.AS = dict.Get("AS")
+	return config
+}
+
+// loadOrder returns the presentation order items of the array `obj`.  An array following a group
+// holds the items nested under the group, and an array starting with a string is a labeled
+// collection of items.
+func (props *PdfOCProperties) loadOrder(obj PdfObject, depth int) []*PdfOCOrderItem {
+	arr, ok := GetArray(obj)
+	if !ok || depth > ocMaxDepth {
+		return nil
+	}
+	items := []*PdfOCOrderItem{}
+	for _, elem := range arr.Elements() {
+		if ocg := props.loadGroup(elem); ocg != nil {
+			items = append(items, &PdfOCOrderItem{Group: ocg})
+			continue
+		}
+		nested, ok := GetArray(elem)
+		if !ok || nested.Len() == 0 {
+			continue
+		}
+		if label, ok := GetString(nested.Get(0)); ok {
+			rest := MakeArray(nested.Elements()[1:]...)
+			items = append(items, &PdfOCOrderItem{Label: label.Str(), Kids: props.loadOrder(rest, depth+1)})
+			continue
+		}
+		kids := props.loadOrder(nested, depth+1)
+		if n := len(items); n > 0 && items[n-1].Group != nil && items[n-1].Kids == nil {
+			items[n-1].Kids = kids
+		} else {
+			items = append(items, &PdfOCOrderItem{Kids: kids})
+		}
+	}
+	return items
+}
+
+// ToPdfObject returns the optional content properties dictionary.
+func (props *PdfOCProperties) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	dict.Set("OCGs", ocgsToArray(props.OCGs))
+	d := props.D
+	if d == nil {
+		d = &PdfOCConfig{}
+	}
+	dict.Set("D", d.ToPdfObject())
+	if len(props.Configs) > 0 {
+		configs := MakeArray()
+		for _, config := range props.Configs {
+			configs.Append(config.ToPdfObject())
+		}
+		dict.Set("Configs", configs)
+	}
+	return dict
+}
+
+// ToPdfObject returns the configuration dictionary.
+func (config *PdfOCConfig) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	if len(config.Name) > 0 {
+		dict.Set("Name", MakeString(config.Name))
+	}
+	if len(config.Creator) > 0 {
+		dict.Set("Creator", MakeString(config.Creator))
+	}
+	if len(config.BaseState) > 0 {
+		dict.Set("BaseState", MakeName(config.BaseState))
+	}
+	if len(config.ON) > 0 {
+		dict.Set("ON", ocgsToArray(config.ON))
+	}
+	if len(config.OFF) > 0 {
+		dict.Set("OFF", ocgsToArray(config.OFF))
+	}
+	if intent := makeNameOrArray(config.Intent); intent != nil {
+		dict.Set("Intent", intent)
+	}
+	if len(config.Order) > 0 {
+		dict.Set("Order", orderToArray(config.Order))
+	}
+	if len(config.ListMode) > 0 {
+		dict.Set("ListMode", MakeName(config.ListMode))
+	}
+	if len(config.RBGroups) > 0 {
+		groups := MakeArray()
+		for _, group := range config.RBGroups {
+			groups.Append(ocgsToArray(group))
+		}
+		dict.Set("RBGroups", groups)
+	}
+	if len(config.Locked) > 0 {
+		dict.Set("Locked", ocgsToArray(config.Locked))
+	}
+	if config.AS != nil {
+		dict.Set("AS", config.AS)
+	}
+	return dict
+}
+
+// orderToArray returns the presentation order `items` as an Order array.
+func orderToArray(items []*PdfOCOrderItem) *PdfObjectArray {
+	arr := MakeArray()
+	for _, item := range items {
+		switch {
+		case item.Group != nil:
+			arr.Append(item.Group.ToPdfObject())
+			if len(item.Kids) > 0 {
+				arr.Append(orderToArray(item.Kids))
+			}
+		case len(item.Label) > 0:
+			nested := MakeArray(MakeString(item.Label))
+			nested.Append(orderToArray(item.Kids).Elements()...)
+			arr.Append(nested)
+		default:
+			arr.Append(orderToArray(item.Kids))
+		}
+	}
+	return arr
+}
+
+// ocgsToArray returns an array of the groups `ocgs`.
+func ocgsToArray(ocgs []*PdfOptionalContentGroup) *PdfObjectArray {
+	arr := MakeArray()
+	for _, ocg := range ocgs {
+		arr.Append(ocg.ToPdfObject())
+	}
+	return arr
+}
+
+// loadNameOrArray returns the names of `obj`, either a name or an array of names.
+func loadNameOrArray(obj PdfObject) []string {
+	if name, ok := GetNameVal(obj); ok {
+		return []string{name}
+	}
+	names := []string{}
+	if arr, ok := GetArray(obj); ok {
+		for _, elem := range arr.Elements() {
+			if name, ok := GetNameVal(elem); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// makeNameOrArray returns `names` as a name if single, as an array of names otherwise, or nil if
+// empty.
+func makeNameOrArray(names []string) PdfObject {
+	switch len(names) {
+	case 0:
+		return nil
+	case 1:
+		return MakeName(names[0])
+	}
+	arr := MakeArray()
+	for _, name := range names {
+		arr.Append(MakeName(name))
+	}
+	return arr
+}
+
+// PdfOCState holds the visibility states of the optional content groups of a document, e.g. to
+// process content with some groups turned off.
+type PdfOCState struct {
+	props   *PdfOCProperties
+	visible map[*PdfOptionalContentGroup]bool
+}
+
+// NewState returns the initial states of the groups defined by the default configuration: the
+// base state, then the groups turned on and off.
+func (props *PdfOCProperties) NewState() *PdfOCState {
+	state := &PdfOCState{props: props, visible: map[*PdfOptionalContentGroup]bool{}}
+	d := props.D
+	if d == nil {
+		d = &PdfOCConfig{}
+	}
+	for _, ocg := range props.OCGs {
+		state.visible[ocg] = d.BaseState != "OFF"
+	}
+	for _, ocg := range d.ON {
+		state.visible[ocg] = true
+	}
+	for _, ocg := range d.OFF {
+		state.visible[ocg] = false
+	}
+	return state
+}
+
+// SetVisible sets the state of the group `ocg`.  Turning a group on turns off the other groups of
+// its radio button groups (RBGroups of the default configuration).
+func (state *PdfOCState) SetVisible(ocg *PdfOptionalContentGroup, visible bool) {
+	if visible && state.props.D != nil {
+		for _, group := range state.props.D.RBGroups {
+			inGroup := false
+			for _, other := range group {
+				inGroup = inGroup || other == ocg
+			}
+			if !inGroup {
+				continue
+			}
+			for _, other := range group {
+				state.visible[other] = false
+			}
+		}
+	}
+	state.visible[ocg] = visible
+}
+
+// IsGroupVisible returns the state of the group `ocg`.  Groups not defined in the document are
+// visible.
+func (state *PdfOCState) IsGroupVisible(ocg *PdfOptionalContentGroup) bool {
+	visible, has := state.visible[ocg]
+	return visible || !has
+}
+
+// IsMembershipVisible returns whether the content of the membership dictionary `ocmd` is visible.
+func (state *PdfOCState) IsMembershipVisible(ocmd *PdfOptionalContentMembership) bool {
+	if ocmd.VE != nil {
+		return state.isExpressionVisible(ocmd.VE)
+	}
+	if len(ocmd.OCGs) == 0 {
+		return true
+	}
+	on := 0
+	for _, ocg := range ocmd.OCGs {
+		if state.IsGroupVisible(ocg) {
+			on++
+		}
+	}
+	switch ocmd.Policy {
+	case PdfOCPolicyAllOn:
+		return on == len(ocmd.OCGs)
+	case PdfOCPolicyAnyOff:
+		return on < len(ocmd.OCGs)
+	case PdfOCPolicyAllOff:
+		return on == 0
+	}
+	return on > 0
+}
+
+// isExpressionVisible evaluates the visibility expression `ve`.
+func (state *PdfOCState) isExpressionVisible(ve *PdfOCVisibilityExpression) bool {
+	if ve.Group != nil {
+		return state.IsGroupVisible(ve.Group)
+	}
+	switch ve.Operator {
+	case "Not":
+		return len(ve.Operands) != 1 || !state.isExpressionVisible(ve.Operands[0])
+	case "And":
+		for _, operand := range ve.Operands {
+			if !state.isExpressionVisible(operand) {
+				return false
+			}
+		}
+		return true
+	case "Or":
+		for _, operand := range ve.Operands {
+			if state.isExpressionVisible(operand) {
+				return true
+			}
+		}
+		return len(ve.Operands) == 0
+	}
+	common.Log.Debug("Invalid visibility expression operator %s", ve.Operator)
+	return true
+}
+
+// IsVisible returns whether content marked with the optional content `obj` is visible, where
+// `obj` is a group or a membership dictionary.  Other objects are visible.
+func (state *PdfOCState) IsVisible(obj PdfObject) bool {
+	if ocg := state.props.loadGroup(obj); ocg != nil {
+		return state.IsGroupVisible(ocg)
+	}
+	if ocmd := state.props.LoadMembership(obj); ocmd != nil {
+		return state.IsMembershipVisible(ocmd)
+	}
+	return true
+}
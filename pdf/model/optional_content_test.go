@@ -0,0 +1,119 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// Test writing and reading back optional content properties, and the visibility of groups and
+// membership dictionaries.
+func TestOptionalContentRoundTrip(t *testing.T) {
+	props := NewPdfOCProperties()
+	text := NewPdfOptionalContentGroup("Text")
+	english := NewPdfOptionalContentGroup("English")
+	french := NewPdfOptionalContentGroup("French")
+	props.AddGroup(text)
+	props.OCGs = append(props.OCGs, english, french)
+	props.D.Name = "Default"
+	props.D.OFF = []*PdfOptionalContentGroup{french}
+	props.D.Order[0].Kids = []*PdfOCOrderItem{{Label: "Languages", Kids: []*PdfOCOrderItem{{Group: english}, {Group: french}}}}
+	props.D.RBGroups = [][]*PdfOptionalContentGroup{{english, french}}
+
+	ocmd := NewPdfOptionalContentMembership(PdfOCPolicyAllOn, text, french)
+	page := NewPdfPage()
+	page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 612, Ury: 792}
+	page.Resources = NewPdfPageResources()
+	page.Resources.SetPropertiesByName("OC0", english.ToPdfObject())
+	page.Resources.SetPropertiesByName("OC1", ocmd.ToPdfObject())
+
+	writer := NewPdfWriter()
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.SetOptionalContent(props); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := writer.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	reader, err := NewPdfReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	loaded, err := reader.GetOptionalContent()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if loaded == nil || len(loaded.OCGs) != 3 || loaded.D.Name != "Default" {
+		t.Fatalf("Invalid properties %+v", loaded)
+	}
+	english, french = loaded.GetGroupByName("English"), loaded.GetGroupByName("French")
+	if len(loaded.D.Order) != 1 || loaded.D.Order[0].Group.Name != "Text" {
+		t.Fatalf("Invalid order %+v", loaded.D.Order)
+	}
+	kids := loaded.D.Order[0].Kids
+	if len(kids) != 1 || kids[0].Label != "Languages" || len(kids[0].Kids) != 2 || kids[0].Kids[1].Group != french {
+		t.Errorf("Invalid nested order %+v", kids)
+	}
+	if len(loaded.D.RBGroups) != 1 || len(loaded.D.RBGroups[0]) != 2 || loaded.D.RBGroups[0][0] != english {
+		t.Errorf("Invalid radio button groups %+v", loaded.D.RBGroups)
+	}
+
+	page, err = reader.GetPage(1)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	oc0, _ := page.Resources.GetPropertiesByName("OC0")
+	oc1, _ := page.Resources.GetPropertiesByName("OC1")
+	state := loaded.NewState()
+	if !state.IsVisible(oc0) || state.IsVisible(oc1) || state.IsGroupVisible(french) {
+		t.Errorf("Invalid initial state")
+	}
+
+	// Turning on French turns off English (radio button group) and the membership is then on.
+	state.SetVisible(french, true)
+	if state.IsVisible(oc0) || !state.IsVisible(oc1) {
+		t.Errorf("Invalid state after turning on French")
+	}
+	state.SetVisible(loaded.GetGroupByName("Text"), false)
+	if state.IsVisible(oc1) {
+		t.Errorf("Membership visible with Text off")
+	}
+}
+
+// Test the evaluation of visibility expressions.
+func TestOptionalContentVisibilityExpression(t *testing.T) {
+	props := NewPdfOCProperties()
+	a, b := NewPdfOptionalContentGroup("A"), NewPdfOptionalContentGroup("B")
+	props.AddGroup(a)
+	props.AddGroup(b)
+	props.D.OFF = []*PdfOptionalContentGroup{b}
+
+	// And(A, Not(B)).
+	ve := MakeArray(MakeName("And"), a.ToPdfObject(), MakeArray(MakeName("Not"), b.ToPdfObject()))
+	dict := MakeDict()
+	dict.Set("Type", MakeName("OCMD"))
+	dict.Set("OCGs", b.ToPdfObject())
+	dict.Set("VE", ve)
+
+	state := props.NewState()
+	if !state.IsVisible(dict) {
+		t.Errorf("Expression not visible")
+	}
+	state.SetVisible(b, true)
+	if state.IsVisible(dict) {
+		t.Errorf("Expression visible with B on")
+	}
+	if !state.IsVisible(MakeDict()) {
+		t.Errorf("Object without optional content not visible")
+	}
+}
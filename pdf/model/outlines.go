@@ -6,6 +6,7 @@
 package model
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/unidoc/unidoc/common"
@@ -52,15 +53,14 @@ func NewPdfOutline() *PdfOutline {
 	container.PdfObject = MakeDict()
 
 	outline.primitive = container
+	outline.context = outline
 
 	return outline
 }
 
 // NewPdfOutlineTree returns an initialized PdfOutline tree.
 func NewPdfOutlineTree() *PdfOutline {
-	outlineTree := NewPdfOutline()
-	outlineTree.context = &outlineTree
-	return outlineTree
+	return NewPdfOutline()
 }
 
 // NewPdfOutlineItem returns an initialized PdfOutlineItem.
@@ -71,22 +71,21 @@ func NewPdfOutlineItem() *PdfOutlineItem {
 	container.PdfObject = MakeDict()
 
 	outlineItem.primitive = container
+	outlineItem.context = outlineItem
 	return outlineItem
 }
 
 // NewOutlineBookmark returns an initialized PdfOutlineItem for a given bookmark title and page.
 func NewOutlineBookmark(title string, page *PdfIndirectObject) *PdfOutlineItem {
-	bookmark := PdfOutlineItem{}
-	bookmark.context = &bookmark
-
-	bookmark.Title = MakeString(title)
-
-	destArray := MakeArray()
-	destArray.Append(page)
-	destArray.Append(MakeName("Fit"))
-	bookmark.Dest = destArray
+	return NewPdfOutlineItemWithDestination(title, NewPdfDestinationFit(page))
+}
 
-	return &bookmark
+// NewPdfOutlineItemWithDestination returns an outline item with `title` going to `dest`.
+func NewPdfOutlineItemWithDestination(title string, dest *PdfDestination) *PdfOutlineItem {
+	item := NewPdfOutlineItem()
+	item.Title = MakeString(title)
+	item.SetDestination(dest)
+	return item
 }
 
 // Does not traverse the tree.
@@ -221,6 +220,13 @@ func (n *PdfOutlineTreeNode) getOuter() PdfModel {
 	return nil
 }
 
+// Item returns the outline item of the tree node, or nil if the node is the root of the outline
+// tree.
+func (n *PdfOutlineTreeNode) Item() *PdfOutlineItem {
+	item, _ := n.context.(*PdfOutlineItem)
+	return item
+}
+
 func (this *PdfOutlineTreeNode) GetContainingPdfObject() PdfObject {
 	return this.getOuter().GetContainingPdfObject()
 }
@@ -240,6 +246,14 @@ func (this *PdfOutline) ToPdfObject() PdfObject {
 
 	dict.Set("Type", MakeName("Outlines"))
 
+	this.updateCount()
+	for _, key := range []PdfObjectName{"Count", "First", "Last"} {
+		dict.Remove(key)
+	}
+	if this.Count != nil {
+		dict.Set("Count", MakeInteger(*this.Count))
+	}
+
 	if this.First != nil {
 		dict.Set("First", this.First.ToPdfObject())
 	}
@@ -267,6 +281,10 @@ func (this *PdfOutlineItem) ToPdfObject() PdfObject {
 	dict := container.PdfObject.(*PdfObjectDictionary)
 
 	dict.Set("Title", this.Title)
+	// Entries removed by editing are not kept from the loaded dictionary.
+	for _, key := range []PdfObjectName{"A", "C", "Dest", "F", "Count", "Next", "First", "Prev", "Last"} {
+		dict.Remove(key)
+	}
 	if this.A != nil {
 		dict.Set("A", this.A)
 	}
@@ -314,3 +332,287 @@ func (this *PdfOutlineItem) ToPdfObject() PdfObject {
 
 	return container
 }
+
+// Children returns the child items of the node, in order.
+func (n *PdfOutlineTreeNode) Children() []*PdfOutlineItem {
+	items := []*PdfOutlineItem{}
+	for node := n.First; node != nil; {
+		item := node.Item()
+		if item == nil {
+			break
+		}
+		items = append(items, item)
+		node = item.Next
+	}
+	return items
+}
+
+// Walk calls `fn` for each item below the node in document order, where `depth` is 0 for the
+// children of the node.  The walk stops at the first error returned by `fn`.
+func (n *PdfOutlineTreeNode) Walk(fn func(item *PdfOutlineItem, depth int) error) error {
+	return n.walk(fn, 0)
+}
+
+func (n *PdfOutlineTreeNode) walk(fn func(item *PdfOutlineItem, depth int) error, depth int) error {
+	for _, item := range n.Children() {
+		if err := fn(item, depth); err != nil {
+			return err
+		}
+		if err := item.walk(fn, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddChild appends `item` to the children of the node, removing it from its current parent.
+func (n *PdfOutlineTreeNode) AddChild(item *PdfOutlineItem) error {
+	return n.InsertChild(len(n.Children()), item)
+}
+
+// InsertChild inserts `item` at position `index` of the children of the node, removing it from its
+// current parent.  An error is returned if `index` is out of range or if `item` is the node or one
+// of its ancestors.
+func (n *PdfOutlineTreeNode) InsertChild(index int, item *PdfOutlineItem) error {
+	for node := n; node != nil; {
+		if node == &item.PdfOutlineTreeNode {
+			return errors.New("Cannot insert an outline item below itself")
+		}
+		parent := node.Item()
+		if parent == nil {
+			break
+		}
+		node = parent.Parent
+	}
+
+	item.Remove()
+	children := n.Children()
+	if index < 0 || index > len(children) {
+		return fmt.Errorf("Outline item index %d out of range (%d children)", index, len(children))
+	}
+
+	item.Parent = n
+	if index > 0 {
+		prev := children[index-1]
+		item.Prev = &prev.PdfOutlineTreeNode
+		prev.Next = &item.PdfOutlineTreeNode
+	} else {
+		n.First = &item.PdfOutlineTreeNode
+	}
+	if index < len(children) {
+		next := children[index]
+		item.Next = &next.PdfOutlineTreeNode
+		next.Prev = &item.PdfOutlineTreeNode
+	} else {
+		n.Last = &item.PdfOutlineTreeNode
+	}
+	return nil
+}
+
+// Remove removes the item, with its descendants, from its parent.
+func (this *PdfOutlineItem) Remove() {
+	parent := this.Parent
+	if parent == nil {
+		return
+	}
+	if this.Prev != nil {
+		this.Prev.Item().Next = this.Next
+	} else {
+		parent.First = this.Next
+	}
+	if this.Next != nil {
+		this.Next.Item().Prev = this.Prev
+	} else {
+		parent.Last = this.Prev
+	}
+	this.Parent, this.Prev, this.Next = nil, nil, nil
+}
+
+// MoveTo moves the item, with its descendants, to position `index` of the children of `parent`.
+func (this *PdfOutlineItem) MoveTo(parent *PdfOutlineTreeNode, index int) error {
+	return parent.InsertChild(index, this)
+}
+
+// updateCounts sets the Count entries of the items below the node and returns the number of items
+// below the node that are visible when the node is open.
+func (n *PdfOutlineTreeNode) updateCounts() int64 {
+	count := int64(0)
+	for _, item := range n.Children() {
+		open := item.IsOpen()
+		kids := item.updateCounts()
+		item.Count = nil
+		if kids > 0 {
+			c := kids
+			if !open {
+				c = -kids
+			}
+			item.Count = &c
+		}
+
+		count++
+		if open {
+			count += kids
+		}
+	}
+	return count
+}
+
+// updateCount sets the Count entries of the outline items and the total number of visible items.
+func (this *PdfOutline) updateCount() {
+	this.Count = nil
+	if count := this.updateCounts(); count > 0 {
+		this.Count = &count
+	}
+}
+
+// GetTitle returns the title of the item.
+func (this *PdfOutlineItem) GetTitle() string {
+	if this.Title == nil {
+		return ""
+	}
+	return this.Title.Str()
+}
+
+// SetTitle sets the title of the item.
+func (this *PdfOutlineItem) SetTitle(title string) {
+	this.Title = MakeString(title)
+}
+
+// IsOpen returns whether the children of the item are shown when the document is opened.
+func (this *PdfOutlineItem) IsOpen() bool {
+	return this.Count == nil || *this.Count >= 0
+}
+
+// SetOpen sets whether the children of the item are shown when the document is opened.  The
+// Count entries are updated when the outlines are written.
+func (this *PdfOutlineItem) SetOpen(open bool) {
+	if open == this.IsOpen() {
+		return
+	}
+	count := int64(-1)
+	if this.Count != nil {
+		count = -*this.Count
+	}
+	this.Count = &count
+}
+
+// GetColor returns the color of the item title in DeviceRGB (black by default).
+func (this *PdfOutlineItem) GetColor() (r, g, b float64) {
+	arr, ok := GetArray(this.C)
+	if !ok || arr.Len() != 3 {
+		return 0, 0, 0
+	}
+	rgb, err := arr.ToFloat64Array()
+	if err != nil {
+		return 0, 0, 0
+	}
+	return rgb[0], rgb[1], rgb[2]
+}
+
+// SetColor sets the color of the item title in DeviceRGB, with components in the range 0 to 1.
+func (this *PdfOutlineItem) SetColor(r, g, b float64) {
+	this.C = MakeArrayFromFloats([]float64{r, g, b})
+}
+
+// PdfOutlineItemFlag is a style flag of the title of an outline item (F entry).
+type PdfOutlineItemFlag int
+
+// Outline item style flags (Table 154 - p. 377).
+const (
+	OutlineItemItalic PdfOutlineItemFlag = 1
+	OutlineItemBold   PdfOutlineItemFlag = 2
+)
+
+// GetFlags returns the style flags of the item title.
+func (this *PdfOutlineItem) GetFlags() PdfOutlineItemFlag {
+	flags, _ := GetIntVal(this.F)
+	return PdfOutlineItemFlag(flags)
+}
+
+// SetFlags sets the style flags of the item title, e.g. OutlineItemBold|OutlineItemItalic.
+func (this *PdfOutlineItem) SetFlags(flags PdfOutlineItemFlag) {
+	this.F = nil
+	if flags != 0 {
+		this.F = MakeInteger(int64(flags))
+	}
+}
+
+// SetDestination sets the item to go to the explicit destination `dest`.
+func (this *PdfOutlineItem) SetDestination(dest *PdfDestination) {
+	this.Dest = dest.ToPdfObject()
+	this.A = nil
+}
+
+// SetNamedDestination sets the item to go to the named destination `name`.
+func (this *PdfOutlineItem) SetNamedDestination(name string) {
+	this.Dest = MakeString(name)
+	this.A = nil
+}
+
+// SetGoToAction sets the item to perform a GoTo action to the explicit destination `dest`.
+func (this *PdfOutlineItem) SetGoToAction(dest *PdfDestination) {
+	action := MakeDict()
+	action.Set("S", MakeName("GoTo"))
+	action.Set("D", dest.ToPdfObject())
+	this.A = action
+	this.Dest = nil
+}
+
+// SetURIAction sets the item to perform a URI action resolving `uri`, e.g. opening a web page.
+func (this *PdfOutlineItem) SetURIAction(uri string) {
+	action := MakeDict()
+	action.Set("S", MakeName("URI"))
+	action.Set("URI", MakeString(uri))
+	this.A = action
+	this.Dest = nil
+}
+
+// target returns the destination of the item, either the Dest entry or the destination of a GoTo
+// action.
+func (this *PdfOutlineItem) target() PdfObject {
+	if this.Dest != nil {
+		return TraceToDirectObject(this.Dest)
+	}
+	action, ok := GetDict(this.A)
+	if !ok {
+		return nil
+	}
+	if s, _ := GetNameVal(action.Get("S")); s != "GoTo" {
+		return nil
+	}
+	return TraceToDirectObject(action.Get("D"))
+}
+
+// GetDestination returns the explicit destination of the item, from the Dest entry or a GoTo
+// action.  Returns nil if the item has no explicit destination, e.g. a named destination.
+func (this *PdfOutlineItem) GetDestination() (*PdfDestination, error) {
+	if _, isArray := this.target().(*PdfObjectArray); !isArray {
+		return nil, nil
+	}
+	return NewPdfDestinationFromObject(this.target())
+}
+
+// GetNamedDestination returns the named destination of the item, from the Dest entry or a GoTo
+// action.  The bool flag indicates whether the item goes to a named destination.
+func (this *PdfOutlineItem) GetNamedDestination() (string, bool) {
+	switch t := this.target().(type) {
+	case *PdfObjectString:
+		return t.Str(), true
+	case *PdfObjectName:
+		return string(*t), true
+	}
+	return "", false
+}
+
+// GetURI returns the URI of the URI action of the item.  The bool flag indicates whether the item
+// has a URI action.
+func (this *PdfOutlineItem) GetURI() (string, bool) {
+	action, ok := GetDict(this.A)
+	if !ok {
+		return "", false
+	}
+	if s, _ := GetNameVal(action.Get("S")); s != "URI" {
+		return "", false
+	}
+	return GetStringVal(action.Get("URI"))
+}
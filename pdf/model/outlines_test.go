@@ -0,0 +1,152 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// writeOutlineDocument writes a document with `pages` and `outlines`, and returns a reader of the
+// written document.
+func writeOutlineDocument(t *testing.T, pages []*PdfPage, outlines *PdfOutline) *PdfReader {
+	writer := NewPdfWriter()
+	for _, page := range pages {
+		if err := writer.AddPage(page); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+	writer.SetOutlines(outlines)
+	var buf bytes.Buffer
+	if err := writer.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	reader, err := NewPdfReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return reader
+}
+
+// outlineString returns the titles of the items below `node` as nested lists, with the counts of
+// items with children, e.g. "A B(-2)[B1 B2]".
+func outlineString(node *PdfOutlineTreeNode) string {
+	parts := []string{}
+	for _, item := range node.Children() {
+		part := item.GetTitle()
+		if item.Count != nil {
+			part += fmt.Sprintf("(%d)", *item.Count)
+		}
+		if kids := item.Children(); len(kids) > 0 {
+			part += "[" + outlineString(&item.PdfOutlineTreeNode) + "]"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Test building, writing, reading back and editing outlines.
+func TestOutlineEditing(t *testing.T) {
+	pages := []*PdfPage{}
+	for i := 0; i < 2; i++ {
+		page := NewPdfPage()
+		page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 612, Ury: 792}
+		page.Resources = NewPdfPageResources()
+		pages = append(pages, page)
+	}
+	page1, page2 := pages[0].GetPageAsIndirectObject(), pages[1].GetPageAsIndirectObject()
+
+	outlines := NewPdfOutline()
+	a := NewPdfOutlineItemWithDestination("A", NewPdfDestinationXYZ(page1, 10, 700, 2))
+	a.SetColor(1, 0, 0)
+	a.SetFlags(OutlineItemBold | OutlineItemItalic)
+	b := NewOutlineBookmark("B", page2)
+	b.SetOpen(false)
+	b1 := NewPdfOutlineItem()
+	b1.SetTitle("B1")
+	b1.SetNamedDestination("chapter2")
+	b2 := NewPdfOutlineItem()
+	b2.SetTitle("B2")
+	b2.SetURIAction("https://unidoc.io")
+	c := NewPdfOutlineItem()
+	c.SetTitle("C")
+	c.SetGoToAction(NewPdfDestinationFitR(page2, PdfRectangle{Llx: 10, Lly: 20, Urx: 30, Ury: 40}))
+	for _, item := range []*PdfOutlineItem{a, b, c} {
+		if err := outlines.AddChild(item); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+	if err := b.AddChild(b2); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := b.InsertChild(0, b1); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := b1.AddChild(b); err == nil {
+		t.Errorf("Inserting an item below itself not rejected")
+	}
+	if err := b.InsertChild(3, NewPdfOutlineItem()); err == nil {
+		t.Errorf("Out of range index not rejected")
+	}
+
+	reader := writeOutlineDocument(t, pages, outlines)
+	loaded := reader.GetOutlines()
+	if s := outlineString(&loaded.PdfOutlineTreeNode); s != "A B(-2)[B1 B2] C" {
+		t.Errorf("Invalid outlines %s", s)
+	}
+	if loaded.Count == nil || *loaded.Count != 3 {
+		t.Errorf("Invalid outline count %v", loaded.Count)
+	}
+
+	items := loaded.Children()
+	if r, g, b := items[0].GetColor(); r != 1 || g != 0 || b != 0 {
+		t.Errorf("Invalid color %v %v %v", r, g, b)
+	}
+	if flags := items[0].GetFlags(); flags != OutlineItemBold|OutlineItemItalic {
+		t.Errorf("Invalid flags %d", flags)
+	}
+	dest, err := items[0].GetDestination()
+	if err != nil || dest == nil || dest.Fit != DestinationFitXYZ || *dest.Top != 700 || *dest.Zoom != 2 {
+		t.Errorf("Invalid destination %+v (%v)", dest, err)
+	}
+	if items[1].IsOpen() {
+		t.Errorf("Closed item open")
+	}
+	kids := items[1].Children()
+	if name, ok := kids[0].GetNamedDestination(); !ok || name != "chapter2" {
+		t.Errorf("Invalid named destination %q", name)
+	}
+	if uri, ok := kids[1].GetURI(); !ok || uri != "https://unidoc.io" {
+		t.Errorf("Invalid URI %q", uri)
+	}
+	dest, err = items[2].GetDestination()
+	if err != nil || dest == nil || dest.Fit != DestinationFitR || *dest.Right != 30 {
+		t.Errorf("Invalid GoTo destination %+v (%v)", dest, err)
+	}
+	if dest != nil && dest.Page != reader.PageList[1].GetPageAsIndirectObject() {
+		t.Errorf("Destination not to the second page")
+	}
+
+	// Move C first, remove B1, open B and add an item below C.
+	if err := items[2].MoveTo(&loaded.PdfOutlineTreeNode, 0); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	kids[0].Remove()
+	items[1].SetOpen(true)
+	d := NewPdfOutlineItemWithDestination("D", NewPdfDestinationFit(reader.PageList[0].GetPageAsIndirectObject()))
+	if err := items[2].AddChild(d); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	edited := writeOutlineDocument(t, reader.PageList, loaded).GetOutlines()
+	if s := outlineString(&edited.PdfOutlineTreeNode); s != "C(1)[D] A B(1)[B2]" {
+		t.Errorf("Invalid edited outlines %s", s)
+	}
+	if edited.Count == nil || *edited.Count != 5 {
+		t.Errorf("Invalid edited outline count %v", edited.Count)
+	}
+}
@@ -0,0 +1,456 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// Page boxes and geometry (14.11.2 Page Boundaries).  The media box and crop box are inheritable
+// from the page tree, the bleed, trim and art boxes default to the crop box.  All boxes are
+// clipped: the crop box to the media box and the other boxes to the crop box.
+
+// getInheritedAttribute returns the value of the inheritable page attribute `key` from the nearest
+// ancestor page tree node that has it, or nil if none has it.
+func (this *PdfPage) getInheritedAttribute(key PdfObjectName) PdfObject {
+	visited := map[PdfObject]bool{}
+	node := this.Parent
+	for node != nil && !visited[node] {
+		visited[node] = true
+		dict, ok := TraceToDirectObject(node).(*PdfObjectDictionary)
+		if !ok {
+			return nil
+		}
+		if obj := dict.Get(key); obj != nil {
+			return obj
+		}
+		node = dict.Get("Parent")
+	}
+	return nil
+}
+
+// GetCropBox returns the crop box of the page, i.e. the visible region of the page, taking
+// inheritance into account.  Defaults to the media box and is clipped to the media box.
+func (this *PdfPage) GetCropBox() (*PdfRectangle, error) {
+	mbox, err := this.GetMediaBox()
+	if err != nil {
+		return nil, err
+	}
+
+	cbox := this.CropBox
+	if cbox == nil {
+		if arr, ok := TraceToDirectObject(this.getInheritedAttribute("CropBox")).(*PdfObjectArray); ok {
+			cbox, err = NewPdfRectangle(*arr)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if cbox == nil {
+		rect := mbox.normalized()
+		return &rect, nil
+	}
+	rect := cbox.clipTo(*mbox)
+	return &rect, nil
+}
+
+// getCropBoxDefault returns `box` clipped to the crop box, or the crop box if `box` is nil.
+func (this *PdfPage) getCropBoxDefault(box *PdfRectangle) (*PdfRectangle, error) {
+	cbox, err := this.GetCropBox()
+	if err != nil {
+		return nil, err
+	}
+	if box == nil {
+		return cbox, nil
+	}
+	rect := box.clipTo(*cbox)
+	return &rect, nil
+}
+
+// GetBleedBox returns the bleed box of the page, i.e. the region to which the page contents are
+// clipped in a production environment.  Defaults to the crop box and is clipped to the crop box.
+func (this *PdfPage) GetBleedBox() (*PdfRectangle, error) {
+	return this.getCropBoxDefault(this.BleedBox)
+}
+
+// GetTrimBox returns the trim box of the page, i.e. the intended dimensions of the finished page.
+// Defaults to the crop box and is clipped to the crop box.
+func (this *PdfPage) GetTrimBox() (*PdfRectangle, error) {
+	return this.getCropBoxDefault(this.TrimBox)
+}
+
+// GetArtBox returns the art box of the page, i.e. the extent of the meaningful content of the page.
+// Defaults to the crop box and is clipped to the crop box.
+func (this *PdfPage) GetArtBox() (*PdfRectangle, error) {
+	return this.getCropBoxDefault(this.ArtBox)
+}
+
+// GetRotate returns the clockwise rotation of the page when displayed or printed in degrees,
+// taking inheritance into account.  The returned value is 0, 90, 180 or 270.
+func (this *PdfPage) GetRotate() (int64, error) {
+	var rotate int64
+	if this.Rotate != nil {
+		rotate = *this.Rotate
+	} else if obj := this.getInheritedAttribute("Rotate"); obj != nil {
+		val, ok := GetIntVal(obj)
+		if !ok {
+			return 0, errors.New("Invalid Page Rotate object")
+		}
+		rotate = int64(val)
+	}
+	if rotate%90 != 0 {
+		common.Log.Debug("Page rotation %d not a multiple of 90", rotate)
+		return 0, errors.New("Invalid page rotation")
+	}
+	return (rotate%360 + 360) % 360, nil
+}
+
+// GetUserUnit returns the size of default user space units in multiples of 1/72 inch.  Defaults
+// to 1.
+func (this *PdfPage) GetUserUnit() (float64, error) {
+	if this.UserUnit == nil {
+		return 1, nil
+	}
+	unit, err := GetNumberAsFloat(TraceToDirectObject(this.UserUnit))
+	if err != nil {
+		return 0, err
+	}
+	if unit <= 0 {
+		return 0, errors.New("Invalid UserUnit")
+	}
+	return unit, nil
+}
+
+// GetDisplayGeometry returns the visible region of the page in default user space (the crop box),
+// and the transformation matrix [a b c d e f] from default user space to the page as displayed:
+// rotated by the page rotation, with the origin at the lower left corner of the visible region and
+// in units of 1/72 inch.  The displayed page size is the crop box size (with width and height
+// swapped for rotations of 90 and 270 degrees) times the user unit.
+func (this *PdfPage) GetDisplayGeometry() (*PdfRectangle, [6]float64, error) {
+	cbox, err := this.GetCropBox()
+	if err != nil {
+		return nil, [6]float64{}, err
+	}
+	rotate, err := this.GetRotate()
+	if err != nil {
+		return nil, [6]float64{}, err
+	}
+	unit, err := this.GetUserUnit()
+	if err != nil {
+		return nil, [6]float64{}, err
+	}
+
+	m := rotationMatrix(*cbox, rotate)
+	m = multiplyMatrices(m, [6]float64{unit, 0, 0, unit, 0, 0})
+	return cbox, m, nil
+}
+
+// rotationMatrix returns the matrix that rotates `rect` clockwise by `rotate` degrees (a multiple of
+// 90) about its lower left corner and moves the rotated rectangle to the origin.
+func rotationMatrix(rect PdfRectangle, rotate int64) [6]float64 {
+	rect = rect.normalized()
+	w, h := rect.Width(), rect.Height()
+	var r [6]float64
+	switch (rotate%360 + 360) % 360 {
+	case 90:
+		r = [6]float64{0, -1, 1, 0, 0, w}
+	case 180:
+		r = [6]float64{-1, 0, 0, -1, w, h}
+	case 270:
+		r = [6]float64{0, 1, -1, 0, h, 0}
+	default:
+		r = [6]float64{1, 0, 0, 1, 0, 0}
+	}
+	return multiplyMatrices([6]float64{1, 0, 0, 1, -rect.Llx, -rect.Lly}, r)
+}
+
+// multiplyMatrices returns the matrix product `a` × `b`, i.e. the transformation `a` followed by `b`.
+func multiplyMatrices(a, b [6]float64) [6]float64 {
+	return [6]float64{
+		a[0]*b[0] + a[1]*b[2],
+		a[0]*b[1] + a[1]*b[3],
+		a[2]*b[0] + a[3]*b[2],
+		a[2]*b[1] + a[3]*b[3],
+		a[4]*b[0] + a[5]*b[2] + b[4],
+		a[4]*b[1] + a[5]*b[3] + b[5],
+	}
+}
+
+// transformRectangle returns the bounding box of `rect` transformed by `m`.
+func transformRectangle(m [6]float64, rect PdfRectangle) PdfRectangle {
+	coords := transformCoords(m, []float64{rect.Llx, rect.Lly, rect.Urx, rect.Lly, rect.Urx, rect.Ury, rect.Llx, rect.Ury})
+	bbox := PdfRectangle{Llx: coords[0], Lly: coords[1], Urx: coords[0], Ury: coords[1]}
+	for i := 2; i+1 < len(coords); i += 2 {
+		bbox.Llx = math.Min(bbox.Llx, coords[i])
+		bbox.Lly = math.Min(bbox.Lly, coords[i+1])
+		bbox.Urx = math.Max(bbox.Urx, coords[i])
+		bbox.Ury = math.Max(bbox.Ury, coords[i+1])
+	}
+	return bbox
+}
+
+// transformCoords returns the points x1 y1 x2 y2 ... in `coords` transformed by `m`.
+func transformCoords(m [6]float64, coords []float64) []float64 {
+	out := make([]float64, len(coords))
+	for i := 0; i+1 < len(coords); i += 2 {
+		x, y := coords[i], coords[i+1]
+		out[i] = x*m[0] + y*m[2] + m[4]
+		out[i+1] = x*m[1] + y*m[3] + m[5]
+	}
+	return out
+}
+
+// Crop sets the crop box of the page to `rect` (in default user space), clipped to the media box.
+// The bleed, trim and art boxes are clipped to the new crop box.  The page contents are not
+// changed: contents outside the crop box are hidden but not removed.
+func (this *PdfPage) Crop(rect PdfRectangle) error {
+	mbox, err := this.GetMediaBox()
+	if err != nil {
+		return err
+	}
+	cbox := rect.clipTo(*mbox)
+	if cbox.Width() == 0 || cbox.Height() == 0 {
+		return errors.New("Crop rectangle outside media box")
+	}
+	this.CropBox = &cbox
+
+	for _, box := range []**PdfRectangle{&this.BleedBox, &this.TrimBox, &this.ArtBox} {
+		if *box != nil {
+			clipped := (*box).clipTo(cbox)
+			*box = &clipped
+		}
+	}
+	return nil
+}
+
+// Resize scales the visible region of the page (the crop box) to a page of `width` by `height` in
+// default user space, before rotation.  If `keepAspectRatio` is true, the contents are scaled
+// uniformly to fit and are centered.  The media box and crop box are set to the new page size,
+// and the contents, the other page boxes and the annotations are transformed accordingly.
+func (this *PdfPage) Resize(width, height float64, keepAspectRatio bool) error {
+	if width <= 0 || height <= 0 {
+		return errors.New("Invalid page size")
+	}
+	cbox, err := this.GetCropBox()
+	if err != nil {
+		return err
+	}
+	if cbox.Width() == 0 || cbox.Height() == 0 {
+		return errors.New("Empty crop box")
+	}
+
+	sx, sy := width/cbox.Width(), height/cbox.Height()
+	tx, ty := 0.0, 0.0
+	if keepAspectRatio {
+		sx = math.Min(sx, sy)
+		sy = sx
+		tx = (width - cbox.Width()*sx) / 2
+		ty = (height - cbox.Height()*sy) / 2
+	}
+	m := multiplyMatrices([6]float64{1, 0, 0, 1, -cbox.Llx, -cbox.Lly}, [6]float64{sx, 0, 0, sy, tx, ty})
+
+	mbox := PdfRectangle{Llx: 0, Lly: 0, Urx: width, Ury: height}
+	return this.transformPage(m, mbox)
+}
+
+// RotateContent rotates the contents of the page clockwise by `angleDeg` (a multiple of 90).  The
+// page displays rotated by `angleDeg` in addition to the page rotation, which is not changed.
+// The page boxes are rotated with the media box moved to the origin, and the annotations are
+// transformed accordingly.
+func (this *PdfPage) RotateContent(angleDeg int64) error {
+	if angleDeg%90 != 0 {
+		return errors.New("Rotation angle not a multiple of 90")
+	}
+	mbox, err := this.GetMediaBox()
+	if err != nil {
+		return err
+	}
+	m := rotationMatrix(*mbox, angleDeg)
+	return this.transformPage(m, transformRectangle(m, *mbox))
+}
+
+// NormalizeRotation applies the page rotation to the page contents with RotateContent and sets the
+// page rotation to 0, e.g. for processing that does not support rotated pages.  The page displays
+// the same as before.
+func (this *PdfPage) NormalizeRotation() error {
+	rotate, err := this.GetRotate()
+	if err != nil {
+		return err
+	}
+	if rotate != 0 {
+		err = this.RotateContent(rotate)
+		if err != nil {
+			return err
+		}
+	}
+	var zero int64
+	this.Rotate = &zero
+	return nil
+}
+
+// transformPage transforms the page contents by `m` with a cm wrapper and sets the media box to
+// `mbox`.  The crop, bleed, trim and art boxes and the annotations are transformed by `m`.
+func (this *PdfPage) transformPage(m [6]float64, mbox PdfRectangle) error {
+	cbox, err := this.GetCropBox()
+	if err != nil {
+		return err
+	}
+	for _, box := range []**PdfRectangle{&this.BleedBox, &this.TrimBox, &this.ArtBox} {
+		if *box != nil {
+			rect := transformRectangle(m, (*box).clipTo(*cbox))
+			*box = &rect
+		}
+	}
+	newCbox := transformRectangle(m, *cbox).clipTo(mbox)
+	this.CropBox = &newCbox
+	this.MediaBox = &mbox
+
+	this.wrapContents(m)
+
+	visited := map[PdfObject]bool{}
+	for _, annot := range this.Annotations {
+		err := transformAnnotation(annot, m, visited)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wrapContents wraps the page contents in a q/Q pair with `m` concatenated to the current
+// transformation matrix.  New content streams are added around the existing ones, which are not
+// decoded.
+func (this *PdfPage) wrapContents(m [6]float64) {
+	vals := make([]string, 6)
+	for i, v := range m {
+		vals[i] = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	makeStream := func(content string) *PdfObjectStream {
+		stream := &PdfObjectStream{PdfObjectDictionary: MakeDict()}
+		stream.PdfObjectDictionary.Set("Length", MakeInteger(int64(len(content))))
+		stream.Stream = []byte(content)
+		return stream
+	}
+
+	contents := MakeArray(makeStream("q " + strings.Join(vals, " ") + " cm\n"))
+	if arr, ok := TraceToDirectObject(this.Contents).(*PdfObjectArray); ok {
+		contents.Append(arr.Elements()...)
+	} else if this.Contents != nil {
+		contents.Append(this.Contents)
+	}
+	contents.Append(makeStream("\nQ"))
+	this.Contents = contents
+}
+
+// transformAnnotation transforms the coordinates of `annot` by `m`.  If `m` rotates, the
+// appearance streams are rotated too, unless already in `visited`.
+func transformAnnotation(annot *PdfAnnotation, m [6]float64, visited map[PdfObject]bool) error {
+	transform := func(obj PdfObject) (PdfObject, error) {
+		arr, ok := TraceToDirectObject(obj).(*PdfObjectArray)
+		if !ok {
+			return obj, nil
+		}
+		coords, err := arr.ToFloat64Array()
+		if err != nil {
+			return nil, err
+		}
+		return MakeArrayFromFloats(transformCoords(m, coords)), nil
+	}
+
+	if arr, ok := TraceToDirectObject(annot.Rect).(*PdfObjectArray); ok {
+		rect, err := NewPdfRectangle(*arr)
+		if err != nil {
+			return err
+		}
+		bbox := transformRectangle(m, *rect)
+		annot.Rect = bbox.ToPdfObject()
+	}
+
+	var err error
+	switch t := annot.GetContext().(type) {
+	case *PdfAnnotationLink:
+		t.QuadPoints, err = transform(t.QuadPoints)
+	case *PdfAnnotationHighlight:
+		t.QuadPoints, err = transform(t.QuadPoints)
+	case *PdfAnnotationUnderline:
+		t.QuadPoints, err = transform(t.QuadPoints)
+	case *PdfAnnotationSquiggly:
+		t.QuadPoints, err = transform(t.QuadPoints)
+	case *PdfAnnotationStrikeOut:
+		t.QuadPoints, err = transform(t.QuadPoints)
+	case *PdfAnnotationRedact:
+		t.QuadPoints, err = transform(t.QuadPoints)
+	case *PdfAnnotationLine:
+		t.L, err = transform(t.L)
+	case *PdfAnnotationFreeText:
+		t.CL, err = transform(t.CL)
+	case *PdfAnnotationPolygon:
+		t.Vertices, err = transform(t.Vertices)
+	case *PdfAnnotationPolyLine:
+		t.Vertices, err = transform(t.Vertices)
+	case *PdfAnnotationInk:
+		if arr, ok := TraceToDirectObject(t.InkList).(*PdfObjectArray); ok {
+			inkList := MakeArray()
+			for _, path := range arr.Elements() {
+				obj, err := transform(path)
+				if err != nil {
+					return err
+				}
+				inkList.Append(obj)
+			}
+			t.InkList = inkList
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if m[1] == 0 && m[2] == 0 {
+		// Appearance streams are scaled and translated to the annotation rectangle.
+		return nil
+	}
+	// Rotate the appearance streams, so that the rotated bounding boxes are mapped to the rotated
+	// rectangle.
+	rotation := [6]float64{m[0], m[1], m[2], m[3], 0, 0}
+	var streams []*PdfObjectStream
+	if ap, ok := TraceToDirectObject(annot.AP).(*PdfObjectDictionary); ok {
+		for _, key := range ap.Keys() {
+			switch t := TraceToDirectObject(ap.Get(key)).(type) {
+			case *PdfObjectStream:
+				streams = append(streams, t)
+			case *PdfObjectDictionary:
+				for _, state := range t.Keys() {
+					if stream, ok := TraceToDirectObject(t.Get(state)).(*PdfObjectStream); ok {
+						streams = append(streams, stream)
+					}
+				}
+			}
+		}
+	}
+	for _, stream := range streams {
+		if visited[stream] {
+			continue
+		}
+		visited[stream] = true
+		matrix := [6]float64{1, 0, 0, 1, 0, 0}
+		if arr, ok := TraceToDirectObject(stream.Get("Matrix")).(*PdfObjectArray); ok && arr.Len() == 6 {
+			vals, err := arr.ToFloat64Array()
+			if err != nil {
+				return err
+			}
+			copy(matrix[:], vals)
+		}
+		matrix = multiplyMatrices(matrix, rotation)
+		stream.Set("Matrix", MakeArrayFromFloats(matrix[:]))
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+func rectsEqual(a, b PdfRectangle) bool {
+	return math.Abs(a.Llx-b.Llx) < 1e-9 && math.Abs(a.Lly-b.Lly) < 1e-9 &&
+		math.Abs(a.Urx-b.Urx) < 1e-9 && math.Abs(a.Ury-b.Ury) < 1e-9
+}
+
+// Test inheritance, defaults and clipping of the page boxes.
+func TestPageBoxes(t *testing.T) {
+	parent := MakeDict()
+	parent.Set("MediaBox", MakeArrayFromFloats([]float64{0, 0, 600, 800}))
+	parent.Set("CropBox", MakeArrayFromFloats([]float64{500, 900, -10, 50}))
+	parent.Set("Rotate", MakeInteger(-90))
+
+	page := NewPdfPage()
+	page.Parent = MakeIndirectObject(parent)
+	page.TrimBox = &PdfRectangle{Llx: 20, Lly: 20, Urx: 700, Ury: 700}
+	page.UserUnit = MakeFloat(2)
+
+	cbox, err := page.GetCropBox()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !rectsEqual(*cbox, PdfRectangle{Llx: 0, Lly: 50, Urx: 500, Ury: 800}) {
+		t.Errorf("Incorrect crop box %+v", *cbox)
+	}
+	trim, _ := page.GetTrimBox()
+	if !rectsEqual(*trim, PdfRectangle{Llx: 20, Lly: 50, Urx: 500, Ury: 700}) {
+		t.Errorf("Incorrect trim box %+v", *trim)
+	}
+	bleed, _ := page.GetBleedBox()
+	if !rectsEqual(*bleed, *cbox) {
+		t.Errorf("Incorrect bleed box %+v", *bleed)
+	}
+	if rotate, _ := page.GetRotate(); rotate != 270 {
+		t.Errorf("Incorrect rotation %d", rotate)
+	}
+
+	// Rotated by 270 degrees and scaled by 2: the crop box is displayed as a 1500x1000 page with
+	// its lower left corner at the lower right corner.
+	_, m, err := page.GetDisplayGeometry()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	display := transformRectangle(m, *cbox)
+	if !rectsEqual(display, PdfRectangle{Llx: 0, Lly: 0, Urx: 1500, Ury: 1000}) {
+		t.Errorf("Incorrect display rectangle %+v", display)
+	}
+	if x, y := transformCoords(m, []float64{0, 50})[0], transformCoords(m, []float64{0, 50})[1]; x != 1500 || y != 0 {
+		t.Errorf("Incorrect display origin (%v, %v)", x, y)
+	}
+
+	if err := page.Crop(PdfRectangle{Llx: 100, Lly: 100, Urx: 1000, Ury: 600}); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	trim, _ = page.GetTrimBox()
+	if !rectsEqual(*page.CropBox, PdfRectangle{Llx: 100, Lly: 100, Urx: 600, Ury: 600}) ||
+		!rectsEqual(*trim, PdfRectangle{Llx: 100, Lly: 100, Urx: 600, Ury: 600}) {
+		t.Errorf("Incorrect boxes after crop: %+v %+v", *page.CropBox, *trim)
+	}
+}
+
+// Test resizing and rotating the page contents with the annotations.
+func TestPageTransform(t *testing.T) {
+	page := NewPdfPage()
+	page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 200, Ury: 100}
+	page.AddContentStreamByString("0 0 10 10 re f")
+	rotate := int64(90)
+	page.Rotate = &rotate
+
+	link := NewPdfAnnotationLink()
+	link.Rect = MakeArrayFromFloats([]float64{10, 20, 30, 40})
+	link.QuadPoints = MakeArrayFromFloats([]float64{10, 20, 30, 20, 30, 40, 10, 40})
+	form := NewXObjectForm()
+	form.BBox = MakeArrayFromFloats([]float64{0, 0, 20, 20})
+	ap := MakeDict()
+	ap.Set("N", form.ToPdfObject())
+	link.AP = ap
+	page.Annotations = []*PdfAnnotation{link.PdfAnnotation}
+
+	if err := page.Resize(400, 400, true); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !rectsEqual(*page.MediaBox, PdfRectangle{Llx: 0, Lly: 0, Urx: 400, Ury: 400}) {
+		t.Errorf("Incorrect media box %+v", *page.MediaBox)
+	}
+	rect, _ := NewPdfRectangle(*link.Rect.(*PdfObjectArray))
+	if !rectsEqual(*rect, PdfRectangle{Llx: 20, Lly: 140, Urx: 60, Ury: 180}) {
+		t.Errorf("Incorrect annotation rectangle %+v", *rect)
+	}
+	content, _ := page.GetAllContentStreams()
+	if !strings.HasPrefix(content, "q 2 0 0 2 0 100 cm\n") || !strings.HasSuffix(content, "\nQ") {
+		t.Errorf("Incorrect content wrapper: %q", content)
+	}
+
+	if err := page.NormalizeRotation(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if rotate, _ := page.GetRotate(); rotate != 0 {
+		t.Errorf("Rotation not normalized: %d", rotate)
+	}
+	// The point (x,y) is rotated to (y, 400-x).
+	rect, _ = NewPdfRectangle(*link.Rect.(*PdfObjectArray))
+	if !rectsEqual(*rect, PdfRectangle{Llx: 140, Lly: 340, Urx: 180, Ury: 380}) {
+		t.Errorf("Incorrect rotated annotation rectangle %+v", *rect)
+	}
+	quads, _ := link.QuadPoints.(*PdfObjectArray).ToFloat64Array()
+	if quads[0] != 140 || quads[1] != 380 {
+		t.Errorf("Incorrect quad points %v", quads)
+	}
+	matrix, _ := form.ToPdfObject().(*PdfObjectStream).Get("Matrix").(*PdfObjectArray).ToFloat64Array()
+	if len(matrix) != 6 || matrix[0] != 0 || matrix[1] != -1 || matrix[2] != 1 || matrix[3] != 0 {
+		t.Errorf("Incorrect appearance matrix %v", matrix)
+	}
+}
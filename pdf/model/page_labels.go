@@ -0,0 +1,199 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfPageLabelStyle is the numbering style of a page label range.
+type PdfPageLabelStyle string
+
+// Page label numbering styles (12.4.2 Page Labels p. 374).  The empty style labels pages with the
+// prefix only.
+const (
+	PageLabelStyleNone       PdfPageLabelStyle = ""
+	PageLabelStyleDecimal    PdfPageLabelStyle = "D" // 1, 2, 3, ...
+	PageLabelStyleUpperRoman PdfPageLabelStyle = "R" // I, II, III, ...
+	PageLabelStyleLowerRoman PdfPageLabelStyle = "r" // i, ii, iii, ...
+	PageLabelStyleUpperAlpha PdfPageLabelStyle = "A" // A to Z, AA to ZZ, ...
+	PageLabelStyleLowerAlpha PdfPageLabelStyle = "a" // a to z, aa to zz, ...
+)
+
+// PdfPageLabel represents a page label dictionary, which labels a range of pages.
+type PdfPageLabel struct {
+	Style  PdfPageLabelStyle
+	Prefix string
+	Start  int // Number of the first page of the range (default 1).
+}
+
+// NewPdfPageLabelFromObject loads the page label dictionary `obj`.
+func NewPdfPageLabelFromObject(obj PdfObject) (*PdfPageLabel, error) {
+	dict, ok := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !ok {
+		return nil, ErrTypeError
+	}
+	label := &PdfPageLabel{Start: 1}
+	if style, ok := GetNameVal(dict.Get("S")); ok {
+		label.Style = PdfPageLabelStyle(style)
+	}
+	if prefix, ok := GetString(dict.Get("P")); ok {
+		label.Prefix = prefix.Decoded()
+	}
+	if start, ok := GetIntVal(dict.Get("St")); ok && start >= 1 {
+		label.Start = start
+	}
+	return label, nil
+}
+
+// ToPdfObject returns the page label dictionary.
+func (label *PdfPageLabel) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	dict.Set("Type", MakeName("PageLabel"))
+	if label.Style != PageLabelStyleNone {
+		dict.Set("S", MakeName(string(label.Style)))
+	}
+	if len(label.Prefix) > 0 {
+		dict.Set("P", MakeEncodedString(label.Prefix))
+	}
+	if label.Start > 1 {
+		dict.Set("St", MakeInteger(int64(label.Start)))
+	}
+	return dict
+}
+
+// Text returns the label of the page `offset` pages after the first page of the range.
+func (label *PdfPageLabel) Text(offset int) string {
+	start := label.Start
+	if start < 1 {
+		start = 1
+	}
+	num := start + offset
+	switch label.Style {
+	case PageLabelStyleDecimal:
+		return label.Prefix + strconv.Itoa(num)
+	case PageLabelStyleUpperRoman:
+		return label.Prefix + toRoman(num)
+	case PageLabelStyleLowerRoman:
+		return label.Prefix + strings.ToLower(toRoman(num))
+	case PageLabelStyleUpperAlpha:
+		return label.Prefix + toAlpha(num)
+	case PageLabelStyleLowerAlpha:
+		return label.Prefix + strings.ToLower(toAlpha(num))
+	}
+	return label.Prefix
+}
+
+// PdfPageLabels represents the page labels of a document (PageLabels entry of the catalog), as
+// ranges of pages starting at given page indices (starting from 0).
+type PdfPageLabels struct {
+	ranges map[int]PdfPageLabel
+}
+
+// NewPdfPageLabels returns an empty set of page labels.
+func NewPdfPageLabels() *PdfPageLabels {
+	return &PdfPageLabels{ranges: map[int]PdfPageLabel{}}
+}
+
+// NewPdfPageLabelsFromObject loads the page labels from the number tree with root node `obj`.
+// Invalid page label dictionaries are ignored.
+func NewPdfPageLabelsFromObject(obj PdfObject) (*PdfPageLabels, error) {
+	tree, err := NewPdfNumberTreeFromObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	labels := NewPdfPageLabels()
+	for _, key := range tree.Keys() {
+		val, _ := tree.Get(key)
+		label, err := NewPdfPageLabelFromObject(val)
+		if err != nil || key < 0 {
+			common.Log.Debug("Invalid page label for page %d - ignoring", key)
+			continue
+		}
+		labels.ranges[int(key)] = *label
+	}
+	return labels, nil
+}
+
+// SetRange sets the label of the range of pages starting at page index `start` (starting from 0),
+// which extends to the next range.
+func (labels *PdfPageLabels) SetRange(start int, label PdfPageLabel) {
+	labels.ranges[start] = label
+}
+
+// RemoveRange removes the range starting at page index `start`.
+func (labels *PdfPageLabels) RemoveRange(start int) {
+	delete(labels.ranges, start)
+}
+
+// Ranges returns the page indices at which the ranges start, in increasing order.
+func (labels *PdfPageLabels) Ranges() []int {
+	starts := make([]int, 0, len(labels.ranges))
+	for start := range labels.ranges {
+		starts = append(starts, start)
+	}
+	sort.Ints(starts)
+	return starts
+}
+
+// GetRange returns the label of the range starting at page index `start`.
+func (labels *PdfPageLabels) GetRange(start int) (PdfPageLabel, bool) {
+	label, has := labels.ranges[start]
+	return label, has
+}
+
+// Label returns the label of the page with index `pageIndex` (starting from 0).  Pages not
+// within any range are labelled with their page number.
+func (labels *PdfPageLabels) Label(pageIndex int) string {
+	starts := labels.Ranges()
+	i := sort.SearchInts(starts, pageIndex+1) - 1
+	if i < 0 {
+		return strconv.Itoa(pageIndex + 1)
+	}
+	label := labels.ranges[starts[i]]
+	return label.Text(pageIndex - starts[i])
+}
+
+// ToPdfObject returns the root node of the page labels number tree.
+func (labels *PdfPageLabels) ToPdfObject() PdfObject {
+	tree := NewPdfNumberTree()
+	for start, label := range labels.ranges {
+		tree.Set(int64(start), label.ToPdfObject())
+	}
+	return tree.ToPdfObject()
+}
+
+// toRoman returns `num` as an uppercase roman numeral.
+func toRoman(num int) string {
+	if num <= 0 {
+		return ""
+	}
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+	var b strings.Builder
+	for i, val := range values {
+		for num >= val {
+			b.WriteString(symbols[i])
+			num -= val
+		}
+	}
+	return b.String()
+}
+
+// toAlpha returns `num` as an uppercase letter label: A to Z for 1 to 26, AA to ZZ for 27 to 52
+// and so on.
+func toAlpha(num int) string {
+	if num <= 0 {
+		return ""
+	}
+	letter := string(rune('A' + (num-1)%26))
+	return strings.Repeat(letter, (num-1)/26+1)
+}
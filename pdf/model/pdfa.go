@@ -0,0 +1,531 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// XMPNamespacePDFAID is the XMP namespace of the PDF/A identification properties.
+const XMPNamespacePDFAID = "http://www.aiim.org/pdfa/ns/id/"
+
+func init() {
+	xmpNamespacePrefixes[XMPNamespacePDFAID] = "pdfaid"
+}
+
+// PdfAConformance is a PDF/A conformance level (ISO 19005) of the output of a PdfWriter.
+type PdfAConformance int
+
+// PDF/A conformance levels.  Level B (basic) requires the visual appearance of the document to be
+// reliably reproduced.
+const (
+	PdfAConformanceNone PdfAConformance = iota
+	PdfA1B                              // ISO 19005-1, based on PDF 1.4.
+	PdfA2B                              // ISO 19005-2, based on PDF 1.7.
+	PdfA3B                              // ISO 19005-3, PDF/A-2 allowing any embedded file.
+)
+
+// Part returns the part of ISO 19005 of the conformance level, 0 if none.
+func (level PdfAConformance) Part() int {
+	return int(level)
+}
+
+// String returns the name of the conformance level, e.g. PDF/A-1b.
+func (level PdfAConformance) String() string {
+	if level == PdfAConformanceNone {
+		return "None"
+	}
+	return fmt.Sprintf("PDF/A-%db", level.Part())
+}
+
+// PdfOutputIntent represents an output intent, describing the color characteristics of the output
+// device the document is intended for (14.11.5 Output Intents p. 633).
+type PdfOutputIntent struct {
+	Subtype                   string // GTS_PDFA1 for PDF/A, GTS_PDFX for PDF/X.
+	OutputCondition           string
+	OutputConditionIdentifier string
+	RegistryName              string
+	Info                      string
+	DestOutputProfile         []byte // ICC profile.
+	ColorComponents           int    // Number of color components of the profile.
+
+	container *PdfIndirectObject
+}
+
+// NewPdfOutputIntentSRGB returns a PDF/A output intent with an sRGB destination profile.
+func NewPdfOutputIntentSRGB() *PdfOutputIntent {
+	return &PdfOutputIntent{
+		Subtype:                   "GTS_PDFA1",
+		OutputConditionIdentifier: "sRGB IEC61966-2.1",
+		RegistryName:              "http://www.color.org",
+		Info:                      "sRGB IEC61966-2.1",
+		DestOutputProfile:         SRGBICCProfile(),
+		ColorComponents:           3,
+	}
+}
+
+// NewPdfOutputIntentFromObject loads an output intent from the dictionary `obj`.
+func NewPdfOutputIntentFromObject(obj PdfObject) (*PdfOutputIntent, error) {
+	dict, ok := GetDict(obj)
+	if !ok {
+		return nil, ErrTypeError
+	}
+	oi := &PdfOutputIntent{}
+	if ind, isInd := obj.(*PdfIndirectObject); isInd {
+		oi.container = ind
+	}
+	oi.Subtype, _ = GetNameVal(dict.Get("S"))
+	oi.OutputCondition, _ = GetStringVal(dict.Get("OutputCondition"))
+	oi.OutputConditionIdentifier, _ = GetStringVal(dict.Get("OutputConditionIdentifier"))
+	oi.RegistryName, _ = GetStringVal(dict.Get("RegistryName"))
+	oi.Info, _ = GetStringVal(dict.Get("Info"))
+	if stream, ok := GetStream(TraceToDirectObject(dict.Get("DestOutputProfile"))); ok {
+		data, err := DecodeStream(stream)
+		if err != nil {
+			return nil, err
+		}
+		oi.DestOutputProfile = data
+		oi.ColorComponents, _ = GetIntVal(stream.Get("N"))
+	}
+	return oi, nil
+}
+
+// ToPdfObject returns the output intent dictionary in an indirect object.
+func (oi *PdfOutputIntent) ToPdfObject() PdfObject {
+	if oi.container == nil {
+		oi.container = MakeIndirectObject(MakeDict())
+	}
+	dict := MakeDict()
+	dict.Set("Type", MakeName("OutputIntent"))
+	dict.Set("S", MakeName(oi.Subtype))
+	if len(oi.OutputCondition) > 0 {
+		dict.Set("OutputCondition", MakeString(oi.OutputCondition))
+	}
+	dict.Set("OutputConditionIdentifier", MakeString(oi.OutputConditionIdentifier))
+	if len(oi.RegistryName) > 0 {
+		dict.Set("RegistryName", MakeString(oi.RegistryName))
+	}
+	if len(oi.Info) > 0 {
+		dict.Set("Info", MakeString(oi.Info))
+	}
+	if len(oi.DestOutputProfile) > 0 {
+		stream, err := MakeStream(oi.DestOutputProfile, NewFlateEncoder())
+		if err != nil {
+			common.Log.Debug("ERROR: Unable to make stream: %v", err)
+		} else {
+			stream.Set("N", MakeInteger(int64(oi.ColorComponents)))
+			dict.Set("DestOutputProfile", stream)
+		}
+	}
+	oi.container.PdfObject = dict
+	return oi.container
+}
+
+// SetPdfAConformance sets the PDF/A conformance level of the output.  The written document then
+// has a PDF/A output intent (an sRGB one unless added with AddOutputIntent), XMP metadata
+// identifying the conformance level and file identifiers.  Writing fails if the document has
+// constructs not allowed at the level, such as unembedded fonts, transparency for PDF/A-1,
+// JavaScript, encryption or embedded files other than PDF/A files for PDF/A-1 and PDF/A-2.
+//
+// The fonts used without font programs are embedded by replacing them with the substitute fonts
+// set with SetFontSubstitute, e.g. the Liberation fonts of package pdfafonts for the standard
+// fonts.  The widths of the substitutes are those of their font programs, as required for PDF/A.
+func (this *PdfWriter) SetPdfAConformance(level PdfAConformance) {
+	this.pdfaConformance = level
+}
+
+// AddOutputIntent adds an output intent to the document.
+func (this *PdfWriter) AddOutputIntent(oi *PdfOutputIntent) {
+	this.outputIntents = append(this.outputIntents, oi)
+}
+
+// SetFontSubstitute sets the font `font` replacing the font `baseFont` without font program (e.g.
+// Helvetica) in PDF/A output, where all fonts must be embedded.  The substitute of a standard font
+// is expected to be metric compatible with it, e.g. a font loaded with NewPdfFontFromTTFFile, as
+// the text was laid out with the widths of the standard font.  Only fonts with WinAnsiEncoding can
+// be replaced.
+func (this *PdfWriter) SetFontSubstitute(baseFont string, font *PdfFont) {
+	if this.fontSubstitutes == nil {
+		this.fontSubstitutes = map[string]*PdfFont{}
+	}
+	this.fontSubstitutes[baseFont] = font
+}
+
+// updateOutputIntents sets the output intents of the catalog and prepares the PDF/A output: the
+// version, the XMP identification properties and the file identifiers.
+func (this *PdfWriter) updateOutputIntents() error {
+	level := this.pdfaConformance
+	if level != PdfAConformanceNone {
+		if this.crypter != nil {
+			return fmt.Errorf("%s: Encryption not allowed", level)
+		}
+		if len(this.outputIntents) == 0 {
+			this.outputIntents = append(this.outputIntents, NewPdfOutputIntentSRGB())
+		}
+		if level == PdfA1B {
+			this.SetVersion(1, 4)
+		} else {
+			this.SetVersion(1, 7)
+		}
+		if this.xmpMetadata == nil {
+			this.xmpMetadata = NewXMPMetadata()
+		}
+		this.xmpMetadata.SetProperty(XMPNamespacePDFAID, "part", fmt.Sprintf("%d", level.Part()))
+		this.xmpMetadata.SetProperty(XMPNamespacePDFAID, "conformance", "B")
+		if this.ids == nil {
+			this.makeFileIDs()
+		}
+	}
+
+	if len(this.outputIntents) == 0 {
+		return nil
+	}
+	intents := MakeArray()
+	for _, oi := range this.outputIntents {
+		intents.Append(oi.ToPdfObject())
+	}
+	this.catalog.Set("OutputIntents", intents)
+	return this.addObjects(intents)
+}
+
+// Actions not allowed in PDF/A documents, by conformance level.
+var pdfaForbiddenActions = map[string]PdfAConformance{
+	"Launch":      PdfA1B,
+	"Sound":       PdfA1B,
+	"Movie":       PdfA1B,
+	"ResetForm":   PdfA1B,
+	"ImportData":  PdfA1B,
+	"JavaScript":  PdfA1B,
+	"Hide":        PdfA2B,
+	"SetOCGState": PdfA2B,
+	"Rendition":   PdfA2B,
+	"Trans":       PdfA2B,
+	"GoTo3DView":  PdfA2B,
+}
+
+// Annotation types not allowed in PDF/A documents, by conformance level.
+var pdfaForbiddenAnnotations = map[string]PdfAConformance{
+	"Sound":     PdfA1B,
+	"Movie":     PdfA1B,
+	"Screen":    PdfA1B,
+	"3D":        PdfA1B,
+	"RichMedia": PdfA2B,
+}
+
+// checkPdfAConformance checks that the objects to be written conform to the PDF/A level of the
+// writer, replacing the fonts without font programs by their substitutes.
+func (this *PdfWriter) checkPdfAConformance() error {
+	level := this.pdfaConformance
+	if level == PdfAConformanceNone {
+		return nil
+	}
+
+	if names, ok := GetDict(this.catalog.Get("Names")); ok {
+		if names.Get("JavaScript") != nil {
+			return fmt.Errorf("%s: JavaScript not allowed", level)
+		}
+	}
+	if level == PdfA1B && this.catalog.Get("OCProperties") != nil {
+		return fmt.Errorf("%s: Optional content not allowed", level)
+	}
+	if form, ok := GetDict(this.catalog.Get("AcroForm")); ok {
+		if need, _ := GetBoolVal(form.Get("NeedAppearances")); need {
+			return fmt.Errorf("%s: NeedAppearances not allowed", level)
+		}
+	}
+
+	components := 0
+	if len(this.outputIntents) > 0 {
+		components = this.outputIntents[0].ColorComponents
+	}
+
+	// Objects added while checking (substitute fonts) are also checked.
+	for i := 0; i < len(this.objects); i++ {
+		var err error
+		switch t := this.objects[i].(type) {
+		case *PdfIndirectObject:
+			err = this.checkPdfAObject(t.PdfObject, components)
+			if page, ok := t.PdfObject.(*PdfObjectDictionary); ok && err == nil {
+				if typ, _ := GetNameVal(page.Get("Type")); typ == "Page" {
+					err = this.checkPdfAPageContents(page, components)
+				}
+			}
+		case *PdfObjectStream:
+			err = this.checkPdfAStream(t, components)
+			if err == nil {
+				err = this.checkPdfAObject(t.PdfObjectDictionary, components)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPdfAStream checks the filters and the external file entries of the stream `stream`, and the
+// colors of Form XObjects against the output intent with `components` color components.
+func (this *PdfWriter) checkPdfAStream(stream *PdfObjectStream, components int) error {
+	level := this.pdfaConformance
+	if stream.Get("F") != nil || stream.Get("FFilter") != nil {
+		return fmt.Errorf("%s: External stream files not allowed", level)
+	}
+	filters := []PdfObject{stream.Get("Filter")}
+	if arr, ok := GetArray(stream.Get("Filter")); ok {
+		filters = arr.Elements()
+	}
+	for _, filter := range filters {
+		if name, _ := GetNameVal(filter); name == "LZWDecode" || name == "Crypt" {
+			return fmt.Errorf("%s: %s filter not allowed", level, name)
+		}
+	}
+	if subtype, _ := GetNameVal(stream.Get("Subtype")); subtype == "Form" {
+		return this.checkPdfAContentColors(stream, components)
+	}
+	return nil
+}
+
+// checkPdfAPageContents checks the colors of the content streams of the page `page` against the
+// output intent with `components` color components.
+func (this *PdfWriter) checkPdfAPageContents(page *PdfObjectDictionary, components int) error {
+	contents := []PdfObject{page.Get("Contents")}
+	if arr, ok := GetArray(page.Get("Contents")); ok {
+		contents = arr.Elements()
+	}
+	for _, obj := range contents {
+		if stream, ok := GetStream(TraceToDirectObject(obj)); ok {
+			if err := this.checkPdfAContentColors(stream, components); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkPdfAContentColors checks that the color spaces used by the operators of the content stream
+// `stream` are allowed with the output intent with `components` color components.
+func (this *PdfWriter) checkPdfAContentColors(stream *PdfObjectStream, components int) error {
+	content, err := DecodeStream(stream)
+	if err != nil {
+		common.Log.Debug("Unable to decode content stream (%v) - skipping", err)
+		return nil
+	}
+	spaces, err := ContentColorSpaces(content)
+	if err != nil {
+		common.Log.Debug("Unable to parse content stream (%v) - skipping", err)
+		return nil
+	}
+	for _, cs := range spaces {
+		if !PdfADeviceColorAllowed(cs, components) {
+			return fmt.Errorf("%s: %s used without a matching output intent", this.pdfaConformance, cs)
+		}
+	}
+	return nil
+}
+
+// checkPdfAObject checks the direct object `obj` and the direct objects it contains.  `components`
+// is the number of color components of the output intent.
+func (this *PdfWriter) checkPdfAObject(obj PdfObject, components int) error {
+	switch t := obj.(type) {
+	case *PdfObjectArray:
+		for _, elem := range t.Elements() {
+			if err := this.checkPdfAObject(elem, components); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *PdfObjectDictionary:
+		if err := this.checkPdfADict(t, components); err != nil {
+			return err
+		}
+		for _, key := range t.Keys() {
+			if key == "Parent" {
+				continue
+			}
+			if err := this.checkPdfAObject(t.Get(key), components); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkPdfADict checks the entries of the dictionary `dict`.
+func (this *PdfWriter) checkPdfADict(dict *PdfObjectDictionary, components int) error {
+	level := this.pdfaConformance
+	typ, _ := GetNameVal(dict.Get("Type"))
+	subtype, _ := GetNameVal(dict.Get("Subtype"))
+
+	if typ == "Font" {
+		return this.checkPdfAFont(dict)
+	}
+	if dict.Get("AA") != nil {
+		return fmt.Errorf("%s: Additional actions not allowed", level)
+	}
+
+	// Actions.
+	if s, ok := GetNameVal(dict.Get("S")); ok && (typ == "Action" || typ == "") {
+		if forbidden, has := pdfaForbiddenActions[s]; has && forbidden <= level {
+			return fmt.Errorf("%s: %s action not allowed", level, s)
+		}
+		if s == "Named" {
+			switch n, _ := GetNameVal(dict.Get("N")); n {
+			case "NextPage", "PrevPage", "FirstPage", "LastPage":
+			default:
+				return fmt.Errorf("%s: Named action %s not allowed", level, n)
+			}
+		}
+	}
+
+	// Transparency.
+	if level == PdfA1B {
+		if s, _ := GetNameVal(dict.Get("S")); typ == "Group" && s == "Transparency" {
+			return fmt.Errorf("%s: Transparency group not allowed", level)
+		}
+		if smask := dict.Get("SMask"); smask != nil {
+			if name, _ := GetNameVal(smask); name != "None" {
+				return fmt.Errorf("%s: Soft mask not allowed", level)
+			}
+		}
+		for _, key := range []PdfObjectName{"CA", "ca"} {
+			if alpha, err := GetNumberAsFloat(TraceToDirectObject(dict.Get(key))); err == nil && alpha < 1 {
+				return fmt.Errorf("%s: Constant opacity %v not allowed", level, alpha)
+			}
+		}
+		if bm, ok := GetNameVal(dict.Get("BM")); ok && bm != "Normal" && bm != "Compatible" {
+			return fmt.Errorf("%s: Blend mode %s not allowed", level, bm)
+		}
+	}
+
+	// Device color spaces of images must match the output intent.
+	if cs, ok := GetNameVal(dict.Get("ColorSpace")); ok {
+		if !PdfADeviceColorAllowed(cs, components) {
+			return fmt.Errorf("%s: %s used without a matching output intent", level, cs)
+		}
+	}
+
+	if subtype == "Image" {
+		if interpolate, _ := GetBoolVal(dict.Get("Interpolate")); interpolate {
+			return fmt.Errorf("%s: Image interpolation not allowed", level)
+		}
+		if dict.Get("Alternates") != nil || dict.Get("OPI") != nil {
+			return fmt.Errorf("%s: Alternate images not allowed", level)
+		}
+	}
+
+	// Annotations.
+	if _, isAnnot := GetArray(dict.Get("Rect")); isAnnot && len(subtype) > 0 && (typ == "Annot" || typ == "") {
+		if forbidden, has := pdfaForbiddenAnnotations[subtype]; has && forbidden <= level {
+			return fmt.Errorf("%s: %s annotation not allowed", level, subtype)
+		}
+		if subtype == "FileAttachment" && level == PdfA1B {
+			return fmt.Errorf("%s: %s annotation not allowed", level, subtype)
+		}
+		flags, has := GetIntVal(dict.Get("F"))
+		if !has {
+			// Annotations are printed in PDF/A documents.
+			flags = 4
+			dict.Set("F", MakeInteger(int64(flags)))
+		}
+		if flags&(1|2|32) != 0 || flags&4 == 0 {
+			return fmt.Errorf("%s: %s annotation must be printed and visible", level, subtype)
+		}
+	}
+
+	// Embedded files.
+	if typ == "Filespec" && dict.Get("EF") != nil {
+		return this.checkPdfAFileSpec(dict)
+	}
+	return nil
+}
+
+// checkPdfAFileSpec checks the file specification `dict` with embedded files.
+func (this *PdfWriter) checkPdfAFileSpec(dict *PdfObjectDictionary) error {
+	level := this.pdfaConformance
+	spec, err := NewPdfFileSpecFromObject(dict)
+	if err != nil {
+		return err
+	}
+	switch level {
+	case PdfA1B:
+		return fmt.Errorf("%s: Embedded file %s not allowed", level, spec.FileName)
+	case PdfA2B:
+		if spec.EmbeddedFile == nil || spec.EmbeddedFile.MIMEType != "application/pdf" {
+			return fmt.Errorf("%s: Embedded file %s is not a PDF/A file", level, spec.FileName)
+		}
+	default:
+		if len(spec.AFRelationship) == 0 {
+			return fmt.Errorf("%s: Embedded file %s has no AFRelationship", level, spec.FileName)
+		}
+	}
+	return nil
+}
+
+// checkPdfAFont checks that the font `dict` has an embedded font program, replacing a font without
+// font program by its substitute (see SetFontSubstitute).
+func (this *PdfWriter) checkPdfAFont(dict *PdfObjectDictionary) error {
+	level := this.pdfaConformance
+	subtype, _ := GetNameVal(dict.Get("Subtype"))
+	basefont, _ := GetNameVal(dict.Get("BaseFont"))
+
+	descriptorHolder := dict
+	switch subtype {
+	case "Type3":
+		return nil
+	case "Type0":
+		descendants, ok := GetArray(dict.Get("DescendantFonts"))
+		if !ok || descendants.Len() == 0 {
+			return fmt.Errorf("%s: Font %s has no descendant font", level, basefont)
+		}
+		descendant, ok := GetDict(descendants.Get(0))
+		if !ok {
+			return fmt.Errorf("%s: Font %s has no descendant font", level, basefont)
+		}
+		descriptorHolder = descendant
+	}
+
+	if descriptor, ok := GetDict(descriptorHolder.Get("FontDescriptor")); ok {
+		for _, key := range []PdfObjectName{"FontFile", "FontFile2", "FontFile3"} {
+			if descriptor.Get(key) != nil {
+				return nil
+			}
+		}
+	}
+
+	if subtype == "Type0" {
+		return fmt.Errorf("%s: Font %s not embedded", level, basefont)
+	}
+	font, has := this.fontSubstitutes[basefont]
+	if !has {
+		return fmt.Errorf("%s: Font %s not embedded", level, basefont)
+	}
+	if enc, _ := GetNameVal(dict.Get("Encoding")); enc != "WinAnsiEncoding" {
+		return fmt.Errorf("%s: Font %s without WinAnsiEncoding cannot be substituted", level, basefont)
+	}
+	subDict, ok := GetDict(font.ToPdfObject())
+	if !ok {
+		return errors.New("Invalid substitute font")
+	}
+	if enc, _ := GetNameVal(subDict.Get("Encoding")); enc != "WinAnsiEncoding" {
+		return fmt.Errorf("%s: Substitute font of %s without WinAnsiEncoding", level, basefont)
+	}
+	common.Log.Debug("Substituting font %s with %s", basefont, subDict.Get("BaseFont"))
+
+	for _, key := range append([]PdfObjectName{}, dict.Keys()...) {
+		dict.Remove(key)
+	}
+	for _, key := range subDict.Keys() {
+		dict.Set(key, subDict.Get(key))
+		if err := this.addObjects(subDict.Get(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
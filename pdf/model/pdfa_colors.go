@@ -0,0 +1,126 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"errors"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// Content stream operators setting device colors.
+var deviceColorOperators = map[string]string{
+	"g": "DeviceGray", "G": "DeviceGray",
+	"rg": "DeviceRGB", "RG": "DeviceRGB",
+	"k": "DeviceCMYK", "K": "DeviceCMYK",
+}
+
+// PdfADeviceColorAllowed returns true if the color space `name` can be used in a PDF/A document
+// whose output intent has `components` color components (0 without output intent).  DeviceGray
+// requires an output intent, DeviceRGB and DeviceCMYK an RGB or CMYK output intent respectively.
+// Other color spaces are allowed.
+func PdfADeviceColorAllowed(name string, components int) bool {
+	switch name {
+	case "DeviceGray":
+		return components > 0
+	case "DeviceRGB":
+		return components == 3
+	case "DeviceCMYK":
+		return components == 4
+	}
+	return true
+}
+
+// ContentColorSpaces returns the color spaces used by the operators of the content stream
+// `content`: the device color spaces of the g, rg and k operators (and their stroking variants)
+// and the color spaces selected by cs and CS, in order of use.
+func ContentColorSpaces(content []byte) ([]string, error) {
+	spaces := []string{}
+	// Operands of the current operator, names with their leading slash.
+	operands := []string{}
+
+	i := 0
+	for i < len(content) {
+		c := content[i]
+		switch {
+		case IsWhiteSpace(c):
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\r' && content[i] != '\n' {
+				i++
+			}
+		case c == '(':
+			depth := 0
+			for ; i < len(content); i++ {
+				if content[i] == '\\' {
+					i++
+				} else if content[i] == '(' {
+					depth++
+				} else if content[i] == ')' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if i >= len(content) {
+				return nil, errors.New("Unterminated string")
+			}
+			i++
+			operands = append(operands, "")
+		case c == '<' && !bytes.HasPrefix(content[i:], []byte("<<")):
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return nil, errors.New("Unterminated hex string")
+			}
+			i += end + 1
+			operands = append(operands, "")
+		case IsDelimiter(c) && c != '/':
+			// Arrays and dictionaries.
+			i++
+			operands = append(operands, "")
+		default:
+			start := i
+			for i++; i < len(content) && !IsWhiteSpace(content[i]) && !IsDelimiter(content[i]); i++ {
+			}
+			token := string(content[start:i])
+			if c == '/' || IsFloatDigit(c) || c == '+' || c == '-' || token == "true" || token == "false" || token == "null" {
+				operands = append(operands, token)
+				continue
+			}
+
+			if cs, has := deviceColorOperators[token]; has {
+				spaces = append(spaces, cs)
+			}
+			if (token == "cs" || token == "CS") && len(operands) == 1 && len(operands[0]) > 1 && operands[0][0] == '/' {
+				spaces = append(spaces, operands[0][1:])
+			}
+			if token == "ID" {
+				// Skip the inline image data up to the EI operator.
+				end := inlineImageEnd(content[i:])
+				if end < 0 {
+					return nil, errors.New("Unterminated inline image")
+				}
+				i += end
+			}
+			operands = operands[:0]
+		}
+	}
+	return spaces, nil
+}
+
+// inlineImageEnd returns the offset after the EI operator ending the inline image data `data`, or
+// -1 if there is none.
+func inlineImageEnd(data []byte) int {
+	for i := 1; i+2 <= len(data); i++ {
+		if data[i] == 'E' && data[i+1] == 'I' && IsWhiteSpace(data[i-1]) &&
+			(i+2 == len(data) || IsWhiteSpace(data[i+2]) || IsDelimiter(data[i+2])) {
+			return i + 2
+		}
+	}
+	return -1
+}
@@ -0,0 +1,204 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model/fonts"
+)
+
+// Test the header and the tag table of the sRGB profile.
+func TestSRGBICCProfile(t *testing.T) {
+	profile := SRGBICCProfile()
+	if size := binary.BigEndian.Uint32(profile[0:]); int(size) != len(profile) {
+		t.Fatalf("Profile size %d, expected %d", size, len(profile))
+	}
+	if string(profile[12:24]) != "mntrRGB XYZ " || string(profile[36:40]) != "acsp" {
+		t.Errorf("Invalid profile header % x", profile[:40])
+	}
+
+	count := int(binary.BigEndian.Uint32(profile[128:]))
+	sigs := []string{}
+	for i := 0; i < count; i++ {
+		entry := profile[132+12*i:]
+		offset := binary.BigEndian.Uint32(entry[4:])
+		size := binary.BigEndian.Uint32(entry[8:])
+		if offset%4 != 0 || int(offset+size) > len(profile) {
+			t.Errorf("Invalid tag %s at %d (%d bytes)", entry[:4], offset, size)
+		}
+		sigs = append(sigs, string(entry[:4]))
+	}
+	if strings.Join(sigs, " ") != "desc cprt wtpt rXYZ gXYZ bXYZ rTRC gTRC bTRC" {
+		t.Errorf("Invalid tags %v", sigs)
+	}
+}
+
+// newPdfATestWriter returns a writer with a page using the standard font `font` without font program.
+func newPdfATestWriter(t *testing.T, level PdfAConformance, font fonts.Font) *PdfWriter {
+	page := NewPdfPage()
+	page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 612, Ury: 792}
+	page.Resources = NewPdfPageResources()
+	page.Resources.SetFontByName("F1", font.ToPdfObject())
+	if err := page.SetContentStreams([]string{"BT /F1 12 Tf 10 10 Td (Text) Tj ET"}, NewFlateEncoder()); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	writer := NewPdfWriter()
+	writer.SetPdfAConformance(level)
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return &writer
+}
+
+// Test the errors for constructs not allowed in PDF/A documents.
+func TestPdfAErrors(t *testing.T) {
+	// Standard fonts without substitute.
+	writer := newPdfATestWriter(t, PdfA1B, fonts.NewFontHelvetica())
+	var buf bytes.Buffer
+	if err := writer.Write(&buf); err == nil || !strings.Contains(err.Error(), "Helvetica not embedded") {
+		t.Errorf("Expected unembedded font error, got %v", err)
+	}
+
+	writer = newPdfATestWriter(t, PdfA2B, fonts.NewFontHelvetica())
+	if err := writer.Encrypt([]byte("user"), []byte("owner"), nil); err == nil {
+		t.Errorf("Expected encryption error")
+	}
+
+	plain := NewPdfWriter()
+	writer = &plain
+	writer.SetPdfAConformance(PdfA1B)
+	gs := MakeDict()
+	gs.Set("ca", MakeFloat(0.5))
+	page := NewPdfPage()
+	page.Resources = NewPdfPageResources()
+	page.Resources.AddExtGState("GS0", MakeIndirectObject(gs))
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(&buf); err == nil || !strings.Contains(err.Error(), "opacity") {
+		t.Errorf("Expected transparency error, got %v", err)
+	}
+}
+
+// Test the color spaces used by content stream operators.
+func TestContentColorSpaces(t *testing.T) {
+	content := `q 0.5 g /Cs1 cs 1 0 0 RG % 0 0 0 1 k
+BT /F1 12 Tf (Text \) 1 k (nested) 0 g) Tj <0102> Tj [(a) 1 (b)] TJ ET
+BI /W 1 /H 1 /CS /G /BPC 8 ID ` + "\x00 k\n" + ` EI
+/DeviceCMYK CS 0 0 0 1 K Q`
+	spaces, err := ContentColorSpaces([]byte(content))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if s := strings.Join(spaces, " "); s != "DeviceGray Cs1 DeviceRGB DeviceCMYK DeviceCMYK" {
+		t.Errorf("Invalid color spaces %q", s)
+	}
+
+	if _, err := ContentColorSpaces([]byte("(Text Tj")); err == nil {
+		t.Errorf("Unterminated string should fail")
+	}
+}
+
+// Test checking the device colors of the page contents against the output intent.
+func TestPdfADeviceColors(t *testing.T) {
+	for _, c := range []struct {
+		content string
+		valid   bool
+	}{
+		{"1 0 0 rg 0 0 10 10 re f 0 G", true},
+		{"0 0 0 1 k 0 0 10 10 re f", false},
+		{"/DeviceCMYK cs 0 0 0 1 sc", false},
+	} {
+		font, err := NewPdfFontFromTTFFile("../creator/testdata/FreeSans.ttf")
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		writer := newPdfATestWriter(t, PdfA1B, fonts.NewFontHelvetica())
+		writer.SetFontSubstitute("Helvetica", font)
+		page := NewPdfPage()
+		page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 612, Ury: 792}
+		page.Resources = NewPdfPageResources()
+		if err := page.SetContentStreams([]string{c.content}, NewFlateEncoder()); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if err := writer.AddPage(page); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		// The default output intent is sRGB.
+		var buf bytes.Buffer
+		err = writer.Write(&buf)
+		if c.valid && err != nil {
+			t.Errorf("%q: %v", c.content, err)
+		}
+		if !c.valid && (err == nil || !strings.Contains(err.Error(), "DeviceCMYK used without a matching output intent")) {
+			t.Errorf("%q: expected device color error, got %v", c.content, err)
+		}
+	}
+}
+
+// Test the output intent, metadata, file identifiers and substituted fonts of a PDF/A document.
+func TestPdfAOutput(t *testing.T) {
+	font, err := NewPdfFontFromTTFFile("../creator/testdata/FreeSans.ttf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	writer := newPdfATestWriter(t, PdfA3B, fonts.NewFontHelvetica())
+	writer.SetFontSubstitute("Helvetica", font)
+	var buf bytes.Buffer
+	if err := writer.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	reader, err := NewPdfReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	fontObj, has := reader.PageList[0].Resources.GetFontByName("F1")
+	if !has {
+		t.Fatalf("Page font missing")
+	}
+	fontDict, _ := GetDict(fontObj)
+	descriptor, _ := GetDict(fontDict.Get("FontDescriptor"))
+	if basefont, _ := GetNameVal(fontDict.Get("BaseFont")); basefont != "FreeSans" || descriptor == nil || descriptor.Get("FontFile2") == nil {
+		t.Errorf("Font not substituted: %v", fontDict)
+	}
+	if version := reader.PdfVersion(); version != "1.7" {
+		t.Errorf("Version %s", version)
+	}
+	trailer, err := reader.GetTrailer()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if ids, ok := GetArray(trailer.Get("ID")); !ok || ids.Len() != 2 {
+		t.Errorf("Invalid file identifiers %v", trailer.Get("ID"))
+	}
+
+	intents, err := reader.GetOutputIntents()
+	if err != nil || len(intents) != 1 {
+		t.Fatalf("Output intents not read (%v)", err)
+	}
+	oi := intents[0]
+	if oi.Subtype != "GTS_PDFA1" || oi.ColorComponents != 3 || !bytes.Equal(oi.DestOutputProfile, SRGBICCProfile()) {
+		t.Errorf("Invalid output intent %s %d", oi.Subtype, oi.ColorComponents)
+	}
+
+	xmp, err := reader.GetXMPMetadata()
+	if err != nil || xmp == nil {
+		t.Fatalf("Metadata not read (%v)", err)
+	}
+	part, _ := xmp.GetProperty(XMPNamespacePDFAID, "part")
+	conformance, _ := xmp.GetProperty(XMPNamespacePDFAID, "conformance")
+	if part != "3" || conformance != "B" {
+		t.Errorf("Invalid PDF/A identification %q %q", part, conformance)
+	}
+}
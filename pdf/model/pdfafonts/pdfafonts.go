@@ -0,0 +1,61 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+// Package pdfafonts provides the metric compatible Liberation fonts as embedded substitutes of the
+// standard 14 fonts in PDF/A output, where all fonts must be embedded.
+package pdfafonts
+
+import (
+	"github.com/go-fonts/liberation/liberationmonobold"
+	"github.com/go-fonts/liberation/liberationmonobolditalic"
+	"github.com/go-fonts/liberation/liberationmonoitalic"
+	"github.com/go-fonts/liberation/liberationmonoregular"
+	"github.com/go-fonts/liberation/liberationsansbold"
+	"github.com/go-fonts/liberation/liberationsansbolditalic"
+	"github.com/go-fonts/liberation/liberationsansitalic"
+	"github.com/go-fonts/liberation/liberationsansregular"
+	"github.com/go-fonts/liberation/liberationserifbold"
+	"github.com/go-fonts/liberation/liberationserifbolditalic"
+	"github.com/go-fonts/liberation/liberationserifitalic"
+	"github.com/go-fonts/liberation/liberationserifregular"
+
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// substitutes maps the standard 14 fonts to the TrueType programs of the metric compatible
+// Liberation fonts.  Symbol and ZapfDingbats have no substitute.
+var substitutes = map[string][]byte{
+	"Courier":               liberationmonoregular.TTF,
+	"Courier-Bold":          liberationmonobold.TTF,
+	"Courier-BoldOblique":   liberationmonobolditalic.TTF,
+	"Courier-Oblique":       liberationmonoitalic.TTF,
+	"Helvetica":             liberationsansregular.TTF,
+	"Helvetica-Bold":        liberationsansbold.TTF,
+	"Helvetica-BoldOblique": liberationsansbolditalic.TTF,
+	"Helvetica-Oblique":     liberationsansitalic.TTF,
+	"Times-Roman":           liberationserifregular.TTF,
+	"Times-Bold":            liberationserifbold.TTF,
+	"Times-BoldItalic":      liberationserifbolditalic.TTF,
+	"Times-Italic":          liberationserifitalic.TTF,
+}
+
+// FontSubstituter sets the embedded fonts replacing the fonts without font program in PDF/A output,
+// e.g. model.PdfWriter or creator.Creator.
+type FontSubstituter interface {
+	SetFontSubstitute(baseFont string, font *model.PdfFont)
+}
+
+// SetSubstitutes sets the Liberation fonts as the substitutes of the standard 14 fonts other than
+// Symbol and ZapfDingbats in `s`.
+func SetSubstitutes(s FontSubstituter) error {
+	for baseFont, ttf := range substitutes {
+		font, err := model.NewPdfFontFromTTF(ttf)
+		if err != nil {
+			return err
+		}
+		s.SetFontSubstitute(baseFont, font)
+	}
+	return nil
+}
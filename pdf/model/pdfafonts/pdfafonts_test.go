@@ -0,0 +1,84 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdfafonts
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+	"github.com/unidoc/unidoc/pdf/model/fonts"
+)
+
+// writePdfA writes a PDF/A-1b document with a page using the standard font `font`, substituted by
+// the Liberation fonts.
+func writePdfA(t *testing.T, font fonts.Font) ([]byte, error) {
+	page := model.NewPdfPage()
+	page.MediaBox = &model.PdfRectangle{Llx: 0, Lly: 0, Urx: 612, Ury: 792}
+	page.Resources = model.NewPdfPageResources()
+	page.Resources.SetFontByName("F1", font.ToPdfObject())
+	if err := page.SetContentStreams([]string{"BT /F1 12 Tf 10 10 Td (Text) Tj ET"}, core.NewFlateEncoder()); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	writer := model.NewPdfWriter()
+	writer.SetPdfAConformance(model.PdfA1B)
+	if err := SetSubstitutes(&writer); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	var buf bytes.Buffer
+	err := writer.Write(&buf)
+	return buf.Bytes(), err
+}
+
+// Test embedding the substitutes of the standard fonts with the widths of their font programs.
+func TestSetSubstitutes(t *testing.T) {
+	data, err := writePdfA(t, fonts.NewFontTimesBold())
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	reader, err := model.NewPdfReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	fontObj, has := reader.PageList[0].Resources.GetFontByName("F1")
+	if !has {
+		t.Fatalf("Page font missing")
+	}
+	fontDict, _ := core.GetDict(fontObj)
+	descriptor, _ := core.GetDict(fontDict.Get("FontDescriptor"))
+	if basefont, _ := core.GetNameVal(fontDict.Get("BaseFont")); basefont != "LiberationSerif-Bold" || descriptor == nil || descriptor.Get("FontFile2") == nil {
+		t.Fatalf("Font not substituted: %v", fontDict)
+	}
+
+	widths, ok := core.GetArray(fontDict.Get("Widths"))
+	if !ok {
+		t.Fatalf("Widths missing: %v", fontDict)
+	}
+	vals, err := widths.ToFloat64Array()
+	if err != nil || len(vals) != 224 {
+		t.Fatalf("Invalid widths %v (%v)", widths, err)
+	}
+	// Widths of the font program, which match the Times-Bold metrics for space, A, i and W but not
+	// for plusminus.
+	for code, w := range map[int]float64{32: 250, 65: 722, 105: 278, 87: 1000, 177: 549} {
+		if math.Abs(vals[code-32]-w) > 0.5 {
+			t.Errorf("Width of %d: %v, expected %v", code, vals[code-32], w)
+		}
+	}
+
+	// Symbol has no substitute.
+	if _, err := writePdfA(t, fonts.NewFontSymbol()); err == nil || !strings.Contains(err.Error(), "Symbol not embedded") {
+		t.Errorf("Expected unembedded font error, got %v", err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"github.com/unidoc/unidoc/common"
@@ -414,6 +415,18 @@ func (this *PdfReader) GetOutlineTree() *PdfOutlineTreeNode {
 	return this.outlineTree
 }
 
+// GetOutlines returns the root of the document outlines for reading and editing, e.g. to modify
+// the items and write them with PdfWriter.SetOutlines.  Returns an empty outline if the document
+// has none.
+func (this *PdfReader) GetOutlines() *PdfOutline {
+	if this.outlineTree != nil {
+		if outline, ok := this.outlineTree.context.(*PdfOutline); ok {
+			return outline
+		}
+	}
+	return NewPdfOutline()
+}
+
 // Return a flattened list of tree nodes and titles.
 func (this *PdfReader) GetOutlinesFlattened() ([]*PdfOutlineTreeNode, []string, error) {
 	outlineNodeList := []*PdfOutlineTreeNode{}
@@ -762,6 +775,335 @@ func (this *PdfReader) GetOCProperties() (PdfObject, error) {
 	return obj, nil
 }
 
+// GetOptionalContent returns the typed optional content properties of the document, or nil if the
+// document has none.
+func (this *PdfReader) GetOptionalContent() (*PdfOCProperties, error) {
+	obj, err := this.GetOCProperties()
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, nil
+	}
+	if _, isNull := obj.(*PdfObjectNull); isNull {
+		return nil, nil
+	}
+	return NewPdfOCPropertiesFromObject(obj)
+}
+
+// GetPdfInfo returns the document information dictionary.  Returns an empty information model if
+// the document has none.
+func (this *PdfReader) GetPdfInfo() (*PdfInfo, error) {
+	trailerDict, err := this.GetTrailer()
+	if err != nil {
+		return nil, err
+	}
+	obj := trailerDict.Get("Info")
+	if obj == nil {
+		return NewPdfInfo(), nil
+	}
+	obj, err = this.traceToObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	if _, isNull := obj.(*PdfObjectNull); isNull {
+		return NewPdfInfo(), nil
+	}
+	return NewPdfInfoFromObject(obj)
+}
+
+// GetXMPMetadata returns the XMP metadata packet of the document catalog Metadata stream.
+// Returns nil if the document has no metadata stream.
+func (this *PdfReader) GetXMPMetadata() (*XMPMetadata, error) {
+	obj := this.catalog.Get("Metadata")
+	if obj == nil {
+		return nil, nil
+	}
+	obj, err := this.traceToObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := obj.(*PdfObjectStream)
+	if !ok {
+		common.Log.Debug("ERROR: Metadata not a stream (%T)", obj)
+		return nil, ErrTypeError
+	}
+	data, err := DecodeStream(stream)
+	if err != nil {
+		return nil, err
+	}
+	return NewXMPMetadataFromBytes(data)
+}
+
+// loadCatalogEntry returns the catalog entry `key` with all references resolved, or nil if the
+// catalog has no such entry.
+func (this *PdfReader) loadCatalogEntry(key PdfObjectName) (PdfObject, error) {
+	obj := this.catalog.Get(key)
+	if obj == nil {
+		return nil, nil
+	}
+	obj, err := this.traceToObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	err = this.traverseObjectData(obj)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// GetPageLabels returns the page labels of the document.  Returns nil if the document has none.
+func (this *PdfReader) GetPageLabels() (*PdfPageLabels, error) {
+	obj, err := this.loadCatalogEntry("PageLabels")
+	if obj == nil || err != nil {
+		return nil, err
+	}
+	return NewPdfPageLabelsFromObject(obj)
+}
+
+// GetNames returns the name dictionary of the document catalog.  Returns nil if the document has
+// none.
+func (this *PdfReader) GetNames() (*PdfNames, error) {
+	obj, err := this.loadCatalogEntry("Names")
+	if obj == nil || err != nil {
+		return nil, err
+	}
+	return NewPdfNamesFromObject(obj)
+}
+
+// GetNamedDestinations returns the named destinations of the document, from both the Dests name
+// tree of the name dictionary and the Dests dictionary of the catalog (PDF 1.1).  Invalid
+// destinations are ignored.
+func (this *PdfReader) GetNamedDestinations() (map[string]*PdfDestination, error) {
+	dests := map[string]*PdfDestination{}
+
+	obj, err := this.loadCatalogEntry("Dests")
+	if err != nil {
+		return nil, err
+	}
+	if dict, ok := TraceToDirectObject(obj).(*PdfObjectDictionary); ok {
+		for _, key := range dict.Keys() {
+			dest, err := NewPdfDestinationFromObject(dict.Get(key))
+			if err != nil {
+				common.Log.Debug("Invalid destination %s (%v) - ignoring", key, err)
+				continue
+			}
+			dests[string(key)] = dest
+		}
+	}
+
+	names, err := this.GetNames()
+	if err != nil {
+		return nil, err
+	}
+	if names != nil && names.GetTree("Dests") != nil {
+		for _, key := range names.GetTree("Dests").Keys() {
+			if dest, ok := names.GetDestination(key); ok {
+				dests[key] = dest
+			}
+		}
+	}
+	return dests, nil
+}
+
+// GetViewerPreferences returns the viewer preferences of the document.  Returns nil if the
+// document has none.
+func (this *PdfReader) GetViewerPreferences() (*PdfViewerPreferences, error) {
+	obj, err := this.loadCatalogEntry("ViewerPreferences")
+	if obj == nil || err != nil {
+		return nil, err
+	}
+	return NewPdfViewerPreferencesFromObject(obj)
+}
+
+// GetPageMode returns the page mode of the document (UseNone if not specified).
+func (this *PdfReader) GetPageMode() (PdfPageMode, error) {
+	obj, err := this.loadCatalogEntry("PageMode")
+	if err != nil {
+		return "", err
+	}
+	if mode, ok := GetNameVal(obj); ok {
+		return PdfPageMode(mode), nil
+	}
+	return PageModeUseNone, nil
+}
+
+// GetPageLayout returns the page layout of the document (SinglePage if not specified).
+func (this *PdfReader) GetPageLayout() (PdfPageLayout, error) {
+	obj, err := this.loadCatalogEntry("PageLayout")
+	if err != nil {
+		return "", err
+	}
+	if layout, ok := GetNameVal(obj); ok {
+		return PdfPageLayout(layout), nil
+	}
+	return PageLayoutSinglePage, nil
+}
+
+// GetOpenAction returns the destination or action of the document catalog OpenAction entry.
+// Returns nil if the document has none.
+func (this *PdfReader) GetOpenAction() (*PdfOpenAction, error) {
+	obj, err := this.loadCatalogEntry("OpenAction")
+	if obj == nil || err != nil {
+		return nil, err
+	}
+	return NewPdfOpenActionFromObject(obj)
+}
+
+// GetEmbeddedFiles returns the file specifications of the EmbeddedFiles name tree, keyed by name.
+func (this *PdfReader) GetEmbeddedFiles() (map[string]*PdfFileSpec, error) {
+	names, err := this.GetNames()
+	if err != nil {
+		return nil, err
+	}
+	if names == nil {
+		return map[string]*PdfFileSpec{}, nil
+	}
+	return names.GetEmbeddedFiles(), nil
+}
+
+// GetAttachments returns the files attached to the document: the files of the EmbeddedFiles name
+// tree in name order followed by the files of file attachment annotations in page order.  Invalid
+// file specifications are ignored.
+func (this *PdfReader) GetAttachments() ([]*PdfAttachment, error) {
+	files, err := this.GetEmbeddedFiles()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attachments := []*PdfAttachment{}
+	for _, key := range keys {
+		attachments = append(attachments, &PdfAttachment{Name: key, FileSpec: files[key]})
+	}
+
+	for i, page := range this.PageList {
+		for _, annot := range page.Annotations {
+			fileAnnot, ok := annot.GetContext().(*PdfAnnotationFileAttachment)
+			if !ok || fileAnnot.FS == nil {
+				continue
+			}
+			obj, err := this.traceToObject(fileAnnot.FS)
+			if err != nil {
+				return nil, err
+			}
+			err = this.traverseObjectData(obj)
+			if err != nil {
+				return nil, err
+			}
+			spec, err := NewPdfFileSpecFromObject(obj)
+			if err != nil {
+				common.Log.Debug("Invalid file attachment on page %d (%v) - ignoring", i+1, err)
+				continue
+			}
+			attachments = append(attachments, &PdfAttachment{
+				Name:       spec.FileName,
+				PageNumber: i + 1,
+				Annotation: fileAnnot,
+				FileSpec:   spec,
+			})
+		}
+	}
+	return attachments, nil
+}
+
+// GetCollection returns the collection dictionary of a portfolio document.  Returns nil if the
+// document is not a portfolio.
+func (this *PdfReader) GetCollection() (*PdfCollection, error) {
+	obj, err := this.loadCatalogEntry("Collection")
+	if obj == nil || err != nil {
+		return nil, err
+	}
+	return NewPdfCollectionFromObject(obj)
+}
+
+// GetAssociatedFiles returns the associated files of the document (AF entry of the catalog).
+func (this *PdfReader) GetAssociatedFiles() ([]*PdfFileSpec, error) {
+	obj, err := this.loadCatalogEntry("AF")
+	if obj == nil || err != nil {
+		return nil, err
+	}
+	arr, ok := GetArray(obj)
+	if !ok {
+		return nil, ErrTypeError
+	}
+	files := []*PdfFileSpec{}
+	for _, elem := range arr.Elements() {
+		spec, err := NewPdfFileSpecFromObject(elem)
+		if err != nil {
+			common.Log.Debug("Invalid associated file (%v) - ignoring", err)
+			continue
+		}
+		files = append(files, spec)
+	}
+	return files, nil
+}
+
+// GetStructTreeRoot returns the structure tree root of a tagged document.  Returns nil if the
+// document has no logical structure.
+func (this *PdfReader) GetStructTreeRoot() (*PdfStructTreeRoot, error) {
+	obj, err := this.loadCatalogEntry("StructTreeRoot")
+	if obj == nil || err != nil {
+		return nil, err
+	}
+	return NewPdfStructTreeRootFromObject(obj)
+}
+
+// IsTagged returns true if the document is marked as a tagged document (Marked entry of the
+// MarkInfo dictionary of the catalog).
+func (this *PdfReader) IsTagged() (bool, error) {
+	obj, err := this.loadCatalogEntry("MarkInfo")
+	if obj == nil || err != nil {
+		return false, err
+	}
+	markInfo, ok := GetDict(obj)
+	if !ok {
+		return false, nil
+	}
+	marked, _ := GetBoolVal(markInfo.Get("Marked"))
+	return marked, nil
+}
+
+// GetLanguage returns the natural language of the document (Lang entry of the catalog), or an
+// empty string if not specified.
+func (this *PdfReader) GetLanguage() (string, error) {
+	obj, err := this.loadCatalogEntry("Lang")
+	if err != nil {
+		return "", err
+	}
+	if str, ok := GetString(obj); ok {
+		return str.Decoded(), nil
+	}
+	return "", nil
+}
+
+// GetOutputIntents returns the output intents of the document.
+func (this *PdfReader) GetOutputIntents() ([]*PdfOutputIntent, error) {
+	obj, err := this.loadCatalogEntry("OutputIntents")
+	if obj == nil || err != nil {
+		return nil, err
+	}
+	arr, ok := GetArray(obj)
+	if !ok {
+		return nil, ErrTypeError
+	}
+	intents := []*PdfOutputIntent{}
+	for _, elem := range arr.Elements() {
+		oi, err := NewPdfOutputIntentFromObject(elem)
+		if err != nil {
+			common.Log.Debug("Invalid output intent (%v) - ignoring", err)
+			continue
+		}
+		intents = append(intents, oi)
+	}
+	return intents, nil
+}
+
 // Inspect the object types, subtypes and content in the PDF file.
 func (this *PdfReader) Inspect() (map[string]int, error) {
 	return this.parser.Inspect()
@@ -408,3 +408,44 @@ func (r *PdfPageResources) SetXObjectFormByName(keyName PdfObjectName, xform *XO
 	err := r.SetXObjectByName(keyName, stream)
 	return err
 }
+
+// GetPropertiesByName returns the property list specified by keyName, e.g. an optional content
+// group referred to by marked-content operators.  The bool flag indicates whether it was found.
+func (r *PdfPageResources) GetPropertiesByName(keyName PdfObjectName) (PdfObject, bool) {
+	if r.Properties == nil {
+		return nil, false
+	}
+
+	dict, ok := TraceToDirectObject(r.Properties).(*PdfObjectDictionary)
+	if !ok {
+		common.Log.Debug("ERROR: Invalid Properties entry - not a dict (got %T)", r.Properties)
+		return nil, false
+	}
+
+	if obj := dict.Get(keyName); obj != nil {
+		return obj, true
+	}
+	return nil, false
+}
+
+// HasPropertiesByName checks whether a property list is defined by the specified keyName.
+func (r *PdfPageResources) HasPropertiesByName(keyName PdfObjectName) bool {
+	_, has := r.GetPropertiesByName(keyName)
+	return has
+}
+
+// SetPropertiesByName sets the property list `obj` with the specified keyName.
+func (r *PdfPageResources) SetPropertiesByName(keyName PdfObjectName, obj PdfObject) error {
+	if r.Properties == nil {
+		r.Properties = MakeDict()
+	}
+
+	dict, ok := TraceToDirectObject(r.Properties).(*PdfObjectDictionary)
+	if !ok {
+		common.Log.Debug("ERROR: Invalid Properties entry - not a dict (got %T)", r.Properties)
+		return ErrTypeError
+	}
+
+	dict.Set(keyName, obj)
+	return nil
+}
@@ -0,0 +1,425 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"sort"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfStructTreeRoot represents the structure tree root of a tagged document (14.7.2 Structure
+// Hierarchy p. 577).
+type PdfStructTreeRoot struct {
+	Kids     []*PdfStructElement
+	RoleMap  map[string]string // Custom structure types mapped to standard structure types.
+	ClassMap PdfObject         // Attribute classes, nil if none.
+}
+
+// PdfStructElement represents a structure element (14.7.2 Structure Hierarchy p. 577).  Empty
+// strings represent entries that are not set.
+type PdfStructElement struct {
+	Type       string             // Structure type (S), e.g. P, H1 or Table.
+	Parent     *PdfStructElement  // Nil for the kids of the structure tree root.
+	Page       *PdfIndirectObject // Page of the content items of the element (Pg), nil if none.
+	ID         string
+	Title      string // T.
+	Lang       string
+	Alt        string
+	ActualText string
+	Expansion  string    // E.
+	Attributes PdfObject // Attribute objects (A), nil if none.
+	Classes    PdfObject // Attribute classes (C), nil if none.
+	Kids       []*PdfStructKid
+
+	container *PdfIndirectObject
+}
+
+// PdfStructKid is a kid of a structure element: another structure element, a marked-content
+// sequence (14.7.4.2 Marked-Content Sequences as Content Items p. 582) or an object such as an
+// annotation (14.7.4.3 PDF Objects as Content Items p. 584).
+type PdfStructKid struct {
+	Element *PdfStructElement  // Child structure element, nil for content items.
+	MCID    int                // Marked-content identifier, -1 if not a marked-content sequence.
+	Page    *PdfIndirectObject // Page of the content item, nil for the page of the parent element.
+	Stream  PdfObject          // Content stream (Stm) of the marked content, nil for page contents.
+	Object  PdfObject          // Referenced object (Obj), nil if not an object reference.
+}
+
+// NewPdfStructTreeRoot returns an empty structure tree root.
+func NewPdfStructTreeRoot() *PdfStructTreeRoot {
+	return &PdfStructTreeRoot{RoleMap: map[string]string{}}
+}
+
+// NewPdfStructElement returns a structure element of type `structType`.
+func NewPdfStructElement(structType string) *PdfStructElement {
+	return &PdfStructElement{Type: structType, container: MakeIndirectObject(MakeDict())}
+}
+
+// NewPdfStructTreeRootFromObject loads the structure tree root `obj`.  Invalid kids are ignored.
+func NewPdfStructTreeRootFromObject(obj PdfObject) (*PdfStructTreeRoot, error) {
+	dict, ok := GetDict(obj)
+	if !ok {
+		return nil, ErrTypeError
+	}
+
+	root := NewPdfStructTreeRoot()
+	if roleMap, ok := GetDict(dict.Get("RoleMap")); ok {
+		for _, key := range roleMap.Keys() {
+			if role, ok := GetNameVal(roleMap.Get(key)); ok {
+				root.RoleMap[string(key)] = role
+			}
+		}
+	}
+	root.ClassMap = dict.Get("ClassMap")
+
+	visited := map[PdfObject]bool{obj: true}
+	for _, kid := range loadStructKids(dict.Get("K"), nil, visited) {
+		if kid.Element != nil {
+			root.Kids = append(root.Kids, kid.Element)
+		}
+	}
+	return root, nil
+}
+
+// loadStructKids loads the kids `obj` of the structure element `parent` (nil for the structure
+// tree root).  `visited` holds the loaded elements to prevent loops.
+func loadStructKids(obj PdfObject, parent *PdfStructElement, visited map[PdfObject]bool) []*PdfStructKid {
+	if arr, ok := GetArray(obj); ok {
+		kids := []*PdfStructKid{}
+		for _, elem := range arr.Elements() {
+			kids = append(kids, loadStructKids(elem, parent, visited)...)
+		}
+		return kids
+	}
+
+	if mcid, ok := GetIntVal(obj); ok {
+		return []*PdfStructKid{{MCID: mcid}}
+	}
+
+	dict, ok := GetDict(obj)
+	if !ok {
+		return nil
+	}
+	page, _ := GetIndirect(dict.Get("Pg"))
+
+	switch typ, _ := GetNameVal(dict.Get("Type")); typ {
+	case "MCR":
+		mcid, ok := GetIntVal(dict.Get("MCID"))
+		if !ok {
+			common.Log.Debug("Marked-content reference without MCID - ignoring")
+			return nil
+		}
+		return []*PdfStructKid{{MCID: mcid, Page: page, Stream: dict.Get("Stm")}}
+	case "OBJR":
+		return []*PdfStructKid{{MCID: -1, Page: page, Object: dict.Get("Obj")}}
+	}
+
+	if visited[obj] {
+		common.Log.Debug("Structure element loop - ignoring")
+		return nil
+	}
+	visited[obj] = true
+
+	elem := &PdfStructElement{Parent: parent, Page: page}
+	if ind, ok := obj.(*PdfIndirectObject); ok {
+		elem.container = ind
+	} else {
+		elem.container = MakeIndirectObject(dict)
+	}
+	elem.Type, _ = GetNameVal(dict.Get("S"))
+	for _, f := range []struct {
+		key   PdfObjectName
+		field *string
+	}{
+		{"ID", &elem.ID}, {"T", &elem.Title}, {"Lang", &elem.Lang}, {"Alt", &elem.Alt},
+		{"ActualText", &elem.ActualText}, {"E", &elem.Expansion},
+	} {
+		if str, ok := GetString(dict.Get(f.key)); ok {
+			*f.field = str.Decoded()
+		}
+	}
+	elem.Attributes = dict.Get("A")
+	elem.Classes = dict.Get("C")
+	elem.Kids = loadStructKids(dict.Get("K"), elem, visited)
+	return []*PdfStructKid{{Element: elem, MCID: -1}}
+}
+
+// GetContainingPdfObject returns the indirect object holding the structure element dictionary.
+func (elem *PdfStructElement) GetContainingPdfObject() PdfObject {
+	return elem.container
+}
+
+// AddKid appends the structure element `elem` to the kids of the structure tree root.
+func (root *PdfStructTreeRoot) AddKid(elem *PdfStructElement) {
+	elem.Parent = nil
+	root.Kids = append(root.Kids, elem)
+}
+
+// ResolveRole returns the standard structure type of `structType` by following the role map.
+func (root *PdfStructTreeRoot) ResolveRole(structType string) string {
+	visited := map[string]bool{}
+	for !visited[structType] {
+		visited[structType] = true
+		role, has := root.RoleMap[structType]
+		if !has {
+			break
+		}
+		structType = role
+	}
+	return structType
+}
+
+// Walk calls `fn` for each structure element of the tree in depth first order, with the depth of
+// the element (0 for the kids of the root).  Stops and returns the error if `fn` returns an error.
+func (root *PdfStructTreeRoot) Walk(fn func(elem *PdfStructElement, depth int) error) error {
+	var walk func(elems []*PdfStructElement, depth int) error
+	walk = func(elems []*PdfStructElement, depth int) error {
+		for _, elem := range elems {
+			if err := fn(elem, depth); err != nil {
+				return err
+			}
+			if err := walk(elem.ChildElements(), depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(root.Kids, 0)
+}
+
+// GetMarkedContentElements returns the structure elements of the marked-content sequences of the
+// page or form XObject `container`, keyed by marked-content identifier.
+func (root *PdfStructTreeRoot) GetMarkedContentElements(container PdfObject) map[int]*PdfStructElement {
+	elems := map[int]*PdfStructElement{}
+	root.Walk(func(elem *PdfStructElement, depth int) error {
+		for _, kid := range elem.Kids {
+			if kid.MCID >= 0 && elem.kidContainer(kid) == container {
+				elems[kid.MCID] = elem
+			}
+		}
+		return nil
+	})
+	return elems
+}
+
+// ChildElements returns the kids of the element that are structure elements.
+func (elem *PdfStructElement) ChildElements() []*PdfStructElement {
+	elems := []*PdfStructElement{}
+	for _, kid := range elem.Kids {
+		if kid.Element != nil {
+			elems = append(elems, kid.Element)
+		}
+	}
+	return elems
+}
+
+// AddKid appends the structure element `child` to the kids of the element.
+func (elem *PdfStructElement) AddKid(child *PdfStructElement) {
+	child.Parent = elem
+	elem.Kids = append(elem.Kids, &PdfStructKid{Element: child, MCID: -1})
+}
+
+// AddMarkedContent appends the marked-content sequence with identifier `mcid` of the contents of
+// `page` to the kids of the element.
+func (elem *PdfStructElement) AddMarkedContent(page *PdfIndirectObject, mcid int) {
+	if elem.Page == nil {
+		elem.Page = page
+	}
+	kid := &PdfStructKid{MCID: mcid}
+	if page != elem.Page {
+		kid.Page = page
+	}
+	elem.Kids = append(elem.Kids, kid)
+}
+
+// AddObject appends the object `obj` on `page`, e.g. an annotation, to the kids of the element.
+func (elem *PdfStructElement) AddObject(page *PdfIndirectObject, obj PdfObject) {
+	elem.Kids = append(elem.Kids, &PdfStructKid{MCID: -1, Page: page, Object: obj})
+}
+
+// kidContainer returns the page or content stream that contains the marked-content sequence or
+// object `kid`.
+func (elem *PdfStructElement) kidContainer(kid *PdfStructKid) PdfObject {
+	if kid.Stream != nil {
+		return kid.Stream
+	}
+	if kid.Page != nil {
+		return kid.Page
+	}
+	if elem.Page != nil {
+		return elem.Page
+	}
+	return nil
+}
+
+// toPdfObject returns the structure element with `parent` as the parent entry.
+func (elem *PdfStructElement) toPdfObject(parent PdfObject) *PdfIndirectObject {
+	if elem.container == nil {
+		elem.container = MakeIndirectObject(MakeDict())
+	}
+	dict := MakeDict()
+	dict.Set("Type", MakeName("StructElem"))
+	dict.Set("S", MakeName(elem.Type))
+	dict.Set("P", parent)
+	if elem.Page != nil {
+		dict.Set("Pg", elem.Page)
+	}
+	for _, f := range []struct {
+		key PdfObjectName
+		val string
+	}{
+		{"ID", elem.ID}, {"T", elem.Title}, {"Lang", elem.Lang}, {"Alt", elem.Alt},
+		{"ActualText", elem.ActualText}, {"E", elem.Expansion},
+	} {
+		if len(f.val) > 0 {
+			dict.Set(f.key, MakeEncodedString(f.val))
+		}
+	}
+	dict.SetIfNotNil("A", elem.Attributes)
+	dict.SetIfNotNil("C", elem.Classes)
+
+	kids := MakeArray()
+	for _, kid := range elem.Kids {
+		switch {
+		case kid.Element != nil:
+			kids.Append(kid.Element.toPdfObject(elem.container))
+		case kid.Object != nil:
+			objr := MakeDict()
+			objr.Set("Type", MakeName("OBJR"))
+			if kid.Page != nil {
+				objr.Set("Pg", kid.Page)
+			}
+			objr.Set("Obj", kid.Object)
+			kids.Append(objr)
+		case kid.Page == nil && kid.Stream == nil:
+			kids.Append(MakeInteger(int64(kid.MCID)))
+		default:
+			mcr := MakeDict()
+			mcr.Set("Type", MakeName("MCR"))
+			if kid.Page != nil {
+				mcr.Set("Pg", kid.Page)
+			}
+			if kid.Stream != nil {
+				mcr.Set("Stm", kid.Stream)
+			}
+			mcr.Set("MCID", MakeInteger(int64(kid.MCID)))
+			kids.Append(mcr)
+		}
+	}
+	if len(kids.Elements()) == 1 {
+		dict.Set("K", kids.Get(0))
+	} else if len(kids.Elements()) > 1 {
+		dict.Set("K", kids)
+	}
+
+	elem.container.PdfObject = dict
+	return elem.container
+}
+
+// ToPdfObject returns the structure element as an indirect object.
+func (elem *PdfStructElement) ToPdfObject() PdfObject {
+	var parent PdfObject = MakeNull()
+	if elem.Parent != nil && elem.Parent.container != nil {
+		parent = elem.Parent.container
+	}
+	return elem.toPdfObject(parent)
+}
+
+// ToPdfObject returns the structure tree root as an indirect object.  The parent tree is built
+// from the content items of the structure elements, setting the StructParents entries of the pages
+// and form XObjects and the StructParent entries of the referenced objects.
+func (root *PdfStructTreeRoot) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	container := MakeIndirectObject(dict)
+	dict.Set("Type", MakeName("StructTreeRoot"))
+
+	kids := MakeArray()
+	for _, elem := range root.Kids {
+		kids.Append(elem.toPdfObject(container))
+	}
+	dict.Set("K", kids)
+
+	parentTree, nextKey := root.buildParentTree()
+	dict.Set("ParentTree", parentTree.ToPdfObject())
+	dict.Set("ParentTreeNextKey", MakeInteger(nextKey))
+
+	if len(root.RoleMap) > 0 {
+		keys := []string{}
+		for key := range root.RoleMap {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		roleMap := MakeDict()
+		for _, key := range keys {
+			roleMap.Set(PdfObjectName(key), MakeName(root.RoleMap[key]))
+		}
+		dict.Set("RoleMap", roleMap)
+	}
+	dict.SetIfNotNil("ClassMap", root.ClassMap)
+	return container
+}
+
+// buildParentTree returns the parent tree of the structure tree and the next unused key.  The keys
+// are assigned in the order of the structure elements and set in the dictionaries of the content
+// item containers.
+func (root *PdfStructTreeRoot) buildParentTree() (*PdfNumberTree, int64) {
+	tree := NewPdfNumberTree()
+	keys := map[PdfObject]int64{}
+	mcids := map[PdfObject][]PdfObject{}
+	containers := []PdfObject{}
+	nextKey := int64(0)
+
+	root.Walk(func(elem *PdfStructElement, depth int) error {
+		for _, kid := range elem.Kids {
+			switch {
+			case kid.Element != nil:
+			case kid.Object != nil:
+				dict, ok := GetDict(kid.Object)
+				if !ok {
+					continue
+				}
+				dict.Set("StructParent", MakeInteger(nextKey))
+				tree.Set(nextKey, elem.container)
+				nextKey++
+			default:
+				container := elem.kidContainer(kid)
+				if container == nil {
+					common.Log.Debug("Marked content %d without page - ignoring", kid.MCID)
+					continue
+				}
+				if _, has := keys[container]; !has {
+					keys[container] = nextKey
+					nextKey++
+					containers = append(containers, container)
+				}
+				arr := mcids[container]
+				for len(arr) <= kid.MCID {
+					arr = append(arr, MakeNull())
+				}
+				arr[kid.MCID] = elem.container
+				mcids[container] = arr
+			}
+		}
+		return nil
+	})
+
+	for _, container := range containers {
+		var dict *PdfObjectDictionary
+		if stream, ok := GetStream(container); ok {
+			dict = stream.PdfObjectDictionary
+		} else {
+			dict, _ = GetDict(container)
+		}
+		if dict == nil {
+			continue
+		}
+		dict.Set("StructParents", MakeInteger(keys[container]))
+		tree.Set(keys[container], MakeArray(mcids[container]...))
+	}
+	return tree, nextKey
+}
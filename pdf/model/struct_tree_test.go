@@ -0,0 +1,90 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// Test writing and reading a structure tree with marked content and an annotation.
+func TestStructTreeRoundTrip(t *testing.T) {
+	newPage := func() *PdfPage {
+		page := NewPdfPage()
+		page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 612, Ury: 792}
+		page.Resources = NewPdfPageResources()
+		return page
+	}
+	page1, page2 := newPage(), newPage()
+	link := NewPdfAnnotationLink()
+	link.Rect = MakeArrayFromFloats([]float64{10, 10, 50, 20})
+	page2.Annotations = []*PdfAnnotation{link.PdfAnnotation}
+
+	root := NewPdfStructTreeRoot()
+	root.RoleMap["Para"] = "P"
+	doc := NewPdfStructElement("Document")
+	root.AddKid(doc)
+	para := NewPdfStructElement("Para")
+	para.ActualText = "Text"
+	doc.AddKid(para)
+	para.AddMarkedContent(page1.GetPageAsIndirectObject(), 0)
+	para.AddMarkedContent(page2.GetPageAsIndirectObject(), 1)
+	ref := NewPdfStructElement("Link")
+	doc.AddKid(ref)
+	ref.AddMarkedContent(page2.GetPageAsIndirectObject(), 0)
+
+	writer := NewPdfWriter()
+	for _, page := range []*PdfPage{page1, page2} {
+		if err := writer.AddPage(page); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+	ref.AddObject(page2.GetPageAsIndirectObject(), link.GetContainingPdfObject())
+	writer.SetStructTreeRoot(root)
+
+	var buf bytes.Buffer
+	if err := writer.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	reader, err := NewPdfReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	root, err = reader.GetStructTreeRoot()
+	if err != nil || root == nil {
+		t.Fatalf("Structure tree not read: %v", err)
+	}
+
+	if len(root.Kids) != 1 || len(root.Kids[0].ChildElements()) != 2 {
+		t.Fatalf("Invalid structure tree")
+	}
+	para = root.Kids[0].ChildElements()[0]
+	ref = root.Kids[0].ChildElements()[1]
+	if para.Parent != root.Kids[0] || root.ResolveRole(para.Type) != "P" || para.ActualText != "Text" {
+		t.Errorf("Invalid paragraph element %+v", para)
+	}
+	if len(ref.Kids) != 2 || ref.Kids[1].Object == nil {
+		t.Fatalf("Invalid link element %+v", ref)
+	}
+
+	page2 = reader.PageList[1]
+	elems := root.GetMarkedContentElements(page2.GetPageAsIndirectObject())
+	if len(elems) != 2 || elems[0] != ref || elems[1] != para {
+		t.Errorf("Invalid marked content elements of page 2: %v", elems)
+	}
+	if key, _ := GetIntVal(page2.StructParents); key != 1 {
+		t.Errorf("Page 2 StructParents %v", page2.StructParents)
+	}
+	annot, ok := GetDict(ref.Kids[1].Object)
+	if !ok {
+		t.Fatalf("Invalid annotation %v", ref.Kids[1].Object)
+	}
+	if key, _ := GetIntVal(annot.Get("StructParent")); key != 2 {
+		t.Errorf("Annotation StructParent %v", annot.Get("StructParent"))
+	}
+}
@@ -11,8 +11,10 @@ package model
 import (
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
 	"strconv"
+	"time"
 
 	. "github.com/unidoc/unidoc/pdf/core"
 )
@@ -64,6 +66,42 @@ func (rect *PdfRectangle) ToPdfObject() PdfObject {
 	return arr
 }
 
+// normalized returns the rectangle with the lower left and upper right corners ordered.
+func (rect PdfRectangle) normalized() PdfRectangle {
+	return PdfRectangle{
+		Llx: math.Min(rect.Llx, rect.Urx),
+		Lly: math.Min(rect.Lly, rect.Ury),
+		Urx: math.Max(rect.Llx, rect.Urx),
+		Ury: math.Max(rect.Lly, rect.Ury),
+	}
+}
+
+// clipTo returns the intersection of the rectangle with `clip`.  Returns an empty rectangle at the
+// corner of `clip` nearest to the rectangle if they do not intersect.
+func (rect PdfRectangle) clipTo(clip PdfRectangle) PdfRectangle {
+	rect = rect.normalized()
+	clip = clip.normalized()
+	clamp := func(v, min, max float64) float64 {
+		return math.Max(min, math.Min(v, max))
+	}
+	return PdfRectangle{
+		Llx: clamp(rect.Llx, clip.Llx, clip.Urx),
+		Lly: clamp(rect.Lly, clip.Lly, clip.Ury),
+		Urx: clamp(rect.Urx, clip.Llx, clip.Urx),
+		Ury: clamp(rect.Ury, clip.Lly, clip.Ury),
+	}
+}
+
+// Width returns the width of the rectangle.
+func (rect PdfRectangle) Width() float64 {
+	return math.Abs(rect.Urx - rect.Llx)
+}
+
+// Height returns the height of the rectangle.
+func (rect PdfRectangle) Height() float64 {
+	return math.Abs(rect.Ury - rect.Lly)
+}
+
 // PdfDate represents a date, which is a PDF string of the form:
 // (D:YYYYMMDDHHmmSSOHH'mm)
 type PdfDate struct {
@@ -127,3 +165,39 @@ func (date *PdfDate) ToPdfObject() PdfObject {
 		date.utOffsetSign, date.utOffsetHours, date.utOffsetMins)
 	return MakeString(str)
 }
+
+// NewPdfDateFromTime returns a new PdfDate object representing the time `t` in its location.
+func NewPdfDateFromTime(t time.Time) PdfDate {
+	d := PdfDate{
+		year:   int64(t.Year()),
+		month:  int64(t.Month()),
+		day:    int64(t.Day()),
+		hour:   int64(t.Hour()),
+		minute: int64(t.Minute()),
+		second: int64(t.Second()),
+	}
+
+	_, offset := t.Zone()
+	d.utOffsetSign = '+'
+	if offset < 0 {
+		d.utOffsetSign = '-'
+		offset = -offset
+	}
+	d.utOffsetHours = int64(offset / 3600)
+	d.utOffsetMins = int64(offset % 3600 / 60)
+	return d
+}
+
+// ToGoTime returns the date as a time.Time.
+func (date *PdfDate) ToGoTime() time.Time {
+	offset := int(date.utOffsetHours*3600 + date.utOffsetMins*60)
+	if date.utOffsetSign == '-' {
+		offset = -offset
+	}
+	loc := time.FixedZone("", offset)
+	if offset == 0 {
+		loc = time.UTC
+	}
+	return time.Date(int(date.year), time.Month(date.month), int(date.day),
+		int(date.hour), int(date.minute), int(date.second), 0, loc)
+}
@@ -0,0 +1,248 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// Maximum number of entries or kids of a tree node written by ToPdfObject.
+const treeNodeSize = 64
+
+// PdfNameTree represents a name tree (7.9.6 Name Trees p. 88), mapping byte string keys to values.
+// The tree structure is flattened when loading and rebuilt when converting to a PDF object.
+type PdfNameTree struct {
+	entries map[string]PdfObject
+}
+
+// NewPdfNameTree returns an empty name tree.
+func NewPdfNameTree() *PdfNameTree {
+	return &PdfNameTree{entries: map[string]PdfObject{}}
+}
+
+// NewPdfNameTreeFromObject loads the name tree with root node `obj`.
+func NewPdfNameTreeFromObject(obj PdfObject) (*PdfNameTree, error) {
+	tree := NewPdfNameTree()
+	err := loadTreeNode(obj, "Names", map[PdfObject]bool{}, func(key, val PdfObject) error {
+		str, ok := TraceToDirectObject(key).(*PdfObjectString)
+		if !ok {
+			return errors.New("Name tree key not a string")
+		}
+		tree.entries[str.Str()] = val
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// Set sets the value of `key` to `val`.
+func (t *PdfNameTree) Set(key string, val PdfObject) {
+	t.entries[key] = val
+}
+
+// Get returns the value of `key`.
+func (t *PdfNameTree) Get(key string) (PdfObject, bool) {
+	val, has := t.entries[key]
+	return val, has
+}
+
+// Remove removes `key` from the tree.
+func (t *PdfNameTree) Remove(key string) {
+	delete(t.entries, key)
+}
+
+// Len returns the number of entries in the tree.
+func (t *PdfNameTree) Len() int {
+	return len(t.entries)
+}
+
+// Keys returns the keys of the tree in sorted order.
+func (t *PdfNameTree) Keys() []string {
+	keys := make([]string, 0, len(t.entries))
+	for key := range t.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ToPdfObject returns the root node of the name tree as an indirect object.
+func (t *PdfNameTree) ToPdfObject() PdfObject {
+	keys := t.Keys()
+	pairs := make([]PdfObject, 0, 2*len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, MakeString(key), t.entries[key])
+	}
+	return buildTree(pairs, "Names")
+}
+
+// PdfNumberTree represents a number tree (7.9.7 Number Trees p. 91), mapping integer keys to values.
+// The tree structure is flattened when loading and rebuilt when converting to a PDF object.
+type PdfNumberTree struct {
+	entries map[int64]PdfObject
+}
+
+// NewPdfNumberTree returns an empty number tree.
+func NewPdfNumberTree() *PdfNumberTree {
+	return &PdfNumberTree{entries: map[int64]PdfObject{}}
+}
+
+// NewPdfNumberTreeFromObject loads the number tree with root node `obj`.
+func NewPdfNumberTreeFromObject(obj PdfObject) (*PdfNumberTree, error) {
+	tree := NewPdfNumberTree()
+	err := loadTreeNode(obj, "Nums", map[PdfObject]bool{}, func(key, val PdfObject) error {
+		num, ok := TraceToDirectObject(key).(*PdfObjectInteger)
+		if !ok {
+			return errors.New("Number tree key not an integer")
+		}
+		tree.entries[int64(*num)] = val
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// Set sets the value of `key` to `val`.
+func (t *PdfNumberTree) Set(key int64, val PdfObject) {
+	t.entries[key] = val
+}
+
+// Get returns the value of `key`.
+func (t *PdfNumberTree) Get(key int64) (PdfObject, bool) {
+	val, has := t.entries[key]
+	return val, has
+}
+
+// Remove removes `key` from the tree.
+func (t *PdfNumberTree) Remove(key int64) {
+	delete(t.entries, key)
+}
+
+// Len returns the number of entries in the tree.
+func (t *PdfNumberTree) Len() int {
+	return len(t.entries)
+}
+
+// Keys returns the keys of the tree in increasing order.
+func (t *PdfNumberTree) Keys() []int64 {
+	keys := make([]int64, 0, len(t.entries))
+	for key := range t.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// ToPdfObject returns the root node of the number tree as an indirect object.
+func (t *PdfNumberTree) ToPdfObject() PdfObject {
+	keys := t.Keys()
+	pairs := make([]PdfObject, 0, 2*len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, MakeInteger(key), t.entries[key])
+	}
+	return buildTree(pairs, "Nums")
+}
+
+// loadTreeNode loads the entries of the tree node `obj` and its descendants, where `arrayKey` is
+// the key of the key/value arrays of the leaf nodes (Names or Nums).  Calls `add` for each entry.
+func loadTreeNode(obj PdfObject, arrayKey PdfObjectName, visited map[PdfObject]bool, add func(key, val PdfObject) error) error {
+	if visited[obj] {
+		common.Log.Debug("ERROR: Tree node loop - skipping")
+		return nil
+	}
+	visited[obj] = true
+
+	dict, ok := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !ok {
+		return errors.New("Tree node not a dictionary")
+	}
+
+	if arr, ok := TraceToDirectObject(dict.Get(arrayKey)).(*PdfObjectArray); ok {
+		elements := arr.Elements()
+		if len(elements)%2 != 0 {
+			common.Log.Debug("ERROR: Odd number of elements in tree node %s array - ignoring last", arrayKey)
+		}
+		for i := 0; i+1 < len(elements); i += 2 {
+			err := add(elements[i], elements[i+1])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if kids, ok := TraceToDirectObject(dict.Get("Kids")).(*PdfObjectArray); ok {
+		for _, kid := range kids.Elements() {
+			err := loadTreeNode(kid, arrayKey, visited, add)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// buildTree returns the root node of a tree with the sorted key/value `pairs`, where `arrayKey`
+// is the key of the key/value arrays of the leaf nodes.  Trees with more than treeNodeSize entries
+// are split into leaf nodes with Limits under intermediate nodes.
+func buildTree(pairs []PdfObject, arrayKey PdfObjectName) PdfObject {
+	if len(pairs) <= 2*treeNodeSize {
+		dict := MakeDict()
+		dict.Set(arrayKey, MakeArray(pairs...))
+		return MakeIndirectObject(dict)
+	}
+
+	type treeNode struct {
+		obj          *PdfIndirectObject
+		lower, upper PdfObject
+	}
+
+	nodes := []treeNode{}
+	for i := 0; i < len(pairs); i += 2 * treeNodeSize {
+		end := i + 2*treeNodeSize
+		if end > len(pairs) {
+			end = len(pairs)
+		}
+		dict := MakeDict()
+		dict.Set("Limits", MakeArray(pairs[i], pairs[end-2]))
+		dict.Set(arrayKey, MakeArray(pairs[i:end]...))
+		nodes = append(nodes, treeNode{obj: MakeIndirectObject(dict), lower: pairs[i], upper: pairs[end-2]})
+	}
+
+	for len(nodes) > treeNodeSize {
+		parents := []treeNode{}
+		for i := 0; i < len(nodes); i += treeNodeSize {
+			end := i + treeNodeSize
+			if end > len(nodes) {
+				end = len(nodes)
+			}
+			kids := MakeArray()
+			for _, node := range nodes[i:end] {
+				kids.Append(node.obj)
+			}
+			dict := MakeDict()
+			dict.Set("Limits", MakeArray(nodes[i].lower, nodes[end-1].upper))
+			dict.Set("Kids", kids)
+			parents = append(parents, treeNode{obj: MakeIndirectObject(dict), lower: nodes[i].lower, upper: nodes[end-1].upper})
+		}
+		nodes = parents
+	}
+
+	kids := MakeArray()
+	for _, node := range nodes {
+		kids.Append(node.obj)
+	}
+	dict := MakeDict()
+	dict.Set("Kids", kids)
+	return MakeIndirectObject(dict)
+}
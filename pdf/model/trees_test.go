@@ -0,0 +1,187 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// Test that large trees are split into nodes with limits and load back the same.
+func TestTreeRoundTrip(t *testing.T) {
+	names := NewPdfNameTree()
+	nums := NewPdfNumberTree()
+	for i := 0; i < 5000; i++ {
+		names.Set(fmt.Sprintf("dest%05d", i), MakeInteger(int64(i)))
+		nums.Set(int64(3*i), MakeInteger(int64(i)))
+	}
+
+	root, ok := TraceToDirectObject(names.ToPdfObject()).(*PdfObjectDictionary)
+	if !ok || root.Get("Kids") == nil || root.Get("Names") != nil || root.Get("Limits") != nil {
+		t.Fatalf("Invalid name tree root: %v", root)
+	}
+
+	names2, err := NewPdfNameTreeFromObject(root)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	nums2, err := NewPdfNumberTreeFromObject(nums.ToPdfObject())
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if names2.Len() != 5000 || nums2.Len() != 5000 {
+		t.Fatalf("Got %d names and %d numbers", names2.Len(), nums2.Len())
+	}
+	if val, _ := names2.Get("dest04321"); val.String() != "4321" {
+		t.Errorf("Wrong name tree value: %v", val)
+	}
+	if val, _ := nums2.Get(3 * 4321); val.String() != "4321" {
+		t.Errorf("Wrong number tree value: %v", val)
+	}
+}
+
+func TestPageLabelText(t *testing.T) {
+	labels := NewPdfPageLabels()
+	labels.SetRange(2, PdfPageLabel{Style: PageLabelStyleLowerRoman, Start: 1})
+	labels.SetRange(6, PdfPageLabel{Style: PageLabelStyleDecimal, Start: 1})
+	labels.SetRange(9, PdfPageLabel{Style: PageLabelStyleUpperAlpha, Prefix: "A-", Start: 26})
+	labels.SetRange(12, PdfPageLabel{Prefix: "Back"})
+
+	labels, err := NewPdfPageLabelsFromObject(labels.ToPdfObject())
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	expected := []string{"1", "2", "i", "ii", "iii", "iv", "1", "2", "3", "A-Z", "A-AA", "A-BB", "Back", "Back"}
+	for i, exp := range expected {
+		if label := labels.Label(i); label != exp {
+			t.Errorf("Page %d: got %q, expected %q", i, label, exp)
+		}
+	}
+}
+
+// Test writing the catalog navigation entries, reading them back and copying them through a
+// reader and writer.
+func TestNavigationRoundTrip(t *testing.T) {
+	newPage := func() *PdfPage {
+		page := NewPdfPage()
+		page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 612, Ury: 792}
+		page.Resources = NewPdfPageResources()
+		return page
+	}
+	page1, page2, unused := newPage(), newPage(), newPage()
+
+	writer := NewPdfWriter()
+	for _, page := range []*PdfPage{page1, page2} {
+		if err := writer.AddPage(page); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+	labels := NewPdfPageLabels()
+	labels.SetRange(0, PdfPageLabel{Style: PageLabelStyleUpperRoman, Start: 1})
+	writer.SetPageLabels(labels)
+	writer.AddNamedDestination("second", NewPdfDestinationXYZ(page2.GetPageAsIndirectObject(), 10, 700, 0))
+	writer.AddNamedDestination("unused", NewPdfDestinationFit(unused.GetPageAsIndirectObject()))
+	hide := true
+	writer.SetViewerPreferences(&PdfViewerPreferences{HideToolbar: &hide, Direction: "R2L", PrintPageRange: []int{1, 2}})
+	writer.SetPageMode(PageModeUseOutlines)
+	writer.SetPageLayout(PageLayoutTwoColumnLeft)
+	writer.SetOpenAction(&PdfOpenAction{Dest: NewPdfDestinationFit(page2.GetPageAsIndirectObject())})
+
+	var buf bytes.Buffer
+	if err := writer.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	check := func(data []byte) *PdfReader {
+		reader, err := NewPdfReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		page, err := reader.GetPage(2)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		labels, err := reader.GetPageLabels()
+		if err != nil || labels == nil || labels.Label(1) != "II" {
+			t.Errorf("Page labels not read: %v %v", labels, err)
+		}
+		dests, err := reader.GetNamedDestinations()
+		if err != nil || len(dests) != 1 || dests["second"] == nil {
+			t.Fatalf("Named destinations not read: %v %v", dests, err)
+		}
+		dest := dests["second"]
+		if dest.Page != page.GetPageAsIndirectObject() || dest.Fit != DestinationFitXYZ ||
+			dest.Left == nil || *dest.Left != 10 || dest.Zoom != nil {
+			t.Errorf("Invalid destination: %+v", dest)
+		}
+		prefs, err := reader.GetViewerPreferences()
+		if err != nil || prefs == nil || prefs.HideToolbar == nil || !*prefs.HideToolbar ||
+			prefs.HideMenubar != nil || prefs.Direction != "R2L" || len(prefs.PrintPageRange) != 2 {
+			t.Errorf("Viewer preferences not read: %+v %v", prefs, err)
+		}
+		if mode, _ := reader.GetPageMode(); mode != PageModeUseOutlines {
+			t.Errorf("Page mode: %s", mode)
+		}
+		if layout, _ := reader.GetPageLayout(); layout != PageLayoutTwoColumnLeft {
+			t.Errorf("Page layout: %s", layout)
+		}
+		action, err := reader.GetOpenAction()
+		if err != nil || action == nil || action.Dest == nil || action.Dest.Page != page.GetPageAsIndirectObject() {
+			t.Errorf("Open action not read: %+v %v", action, err)
+		}
+
+		// The page of the dropped destination should not be written.
+		numPageObjs := 0
+		for _, num := range reader.GetObjectNums() {
+			obj, err := reader.GetIndirectObjectByNumber(num)
+			if err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+			if dict, ok := TraceToDirectObject(obj).(*PdfObjectDictionary); ok {
+				if name, ok := GetNameVal(dict.Get("Type")); ok && name == "Page" {
+					numPageObjs++
+				}
+			}
+		}
+		if numPageObjs != 2 {
+			t.Errorf("Got %d page objects, expected 2", numPageObjs)
+		}
+		return reader
+	}
+	reader := check(buf.Bytes())
+
+	// Copy the pages and the navigation entries.
+	writer2 := NewPdfWriter()
+	numPages, _ := reader.GetNumPages()
+	for i := 1; i <= numPages; i++ {
+		page, _ := reader.GetPage(i)
+		if err := writer2.AddPage(page); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+	labels, _ = reader.GetPageLabels()
+	writer2.SetPageLabels(labels)
+	names, _ := reader.GetNames()
+	writer2.SetNames(names)
+	prefs, _ := reader.GetViewerPreferences()
+	writer2.SetViewerPreferences(prefs)
+	mode, _ := reader.GetPageMode()
+	writer2.SetPageMode(mode)
+	layout, _ := reader.GetPageLayout()
+	writer2.SetPageLayout(layout)
+	action, _ := reader.GetOpenAction()
+	writer2.SetOpenAction(action)
+
+	var buf2 bytes.Buffer
+	if err := writer2.Write(&buf2); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	check(buf2.Bytes())
+}
@@ -0,0 +1,164 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfPageMode specifies how the document is displayed when opened (PageMode entry of the catalog).
+type PdfPageMode string
+
+// Page modes.
+const (
+	PageModeUseNone        PdfPageMode = "UseNone"        // Neither outlines nor thumbnails visible.
+	PageModeUseOutlines    PdfPageMode = "UseOutlines"    // Outlines visible.
+	PageModeUseThumbs      PdfPageMode = "UseThumbs"      // Thumbnails visible.
+	PageModeFullScreen     PdfPageMode = "FullScreen"     // Full screen mode.
+	PageModeUseOC          PdfPageMode = "UseOC"          // Optional content group panel visible.
+	PageModeUseAttachments PdfPageMode = "UseAttachments" // Attachments panel visible.
+)
+
+// PdfPageLayout specifies the page layout when the document is opened (PageLayout entry of the
+// catalog).
+type PdfPageLayout string
+
+// Page layouts.
+const (
+	PageLayoutSinglePage     PdfPageLayout = "SinglePage"     // One page at a time.
+	PageLayoutOneColumn      PdfPageLayout = "OneColumn"      // Pages in one column.
+	PageLayoutTwoColumnLeft  PdfPageLayout = "TwoColumnLeft"  // Two columns, odd pages on the left.
+	PageLayoutTwoColumnRight PdfPageLayout = "TwoColumnRight" // Two columns, odd pages on the right.
+	PageLayoutTwoPageLeft    PdfPageLayout = "TwoPageLeft"    // Two pages at a time, odd pages on the left.
+	PageLayoutTwoPageRight   PdfPageLayout = "TwoPageRight"   // Two pages at a time, odd pages on the right.
+)
+
+// PdfViewerPreferences represents the viewer preferences dictionary (12.2 Viewer Preferences
+// p. 362).  Nil values and empty names represent entries that are not set.
+type PdfViewerPreferences struct {
+	HideToolbar     *bool
+	HideMenubar     *bool
+	HideWindowUI    *bool
+	FitWindow       *bool
+	CenterWindow    *bool
+	DisplayDocTitle *bool
+
+	NonFullScreenPageMode PdfPageMode // UseNone, UseOutlines, UseThumbs or UseOC.
+	Direction             string      // L2R or R2L.
+	ViewArea              string      // Page boundary names, e.g. CropBox.
+	ViewClip              string
+	PrintArea             string
+	PrintClip             string
+	PrintScaling          string // None or AppDefault.
+	Duplex                string // Simplex, DuplexFlipShortEdge or DuplexFlipLongEdge.
+	PickTrayByPDFSize     *bool
+	PrintPageRange        []int // Pairs of first and last page numbers (starting from 1).
+	NumCopies             int   // 0 if not set.
+}
+
+// NewPdfViewerPreferences returns empty viewer preferences.
+func NewPdfViewerPreferences() *PdfViewerPreferences {
+	return &PdfViewerPreferences{}
+}
+
+// NewPdfViewerPreferencesFromObject loads the viewer preferences dictionary `obj`.  Entries of
+// invalid type are ignored.
+func NewPdfViewerPreferencesFromObject(obj PdfObject) (*PdfViewerPreferences, error) {
+	dict, ok := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !ok {
+		return nil, ErrTypeError
+	}
+
+	prefs := NewPdfViewerPreferences()
+	for _, f := range prefs.boolFields() {
+		if val, ok := GetBoolVal(dict.Get(f.key)); ok {
+			*f.field = &val
+		}
+	}
+	for _, f := range prefs.nameFields() {
+		if val, ok := GetNameVal(dict.Get(f.key)); ok {
+			*f.field = val
+		}
+	}
+	if val, ok := GetNameVal(dict.Get("NonFullScreenPageMode")); ok {
+		prefs.NonFullScreenPageMode = PdfPageMode(val)
+	}
+	if arr, ok := GetArray(dict.Get("PrintPageRange")); ok {
+		vals, err := arr.ToIntegerArray()
+		if err != nil || len(vals)%2 != 0 {
+			common.Log.Debug("Invalid PrintPageRange - ignoring")
+		} else {
+			prefs.PrintPageRange = vals
+		}
+	}
+	if val, ok := GetIntVal(dict.Get("NumCopies")); ok {
+		prefs.NumCopies = val
+	}
+	return prefs, nil
+}
+
+type viewerBoolField struct {
+	key   PdfObjectName
+	field **bool
+}
+
+type viewerNameField struct {
+	key   PdfObjectName
+	field *string
+}
+
+// boolFields returns the boolean entries in the order they are written.
+func (prefs *PdfViewerPreferences) boolFields() []viewerBoolField {
+	return []viewerBoolField{
+		{"HideToolbar", &prefs.HideToolbar},
+		{"HideMenubar", &prefs.HideMenubar},
+		{"HideWindowUI", &prefs.HideWindowUI},
+		{"FitWindow", &prefs.FitWindow},
+		{"CenterWindow", &prefs.CenterWindow},
+		{"DisplayDocTitle", &prefs.DisplayDocTitle},
+		{"PickTrayByPDFSize", &prefs.PickTrayByPDFSize},
+	}
+}
+
+// nameFields returns the name entries, except NonFullScreenPageMode, in the order they are
+// written.
+func (prefs *PdfViewerPreferences) nameFields() []viewerNameField {
+	return []viewerNameField{
+		{"Direction", &prefs.Direction},
+		{"ViewArea", &prefs.ViewArea},
+		{"ViewClip", &prefs.ViewClip},
+		{"PrintArea", &prefs.PrintArea},
+		{"PrintClip", &prefs.PrintClip},
+		{"PrintScaling", &prefs.PrintScaling},
+		{"Duplex", &prefs.Duplex},
+	}
+}
+
+// ToPdfObject returns the viewer preferences dictionary.
+func (prefs *PdfViewerPreferences) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	for _, f := range prefs.boolFields() {
+		if *f.field != nil {
+			dict.Set(f.key, MakeBool(**f.field))
+		}
+	}
+	for _, f := range prefs.nameFields() {
+		if len(*f.field) > 0 {
+			dict.Set(f.key, MakeName(*f.field))
+		}
+	}
+	if len(prefs.NonFullScreenPageMode) > 0 {
+		dict.Set("NonFullScreenPageMode", MakeName(string(prefs.NonFullScreenPageMode)))
+	}
+	if len(prefs.PrintPageRange) > 0 {
+		dict.Set("PrintPageRange", MakeArrayFromIntegers(prefs.PrintPageRange))
+	}
+	if prefs.NumCopies > 0 {
+		dict.Set("NumCopies", MakeInteger(int64(prefs.NumCopies)))
+	}
+	return dict
+}
@@ -59,6 +59,11 @@ type PdfWriter struct {
 	fields      []PdfObject
 	infoObj     *PdfIndirectObject
 
+	// Document information and XMP metadata.
+	info           *PdfInfo
+	xmpMetadata    *XMPMetadata
+	metadataStream *PdfObjectStream
+
 	// Encryption
 	crypter     *PdfCrypt
 	encryptDict *PdfObjectDictionary
@@ -78,6 +83,27 @@ type PdfWriter struct {
 
 	// Forms.
 	acroForm *PdfAcroForm
+
+	// Document navigation and display entries of the catalog.
+	pageLabels        *PdfPageLabels
+	names             *PdfNames
+	viewerPreferences *PdfViewerPreferences
+	pageMode          PdfPageMode
+	pageLayout        PdfPageLayout
+	openAction        *PdfOpenAction
+
+	// Document level attachments.
+	collection      *PdfCollection
+	associatedFiles []*PdfFileSpec
+
+	// Logical structure.
+	structTreeRoot *PdfStructTreeRoot
+	lang           string
+
+	// PDF/A conformance.
+	pdfaConformance PdfAConformance
+	outputIntents   []*PdfOutputIntent
+	fontSubstitutes map[string]*PdfFont
 }
 
 // NewPdfWriter initializes a new PdfWriter.
@@ -151,6 +177,243 @@ func (this *PdfWriter) SetOCProperties(ocProperties PdfObject) error {
 	return nil
 }
 
+// SetOptionalContent sets the optional content properties of the document.
+func (this *PdfWriter) SetOptionalContent(props *PdfOCProperties) error {
+	return this.SetOCProperties(props.ToPdfObject())
+}
+
+// SetPdfInfo sets the document information dictionary.  The Producer and Creator entries default
+// to the UniDoc producer and the creator set with SetPdfCreator if not set in `info`.
+func (this *PdfWriter) SetPdfInfo(info *PdfInfo) {
+	this.info = info
+}
+
+// SetXMPMetadata sets the XMP metadata packet written as the Metadata stream of the document
+// catalog.  When writing, the properties corresponding to the document information entries are
+// updated from the document information dictionary so that the two are synchronized.
+func (this *PdfWriter) SetXMPMetadata(xmp *XMPMetadata) {
+	this.xmpMetadata = xmp
+}
+
+// updateMetadata sets the document information dictionary and the metadata stream prior to writing.
+func (this *PdfWriter) updateMetadata() error {
+	if this.info != nil {
+		info := *this.info
+		if len(info.Producer) == 0 {
+			info.Producer = getPdfProducer()
+		}
+		if len(info.Creator) == 0 {
+			info.Creator = getPdfCreator()
+		}
+		this.infoObj.PdfObject = info.ToPdfObject()
+	}
+
+	if this.xmpMetadata == nil {
+		return nil
+	}
+	info, err := NewPdfInfoFromObject(this.infoObj)
+	if err != nil {
+		return err
+	}
+	this.xmpMetadata.SetPdfInfo(info)
+
+	// The metadata stream is not compressed so that it can be read by applications not aware of PDF.
+	data := this.xmpMetadata.Bytes()
+	if this.metadataStream == nil {
+		this.metadataStream = &PdfObjectStream{PdfObjectDictionary: MakeDict()}
+	}
+	dict := this.metadataStream.PdfObjectDictionary
+	dict.Set("Type", MakeName("Metadata"))
+	dict.Set("Subtype", MakeName("XML"))
+	dict.Set("Length", MakeInteger(int64(len(data))))
+	this.metadataStream.Stream = data
+
+	this.catalog.Set("Metadata", this.metadataStream)
+	return this.addObjects(this.metadataStream)
+}
+
+// SetPageLabels sets the page labels of the document.
+func (this *PdfWriter) SetPageLabels(labels *PdfPageLabels) {
+	this.pageLabels = labels
+}
+
+// SetNames sets the name dictionary of the document.  Named destinations to pages that are not
+// added to the writer are dropped when writing.
+func (this *PdfWriter) SetNames(names *PdfNames) {
+	this.names = names
+}
+
+// AddNamedDestination adds the named destination `name` to the Dests name tree of the name
+// dictionary.  The destination page should be added to the writer with AddPage.
+func (this *PdfWriter) AddNamedDestination(name string, dest *PdfDestination) {
+	if this.names == nil {
+		this.names = NewPdfNames()
+	}
+	this.names.SetDestination(name, dest)
+}
+
+// SetViewerPreferences sets the viewer preferences of the document.
+func (this *PdfWriter) SetViewerPreferences(prefs *PdfViewerPreferences) {
+	this.viewerPreferences = prefs
+}
+
+// SetPageMode sets the page mode of the document.
+func (this *PdfWriter) SetPageMode(mode PdfPageMode) {
+	this.pageMode = mode
+}
+
+// SetPageLayout sets the page layout of the document.
+func (this *PdfWriter) SetPageLayout(layout PdfPageLayout) {
+	this.pageLayout = layout
+}
+
+// SetOpenAction sets the destination or action of the document catalog OpenAction entry.
+func (this *PdfWriter) SetOpenAction(action *PdfOpenAction) {
+	this.openAction = action
+}
+
+// AddEmbeddedFile adds the file specification `spec` as `name` to the EmbeddedFiles name tree of
+// the name dictionary.
+func (this *PdfWriter) AddEmbeddedFile(name string, spec *PdfFileSpec) {
+	if this.names == nil {
+		this.names = NewPdfNames()
+	}
+	this.names.SetEmbeddedFile(name, spec)
+}
+
+// AddAssociatedFile adds the file specification `spec` to the associated files of the document
+// (AF entry of the catalog).  The file should also be added with AddEmbeddedFile so that it is
+// listed by viewers.
+func (this *PdfWriter) AddAssociatedFile(spec *PdfFileSpec) {
+	this.associatedFiles = append(this.associatedFiles, spec)
+}
+
+// SetCollection sets the collection dictionary of the document, making it a portfolio of its
+// embedded files.
+func (this *PdfWriter) SetCollection(collection *PdfCollection) {
+	this.collection = collection
+}
+
+// SetStructTreeRoot sets the structure tree root of the document, marking it as a tagged
+// document.  The pages of the content items should be added to the writer with AddPage.
+func (this *PdfWriter) SetStructTreeRoot(root *PdfStructTreeRoot) {
+	this.structTreeRoot = root
+}
+
+// SetLanguage sets the natural language of the document (Lang entry of the catalog), e.g. en-US.
+func (this *PdfWriter) SetLanguage(lang string) {
+	this.lang = lang
+}
+
+// hasPage returns true if the destination page `page` has been added to the writer.
+func (this *PdfWriter) hasPage(page PdfObject) bool {
+	pagesDict, ok := this.pages.PdfObject.(*PdfObjectDictionary)
+	if !ok {
+		return false
+	}
+	kids, ok := pagesDict.Get("Kids").(*PdfObjectArray)
+	if !ok {
+		return false
+	}
+	for _, kid := range kids.Elements() {
+		if kid == page {
+			return true
+		}
+	}
+	return false
+}
+
+// updateNavigation sets the page labels, name dictionary, viewer preferences, page mode, page
+// layout, open action, collection, associated files, language and logical structure entries of
+// the catalog.  Destinations to pages that have not been added to the writer are dropped, so that
+// no pages outside of the page tree are written.
+func (this *PdfWriter) updateNavigation() error {
+	entries := map[PdfObjectName]PdfObject{}
+
+	if this.pageLabels != nil && len(this.pageLabels.Ranges()) > 0 {
+		entries["PageLabels"] = this.pageLabels.ToPdfObject()
+	}
+
+	if this.names != nil {
+		names := NewPdfNames()
+		for _, category := range this.names.Categories() {
+			names.SetTree(category, this.names.GetTree(category))
+		}
+		if tree := this.names.GetTree("Dests"); tree != nil {
+			dests := NewPdfNameTree()
+			for _, key := range tree.Keys() {
+				obj, _ := tree.Get(key)
+				dest, err := NewPdfDestinationFromObject(obj)
+				if err != nil || !this.hasPage(dest.Page) {
+					common.Log.Debug("Destination %q to a page not written - dropping", key)
+					continue
+				}
+				dests.Set(key, obj)
+			}
+			names.SetTree("Dests", dests)
+			if dests.Len() == 0 {
+				names.SetTree("Dests", nil)
+			}
+		}
+		if len(names.Categories()) > 0 {
+			entries["Names"] = names.ToPdfObject()
+		}
+	}
+
+	if this.viewerPreferences != nil {
+		entries["ViewerPreferences"] = this.viewerPreferences.ToPdfObject()
+	}
+	if len(this.pageMode) > 0 {
+		entries["PageMode"] = MakeName(string(this.pageMode))
+	}
+	if len(this.pageLayout) > 0 {
+		entries["PageLayout"] = MakeName(string(this.pageLayout))
+	}
+
+	if this.openAction != nil {
+		if this.openAction.Dest != nil && !this.hasPage(this.openAction.Dest.Page) {
+			common.Log.Debug("Open action destination to a page not written - dropping")
+		} else if obj := this.openAction.ToPdfObject(); obj != nil {
+			entries["OpenAction"] = obj
+		}
+	}
+
+	if this.collection != nil {
+		entries["Collection"] = this.collection.ToPdfObject()
+	}
+	if len(this.associatedFiles) > 0 {
+		files := MakeArray()
+		for _, spec := range this.associatedFiles {
+			files.Append(spec.ToPdfObject())
+		}
+		entries["AF"] = files
+	}
+
+	if len(this.lang) > 0 {
+		entries["Lang"] = MakeEncodedString(this.lang)
+	}
+	if this.structTreeRoot != nil {
+		entries["StructTreeRoot"] = this.structTreeRoot.ToPdfObject()
+		markInfo := MakeDict()
+		markInfo.Set("Marked", MakeBool(true))
+		entries["MarkInfo"] = markInfo
+	}
+
+	for _, key := range []PdfObjectName{"PageLabels", "Names", "ViewerPreferences", "PageMode", "PageLayout",
+		"OpenAction", "Collection", "AF", "Lang", "StructTreeRoot", "MarkInfo"} {
+		obj, has := entries[key]
+		if !has {
+			continue
+		}
+		this.catalog.Set(key, obj)
+		err := this.addObjects(obj)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (this *PdfWriter) hasObject(obj PdfObject) bool {
 	// Check if already added.
 	for _, o := range this.objects {
@@ -381,6 +644,12 @@ func procPage(p *PdfPage) {
 	p.ToPdfObject()
 }
 
+// SetOutlines sets the document outlines.  The Count entries of the items are updated when
+// written.
+func (this *PdfWriter) SetOutlines(outlines *PdfOutline) {
+	this.outlineTree = &outlines.PdfOutlineTreeNode
+}
+
 // AddOutlineTree adds outlines to a PDF file.
 func (this *PdfWriter) AddOutlineTree(outlineTree *PdfOutlineTreeNode) {
 	this.outlineTree = outlineTree
@@ -479,8 +748,26 @@ type EncryptOptions struct {
 	Permissions AccessPermissions
 }
 
+// makeFileIDs sets the file identifiers of the trailer to new random identifiers.
+func (this *PdfWriter) makeFileIDs() {
+	hashcode := md5.Sum([]byte(time.Now().Format(time.RFC850)))
+	id0 := string(hashcode[:])
+	b := make([]byte, 100)
+	rand.Read(b)
+	hashcode = md5.Sum(b)
+	id1 := string(hashcode[:])
+	common.Log.Trace("Random b: % x", b)
+
+	this.ids = MakeArray(MakeHexString(id0), MakeHexString(id1))
+	common.Log.Trace("Gen Id 0: % x", id0)
+}
+
 // Encrypt encrypts the output file with a specified user/owner password.
+// Encryption is not allowed in PDF/A documents.
 func (this *PdfWriter) Encrypt(userPass, ownerPass []byte, options *EncryptOptions) error {
+	if this.pdfaConformance != PdfAConformanceNone {
+		return fmt.Errorf("%s: Encryption not allowed", this.pdfaConformance)
+	}
 	crypter := PdfCrypt{}
 	this.crypter = &crypter
 
@@ -500,18 +787,9 @@ func (this *PdfWriter) Encrypt(userPass, ownerPass []byte, options *EncryptOptio
 	}
 
 	// Prepare the ID object for the trailer.
-	hashcode := md5.Sum([]byte(time.Now().Format(time.RFC850)))
-	id0 := string(hashcode[:])
-	b := make([]byte, 100)
-	rand.Read(b)
-	hashcode = md5.Sum(b)
-	id1 := string(hashcode[:])
-	common.Log.Trace("Random b: % x", b)
-
-	this.ids = MakeArray(MakeHexString(id0), MakeHexString(id1))
-	common.Log.Trace("Gen Id 0: % x", id0)
-
-	crypter.Id0 = string(id0)
+	this.makeFileIDs()
+	id0, _ := GetStringVal(this.ids.Get(0))
+	crypter.Id0 = id0
 
 	// Make the O and U objects.
 	O, err := crypter.Alg3(userPass, ownerPass)
@@ -603,6 +881,29 @@ func (this *PdfWriter) Write(writer io.Writer) error {
 		}
 	}
 
+	// Page labels, named destinations and viewer preferences.
+	err := this.updateNavigation()
+	if err != nil {
+		return err
+	}
+
+	// Output intents and PDF/A identification.
+	err = this.updateOutputIntents()
+	if err != nil {
+		return err
+	}
+
+	// Document information and metadata.
+	err = this.updateMetadata()
+	if err != nil {
+		return err
+	}
+
+	err = this.checkPdfAConformance()
+	if err != nil {
+		return err
+	}
+
 	// Check pending objects prior to write.
 	for pendingObj, pendingObjDict := range this.pendingObjects {
 		if !this.hasObject(pendingObj) {
@@ -672,6 +973,8 @@ func (this *PdfWriter) Write(writer io.Writer) error {
 	// If encrypted!
 	if this.crypter != nil {
 		trailer.Set("Encrypt", this.encryptObj)
+	}
+	if this.ids != nil {
 		trailer.Set("ID", this.ids)
 		common.Log.Trace("Ids: %s", this.ids)
 	}
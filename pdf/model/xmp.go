@@ -0,0 +1,488 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/unidoc/unidoc/common"
+)
+
+// XMP namespaces of the properties corresponding to the document information entries
+// (14.3.2 Metadata Streams p. 548).
+const (
+	XMPNamespaceRDF  = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	XMPNamespaceDC   = "http://purl.org/dc/elements/1.1/"
+	XMPNamespaceXMP  = "http://ns.adobe.com/xap/1.0/"
+	XMPNamespacePDF  = "http://ns.adobe.com/pdf/1.3/"
+	XMPNamespacePDFX = "http://ns.adobe.com/pdfx/1.3/" // Custom document information entries.
+)
+
+const xmpNamespaceXML = "http://www.w3.org/XML/1998/namespace"
+
+// Preferred prefixes of the known namespaces, used when adding properties.
+var xmpNamespacePrefixes = map[string]string{
+	XMPNamespaceDC:   "dc",
+	XMPNamespaceXMP:  "xmp",
+	XMPNamespacePDF:  "pdf",
+	XMPNamespacePDFX: "pdfx",
+}
+
+// Empty XMP packet content.
+const xmpEmptyPacket = `<x:xmpmeta xmlns:x="adobe:ns:meta/">
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+</rdf:RDF>
+</x:xmpmeta>`
+
+// XMP date formats, from the most to the least precise.
+var xmpDateLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// Escaping of character data and attribute values.  Unlike xml.EscapeText, white space is kept as is.
+var (
+	xmpTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	xmpAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;")
+)
+
+// Valid names of custom properties (a subset of the XML names).
+var reXMPPropertyName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// XMPMetadata represents an XMP metadata packet as stored in the Metadata stream of the document
+// catalog (14.3.2 Metadata Streams p. 548).  The packet content is kept when modifying properties, so
+// that properties not handled here are preserved.
+type XMPMetadata struct {
+	root *xmpElement
+}
+
+// xmpElement represents an XML element of an XMP packet.  Names are kept with their prefixes and
+// resolved with the namespace declarations in scope.
+type xmpElement struct {
+	name    xml.Name
+	attrs   []xml.Attr
+	content []interface{} // *xmpElement, xml.CharData, xml.Comment or xml.ProcInst.
+	parent  *xmpElement
+}
+
+// NewXMPMetadata returns an empty XMP metadata packet.
+func NewXMPMetadata() *XMPMetadata {
+	m, err := NewXMPMetadataFromBytes([]byte(xmpEmptyPacket))
+	if err != nil {
+		// Not expected as the empty packet is valid.
+		common.Log.Debug("ERROR: Invalid empty XMP packet: %v", err)
+	}
+	return m
+}
+
+// NewXMPMetadataFromBytes loads the XMP packet `data`.
+func NewXMPMetadataFromBytes(data []byte) (*XMPMetadata, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var root, current *xmpElement
+	for {
+		tok, err := decoder.RawToken()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			elem := &xmpElement{name: t.Name, attrs: append([]xml.Attr{}, t.Attr...), parent: current}
+			if current != nil {
+				current.content = append(current.content, elem)
+			} else if root == nil {
+				root = elem
+			} else {
+				return nil, errors.New("Multiple XMP root elements")
+			}
+			current = elem
+		case xml.EndElement:
+			if current == nil {
+				return nil, errors.New("Invalid XMP packet")
+			}
+			current = current.parent
+		case xml.CharData:
+			if current != nil {
+				current.content = append(current.content, t.Copy())
+			}
+		case xml.Comment:
+			if current != nil {
+				current.content = append(current.content, t.Copy())
+			}
+		case xml.ProcInst:
+			// The xpacket wrapper is regenerated on output.
+			if current != nil && t.Target != "xpacket" {
+				current.content = append(current.content, t.Copy())
+			}
+		}
+	}
+	if root == nil || current != nil {
+		return nil, errors.New("Invalid XMP packet")
+	}
+
+	m := &XMPMetadata{root: root}
+	if m.rdf() == nil {
+		return nil, errors.New("XMP packet without rdf:RDF element")
+	}
+	return m, nil
+}
+
+// Bytes returns the XMP packet including the xpacket wrapper.
+func (m *XMPMetadata) Bytes() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	m.root.write(&buf)
+	buf.WriteString("\n<?xpacket end=\"w\"?>")
+	return buf.Bytes()
+}
+
+// GetProperty returns the value of the property `name` in `namespace`.  For language alternatives
+// the default value is returned and the items of ordered and unordered arrays are joined with
+// commas.
+func (m *XMPMetadata) GetProperty(namespace, name string) (string, bool) {
+	for _, desc := range m.descriptions() {
+		for _, attr := range desc.attrs {
+			if attr.Name.Local == name && desc.resolve(attr.Name.Space) == namespace {
+				return attr.Value, true
+			}
+		}
+		for _, prop := range desc.children() {
+			if prop.name.Local == name && prop.namespace() == namespace {
+				return prop.propertyValue(), true
+			}
+		}
+	}
+	return "", false
+}
+
+// SetProperty sets the simple property `name` in `namespace` to `value`, replacing any existing
+// value.  The property is removed if `value` is empty.
+func (m *XMPMetadata) SetProperty(namespace, name, value string) {
+	m.setProperty(namespace, name, value, "")
+}
+
+// RemoveProperty removes the property `name` in `namespace`.
+func (m *XMPMetadata) RemoveProperty(namespace, name string) {
+	for _, desc := range m.descriptions() {
+		attrs := desc.attrs[:0]
+		for _, attr := range desc.attrs {
+			if attr.Name.Local == name && desc.resolve(attr.Name.Space) == namespace {
+				continue
+			}
+			attrs = append(attrs, attr)
+		}
+		desc.attrs = attrs
+
+		content := desc.content[:0]
+		for _, item := range desc.content {
+			if prop, ok := item.(*xmpElement); ok && prop.name.Local == name && prop.namespace() == namespace {
+				continue
+			}
+			content = append(content, item)
+		}
+		desc.content = content
+	}
+}
+
+// setProperty sets the property `name` in `namespace` to `value`, as an array of type `arrayType`
+// (Alt, Seq or Bag) with a single item if specified, otherwise as a simple property.
+func (m *XMPMetadata) setProperty(namespace, name, value, arrayType string) {
+	m.RemoveProperty(namespace, name)
+	if len(value) == 0 {
+		return
+	}
+
+	desc, prefix := m.descriptionFor(namespace)
+	prop := &xmpElement{name: xml.Name{Space: prefix, Local: name}, parent: desc}
+	if len(arrayType) == 0 {
+		prop.content = []interface{}{xml.CharData(value)}
+	} else {
+		rdfPrefix := m.rdf().name.Space
+		array := &xmpElement{name: xml.Name{Space: rdfPrefix, Local: arrayType}, parent: prop}
+		item := &xmpElement{name: xml.Name{Space: rdfPrefix, Local: "li"}, parent: array}
+		if arrayType == "Alt" {
+			item.attrs = []xml.Attr{{Name: xml.Name{Space: "xml", Local: "lang"}, Value: "x-default"}}
+		}
+		item.content = []interface{}{xml.CharData(value)}
+		array.content = []interface{}{item}
+		prop.content = []interface{}{array}
+	}
+	desc.content = append(desc.content, xml.CharData("\n"), prop)
+}
+
+// PdfInfo returns the document information entries represented by the XMP properties.
+func (m *XMPMetadata) PdfInfo() *PdfInfo {
+	info := NewPdfInfo()
+	info.Title, _ = m.GetProperty(XMPNamespaceDC, "title")
+	info.Author, _ = m.GetProperty(XMPNamespaceDC, "creator")
+	info.Subject, _ = m.GetProperty(XMPNamespaceDC, "description")
+	info.Creator, _ = m.GetProperty(XMPNamespaceXMP, "CreatorTool")
+	info.Producer, _ = m.GetProperty(XMPNamespacePDF, "Producer")
+	info.Trapped, _ = m.GetProperty(XMPNamespacePDF, "Trapped")
+
+	var has bool
+	info.Keywords, has = m.GetProperty(XMPNamespacePDF, "Keywords")
+	if !has {
+		info.Keywords, _ = m.GetProperty(XMPNamespaceDC, "subject")
+	}
+
+	if val, has := m.GetProperty(XMPNamespaceXMP, "CreateDate"); has {
+		info.CreationDate = parseXMPDate(val)
+	}
+	if val, has := m.GetProperty(XMPNamespaceXMP, "ModifyDate"); has {
+		info.ModDate = parseXMPDate(val)
+	}
+
+	for _, desc := range m.descriptions() {
+		for _, attr := range desc.attrs {
+			if desc.resolve(attr.Name.Space) == XMPNamespacePDFX {
+				info.SetCustom(attr.Name.Local, attr.Value)
+			}
+		}
+		for _, prop := range desc.children() {
+			if prop.namespace() == XMPNamespacePDFX {
+				info.SetCustom(prop.name.Local, prop.propertyValue())
+			}
+		}
+	}
+	return info
+}
+
+// SetPdfInfo sets the XMP properties corresponding to the document information entries of `info`.
+// The properties of entries that are not set in `info` are removed.
+func (m *XMPMetadata) SetPdfInfo(info *PdfInfo) {
+	m.setProperty(XMPNamespaceDC, "title", info.Title, "Alt")
+	m.setProperty(XMPNamespaceDC, "creator", info.Author, "Seq")
+	m.setProperty(XMPNamespaceDC, "description", info.Subject, "Alt")
+	m.SetProperty(XMPNamespacePDF, "Keywords", info.Keywords)
+	m.SetProperty(XMPNamespaceXMP, "CreatorTool", info.Creator)
+	m.SetProperty(XMPNamespacePDF, "Producer", info.Producer)
+	m.SetProperty(XMPNamespacePDF, "Trapped", info.Trapped)
+
+	createDate, modifyDate := "", ""
+	if info.CreationDate != nil {
+		createDate = formatXMPDate(info.CreationDate)
+	}
+	if info.ModDate != nil {
+		modifyDate = formatXMPDate(info.ModDate)
+	}
+	m.SetProperty(XMPNamespaceXMP, "CreateDate", createDate)
+	m.SetProperty(XMPNamespaceXMP, "ModifyDate", modifyDate)
+	m.SetProperty(XMPNamespaceXMP, "MetadataDate", modifyDate)
+
+	// Custom entries.
+	for _, key := range m.PdfInfo().CustomKeys() {
+		m.RemoveProperty(XMPNamespacePDFX, key)
+	}
+	for _, key := range info.CustomKeys() {
+		if !reXMPPropertyName.MatchString(key) {
+			common.Log.Debug("Custom info entry %s is not a valid XMP property name - skipping", key)
+			continue
+		}
+		val, _ := info.GetCustom(key)
+		m.SetProperty(XMPNamespacePDFX, key, val)
+	}
+}
+
+// rdf returns the rdf:RDF element.
+func (m *XMPMetadata) rdf() *xmpElement {
+	if m.root.name.Local == "RDF" && m.root.namespace() == XMPNamespaceRDF {
+		return m.root
+	}
+	for _, elem := range m.root.children() {
+		if elem.name.Local == "RDF" && elem.namespace() == XMPNamespaceRDF {
+			return elem
+		}
+	}
+	return nil
+}
+
+// descriptions returns the rdf:Description elements.
+func (m *XMPMetadata) descriptions() []*xmpElement {
+	descs := []*xmpElement{}
+	for _, elem := range m.rdf().children() {
+		if elem.name.Local == "Description" && elem.namespace() == XMPNamespaceRDF {
+			descs = append(descs, elem)
+		}
+	}
+	return descs
+}
+
+// descriptionFor returns an rdf:Description element declaring `namespace` along with the prefix
+// of the namespace, adding a new description if there is none.
+func (m *XMPMetadata) descriptionFor(namespace string) (*xmpElement, string) {
+	for _, desc := range m.descriptions() {
+		for _, attr := range desc.attrs {
+			if attr.Name.Space == "xmlns" && attr.Value == namespace {
+				return desc, attr.Name.Local
+			}
+		}
+	}
+
+	prefix, has := xmpNamespacePrefixes[namespace]
+	if !has {
+		prefix = "ns"
+	}
+	rdf := m.rdf()
+	desc := &xmpElement{
+		name: xml.Name{Space: rdf.name.Space, Local: "Description"},
+		attrs: []xml.Attr{
+			{Name: xml.Name{Space: rdf.name.Space, Local: "about"}, Value: ""},
+			{Name: xml.Name{Space: "xmlns", Local: prefix}, Value: namespace},
+		},
+		parent: rdf,
+	}
+	rdf.content = append(rdf.content, xml.CharData("\n"), desc)
+	return desc, prefix
+}
+
+// children returns the child elements of `e`.
+func (e *xmpElement) children() []*xmpElement {
+	elems := []*xmpElement{}
+	if e == nil {
+		return elems
+	}
+	for _, item := range e.content {
+		if elem, ok := item.(*xmpElement); ok {
+			elems = append(elems, elem)
+		}
+	}
+	return elems
+}
+
+// namespace returns the namespace of the element name.
+func (e *xmpElement) namespace() string {
+	return e.resolve(e.name.Space)
+}
+
+// resolve returns the namespace bound to `prefix` in the scope of `e`.
+func (e *xmpElement) resolve(prefix string) string {
+	if prefix == "xml" {
+		return xmpNamespaceXML
+	}
+	for elem := e; elem != nil; elem = elem.parent {
+		for _, attr := range elem.attrs {
+			if (len(prefix) > 0 && attr.Name.Space == "xmlns" && attr.Name.Local == prefix) ||
+				(len(prefix) == 0 && len(attr.Name.Space) == 0 && attr.Name.Local == "xmlns") {
+				return attr.Value
+			}
+		}
+	}
+	return ""
+}
+
+// text returns the character data of `e`.
+func (e *xmpElement) text() string {
+	var buf bytes.Buffer
+	for _, item := range e.content {
+		if data, ok := item.(xml.CharData); ok {
+			buf.Write(data)
+		}
+	}
+	return buf.String()
+}
+
+// propertyValue returns the value of the property element `e`: the text of simple properties, the
+// default item of language alternatives and the items of other arrays joined with commas.
+func (e *xmpElement) propertyValue() string {
+	children := e.children()
+	if len(children) == 0 {
+		return e.text()
+	}
+
+	array := children[0]
+	items := []string{}
+	for _, item := range array.children() {
+		if item.name.Local != "li" || item.namespace() != XMPNamespaceRDF {
+			continue
+		}
+		if array.name.Local == "Alt" {
+			for _, attr := range item.attrs {
+				if attr.Name.Space == "xml" && attr.Name.Local == "lang" && attr.Value == "x-default" {
+					return item.text()
+				}
+			}
+		}
+		items = append(items, item.text())
+	}
+	if array.name.Local == "Alt" && len(items) > 0 {
+		return items[0]
+	}
+	return strings.Join(items, ", ")
+}
+
+// write writes the element `e` as XML to `buf`.
+func (e *xmpElement) write(buf *bytes.Buffer) {
+	buf.WriteString("<" + xmpName(e.name))
+	for _, attr := range e.attrs {
+		buf.WriteString(" " + xmpName(attr.Name) + "=\"" + xmpAttrEscaper.Replace(attr.Value) + "\"")
+	}
+	if len(e.content) == 0 {
+		buf.WriteString("/>")
+		return
+	}
+	buf.WriteString(">")
+
+	for _, item := range e.content {
+		switch t := item.(type) {
+		case *xmpElement:
+			t.write(buf)
+		case xml.CharData:
+			buf.WriteString(xmpTextEscaper.Replace(string(t)))
+		case xml.Comment:
+			buf.WriteString("<!--")
+			buf.Write(t)
+			buf.WriteString("-->")
+		case xml.ProcInst:
+			buf.WriteString("<?" + t.Target + " ")
+			buf.Write(t.Inst)
+			buf.WriteString("?>")
+		}
+	}
+	buf.WriteString("</" + xmpName(e.name) + ">")
+}
+
+// xmpName returns the prefixed name `name`.
+func xmpName(name xml.Name) string {
+	if len(name.Space) == 0 {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// parseXMPDate returns the PdfDate represented by the XMP date `val`, or nil if invalid.
+func parseXMPDate(val string) *PdfDate {
+	val = strings.TrimSpace(val)
+	for _, layout := range xmpDateLayouts {
+		if t, err := time.Parse(layout, val); err == nil {
+			date := NewPdfDateFromTime(t)
+			return &date
+		}
+	}
+	common.Log.Debug("Invalid XMP date %q - ignoring", val)
+	return nil
+}
+
+// formatXMPDate returns the XMP representation of `date`.
+func formatXMPDate(date *PdfDate) string {
+	return date.ToGoTime().Format("2006-01-02T15:04:05-07:00")
+}
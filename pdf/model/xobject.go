@@ -112,6 +112,42 @@ func NewXObjectFormFromStream(stream *PdfObjectStream) (*XObjectForm, error) {
 	return form, nil
 }
 
+// NewXObjectFormFromPage creates a Form XObject with the contents and resources of `page`, e.g. for
+// placing the page on another page.  The bounding box is the crop box of the page (media box if not
+// set) in the page's default user space.  The page rotation is not applied.
+func NewXObjectFormFromPage(page *PdfPage) (*XObjectForm, error) {
+	bbox := page.CropBox
+	if bbox == nil {
+		mbox, err := page.GetMediaBox()
+		if err != nil {
+			return nil, err
+		}
+		bbox = mbox
+	}
+	resources, err := page.getResources()
+	if err != nil {
+		return nil, err
+	}
+	if resources == nil {
+		resources = NewPdfPageResources()
+	}
+	content, err := page.GetAllContentStreams()
+	if err != nil {
+		return nil, err
+	}
+
+	xform := NewXObjectForm()
+	xform.BBox = bbox.ToPdfObject()
+	xform.Resources = resources
+	xform.Group = page.Group
+	xform.Filter = NewFlateEncoder()
+	err = xform.SetContentStream([]byte(content), nil)
+	if err != nil {
+		return nil, err
+	}
+	return xform, nil
+}
+
 func (xform *XObjectForm) GetContainingPdfObject() PdfObject {
 	return xform.primitive
 }
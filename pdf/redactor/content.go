@@ -0,0 +1,545 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package redactor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Maximum nesting depth of Form XObjects that are redacted.  Deeper forms intersecting a redacted
+// region are removed.
+const maxFormDepth = 16
+
+// Half size of the area (in default user space units) outside the redacted regions that is kept
+// visible by the exclusion clipping paths.
+const exclusionClipExtent = 1e5
+
+// xobjectUsage tracks the use of the XObjects of a resources dictionary by redacted content.
+type xobjectUsage struct {
+	used     map[core.PdfObjectName]bool // Drawn by the redacted content.
+	replaced map[core.PdfObjectName]bool // Removed from or replaced in the redacted content.
+}
+
+func newXObjectUsage() *xobjectUsage {
+	return &xobjectUsage{
+		used:     map[core.PdfObjectName]bool{},
+		replaced: map[core.PdfObjectName]bool{},
+	}
+}
+
+// removeUnused removes the XObjects that were replaced and are no longer used from `resources`,
+// so that the unredacted data is not kept with the page.
+func (u *xobjectUsage) removeUnused(resources *model.PdfPageResources) {
+	xobjects, ok := core.GetDict(resources.XObject)
+	if !ok {
+		return
+	}
+	for name := range u.replaced {
+		if !u.used[name] {
+			xobjects.Remove(name)
+		}
+	}
+}
+
+// contentRedactor removes the content within the redacted regions from a content stream.
+type contentRedactor struct {
+	regions   []model.PdfRectangle // Redacted regions in default user space.
+	resources *model.PdfPageResources
+	base      contentstream.Matrix // Maps the user space of the content stream to default user space.
+	usage     *xobjectUsage
+	depth     int
+
+	out contentstream.ContentStreamOperations
+
+	// Path construction and clipping operations of the current path object.
+	path    []*contentstream.ContentStreamOperation
+	pathCTM contentstream.Matrix
+}
+
+func newContentRedactor(regions []model.PdfRectangle, resources *model.PdfPageResources, base contentstream.Matrix,
+	usage *xobjectUsage, depth int) *contentRedactor {
+	return &contentRedactor{
+		regions:   regions,
+		resources: resources,
+		base:      base,
+		usage:     usage,
+		depth:     depth,
+		out:       contentstream.ContentStreamOperations{},
+	}
+}
+
+// redact returns the operations of `content` with the content within the redacted regions removed.
+func (r *contentRedactor) redact(content string) (*contentstream.ContentStreamOperations, error) {
+	parser := contentstream.NewContentStreamParser(content)
+	operations, err := parser.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	processor := contentstream.NewContentStreamProcessor(*operations)
+	processor.AddHandler(contentstream.HandlerConditionEnumAllOperands, "",
+		func(op *contentstream.ContentStreamOperation, gs contentstream.GraphicsState, resources *model.PdfPageResources) error {
+			return r.handleOperation(op, gs)
+		})
+	err = processor.Process(r.resources)
+	if err != nil {
+		return nil, err
+	}
+
+	// A path object that is not ended by a painting operator paints nothing.
+	r.path = nil
+	return &r.out, nil
+}
+
+func (r *contentRedactor) emit(ops ...*contentstream.ContentStreamOperation) {
+	r.out = append(r.out, ops...)
+}
+
+// handleOperation adds operation `op` to the output, removing or modifying it when it draws within
+// the redacted regions.
+func (r *contentRedactor) handleOperation(op *contentstream.ContentStreamOperation, gs contentstream.GraphicsState) error {
+	ctm := gs.CTM.Mult(r.base)
+
+	switch op.Operand {
+	case "m", "l", "c", "v", "y", "h", "re":
+		if len(r.path) == 0 {
+			r.pathCTM = ctm
+		}
+		r.path = append(r.path, op)
+		return nil
+	case "W", "W*":
+		r.path = append(r.path, op)
+		return nil
+	case "S", "s", "f", "F", "f*", "B", "B*", "b", "b*", "n":
+		r.redactPath(op, gs)
+		return nil
+	}
+
+	if len(r.path) > 0 {
+		common.Log.Debug("Path object not ended by a painting operator - ignoring")
+		r.path = nil
+	}
+
+	switch op.Operand {
+	case "Tj", "TJ", "'", `"`:
+		r.redactText(op, gs)
+	case "Do":
+		return r.redactXObject(op, ctm)
+	case "BI":
+		r.redactInlineImage(op, ctm)
+	case "sh":
+		// The extent of shadings is not determined, the redacted regions are clipped out.
+		inv, ok := ctm.Inverse()
+		if !ok {
+			return nil
+		}
+		r.emit(&contentstream.ContentStreamOperation{Operand: "q"})
+		r.emitExclusionClips(inv, r.regions, exclusionArea(r.regions))
+		r.emit(op, &contentstream.ContentStreamOperation{Operand: "Q"})
+	default:
+		r.emit(op)
+	}
+	return nil
+}
+
+// redactText removes the glyphs within the redacted regions from the text showing operation `op`.
+// The operation is replaced by a TJ operation where the removed glyphs are replaced by position
+// adjustments, so that the position of the remaining glyphs is unchanged.
+func (r *contentRedactor) redactText(op *contentstream.ContentStreamOperation, gs contentstream.GraphicsState) {
+	glyphs, _, err := gs.TextGlyphs(op)
+	if err != nil {
+		common.Log.Debug("Invalid %s operation (%v) - removing", op.Operand, err)
+		return
+	}
+
+	removed := make([]bool, len(glyphs))
+	anyRemoved := false
+	for i, glyph := range glyphs {
+		glyph.Trm = glyph.Trm.Mult(r.base)
+		if len(r.intersecting(glyph.BBox())) > 0 {
+			removed[i] = true
+			anyRemoved = true
+		}
+	}
+	if !anyRemoved {
+		r.emit(op)
+		return
+	}
+
+	// Line moves of the ' and " operators.
+	switch op.Operand {
+	case "'":
+		r.emit(&contentstream.ContentStreamOperation{Operand: "T*"})
+	case `"`:
+		r.emit(
+			&contentstream.ContentStreamOperation{Operand: "Tw", Params: op.Params[0:1]},
+			&contentstream.ContentStreamOperation{Operand: "Tc", Params: op.Params[1:2]},
+			&contentstream.ContentStreamOperation{Operand: "T*"})
+	}
+
+	scale := gs.Text.FontSize * gs.Text.HorizontalScaling / 100
+	if scale == 0 {
+		return
+	}
+
+	var elements []core.PdfObject
+	if op.Operand == "TJ" {
+		arr, _ := core.GetArray(op.Params[0])
+		elements = arr.Elements()
+	} else {
+		elements = op.Params[len(op.Params)-1:]
+	}
+
+	arr := core.MakeArray()
+	var str []byte
+	adjustment := 0.0
+	flushString := func() {
+		if len(str) > 0 {
+			arr.Append(core.MakeString(string(str)))
+			str = nil
+		}
+	}
+	flushAdjustment := func() {
+		if adjustment != 0 {
+			arr.Append(core.MakeFloat(adjustment))
+			adjustment = 0
+		}
+	}
+
+	g := 0
+	for i, elem := range elements {
+		if _, ok := core.GetString(elem); ok {
+			for ; g < len(glyphs) && glyphs[g].StringIndex == i; g++ {
+				if removed[g] {
+					flushString()
+					adjustment -= glyphs[g].Advance / scale * 1000
+				} else {
+					flushAdjustment()
+					str = append(str, glyphs[g].Data...)
+				}
+			}
+			continue
+		}
+		if val, err := core.GetNumberAsFloat(core.TraceToDirectObject(elem)); err == nil {
+			flushString()
+			adjustment += val
+		}
+	}
+	flushString()
+	flushAdjustment()
+
+	if arr.Len() > 0 {
+		r.emit(&contentstream.ContentStreamOperation{Operand: "TJ", Params: []core.PdfObject{arr}})
+	}
+}
+
+// redactPath paints the current path object with the painting operation `paint`, removing the
+// painted areas within the redacted regions.
+func (r *contentRedactor) redactPath(paint *contentstream.ContentStreamOperation, gs contentstream.GraphicsState) {
+	path := r.path
+	r.path = nil
+
+	var construct, clip []*contentstream.ContentStreamOperation
+	for _, op := range path {
+		if op.Operand == "W" || op.Operand == "W*" {
+			clip = append(clip, op)
+		} else {
+			construct = append(construct, op)
+		}
+	}
+
+	bbox, ok := pathBBox(construct, r.pathCTM)
+	if paint.Operand == "n" || !ok {
+		// Nothing painted.
+		r.emit(path...)
+		r.emit(paint)
+		return
+	}
+
+	// Stroked paths extend by half the line width beyond the path.
+	margin := gs.LineWidth / 2 * maxScale(r.pathCTM)
+	bbox = model.PdfRectangle{Llx: bbox.Llx - margin, Lly: bbox.Lly - margin, Urx: bbox.Urx + margin, Ury: bbox.Ury + margin}
+
+	regions := r.intersecting(bbox)
+	if len(regions) == 0 {
+		r.emit(path...)
+		r.emit(paint)
+		return
+	}
+
+	if !r.covered(bbox) {
+		if inv, ok := r.pathCTM.Inverse(); ok {
+			r.emit(&contentstream.ContentStreamOperation{Operand: "q"})
+			r.emitExclusionClips(inv, regions, unionRect(bbox, exclusionArea(regions)))
+			r.emit(construct...)
+			r.emit(paint, &contentstream.ContentStreamOperation{Operand: "Q"})
+		}
+	}
+
+	if len(clip) > 0 {
+		// Keep the clipping effect of the path.
+		r.emit(construct...)
+		r.emit(clip...)
+		r.emit(&contentstream.ContentStreamOperation{Operand: "n"})
+	}
+}
+
+// emitExclusionClips adds clipping paths that exclude each of `regions` from the area `outer`.
+// Both are specified in default user space and mapped to the current user space with `inv`.
+func (r *contentRedactor) emitExclusionClips(inv contentstream.Matrix, regions []model.PdfRectangle, outer model.PdfRectangle) {
+	for _, region := range regions {
+		r.emitRectPath(inv, outer)
+		r.emitRectPath(inv, region)
+		r.emit(
+			&contentstream.ContentStreamOperation{Operand: "W*"},
+			&contentstream.ContentStreamOperation{Operand: "n"})
+	}
+}
+
+// emitRectPath adds a closed subpath of the rectangle `rect` mapped by `m`.
+func (r *contentRedactor) emitRectPath(m contentstream.Matrix, rect model.PdfRectangle) {
+	corners := [][2]float64{{rect.Llx, rect.Lly}, {rect.Urx, rect.Lly}, {rect.Urx, rect.Ury}, {rect.Llx, rect.Ury}}
+	for i, corner := range corners {
+		x, y := m.Transform(corner[0], corner[1])
+		operand := "l"
+		if i == 0 {
+			operand = "m"
+		}
+		r.emit(&contentstream.ContentStreamOperation{
+			Operand: operand,
+			Params:  []core.PdfObject{core.MakeFloat(x), core.MakeFloat(y)},
+		})
+	}
+	r.emit(&contentstream.ContentStreamOperation{Operand: "h"})
+}
+
+// redactXObject handles the Do operation `op`.  Images and forms intersecting the redacted regions
+// are replaced by redacted copies, or removed if fully covered or not redactable.
+func (r *contentRedactor) redactXObject(op *contentstream.ContentStreamOperation, ctm contentstream.Matrix) error {
+	if len(op.Params) != 1 {
+		return errors.New("Invalid parameters")
+	}
+	name, ok := core.GetName(op.Params[0])
+	if !ok {
+		return core.ErrTypeError
+	}
+
+	stream, xtype := r.resources.GetXObjectByName(*name)
+	switch xtype {
+	case model.XObjectTypeImage:
+		bbox := transformRect(ctm, unitRect)
+		regions := r.intersecting(bbox)
+		if len(regions) == 0 {
+			break
+		}
+		r.usage.replaced[*name] = true
+		if r.covered(bbox) {
+			return nil
+		}
+		redacted, err := redactImageStream(stream, ctm, regions)
+		if err != nil {
+			common.Log.Debug("Unable to redact image %s (%v) - removing", *name, err)
+			return nil
+		}
+		r.emitDo(r.addXObject("RdIm", redacted))
+		return nil
+
+	case model.XObjectTypeForm:
+		xform, err := model.NewXObjectFormFromStream(stream)
+		if err != nil {
+			return err
+		}
+		matrix := contentstream.IdentityMatrix()
+		if xform.Matrix != nil {
+			if arr, ok := core.GetArray(xform.Matrix); ok {
+				if vals, err := arr.ToFloat64Array(); err == nil && len(vals) == 6 {
+					matrix = contentstream.NewMatrix(vals[0], vals[1], vals[2], vals[3], vals[4], vals[5])
+				}
+			}
+		}
+		formCTM := matrix.Mult(ctm)
+
+		var regions []model.PdfRectangle
+		bbox, err := rectFromObject(xform.BBox)
+		if err != nil {
+			// Without a valid bounding box the form is treated as intersecting all the regions.
+			regions = r.regions
+		} else {
+			bbox = transformRect(formCTM, bbox)
+			regions = r.intersecting(bbox)
+			if len(regions) == 0 {
+				break
+			}
+			if r.covered(bbox) {
+				r.usage.replaced[*name] = true
+				return nil
+			}
+		}
+
+		r.usage.replaced[*name] = true
+		redacted, err := r.redactForm(stream, xform, formCTM)
+		if err != nil {
+			common.Log.Debug("Unable to redact form %s (%v) - removing", *name, err)
+			return nil
+		}
+		r.emitDo(r.addXObject("RdFm", redacted))
+		return nil
+	}
+
+	r.usage.used[*name] = true
+	r.emit(op)
+	return nil
+}
+
+// redactForm returns a redacted copy of the form XObject `stream` drawn with `formCTM`.
+func (r *contentRedactor) redactForm(stream *core.PdfObjectStream, xform *model.XObjectForm, formCTM contentstream.Matrix) (
+	*core.PdfObjectStream, error) {
+	if r.depth >= maxFormDepth {
+		return nil, errors.New("Form nesting too deep")
+	}
+	content, err := xform.GetContentStream()
+	if err != nil {
+		return nil, err
+	}
+
+	// Forms without resources use the resources of the page (deprecated, but permitted in PDF 1.1).
+	resources := r.resources
+	usage := r.usage
+	if xform.Resources != nil {
+		resources = copyResources(xform.Resources)
+		usage = newXObjectUsage()
+	}
+
+	sub := newContentRedactor(r.regions, resources, formCTM, usage, r.depth+1)
+	ops, err := sub.redact(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	redacted, err := core.MakeStream(ops.Bytes(), core.NewFlateEncoder())
+	if err != nil {
+		return nil, err
+	}
+	copyStreamDict(redacted.PdfObjectDictionary, stream.PdfObjectDictionary)
+	if xform.Resources != nil {
+		usage.removeUnused(resources)
+		redacted.PdfObjectDictionary.Set("Resources", resources.ToPdfObject())
+	}
+	return redacted, nil
+}
+
+// redactInlineImage handles the inline image operation `op`.
+func (r *contentRedactor) redactInlineImage(op *contentstream.ContentStreamOperation, ctm contentstream.Matrix) {
+	bbox := transformRect(ctm, unitRect)
+	regions := r.intersecting(bbox)
+	if len(regions) == 0 {
+		r.emit(op)
+		return
+	}
+	if r.covered(bbox) || len(op.Params) != 1 {
+		return
+	}
+	iimg, ok := op.Params[0].(*contentstream.ContentStreamInlineImage)
+	if !ok {
+		return
+	}
+
+	redacted, err := redactInlineImage(iimg, r.resources, ctm, regions)
+	if err != nil {
+		common.Log.Debug("Unable to redact inline image (%v) - removing", err)
+		return
+	}
+	r.emit(&contentstream.ContentStreamOperation{Operand: "BI", Params: []core.PdfObject{redacted}})
+}
+
+// emitDo adds a Do operation drawing the XObject `name`.
+func (r *contentRedactor) emitDo(name core.PdfObjectName) {
+	r.usage.used[name] = true
+	r.emit(&contentstream.ContentStreamOperation{Operand: "Do", Params: []core.PdfObject{core.MakeName(string(name))}})
+}
+
+// addXObject adds `stream` to the XObject resources under an unused name starting with `prefix`
+// and returns the name.
+func (r *contentRedactor) addXObject(prefix string, stream *core.PdfObjectStream) core.PdfObjectName {
+	name := uniqueName(prefix, r.resources.HasXObjectByName)
+	r.resources.SetXObjectByName(name, stream)
+	return name
+}
+
+// intersecting returns the redacted regions that intersect `bbox`.
+func (r *contentRedactor) intersecting(bbox model.PdfRectangle) []model.PdfRectangle {
+	var regions []model.PdfRectangle
+	for _, region := range r.regions {
+		if intersects(region, bbox) {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// covered returns true if `bbox` is within one of the redacted regions.
+func (r *contentRedactor) covered(bbox model.PdfRectangle) bool {
+	for _, region := range r.regions {
+		if contains(region, bbox) {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueName returns the first name `prefix`1, `prefix`2, ... for which `has` returns false.
+func uniqueName(prefix string, has func(core.PdfObjectName) bool) core.PdfObjectName {
+	for i := 1; ; i++ {
+		name := core.PdfObjectName(fmt.Sprintf("%s%d", prefix, i))
+		if !has(name) {
+			return name
+		}
+	}
+}
+
+// copyResources returns a copy of `resources` with copies of the XObject and Font dictionaries,
+// which can be modified without affecting other users of `resources`.
+func copyResources(resources *model.PdfPageResources) *model.PdfPageResources {
+	dup := model.NewPdfPageResources()
+	dup.ExtGState = resources.ExtGState
+	dup.ColorSpace = resources.ColorSpace
+	dup.Pattern = resources.Pattern
+	dup.Shading = resources.Shading
+	dup.ProcSet = resources.ProcSet
+	dup.Properties = resources.Properties
+	dup.XObject = copyDict(resources.XObject)
+	dup.Font = copyDict(resources.Font)
+	return dup
+}
+
+// copyDict returns a shallow copy of the dictionary `obj`, or nil if `obj` is not a dictionary.
+func copyDict(obj core.PdfObject) core.PdfObject {
+	dict, ok := core.GetDict(obj)
+	if !ok {
+		return nil
+	}
+	dup := core.MakeDict()
+	dup.Merge(dict)
+	return dup
+}
+
+// copyStreamDict copies the entries of stream dictionary `src` to `dst`, except for the entries
+// describing the stream encoding.
+func copyStreamDict(dst, src *core.PdfObjectDictionary) {
+	for _, key := range src.Keys() {
+		switch key {
+		case "Length", "Filter", "DecodeParms", "F", "FFilter", "FDecodeParms", "DL":
+			continue
+		}
+		dst.Set(key, src.Get(key))
+	}
+}
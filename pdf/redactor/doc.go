@@ -0,0 +1,14 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+// Package redactor provides redaction of page content (section 12.5.6.23 Redaction Annotations).
+//
+// Redaction removes the content within the redacted regions of a page rather than covering it:
+// text glyphs are removed from the text showing operations, image samples are blanked, vector
+// paths are dropped or clipped out, and Form XObjects are redacted recursively.
+//
+// ApplyRedactions applies the redact annotations of a page, drawing their overlay appearance and
+// removing the annotations.  RedactRegions redacts arbitrary regions without drawing an overlay.
+package redactor
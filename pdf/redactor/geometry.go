@@ -0,0 +1,123 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package redactor
+
+import (
+	"errors"
+	"math"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// unitRect is the unit square onto which images are mapped in user space.
+var unitRect = model.PdfRectangle{Llx: 0, Lly: 0, Urx: 1, Ury: 1}
+
+// pointsBBox returns the bounding box of the points `vals` given as x1 y1 x2 y2 ...
+func pointsBBox(vals []float64) model.PdfRectangle {
+	bbox := model.PdfRectangle{Llx: vals[0], Lly: vals[1], Urx: vals[0], Ury: vals[1]}
+	for i := 2; i+1 < len(vals); i += 2 {
+		bbox.Llx = math.Min(bbox.Llx, vals[i])
+		bbox.Urx = math.Max(bbox.Urx, vals[i])
+		bbox.Lly = math.Min(bbox.Lly, vals[i+1])
+		bbox.Ury = math.Max(bbox.Ury, vals[i+1])
+	}
+	return bbox
+}
+
+// transformRect returns the bounding box of `rect` mapped by `m`.
+func transformRect(m contentstream.Matrix, rect model.PdfRectangle) model.PdfRectangle {
+	x1, y1 := m.Transform(rect.Llx, rect.Lly)
+	x2, y2 := m.Transform(rect.Urx, rect.Lly)
+	x3, y3 := m.Transform(rect.Urx, rect.Ury)
+	x4, y4 := m.Transform(rect.Llx, rect.Ury)
+	return pointsBBox([]float64{x1, y1, x2, y2, x3, y3, x4, y4})
+}
+
+// intersects returns true if the rectangles `a` and `b` overlap with a positive area.
+func intersects(a, b model.PdfRectangle) bool {
+	return a.Llx < b.Urx && b.Llx < a.Urx && a.Lly < b.Ury && b.Lly < a.Ury
+}
+
+// contains returns true if `inner` is within `outer`.
+func contains(outer, inner model.PdfRectangle) bool {
+	return outer.Llx <= inner.Llx && inner.Urx <= outer.Urx && outer.Lly <= inner.Lly && inner.Ury <= outer.Ury
+}
+
+// unionRect returns the bounding box of rectangles `a` and `b`.
+func unionRect(a, b model.PdfRectangle) model.PdfRectangle {
+	return model.PdfRectangle{
+		Llx: math.Min(a.Llx, b.Llx),
+		Lly: math.Min(a.Lly, b.Lly),
+		Urx: math.Max(a.Urx, b.Urx),
+		Ury: math.Max(a.Ury, b.Ury),
+	}
+}
+
+// exclusionArea returns the area outside of `regions` that is kept visible by exclusion clipping
+// paths.
+func exclusionArea(regions []model.PdfRectangle) model.PdfRectangle {
+	area := regions[0]
+	for _, region := range regions[1:] {
+		area = unionRect(area, region)
+	}
+	area.Llx -= exclusionClipExtent
+	area.Lly -= exclusionClipExtent
+	area.Urx += exclusionClipExtent
+	area.Ury += exclusionClipExtent
+	return area
+}
+
+// maxScale returns the largest scaling factor of `m`.
+func maxScale(m contentstream.Matrix) float64 {
+	return math.Max(math.Abs(m.ScalingFactorX()), math.Abs(m.ScalingFactorY()))
+}
+
+// rectFromObject returns the rectangle represented by the array `obj`, normalized so that the
+// lower left corner comes first.
+func rectFromObject(obj core.PdfObject) (model.PdfRectangle, error) {
+	arr, ok := core.GetArray(obj)
+	if !ok {
+		return model.PdfRectangle{}, errors.New("Rectangle missing")
+	}
+	vals, err := arr.ToFloat64Array()
+	if err != nil {
+		return model.PdfRectangle{}, err
+	}
+	if len(vals) != 4 {
+		return model.PdfRectangle{}, errors.New("Invalid rectangle")
+	}
+	return pointsBBox(vals), nil
+}
+
+// pathBBox returns the default user space bounding box of the path constructed by `ops` in the
+// user space mapped by `ctm`.  The control points of Bézier curves are included, so the bounding
+// box may exceed the path.  Returns false if the path has no points.
+func pathBBox(ops []*contentstream.ContentStreamOperation, ctm contentstream.Matrix) (model.PdfRectangle, bool) {
+	var points []float64
+	for _, op := range ops {
+		vals, err := core.GetNumbersAsFloat(op.Params)
+		if err != nil {
+			continue
+		}
+		if op.Operand == "re" {
+			if len(vals) != 4 {
+				continue
+			}
+			x, y, w, h := vals[0], vals[1], vals[2], vals[3]
+			vals = []float64{x, y, x + w, y, x + w, y + h, x, y + h}
+		}
+		for i := 0; i+1 < len(vals); i += 2 {
+			x, y := ctm.Transform(vals[i], vals[i+1])
+			points = append(points, x, y)
+		}
+	}
+	if len(points) == 0 {
+		return model.PdfRectangle{}, false
+	}
+	return pointsBBox(points), true
+}
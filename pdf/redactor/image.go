@@ -0,0 +1,202 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package redactor
+
+import (
+	"errors"
+	"math"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// redactImageStream returns a copy of the image XObject `stream`, drawn with `ctm`, with the samples
+// within `regions` blanked.  The soft mask of the image, if any, is redacted likewise so that the
+// blanked samples are transparent.
+func redactImageStream(stream *core.PdfObjectStream, ctm contentstream.Matrix, regions []model.PdfRectangle) (
+	*core.PdfObjectStream, error) {
+	dict := stream.PdfObjectDictionary
+
+	width, ok := core.GetIntVal(dict.Get("Width"))
+	if !ok {
+		return nil, errors.New("Width missing")
+	}
+	height, ok := core.GetIntVal(dict.Get("Height"))
+	if !ok {
+		return nil, errors.New("Height missing")
+	}
+
+	isMask, _ := core.GetBoolVal(dict.Get("ImageMask"))
+	bpc, components := 1, 1
+	if !isMask {
+		bpc, ok = core.GetIntVal(dict.Get("BitsPerComponent"))
+		if !ok {
+			return nil, errors.New("Bits per component missing")
+		}
+		csObj := dict.Get("ColorSpace")
+		if csObj == nil {
+			return nil, errors.New("Colorspace missing")
+		}
+		cs, err := model.NewPdfColorspaceFromPdfObject(csObj)
+		if err != nil {
+			return nil, err
+		}
+		components = cs.GetNumComponents()
+	}
+
+	data, err := core.DecodeStream(stream)
+	if err != nil {
+		return nil, err
+	}
+	err = blankSamples(data, width, height, bpc, components, blankSampleValue(isMask, dict.Get("Decode")), ctm, regions)
+	if err != nil {
+		return nil, err
+	}
+
+	redacted, err := core.MakeStream(data, core.NewFlateEncoder())
+	if err != nil {
+		return nil, err
+	}
+	copyStreamDict(redacted.PdfObjectDictionary, dict)
+
+	if smask, ok := core.GetStream(dict.Get("SMask")); ok {
+		redactedMask, err := redactImageStream(smask, ctm, regions)
+		if err != nil {
+			return nil, err
+		}
+		redacted.PdfObjectDictionary.Set("SMask", redactedMask)
+	}
+	return redacted, nil
+}
+
+// redactInlineImage returns a copy of the inline image `iimg`, drawn with `ctm`, with the samples
+// within `regions` blanked.
+func redactInlineImage(iimg *contentstream.ContentStreamInlineImage, resources *model.PdfPageResources,
+	ctm contentstream.Matrix, regions []model.PdfRectangle) (*contentstream.ContentStreamInlineImage, error) {
+	img, err := iimg.ToImage(resources)
+	if err != nil {
+		return nil, err
+	}
+	isMask, err := iimg.IsMask()
+	if err != nil {
+		return nil, err
+	}
+
+	err = blankSamples(img.Data, int(img.Width), int(img.Height), int(img.BitsPerComponent), img.ColorComponents,
+		blankSampleValue(isMask, iimg.Decode), ctm, regions)
+	if err != nil {
+		return nil, err
+	}
+
+	redacted, err := contentstream.NewInlineImageFromImage(*img, core.NewFlateEncoder())
+	if err != nil {
+		return nil, err
+	}
+	if isMask {
+		redacted.ColorSpace = nil
+		redacted.ImageMask = iimg.ImageMask
+	} else {
+		redacted.ColorSpace = iimg.ColorSpace
+	}
+	redacted.Decode = iimg.Decode
+	redacted.Intent = iimg.Intent
+	redacted.Interpolate = iimg.Interpolate
+	return redacted, nil
+}
+
+// blankSampleValue returns the sample value used for blanking.  Image mask samples are set to
+// the value that is not painted, other samples are set to 0.
+func blankSampleValue(isMask bool, decode core.PdfObject) uint32 {
+	if !isMask {
+		return 0
+	}
+	// With the default decode array [0 1], sample value 1 is masked out.
+	if arr, ok := core.GetArray(decode); ok {
+		if vals, err := arr.ToFloat64Array(); err == nil && len(vals) == 2 && vals[0] == 1 {
+			return 0
+		}
+	}
+	return 1
+}
+
+// blankSamples sets all the components of the samples of the `width` x `height` image `data`,
+// drawn with `ctm`, which are within `regions` to `value`.  The image data is stored row by row
+// starting with the top row, with each row padded to a whole number of bytes.
+func blankSamples(data []byte, width, height, bpc, components int, value uint32, ctm contentstream.Matrix,
+	regions []model.PdfRectangle) error {
+	switch bpc {
+	case 1, 2, 4, 8, 16:
+	default:
+		return errors.New("Unsupported bits per component")
+	}
+	if width <= 0 || height <= 0 {
+		return errors.New("Invalid image size")
+	}
+	rowBytes := (width*components*bpc + 7) / 8
+	if len(data) < rowBytes*height {
+		common.Log.Debug("ERROR: Image data too short (%d < %d)", len(data), rowBytes*height)
+		return errors.New("Image data too short")
+	}
+	inv, ok := ctm.Inverse()
+	if !ok {
+		return errors.New("Image matrix not invertible")
+	}
+
+	for _, region := range regions {
+		// Range of samples that may intersect the region.
+		area := transformRect(inv, region)
+		i0 := clampInt(int(math.Floor(area.Llx*float64(width))), 0, width)
+		i1 := clampInt(int(math.Ceil(area.Urx*float64(width))), 0, width)
+		j0 := clampInt(int(math.Floor((1-area.Ury)*float64(height))), 0, height)
+		j1 := clampInt(int(math.Ceil((1-area.Lly)*float64(height))), 0, height)
+
+		for j := j0; j < j1; j++ {
+			for i := i0; i < i1; i++ {
+				sample := model.PdfRectangle{
+					Llx: float64(i) / float64(width),
+					Lly: 1 - float64(j+1)/float64(height),
+					Urx: float64(i+1) / float64(width),
+					Ury: 1 - float64(j)/float64(height),
+				}
+				if !intersects(region, transformRect(ctm, sample)) {
+					continue
+				}
+				for c := 0; c < components; c++ {
+					setSample(data[j*rowBytes:(j+1)*rowBytes], i*components+c, bpc, value)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// setSample sets sample `index` of the image row `row` with `bpc` bits per sample to `value`.
+func setSample(row []byte, index, bpc int, value uint32) {
+	switch bpc {
+	case 8:
+		row[index] = byte(value)
+	case 16:
+		row[2*index] = byte(value >> 8)
+		row[2*index+1] = byte(value)
+	default:
+		bit := index * bpc
+		shift := uint(8 - bpc - bit%8)
+		mask := byte((1<<uint(bpc))-1) << shift
+		row[bit/8] = row[bit/8]&^mask | byte(value)<<shift&mask
+	}
+}
+
+func clampInt(val, min, max int) int {
+	if val < min {
+		return min
+	}
+	if val > max {
+		return max
+	}
+	return val
+}
@@ -0,0 +1,230 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package redactor
+
+import (
+	"math"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Font size used for overlay text when the default appearance does not specify one (auto size),
+// and the space between repeated overlay texts in units of the font size.
+const (
+	overlayMaxAutoFontSize = 12.0
+	overlayRepeatSpacing   = 1.0
+)
+
+// drawOverlay draws the overlay appearance of the redact annotation `redact` over `regions` with
+// `cc`, adding the required resources to `resources`: the RO form XObject scaled to each region if
+// specified, otherwise the regions filled with the interior color IC and the overlay text.
+func drawOverlay(cc *contentstream.ContentCreator, resources *model.PdfPageResources, redact *model.PdfAnnotationRedact,
+	regions []model.PdfRectangle) error {
+	if stream, ok := core.GetStream(redact.RO); ok {
+		return drawOverlayForm(cc, resources, stream, regions)
+	}
+
+	if color, ok := overlayInteriorColor(redact.IC); ok {
+		cc.Add_q()
+		*cc.Operations() = append(*cc.Operations(), color)
+		for _, region := range regions {
+			cc.Add_re(region.Llx, region.Lly, region.Urx-region.Llx, region.Ury-region.Lly)
+		}
+		cc.Add_f()
+		cc.Add_Q()
+	}
+
+	text, ok := core.GetString(redact.OverlayText)
+	if !ok || len(text.Decoded()) == 0 {
+		return nil
+	}
+	return drawOverlayText(cc, resources, redact, text.Decoded(), regions)
+}
+
+// drawOverlayForm draws the overlay form XObject `stream` scaled to each of `regions`.
+func drawOverlayForm(cc *contentstream.ContentCreator, resources *model.PdfPageResources, stream *core.PdfObjectStream,
+	regions []model.PdfRectangle) error {
+	xform, err := model.NewXObjectFormFromStream(stream)
+	if err != nil {
+		return err
+	}
+	bbox, err := rectFromObject(xform.BBox)
+	if err != nil {
+		return err
+	}
+	if arr, ok := core.GetArray(xform.Matrix); ok {
+		if vals, err := arr.ToFloat64Array(); err == nil && len(vals) == 6 {
+			bbox = transformRect(contentstream.NewMatrix(vals[0], vals[1], vals[2], vals[3], vals[4], vals[5]), bbox)
+		}
+	}
+	if bbox.Urx-bbox.Llx == 0 || bbox.Ury-bbox.Lly == 0 {
+		common.Log.Debug("Empty overlay form bounding box - ignoring")
+		return nil
+	}
+
+	name := uniqueName("RdOv", resources.HasXObjectByName)
+	err = resources.SetXObjectByName(name, stream)
+	if err != nil {
+		return err
+	}
+	for _, region := range regions {
+		sx := (region.Urx - region.Llx) / (bbox.Urx - bbox.Llx)
+		sy := (region.Ury - region.Lly) / (bbox.Ury - bbox.Lly)
+		cc.Add_q()
+		cc.Add_cm(sx, 0, 0, sy, region.Llx-bbox.Llx*sx, region.Lly-bbox.Lly*sy)
+		cc.Add_Do(name)
+		cc.Add_Q()
+	}
+	return nil
+}
+
+// drawOverlayText draws the overlay `text` in each of `regions` with the font size, color and
+// alignment specified by the DA and Q entries of `redact`, repeated to fill the regions if Repeat
+// is true.  The text is drawn with Helvetica, which is added to `resources`.
+func drawOverlayText(cc *contentstream.ContentCreator, resources *model.PdfPageResources, redact *model.PdfAnnotationRedact,
+	text string, regions []model.PdfRectangle) error {
+	font, err := model.NewStandard14Font("Helvetica")
+	if err != nil {
+		return err
+	}
+	fontName := uniqueName("RdF", resources.HasFontByName)
+	err = resources.SetFontByName(fontName, font.ToPdfObject())
+	if err != nil {
+		return err
+	}
+
+	encoded := text
+	if encoder := font.Encoder(); encoder != nil {
+		encoded = encoder.Encode(text)
+	}
+	width := 0.0
+	for _, b := range []byte(encoded) {
+		if metrics, found := font.GetCharMetrics(uint16(b)); found {
+			width += metrics.Wx / 1000
+		}
+	}
+
+	fontSize, colorOps := parseOverlayAppearance(redact.DA)
+	quadding, _ := core.GetIntVal(redact.Q)
+	repeat, _ := core.GetBoolVal(redact.Repeat)
+
+	for _, region := range regions {
+		w, h := region.Urx-region.Llx, region.Ury-region.Lly
+		size := fontSize
+		if size <= 0 {
+			// Auto size: fit the text within the region.
+			size = math.Min(overlayMaxAutoFontSize, h)
+			if !repeat && width > 0 {
+				size = math.Min(size, w/width)
+			}
+		}
+		textWidth := width * size
+		if size <= 0 || textWidth <= 0 {
+			continue
+		}
+
+		cc.Add_q()
+		cc.Add_re(region.Llx, region.Lly, w, h).Add_W().Add_n()
+		*cc.Operations() = append(*cc.Operations(), colorOps...)
+		cc.Add_BT()
+		cc.Add_Tf(fontName, size)
+
+		// Positions of the text baselines.
+		var positions [][2]float64
+		if repeat {
+			step := textWidth + overlayRepeatSpacing*size
+			for y := region.Ury - size; y > region.Lly-size; y -= size {
+				for x := region.Llx; x < region.Urx; x += step {
+					positions = append(positions, [2]float64{x, y})
+				}
+			}
+		} else {
+			x := region.Llx
+			switch quadding {
+			case 1:
+				x += (w - textWidth) / 2
+			case 2:
+				x += w - textWidth
+			}
+			// Vertically centered, with the descent assumed to be 20% of the font size.
+			y := region.Lly + (h-size)/2 + 0.2*size
+			positions = append(positions, [2]float64{x, y})
+		}
+		for _, pos := range positions {
+			cc.Add_Tm(1, 0, 0, 1, pos[0], pos[1])
+			cc.Add_Tj(*core.MakeString(encoded))
+		}
+
+		cc.Add_ET()
+		cc.Add_Q()
+	}
+	return nil
+}
+
+// parseOverlayAppearance returns the font size and the color operations of the default appearance
+// string `da`.  The font size is 0 (auto size) if not specified.
+func parseOverlayAppearance(da core.PdfObject) (float64, []*contentstream.ContentStreamOperation) {
+	fontSize := 0.0
+	ops := []*contentstream.ContentStreamOperation{}
+
+	str, ok := core.GetStringVal(da)
+	if !ok {
+		return fontSize, ops
+	}
+	operations, err := contentstream.NewContentStreamParser(str).Parse()
+	if err != nil {
+		common.Log.Debug("ERROR: Invalid DA: %s (%v)", str, err)
+		return fontSize, ops
+	}
+	for _, op := range *operations {
+		switch op.Operand {
+		case "Tf":
+			if len(op.Params) == 2 {
+				if size, err := core.GetNumberAsFloat(op.Params[1]); err == nil {
+					fontSize = size
+				}
+			}
+		case "g", "rg", "k":
+			ops = append(ops, op)
+		}
+	}
+	return fontSize, ops
+}
+
+// overlayInteriorColor returns the color operation setting the fill color to the interior color
+// array `ic`.  Returns false if no interior color is specified, in which case the regions are not
+// filled.
+func overlayInteriorColor(ic core.PdfObject) (*contentstream.ContentStreamOperation, bool) {
+	arr, ok := core.GetArray(ic)
+	if !ok {
+		return nil, false
+	}
+	vals, err := arr.ToFloat64Array()
+	if err != nil {
+		common.Log.Debug("ERROR: Invalid IC: %v", err)
+		return nil, false
+	}
+
+	operand := ""
+	switch len(vals) {
+	case 1:
+		operand = "g"
+	case 3:
+		operand = "rg"
+	case 4:
+		operand = "k"
+	default:
+		return nil, false
+	}
+	params := []core.PdfObject{}
+	for _, val := range vals {
+		params = append(params, core.MakeFloat(val))
+	}
+	return &contentstream.ContentStreamOperation{Operand: operand, Params: params}, true
+}
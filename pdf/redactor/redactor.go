@@ -0,0 +1,170 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package redactor
+
+import (
+	"errors"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// RedactRegions removes the content of `page` that lies within `regions`, specified in the default
+// user space of the page.  The page resources are replaced with a copy, so that resources shared
+// with other pages are not modified, and XObjects that are no longer used by the page are removed
+// from it.
+func RedactRegions(page *model.PdfPage, regions []model.PdfRectangle) error {
+	if len(regions) == 0 {
+		return nil
+	}
+	ops, err := redactPageContent(page, regions)
+	if err != nil {
+		return err
+	}
+	return page.SetContentStreams([]string{string(ops.Bytes())}, core.NewFlateEncoder())
+}
+
+// ApplyRedactions applies the redact annotations of `page`: the content within the regions
+// specified by their QuadPoints (or Rect if no QuadPoints are specified) is removed, the overlay
+// appearance of the annotations is drawn in its place and the annotations are removed from the
+// page along with their popups.
+func ApplyRedactions(page *model.PdfPage) error {
+	redactions := []*model.PdfAnnotationRedact{}
+	for _, annot := range page.Annotations {
+		if redact, ok := annot.GetContext().(*model.PdfAnnotationRedact); ok {
+			redactions = append(redactions, redact)
+		}
+	}
+	if len(redactions) == 0 {
+		return nil
+	}
+
+	regions := []model.PdfRectangle{}
+	annotRegions := make([][]model.PdfRectangle, len(redactions))
+	for i, redact := range redactions {
+		rects, err := RedactAnnotationRegions(redact)
+		if err != nil {
+			return err
+		}
+		annotRegions[i] = rects
+		regions = append(regions, rects...)
+	}
+
+	ops, err := redactPageContent(page, regions)
+	if err != nil {
+		return err
+	}
+
+	cc := contentstream.NewContentCreator()
+	*cc.Operations() = *ops
+	for i, redact := range redactions {
+		err := drawOverlay(cc, page.Resources, redact, annotRegions[i])
+		if err != nil {
+			return err
+		}
+	}
+	err = page.SetContentStreams([]string{string(cc.Bytes())}, core.NewFlateEncoder())
+	if err != nil {
+		return err
+	}
+
+	page.Annotations = removeRedactAnnotations(page.Annotations, redactions)
+	return nil
+}
+
+// RedactAnnotationRegions returns the regions covered by the redact annotation `redact`: the
+// bounding boxes of its QuadPoints quadrilaterals, or its Rect if QuadPoints is not specified.
+func RedactAnnotationRegions(redact *model.PdfAnnotationRedact) ([]model.PdfRectangle, error) {
+	if redact.QuadPoints != nil {
+		arr, ok := core.GetArray(redact.QuadPoints)
+		if !ok {
+			return nil, core.ErrTypeError
+		}
+		vals, err := arr.ToFloat64Array()
+		if err != nil {
+			return nil, err
+		}
+		if len(vals) == 0 || len(vals)%8 != 0 {
+			common.Log.Debug("ERROR: Invalid QuadPoints length %d", len(vals))
+			return nil, errors.New("Invalid QuadPoints")
+		}
+		regions := []model.PdfRectangle{}
+		for i := 0; i < len(vals); i += 8 {
+			regions = append(regions, pointsBBox(vals[i:i+8]))
+		}
+		return regions, nil
+	}
+
+	arr, ok := core.GetArray(redact.Rect)
+	if !ok {
+		return nil, errors.New("Redact annotation Rect missing")
+	}
+	vals, err := arr.ToFloat64Array()
+	if err != nil {
+		return nil, err
+	}
+	if len(vals) != 4 {
+		return nil, errors.New("Invalid Rect")
+	}
+	return []model.PdfRectangle{pointsBBox(vals)}, nil
+}
+
+// redactPageContent returns the content stream operations of `page` with the content within
+// `regions` removed, wrapped within q ... Q.  The page resources are replaced with the copy used
+// by the redacted content.
+func redactPageContent(page *model.PdfPage, regions []model.PdfRectangle) (*contentstream.ContentStreamOperations, error) {
+	contents, err := page.GetAllContentStreams()
+	if err != nil {
+		return nil, err
+	}
+
+	resources := model.NewPdfPageResources()
+	if page.Resources != nil {
+		resources = copyResources(page.Resources)
+	}
+	page.Resources = resources
+
+	r := newContentRedactor(regions, resources, contentstream.IdentityMatrix(), newXObjectUsage(), 0)
+	ops, err := r.redact(contents)
+	if err != nil {
+		return nil, err
+	}
+	r.usage.removeUnused(resources)
+
+	return ops.WrapIfNeeded(), nil
+}
+
+// removeRedactAnnotations returns `annotations` without the annotations in `redactions` and
+// their popups.
+func removeRedactAnnotations(annotations []*model.PdfAnnotation, redactions []*model.PdfAnnotationRedact) []*model.PdfAnnotation {
+	removed := map[core.PdfObject]bool{}
+	for _, redact := range redactions {
+		if container := redact.GetContainingPdfObject(); container != nil {
+			removed[container] = true
+		}
+		if redact.Popup != nil && redact.Popup.PdfAnnotation != nil {
+			if container := redact.Popup.GetContainingPdfObject(); container != nil {
+				removed[container] = true
+			}
+		}
+	}
+
+	kept := []*model.PdfAnnotation{}
+	for _, annot := range annotations {
+		if removed[annot.GetContainingPdfObject()] {
+			continue
+		}
+		if popup, ok := annot.GetContext().(*model.PdfAnnotationPopup); ok && popup.Parent != nil {
+			if removed[popup.Parent] {
+				continue
+			}
+		}
+		kept = append(kept, annot)
+	}
+	return kept
+}
@@ -0,0 +1,178 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package redactor
+
+import (
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// makeTestPage returns a page with a line of text, a filled rectangle and an 8x8 white image.
+func makeTestPage(t *testing.T) *model.PdfPage {
+	page := model.NewPdfPage()
+	page.MediaBox = &model.PdfRectangle{Llx: 0, Lly: 0, Urx: 612, Ury: 792}
+	page.Resources = model.NewPdfPageResources()
+
+	font, err := model.NewStandard14Font("Helvetica")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	page.Resources.SetFontByName("F1", font.ToPdfObject())
+
+	data := make([]byte, 64)
+	for i := range data {
+		data[i] = 255
+	}
+	img := &model.Image{Width: 8, Height: 8, BitsPerComponent: 8, ColorComponents: 1, Data: data}
+	ximg, err := model.NewXObjectImageFromImage(img, nil, core.NewFlateEncoder())
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	page.Resources.SetXObjectImageByName("Im1", ximg)
+
+	content := `BT /F1 10 Tf 100 700 Td (Hello World) Tj ET
+0 0 1 rg 100 500 200 50 re f
+q 80 0 0 80 100 300 cm /Im1 Do Q
+`
+	err = page.SetContentStreams([]string{content}, nil)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return page
+}
+
+func getPageOperations(t *testing.T, page *model.PdfPage) contentstream.ContentStreamOperations {
+	content, err := page.GetAllContentStreams()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	ops, err := contentstream.NewContentStreamParser(content).Parse()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return *ops
+}
+
+func TestRedactRegions(t *testing.T) {
+	page := makeTestPage(t)
+
+	regions := []model.PdfRectangle{
+		{Llx: 126, Lly: 695, Urx: 200, Ury: 712}, // "World"
+		{Llx: 150, Lly: 510, Urx: 160, Ury: 520}, // Inside the rectangle.
+		{Llx: 140, Lly: 290, Urx: 200, Ury: 390}, // Right half of the image.
+	}
+	err := RedactRegions(page, regions)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var text []core.PdfObject
+	clips := 0
+	var imageName core.PdfObjectName
+	for _, op := range getPageOperations(t, page) {
+		switch op.Operand {
+		case "Tj":
+			t.Fatalf("Unexpected Tj: %s", op.Params[0])
+		case "TJ":
+			arr, _ := core.GetArray(op.Params[0])
+			text = arr.Elements()
+		case "W*":
+			clips++
+		case "Do":
+			name, _ := core.GetName(op.Params[0])
+			imageName = *name
+		}
+	}
+
+	// The removed glyphs are replaced by a position adjustment.
+	if len(text) != 2 {
+		t.Fatalf("Unexpected redacted text: %v", text)
+	}
+	if str, _ := core.GetStringVal(text[0]); str != "Hello " {
+		t.Errorf("Kept text: got %q, expected \"Hello \"", str)
+	}
+	// Width of World in Helvetica: 944 + 556 + 333 + 222 + 556.
+	if adj, _ := core.GetNumberAsFloat(text[1]); adj != -2611 {
+		t.Errorf("Adjustment: got %v, expected -2611", adj)
+	}
+
+	if clips != 1 {
+		t.Errorf("Expected 1 exclusion clip for the rectangle, got %d", clips)
+	}
+
+	if imageName == "" || imageName == "Im1" {
+		t.Fatalf("Image not replaced (%q)", imageName)
+	}
+	if page.Resources.HasXObjectByName("Im1") {
+		t.Errorf("Unredacted image still in page resources")
+	}
+	ximg, err := page.Resources.GetXObjectImageByName(imageName)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	img, err := ximg.ToImage()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	// Image samples span 10 units: columns 4-7 are within the region.
+	for j := 0; j < 8; j++ {
+		for i := 0; i < 8; i++ {
+			expected := byte(255)
+			if i >= 4 {
+				expected = 0
+			}
+			if img.Data[j*8+i] != expected {
+				t.Fatalf("Sample (%d, %d): got %d, expected %d", i, j, img.Data[j*8+i], expected)
+			}
+		}
+	}
+}
+
+func TestApplyRedactions(t *testing.T) {
+	page := makeTestPage(t)
+
+	redact := model.NewPdfAnnotationRedact()
+	redact.Rect = core.MakeArrayFromFloats([]float64{90, 690, 260, 715})
+	redact.IC = core.MakeArrayFromFloats([]float64{0, 0, 0})
+	redact.OverlayText = core.MakeString("REDACTED")
+	page.Annotations = append(page.Annotations, redact.PdfAnnotation)
+
+	err := ApplyRedactions(page)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(page.Annotations) != 0 {
+		t.Errorf("Redact annotation not removed")
+	}
+
+	filled := false
+	for _, op := range getPageOperations(t, page) {
+		switch op.Operand {
+		case "TJ":
+			arr, _ := core.GetArray(op.Params[0])
+			for _, elem := range arr.Elements() {
+				if _, isString := core.GetString(elem); isString {
+					t.Errorf("Text not removed: %s", arr)
+				}
+			}
+		case "Tj":
+			if str, _ := core.GetStringVal(op.Params[0]); str != "REDACTED" {
+				t.Errorf("Unexpected text: %q", str)
+			}
+		case "re":
+			vals, _ := core.GetNumbersAsFloat(op.Params)
+			if len(vals) == 4 && vals[0] == 90 && vals[1] == 690 {
+				filled = true
+			}
+		}
+	}
+	if !filled {
+		t.Errorf("Overlay not drawn")
+	}
+}
@@ -0,0 +1,15 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+// Package validator checks documents for conformance to PDF/A (ISO 19005) and PDF/UA
+// (ISO 14289), e.g. before archiving incoming files.
+//
+// Validate walks the objects of a document loaded by a model.PdfReader and reports the violations
+// found, each with the rule it breaks and the reference of the object at fault.  The checks cover
+// the common causes of non-conformance (unembedded fonts, device colors without an output intent,
+// transparency in PDF/A-1, missing metadata, encryption, forbidden filters and actions, untagged
+// content, figures without alternate descriptions, ...) rather than the complete standards, so
+// that a document without violations is not necessarily conforming.
+package validator
@@ -0,0 +1,324 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package validator
+
+import (
+	"fmt"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Actions not allowed in PDF/A documents, with the first level they are not allowed at.
+var forbiddenActions = map[string]model.PdfAConformance{
+	"Launch":      model.PdfA1B,
+	"Sound":       model.PdfA1B,
+	"Movie":       model.PdfA1B,
+	"ResetForm":   model.PdfA1B,
+	"ImportData":  model.PdfA1B,
+	"Hide":        model.PdfA2B,
+	"SetOCGState": model.PdfA2B,
+	"Rendition":   model.PdfA2B,
+	"Trans":       model.PdfA2B,
+	"GoTo3DView":  model.PdfA2B,
+}
+
+// Annotation types not allowed in PDF/A documents, with the first level they are not allowed at.
+var forbiddenAnnotations = map[string]model.PdfAConformance{
+	"Sound":     model.PdfA1B,
+	"Movie":     model.PdfA1B,
+	"Screen":    model.PdfA1B,
+	"3D":        model.PdfA1B,
+	"RichMedia": model.PdfA2B,
+}
+
+// checkPdfADocument checks the trailer and the document level entries of the catalog.
+func (v *validator) checkPdfADocument(trailer *core.PdfObjectDictionary) error {
+	level := v.opts.PdfA
+	if trailer.Get("Encrypt") != nil {
+		v.report(RuleEncryption, core.PdfObjectReference{}, "Document is encrypted")
+	}
+	if ids, ok := core.GetArray(trailer.Get("ID")); !ok || ids.Len() != 2 {
+		v.report(RuleFileID, core.PdfObjectReference{}, "Missing file identifiers")
+	}
+
+	// Metadata and identification.
+	if _, ok := v.resolve(v.catalog.Get("Metadata")).(*core.PdfObjectStream); !ok {
+		v.report(RuleMetadata, v.catalogRef, "Missing XMP metadata")
+	} else {
+		xmp, err := v.reader.GetXMPMetadata()
+		if err != nil || xmp == nil {
+			v.report(RuleMetadata, v.catalogRef, "Invalid XMP metadata")
+		} else {
+			part, _ := xmp.GetProperty(model.XMPNamespacePDFAID, "part")
+			conformance, _ := xmp.GetProperty(model.XMPNamespacePDFAID, "conformance")
+			if part != fmt.Sprintf("%d", level.Part()) || (conformance != "B" && conformance != "A" && conformance != "U") {
+				v.report(RuleIdentification, v.catalogRef, "XMP identifies part %q conformance %q, expected %s", part, conformance, level)
+			}
+		}
+	}
+
+	// Output intents.
+	intents, err := v.reader.GetOutputIntents()
+	if err != nil {
+		return err
+	}
+	var profile []byte
+	for _, oi := range intents {
+		if oi.Subtype != "GTS_PDFA1" {
+			continue
+		}
+		if len(oi.DestOutputProfile) == 0 {
+			v.report(RuleOutputIntent, v.catalogRef, "PDF/A output intent without destination profile")
+			continue
+		}
+		if profile != nil && string(profile) != string(oi.DestOutputProfile) {
+			v.report(RuleOutputIntent, v.catalogRef, "PDF/A output intents with different profiles")
+		}
+		profile = oi.DestOutputProfile
+		v.intentComponents = oi.ColorComponents
+	}
+
+	if names, ok := v.resolveDict(v.catalog.Get("Names")); ok {
+		if names.Get("JavaScript") != nil {
+			v.report(RuleJavaScript, v.catalogRef, "Document level JavaScript")
+		}
+		if level == model.PdfA1B && names.Get("EmbeddedFiles") != nil {
+			v.report(RuleEmbeddedFile, v.catalogRef, "Embedded files not allowed")
+		}
+	}
+	if level == model.PdfA1B && v.catalog.Get("OCProperties") != nil {
+		v.report(RuleOptionalContent, v.catalogRef, "Optional content not allowed")
+	}
+	if form, ok := v.resolveDict(v.catalog.Get("AcroForm")); ok {
+		if need, _ := core.GetBoolVal(v.resolve(form.Get("NeedAppearances"))); need {
+			v.report(RuleNeedAppearances, v.catalogRef, "NeedAppearances set in the interactive form")
+		}
+	}
+	return nil
+}
+
+// checkPdfAStream checks the stream `stream` of the object `ref`: external data, filters and the
+// colors of the content of Form XObjects.
+func (v *validator) checkPdfAStream(ref core.PdfObjectReference, stream *core.PdfObjectStream) {
+	if stream.Get("F") != nil || stream.Get("FFilter") != nil || stream.Get("FDecodeParms") != nil {
+		v.report(RuleExternalStream, ref, "Stream with external data")
+	}
+	filters := []core.PdfObject{v.resolve(stream.Get("Filter"))}
+	if arr, ok := core.GetArray(filters[0]); ok {
+		filters = arr.Elements()
+	}
+	for _, filter := range filters {
+		if name, _ := core.GetNameVal(filter); name == "LZWDecode" {
+			v.report(RuleFilter, ref, "LZWDecode filter not allowed")
+		}
+	}
+
+	if subtype, _ := core.GetNameVal(stream.Get("Subtype")); subtype == "Form" {
+		data, err := core.DecodeStream(stream)
+		if err != nil {
+			common.Log.Debug("Unable to decode form %s (%v) - skipping", ref.String(), err)
+			return
+		}
+		v.checkContentColors(ref, data)
+	}
+}
+
+// checkPdfADict checks the entries of the dictionary `dict` of the object `ref`.
+func (v *validator) checkPdfADict(ref core.PdfObjectReference, dict *core.PdfObjectDictionary) {
+	level := v.opts.PdfA
+	typ, _ := core.GetNameVal(dict.Get("Type"))
+	subtype, _ := core.GetNameVal(dict.Get("Subtype"))
+
+	if typ == "Font" {
+		v.checkPdfAFont(ref, dict)
+		return
+	}
+	if dict.Get("AA") != nil {
+		v.report(RuleAdditionalActions, ref, "Additional actions not allowed")
+	}
+
+	// Actions.
+	if s, ok := core.GetNameVal(dict.Get("S")); ok && (typ == "Action" || typ == "") {
+		if s == "JavaScript" {
+			v.report(RuleJavaScript, ref, "JavaScript action")
+		} else if first, has := forbiddenActions[s]; has && first <= level {
+			v.report(RuleAction, ref, "%s action not allowed", s)
+		} else if s == "Named" {
+			switch n, _ := core.GetNameVal(dict.Get("N")); n {
+			case "NextPage", "PrevPage", "FirstPage", "LastPage":
+			default:
+				v.report(RuleAction, ref, "Named action %s not allowed", n)
+			}
+		}
+	}
+
+	// Transparency.
+	if level == model.PdfA1B {
+		if s, _ := core.GetNameVal(dict.Get("S")); typ == "Group" && s == "Transparency" {
+			v.report(RuleTransparency, ref, "Transparency group")
+		}
+		if smask := dict.Get("SMask"); smask != nil {
+			if name, _ := core.GetNameVal(smask); name != "None" {
+				v.report(RuleTransparency, ref, "Soft mask")
+			}
+		}
+		for _, key := range []core.PdfObjectName{"CA", "ca"} {
+			if alpha, err := core.GetNumberAsFloat(v.resolve(dict.Get(key))); err == nil && alpha < 1 {
+				v.report(RuleTransparency, ref, "Constant opacity %s %v", key, alpha)
+			}
+		}
+		if bm, ok := core.GetNameVal(dict.Get("BM")); ok && bm != "Normal" && bm != "Compatible" {
+			v.report(RuleTransparency, ref, "Blend mode %s", bm)
+		}
+	}
+
+	// Color spaces of images, shadings and color space resources.
+	if cs := dict.Get("ColorSpace"); cs != nil {
+		if resources, ok := v.resolve(cs).(*core.PdfObjectDictionary); ok {
+			for _, key := range resources.Keys() {
+				v.checkColorSpace(ref, resources.Get(key))
+			}
+		} else {
+			v.checkColorSpace(ref, cs)
+		}
+	}
+
+	if subtype == "Image" {
+		if interpolate, _ := core.GetBoolVal(dict.Get("Interpolate")); interpolate {
+			v.report(RuleImage, ref, "Image interpolation")
+		}
+		if dict.Get("Alternates") != nil || dict.Get("OPI") != nil {
+			v.report(RuleImage, ref, "Alternate images")
+		}
+	}
+
+	// Annotations.
+	if _, isAnnot := core.GetArray(dict.Get("Rect")); isAnnot && len(subtype) > 0 && (typ == "Annot" || typ == "") {
+		if first, has := forbiddenAnnotations[subtype]; has && first <= level {
+			v.report(RuleAnnotation, ref, "%s annotation not allowed", subtype)
+		} else if subtype == "FileAttachment" && level == model.PdfA1B {
+			v.report(RuleAnnotation, ref, "%s annotation not allowed", subtype)
+		}
+		if subtype != "Popup" {
+			flags, _ := core.GetIntVal(dict.Get("F"))
+			if flags&4 == 0 || flags&(1|2|32) != 0 {
+				v.report(RuleAnnotationFlags, ref, "%s annotation not printed or hidden (flags %d)", subtype, flags)
+			}
+		}
+	}
+
+	if typ == "Filespec" && dict.Get("EF") != nil {
+		v.checkPdfAFileSpec(ref, dict)
+	}
+}
+
+// checkColorSpace checks that the color space `obj` of the object `ref` is not a device color
+// space without a matching output intent.  The base color space of Indexed color spaces is
+// checked.
+func (v *validator) checkColorSpace(ref core.PdfObjectReference, obj core.PdfObject) {
+	obj = v.resolve(obj)
+	if arr, ok := obj.(*core.PdfObjectArray); ok && arr.Len() >= 2 {
+		if family, _ := core.GetNameVal(arr.Get(0)); family == "Indexed" {
+			obj = v.resolve(arr.Get(1))
+		}
+	}
+	if name, ok := core.GetNameVal(obj); ok {
+		v.checkDeviceColor(ref, name)
+	}
+}
+
+// checkDeviceColor checks that the color space `name` used by the object `ref` is allowed with the
+// output intent of the document.  Each device color space is reported once per object.
+func (v *validator) checkDeviceColor(ref core.PdfObjectReference, name string) {
+	if model.PdfADeviceColorAllowed(name, v.intentComponents) || v.deviceColors[ref][name] {
+		return
+	}
+	if v.deviceColors[ref] == nil {
+		v.deviceColors[ref] = map[string]bool{}
+	}
+	v.deviceColors[ref][name] = true
+	v.report(RuleDeviceColor, ref, "%s used without a matching output intent", name)
+}
+
+// checkContentColors checks the device colors set by the operators of the content stream
+// `content` of the object `ref` (page or Form XObject).
+func (v *validator) checkContentColors(ref core.PdfObjectReference, content []byte) {
+	spaces, err := model.ContentColorSpaces(content)
+	if err != nil {
+		common.Log.Debug("Unable to parse content of %s (%v) - skipping", ref.String(), err)
+		return
+	}
+	for _, cs := range spaces {
+		v.checkDeviceColor(ref, cs)
+	}
+}
+
+// checkPdfAFont checks that the font `dict` of the object `ref` has an embedded font program.
+func (v *validator) checkPdfAFont(ref core.PdfObjectReference, dict *core.PdfObjectDictionary) {
+	subtype, _ := core.GetNameVal(dict.Get("Subtype"))
+	basefont, _ := core.GetNameVal(dict.Get("BaseFont"))
+
+	descriptorHolder := dict
+	switch subtype {
+	case "Type3":
+		return
+	case "Type0":
+		descendants, ok := core.GetArray(v.resolve(dict.Get("DescendantFonts")))
+		if !ok || descendants.Len() == 0 {
+			v.report(RuleFontEmbedded, ref, "Font %s has no descendant font", basefont)
+			return
+		}
+		descendant, ok := v.resolveDict(descendants.Get(0))
+		if !ok {
+			v.report(RuleFontEmbedded, ref, "Font %s has no descendant font", basefont)
+			return
+		}
+		descriptorHolder = descendant
+	}
+
+	if descriptor, ok := v.resolveDict(descriptorHolder.Get("FontDescriptor")); ok {
+		for _, key := range []core.PdfObjectName{"FontFile", "FontFile2", "FontFile3"} {
+			if descriptor.Get(key) != nil {
+				return
+			}
+		}
+	}
+	v.report(RuleFontEmbedded, ref, "Font %s not embedded", basefont)
+}
+
+// checkPdfAFileSpec checks the file specification `dict` of the object `ref` with embedded files.
+func (v *validator) checkPdfAFileSpec(ref core.PdfObjectReference, dict *core.PdfObjectDictionary) {
+	level := v.opts.PdfA
+	name, _ := core.GetStringVal(v.resolve(dict.Get("UF")))
+	if len(name) == 0 {
+		name, _ = core.GetStringVal(v.resolve(dict.Get("F")))
+	}
+
+	switch level {
+	case model.PdfA1B:
+		v.report(RuleEmbeddedFile, ref, "Embedded file %s not allowed", name)
+	case model.PdfA2B:
+		mime := ""
+		if ef, ok := v.resolveDict(dict.Get("EF")); ok {
+			file, ok := v.resolveDict(ef.Get("UF"))
+			if !ok {
+				file, ok = v.resolveDict(ef.Get("F"))
+			}
+			if ok {
+				mime, _ = core.GetNameVal(v.resolve(file.Get("Subtype")))
+			}
+		}
+		if mime != "application/pdf" {
+			v.report(RuleEmbeddedFile, ref, "Embedded file %s is not a PDF/A file", name)
+		}
+	default:
+		if _, ok := core.GetNameVal(v.resolve(dict.Get("AFRelationship"))); !ok {
+			v.report(RuleEmbeddedFile, ref, "Embedded file %s has no AFRelationship", name)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package validator
+
+import (
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// XMP namespace of the PDF/UA identification properties.
+const xmpNamespacePDFUAID = "http://www.aiim.org/pdfua/ns/id/"
+
+// Standard structure types (14.8.4 Standard Structure Types p. 615).
+var standardStructureTypes = map[string]bool{}
+
+func init() {
+	for _, t := range []string{
+		"Document", "Part", "Art", "Sect", "Div", "BlockQuote", "Caption", "TOC", "TOCI", "Index",
+		"NonStruct", "Private", "P", "H", "H1", "H2", "H3", "H4", "H5", "H6", "L", "LI", "Lbl",
+		"LBody", "Table", "TR", "TH", "TD", "THead", "TBody", "TFoot", "Span", "Quote", "Note",
+		"Reference", "BibEntry", "Code", "Link", "Annot", "Ruby", "RB", "RT", "RP", "Warichu", "WT",
+		"WP", "Figure", "Formula", "Form",
+	} {
+		standardStructureTypes[t] = true
+	}
+}
+
+// Content stream operators painting text, paths, images or XObjects.
+var paintingOperators = map[string]bool{
+	"Tj": true, "TJ": true, "'": true, "\"": true,
+	"S": true, "s": true, "f": true, "F": true, "f*": true, "B": true, "B*": true, "b": true, "b*": true,
+	"sh": true, "Do": true, "BI": true,
+}
+
+// checkPdfUADocument checks the document level PDF/UA requirements: tagging, identification,
+// title and language.
+func (v *validator) checkPdfUADocument() error {
+	tagged, err := v.reader.IsTagged()
+	if err != nil {
+		return err
+	}
+	if !tagged {
+		v.report(RuleUAMarked, v.catalogRef, "Document not marked as tagged")
+	}
+	if v.catalog.Get("StructTreeRoot") == nil {
+		v.report(RuleUAStructTree, v.catalogRef, "Missing structure tree")
+	}
+
+	lang, err := v.reader.GetLanguage()
+	if err != nil {
+		return err
+	}
+	if len(lang) == 0 {
+		v.report(RuleUALanguage, v.catalogRef, "Missing document language")
+	}
+
+	xmp, err := v.reader.GetXMPMetadata()
+	if err != nil {
+		return err
+	}
+	title := ""
+	if xmp != nil {
+		title, _ = xmp.GetProperty(model.XMPNamespaceDC, "title")
+		if part, _ := xmp.GetProperty(xmpNamespacePDFUAID, "part"); part != "1" {
+			v.report(RuleUAIdentification, v.catalogRef, "XMP identifies PDF/UA part %q, expected 1", part)
+		}
+	} else {
+		v.report(RuleUAIdentification, v.catalogRef, "Missing XMP metadata")
+	}
+	if len(title) == 0 {
+		v.report(RuleUATitle, v.catalogRef, "Missing document title (dc:title)")
+	}
+	prefs, err := v.reader.GetViewerPreferences()
+	if err != nil {
+		return err
+	}
+	if prefs == nil || prefs.DisplayDocTitle == nil || !*prefs.DisplayDocTitle {
+		v.report(RuleUATitle, v.catalogRef, "Document title not displayed (DisplayDocTitle)")
+	}
+	return nil
+}
+
+// checkPdfUAPage checks the tab order of the page `page` and that its content `content` is tagged
+// or marked as artifacts.  Untagged content is reported once per page.
+func (v *validator) checkPdfUAPage(ref core.PdfObjectReference, page *model.PdfPage, content string) {
+	if len(page.Annotations) > 0 {
+		if tabs, _ := core.GetNameVal(page.Tabs); tabs != "S" {
+			v.report(RuleUATabOrder, ref, "Page with annotations without structure tab order")
+		}
+	}
+
+	ops, err := contentstream.NewContentStreamParser(content).Parse()
+	if err != nil {
+		common.Log.Debug("Unable to parse content of %s (%v) - skipping", ref.String(), err)
+		return
+	}
+	depth := 0
+	for _, op := range *ops {
+		switch op.Operand {
+		case "BMC", "BDC":
+			depth++
+		case "EMC":
+			if depth > 0 {
+				depth--
+			}
+		default:
+			if depth == 0 && paintingOperators[op.Operand] {
+				v.report(RuleUAUntaggedContent, ref, "Content (%s) neither tagged nor marked as artifact", op.Operand)
+				return
+			}
+		}
+	}
+}
+
+// checkPdfUAStructure checks the types of the structure elements and the alternate descriptions
+// of the figures.
+func (v *validator) checkPdfUAStructure() error {
+	root, err := v.reader.GetStructTreeRoot()
+	if err != nil || root == nil {
+		return err
+	}
+	return root.Walk(func(elem *model.PdfStructElement, depth int) error {
+		ref := objectReference(elem.GetContainingPdfObject())
+		role := root.ResolveRole(elem.Type)
+		if !standardStructureTypes[role] {
+			v.report(RuleUARoleMap, ref, "Structure type %s not mapped to a standard type", elem.Type)
+		}
+		if role == "Figure" && len(elem.Alt) == 0 && len(elem.ActualText) == 0 {
+			v.report(RuleUAFigureAlt, ref, "Figure without alternate description")
+		}
+		return nil
+	})
+}
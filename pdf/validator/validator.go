@@ -0,0 +1,234 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package validator
+
+import (
+	"fmt"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Rule identifies a conformance rule.  The comments refer to the clauses of ISO 19005-1 (PDF/A-1),
+// ISO 19005-2 (PDF/A-2) and ISO 14289-1 (PDF/UA-1).
+type Rule string
+
+// PDF/A rules.
+const (
+	RuleFileID            Rule = "pdfa-file-id"            // 6.1.3: trailer ID.
+	RuleEncryption        Rule = "pdfa-encryption"         // 6.1.3: no Encrypt entry.
+	RuleExternalStream    Rule = "pdfa-external-stream"    // 6.1.7: no external stream data.
+	RuleFilter            Rule = "pdfa-filter"             // 6.1.10 / 6.1.7.2: no LZW filter.
+	RuleEmbeddedFile      Rule = "pdfa-embedded-file"      // 6.1.11 / 6.8: embedded files.
+	RuleOptionalContent   Rule = "pdfa-optional-content"   // 6.1.13: no optional content in PDF/A-1.
+	RuleOutputIntent      Rule = "pdfa-output-intent"      // 6.2.2 / 6.2.3: PDF/A output intents.
+	RuleDeviceColor       Rule = "pdfa-device-color"       // 6.2.3.3 / 6.2.4.3: device colors.
+	RuleImage             Rule = "pdfa-image"              // 6.2.4 / 6.2.8: image dictionaries.
+	RuleFontEmbedded      Rule = "pdfa-font-embedded"      // 6.3.4 / 6.2.11.4: embedded fonts.
+	RuleTransparency      Rule = "pdfa-transparency"       // 6.4: no transparency in PDF/A-1.
+	RuleAnnotation        Rule = "pdfa-annotation"         // 6.5.2 / 6.3.1: annotation types.
+	RuleAnnotationFlags   Rule = "pdfa-annotation-flags"   // 6.5.3 / 6.3.2: printed annotations.
+	RuleAction            Rule = "pdfa-action"             // 6.6.1 / 6.5.1: action types.
+	RuleAdditionalActions Rule = "pdfa-additional-actions" // 6.6.2 / 6.5.2: no AA entries.
+	RuleJavaScript        Rule = "pdfa-javascript"         // 6.6.1: no JavaScript.
+	RuleMetadata          Rule = "pdfa-metadata"           // 6.7.2 / 6.6.2.1: XMP metadata.
+	RuleIdentification    Rule = "pdfa-identification"     // 6.7.11 / 6.6.4: pdfaid properties.
+	RuleNeedAppearances   Rule = "pdfa-need-appearances"   // 6.9 / 6.4.1: form appearances.
+)
+
+// PDF/UA rules.
+const (
+	RuleUAIdentification  Rule = "pdfua-identification"   // 5: pdfuaid properties.
+	RuleUAMarked          Rule = "pdfua-marked"           // 7.1: MarkInfo Marked.
+	RuleUAStructTree      Rule = "pdfua-struct-tree"      // 7.1: structure tree.
+	RuleUAUntaggedContent Rule = "pdfua-untagged-content" // 7.1: content tagged or artifact.
+	RuleUARoleMap         Rule = "pdfua-role-map"         // 7.1: standard structure types.
+	RuleUATitle           Rule = "pdfua-title"            // 7.1: document title displayed.
+	RuleUALanguage        Rule = "pdfua-language"         // 7.2: natural language.
+	RuleUAFigureAlt       Rule = "pdfua-figure-alt"       // 7.3: alternate descriptions.
+	RuleUATabOrder        Rule = "pdfua-tab-order"        // 7.18.3: tab order of pages.
+)
+
+// Violation is a violation of a conformance rule by an object of the document.
+type Violation struct {
+	Rule    Rule
+	Object  core.PdfObjectReference // Zero object number for the trailer.
+	Message string
+}
+
+// String returns a description of the violation, e.g.
+// "pdfa-font-embedded (5 0 R): Font Helvetica not embedded".
+func (v Violation) String() string {
+	obj := "trailer"
+	if v.Object.ObjectNumber > 0 {
+		obj = fmt.Sprintf("%d %d R", v.Object.ObjectNumber, v.Object.GenerationNumber)
+	}
+	return fmt.Sprintf("%s (%s): %s", v.Rule, obj, v.Message)
+}
+
+// Options specifies the standards to check.
+type Options struct {
+	PdfA  model.PdfAConformance // PDF/A level, PdfAConformanceNone to skip the PDF/A rules.
+	PdfUA bool                  // Check the PDF/UA rules.
+}
+
+// validator holds the state of a validation.
+type validator struct {
+	reader     *model.PdfReader
+	opts       Options
+	catalog    *core.PdfObjectDictionary
+	catalogRef core.PdfObjectReference
+	violations []Violation
+
+	// Number of color components of the PDF/A output intent, 0 if none.
+	intentComponents int
+
+	// Reported device color spaces, by object.
+	deviceColors map[core.PdfObjectReference]map[string]bool
+}
+
+// Validate checks the document of `reader` against the standards of `opts` and returns the
+// violations found, ordered by document, object, page content and structure.  Encrypted documents
+// must be decrypted beforehand.
+func Validate(reader *model.PdfReader, opts Options) ([]Violation, error) {
+	trailer, err := reader.GetTrailer()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &validator{
+		reader:       reader,
+		opts:         opts,
+		deviceColors: map[core.PdfObjectReference]map[string]bool{},
+	}
+	rootObj := trailer.Get("Root")
+	if ref, ok := rootObj.(*core.PdfObjectReference); ok {
+		rootObj, err = reader.GetIndirectObjectByNumber(int(ref.ObjectNumber))
+		if err != nil {
+			return nil, err
+		}
+	}
+	catalog, ok := core.GetDict(rootObj)
+	if !ok {
+		return nil, fmt.Errorf("Invalid catalog (trailer: %s)", trailer)
+	}
+	v.catalog = catalog
+	v.catalogRef = objectReference(rootObj)
+
+	if opts.PdfA != model.PdfAConformanceNone {
+		if err := v.checkPdfADocument(trailer); err != nil {
+			return nil, err
+		}
+	}
+	if opts.PdfUA {
+		if err := v.checkPdfUADocument(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Objects.
+	for _, num := range reader.GetObjectNums() {
+		obj, err := reader.GetIndirectObjectByNumber(num)
+		if err != nil {
+			common.Log.Debug("Unable to load object %d (%v) - skipping", num, err)
+			continue
+		}
+		ref := objectReference(obj)
+		switch t := obj.(type) {
+		case *core.PdfIndirectObject:
+			v.checkObject(ref, t.PdfObject)
+		case *core.PdfObjectStream:
+			if opts.PdfA != model.PdfAConformanceNone {
+				v.checkPdfAStream(ref, t)
+			}
+			v.checkObject(ref, t.PdfObjectDictionary)
+		}
+	}
+
+	// Page contents.
+	for _, page := range reader.PageList {
+		ref := objectReference(page.GetPageAsIndirectObject())
+		content, err := page.GetAllContentStreams()
+		if err != nil {
+			common.Log.Debug("Unable to load contents of page %s (%v) - skipping", ref.String(), err)
+			continue
+		}
+		if opts.PdfA != model.PdfAConformanceNone {
+			v.checkContentColors(ref, []byte(content))
+		}
+		if opts.PdfUA {
+			v.checkPdfUAPage(ref, page, content)
+		}
+	}
+
+	if opts.PdfUA {
+		if err := v.checkPdfUAStructure(); err != nil {
+			return nil, err
+		}
+	}
+	return v.violations, nil
+}
+
+// report records a violation of `rule` by the object `ref`.
+func (v *validator) report(rule Rule, ref core.PdfObjectReference, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{Rule: rule, Object: ref, Message: fmt.Sprintf(format, args...)})
+}
+
+// resolve returns the direct object of `obj`, loading referenced objects.
+func (v *validator) resolve(obj core.PdfObject) core.PdfObject {
+	if ref, ok := obj.(*core.PdfObjectReference); ok {
+		var err error
+		obj, err = v.reader.GetIndirectObjectByNumber(int(ref.ObjectNumber))
+		if err != nil {
+			common.Log.Debug("Unable to load object %d (%v)", ref.ObjectNumber, err)
+			return nil
+		}
+	}
+	return core.TraceToDirectObject(obj)
+}
+
+// resolveDict returns the dictionary `obj`, loading referenced objects.  For streams the stream
+// dictionary is returned.
+func (v *validator) resolveDict(obj core.PdfObject) (*core.PdfObjectDictionary, bool) {
+	obj = v.resolve(obj)
+	if stream, ok := obj.(*core.PdfObjectStream); ok {
+		return stream.PdfObjectDictionary, true
+	}
+	return core.GetDict(obj)
+}
+
+// checkObject checks the direct object `obj` of the object `ref` and the direct objects it
+// contains.  Indirect objects are checked separately.
+func (v *validator) checkObject(ref core.PdfObjectReference, obj core.PdfObject) {
+	switch t := obj.(type) {
+	case *core.PdfObjectArray:
+		for _, elem := range t.Elements() {
+			v.checkObject(ref, elem)
+		}
+	case *core.PdfObjectDictionary:
+		if v.opts.PdfA != model.PdfAConformanceNone {
+			v.checkPdfADict(ref, t)
+		}
+		for _, key := range t.Keys() {
+			if key != "Parent" {
+				v.checkObject(ref, t.Get(key))
+			}
+		}
+	}
+}
+
+// objectReference returns the reference of the indirect or stream object `obj`, or a zero
+// reference for direct objects.
+func objectReference(obj core.PdfObject) core.PdfObjectReference {
+	switch t := obj.(type) {
+	case *core.PdfIndirectObject:
+		return t.PdfObjectReference
+	case *core.PdfObjectStream:
+		return t.PdfObjectReference
+	}
+	return core.PdfObjectReference{}
+}
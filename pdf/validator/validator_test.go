@@ -0,0 +1,173 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package validator
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+	"github.com/unidoc/unidoc/pdf/model/fonts"
+)
+
+// writeTestDocument writes a one page document with text in a standard font and a device color,
+// and returns a reader of the written document.  `setupPage` and `setupWriter` are called, if not
+// nil, to add to the page and to configure the writer.
+func writeTestDocument(t *testing.T, setupPage func(page *model.PdfPage), setupWriter func(w *model.PdfWriter)) *model.PdfReader {
+	page := model.NewPdfPage()
+	page.MediaBox = &model.PdfRectangle{Llx: 0, Lly: 0, Urx: 612, Ury: 792}
+	page.Resources = model.NewPdfPageResources()
+	page.Resources.SetFontByName("F1", fonts.NewFontHelvetica().ToPdfObject())
+	content := "BT /F1 12 Tf 0 0 1 rg 10 10 Td (Text) Tj ET"
+	if err := page.SetContentStreams([]string{content}, core.NewFlateEncoder()); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if setupPage != nil {
+		setupPage(page)
+	}
+
+	writer := model.NewPdfWriter()
+	if setupWriter != nil {
+		setupWriter(&writer)
+	}
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := writer.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	reader, err := model.NewPdfReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return reader
+}
+
+// violatedRules returns the violations by rule.
+func violatedRules(violations []Violation) map[Rule][]Violation {
+	rules := map[Rule][]Violation{}
+	for _, v := range violations {
+		rules[v.Rule] = append(rules[v.Rule], v)
+	}
+	return rules
+}
+
+// Test the PDF/A violations of a document not written for PDF/A.
+func TestValidatePdfA(t *testing.T) {
+	reader := writeTestDocument(t, func(page *model.PdfPage) {
+		gs := core.MakeDict()
+		gs.Set("ca", core.MakeFloat(0.5))
+		page.Resources.AddExtGState("GS0", core.MakeIndirectObject(gs))
+
+		action := core.MakeDict()
+		action.Set("S", core.MakeName("JavaScript"))
+		action.Set("JS", core.MakeString("app.alert('Hello');"))
+		link := model.NewPdfAnnotationLink()
+		link.Rect = core.MakeArrayFromFloats([]float64{10, 10, 50, 20})
+		link.A = core.MakeIndirectObject(action)
+		page.Annotations = []*model.PdfAnnotation{link.PdfAnnotation}
+	}, nil)
+
+	violations, err := Validate(reader, Options{PdfA: model.PdfA1B})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	rules := violatedRules(violations)
+	for _, rule := range []Rule{RuleFileID, RuleMetadata, RuleFontEmbedded, RuleTransparency,
+		RuleDeviceColor, RuleJavaScript, RuleAnnotationFlags} {
+		if len(rules[rule]) == 0 {
+			t.Errorf("Rule %s not violated: %v", rule, violations)
+		}
+	}
+	for _, rule := range []Rule{RuleFontEmbedded, RuleTransparency, RuleJavaScript, RuleDeviceColor} {
+		for _, v := range rules[rule] {
+			if v.Object.ObjectNumber == 0 {
+				t.Errorf("Violation without object: %s", v)
+			}
+		}
+	}
+	if len(rules[RuleUAMarked]) > 0 {
+		t.Errorf("PDF/UA rules checked")
+	}
+}
+
+// Test that a document written for PDF/A-1b has no PDF/A violations.
+func TestValidateConformingPdfA(t *testing.T) {
+	font, err := model.NewPdfFontFromTTFFile("../creator/testdata/FreeSans.ttf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	reader := writeTestDocument(t, nil, func(w *model.PdfWriter) {
+		w.SetPdfAConformance(model.PdfA1B)
+		w.SetFontSubstitute("Helvetica", font)
+	})
+	violations, err := Validate(reader, Options{PdfA: model.PdfA1B})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	for _, v := range violations {
+		t.Errorf("Unexpected violation %s", v)
+	}
+
+	// The identification does not match other parts.
+	violations, err = Validate(reader, Options{PdfA: model.PdfA2B})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if rules := violatedRules(violations); len(violations) != 1 || len(rules[RuleIdentification]) != 1 {
+		t.Errorf("Expected identification violation, got %v", violations)
+	}
+}
+
+// Test the PDF/UA violations of untagged and tagged documents.
+func TestValidatePdfUA(t *testing.T) {
+	reader := writeTestDocument(t, nil, nil)
+	violations, err := Validate(reader, Options{PdfUA: true})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	rules := violatedRules(violations)
+	for _, rule := range []Rule{RuleUAMarked, RuleUAStructTree, RuleUALanguage, RuleUATitle,
+		RuleUAIdentification, RuleUAUntaggedContent} {
+		if len(rules[rule]) == 0 {
+			t.Errorf("Rule %s not violated: %v", rule, violations)
+		}
+	}
+
+	var pageObj *core.PdfIndirectObject
+	reader = writeTestDocument(t, func(page *model.PdfPage) {
+		pageObj = page.GetPageAsIndirectObject()
+	}, func(w *model.PdfWriter) {
+		root := model.NewPdfStructTreeRoot()
+		doc := model.NewPdfStructElement("Document")
+		root.AddKid(doc)
+		figure := model.NewPdfStructElement("Figure")
+		doc.AddKid(figure)
+		doc.AddKid(model.NewPdfStructElement("Chart"))
+		described := model.NewPdfStructElement("Figure")
+		described.Alt = "Chart"
+		doc.AddKid(described)
+		figure.AddMarkedContent(pageObj, 0)
+		w.SetStructTreeRoot(root)
+		w.SetLanguage("en")
+	})
+	violations, err = Validate(reader, Options{PdfUA: true})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	rules = violatedRules(violations)
+	if len(rules[RuleUAMarked]) > 0 || len(rules[RuleUAStructTree]) > 0 || len(rules[RuleUALanguage]) > 0 {
+		t.Errorf("Unexpected document violations: %v", violations)
+	}
+	if len(rules[RuleUAFigureAlt]) != 1 || len(rules[RuleUARoleMap]) != 1 {
+		t.Errorf("Expected figure and role map violations: %v", violations)
+	}
+	if v := rules[RuleUAFigureAlt]; len(v) == 1 && v[0].Object.ObjectNumber == 0 {
+		t.Errorf("Figure violation without object")
+	}
+}